@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -14,6 +15,16 @@ var (
 	colorAutocopy  bool
 	colorNotify    bool
 	colorLowercase bool
+	colorNoHistory bool
+	colorContrast  bool
+	colorFile      string
+	colorRadius    int
+	colorSample    string
+
+	colorHistoryJSON  bool
+	colorHistoryLimit int
+	colorExportFormat string
+	colorExportFile   string
 )
 
 var colorCmd = &cobra.Command{
@@ -30,50 +41,251 @@ var colorPickCmd = &cobra.Command{
 Click on any pixel to capture its color, or press Escape to cancel.
 
 Output format flags (mutually exclusive, default: --hex):
-  --hex  - Hexadecimal (#RRGGBB)
-  --rgb  - RGB values (R G B)
-  --hsl  - HSL values (H S% L%)
-  --hsv  - HSV values (H S% V%)
-  --cmyk - CMYK values (C% M% Y% K%)
-  --json - JSON with all formats
+  --hex     - Hexadecimal (#RRGGBB)
+  --rgb     - RGB values (R G B)
+  --hsl     - HSL values (H S% L%)
+  --hsv     - HSV values (H S% V%)
+  --cmyk    - CMYK values (C% M% Y% K%)
+  --oklch   - CSS oklch() (L% C H)
+  --oklab   - CSS oklab() (L% a b)
+  --lab     - CSS lab() (CIELAB, D65)
+  --hexa    - Hexadecimal with alpha (#RRGGBBAA)
+  --css-rgb - CSS rgb() (rgb(R G B))
+  --css-hsl - CSS hsl() (hsl(H S% L%))
+  --qcolor  - Qt QColor(R, G, B)
+  --name    - Nearest CSS named color
+  --json    - JSON with all formats
+
+Scroll up or down in the magnifier to sample a larger or smaller square
+around the pointer, and press M to switch between average, median and
+dominant color. --radius and --sample set the starting values.
+
+With --file, the image is shown full screen instead of the desktop, for
+picking colors from mockups and screenshots.
+
+With --contrast, pick a foreground color and then a background color. The
+preview shows the contrast while choosing the second color, and the WCAG 2
+ratio and APCA lightness contrast are printed at the end.
 
 Examples:
   dms color pick                # Pick color, output as hex
   dms color pick --rgb          # Output as RGB
+  dms color pick --oklch        # Output as oklch(...)
   dms color pick --json         # Output all formats as JSON
   dms color pick --hex -l       # Output hex in lowercase
-  dms color pick -a             # Auto-copy result to clipboard`,
+  dms color pick -a             # Auto-copy result to clipboard
+  dms color pick --contrast     # Check text/background contrast
+  dms color pick -r 2 -s median # Median of a 5x5 area
+  dms color pick --file ui.png  # Pick from an image file
+
+Picked colors are added to the color history unless --no-history is given.`,
 	Run: runColorPick,
 }
 
+var colorContrastCmd = &cobra.Command{
+	Use:   "contrast <foreground> <background>",
+	Short: "Show the contrast between two colors",
+	Long: `Show the WCAG 2 contrast ratio and APCA lightness contrast (Lc) of a
+foreground color on a background color.
+
+Examples:
+  dms color contrast '#ffffff' '#1e66f5'
+  dms color contrast fff 000 --json`,
+	Args: cobra.ExactArgs(2),
+	Run:  runColorContrast,
+}
+
+var colorHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previously picked colors",
+	Long: `List picked colors, most recent first.
+
+Examples:
+  dms color history                         # Recent colors
+  dms color history --json -n 10            # Last 10 as JSON
+  dms color history export -f gpl > h.gpl   # Export as a GIMP palette
+  dms color history remove '#ff0000'        # Forget one color
+  dms color history clear                   # Forget everything`,
+	Args: cobra.NoArgs,
+	Run:  runColorHistory,
+}
+
+var colorHistoryRemoveCmd = &cobra.Command{
+	Use:   "remove <color>",
+	Short: "Remove a color from the history",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.RemoveHistoryEntry(args[0]))
+	},
+}
+
+var colorHistoryClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the color history",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.ClearHistory())
+	},
+}
+
+var colorHistoryExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the color history as a palette",
+	Args:  cobra.NoArgs,
+	Run:   runColorHistoryExport,
+}
+
+var colorPaletteCmd = &cobra.Command{
+	Use:   "palette",
+	Short: "Manage named color palettes",
+	Long: `Manage named color palettes.
+
+Examples:
+  dms color palette                              # List palettes
+  dms color palette create brand                 # New empty palette
+  dms color palette add brand '#1e66f5'          # Add a color (creates the palette)
+  dms color palette add brand                    # Add the most recently picked color
+  dms color palette remove brand '#1e66f5'       # Remove a color
+  dms color palette rename brand acme            # Rename a palette
+  dms color palette delete acme                  # Delete a palette
+  dms color palette export brand -f css          # CSS custom properties
+  dms color palette export brand -f gpl -O b.gpl # GIMP palette file`,
+	Args: cobra.NoArgs,
+	Run:  runColorPaletteList,
+}
+
+var colorPaletteShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the colors in a palette",
+	Args:  cobra.ExactArgs(1),
+	Run:   runColorPaletteShow,
+}
+
+var colorPaletteCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an empty palette",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.CreatePalette(args[0]))
+	},
+}
+
+var colorPaletteDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a palette",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.DeletePalette(args[0]))
+	},
+}
+
+var colorPaletteRenameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename a palette",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.RenamePalette(args[0], args[1]))
+	},
+}
+
+var colorPaletteAddCmd = &cobra.Command{
+	Use:   "add <name> [color...]",
+	Short: "Add colors to a palette",
+	Long:  "Add colors to a palette, creating it if needed. Without colors, the most recently picked color is added.",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runColorPaletteAdd,
+}
+
+var colorPaletteRemoveCmd = &cobra.Command{
+	Use:   "remove <name> <color>",
+	Short: "Remove a color from a palette",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(colorpicker.RemovePaletteColor(args[0], args[1]))
+	},
+}
+
+var colorPaletteExportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export a palette (gpl, css, json)",
+	Args:  cobra.ExactArgs(1),
+	Run:   runColorPaletteExport,
+}
+
 func init() {
 	colorPickCmd.Flags().Bool("hex", false, "Output as hexadecimal (#RRGGBB)")
 	colorPickCmd.Flags().Bool("rgb", false, "Output as RGB (R G B)")
 	colorPickCmd.Flags().Bool("hsl", false, "Output as HSL (H S% L%)")
 	colorPickCmd.Flags().Bool("hsv", false, "Output as HSV (H S% V%)")
 	colorPickCmd.Flags().Bool("cmyk", false, "Output as CMYK (C% M% Y% K%)")
+	colorPickCmd.Flags().Bool("oklch", false, "Output as CSS oklch()")
+	colorPickCmd.Flags().Bool("oklab", false, "Output as CSS oklab()")
+	colorPickCmd.Flags().Bool("lab", false, "Output as CSS lab() (CIELAB)")
+	colorPickCmd.Flags().Bool("hexa", false, "Output as hexadecimal with alpha (#RRGGBBAA)")
+	colorPickCmd.Flags().Bool("css-rgb", false, "Output as CSS rgb()")
+	colorPickCmd.Flags().Bool("css-hsl", false, "Output as CSS hsl()")
+	colorPickCmd.Flags().Bool("qcolor", false, "Output as Qt QColor(R, G, B)")
+	colorPickCmd.Flags().Bool("name", false, "Output the nearest CSS named color")
 	colorPickCmd.Flags().Bool("json", false, "Output all formats as JSON")
 	colorPickCmd.Flags().StringVarP(&colorOutputFmt, "output-format", "o", "", "Custom output format template")
 	colorPickCmd.Flags().BoolVarP(&colorAutocopy, "autocopy", "a", false, "Copy result to clipboard")
 	colorPickCmd.Flags().BoolVarP(&colorLowercase, "lowercase", "l", false, "Output hex in lowercase")
 
-	colorPickCmd.MarkFlagsMutuallyExclusive("hex", "rgb", "hsl", "hsv", "cmyk", "json")
+	colorPickCmd.Flags().BoolVar(&colorNoHistory, "no-history", false, "Don't record the color in the color history")
+	colorPickCmd.Flags().BoolVar(&colorContrast, "contrast", false, "Pick foreground and background colors and report their contrast")
+	colorPickCmd.Flags().StringVar(&colorFile, "file", "", "Pick from an image file instead of the screen")
+	colorPickCmd.Flags().IntVarP(&colorRadius, "radius", "r", 0, "Sampling radius in pixels (0 for a single pixel)")
+	colorPickCmd.Flags().StringVarP(&colorSample, "sample", "s", "average", "How to combine sampled pixels (average, median, dominant)")
+
+	colorPickCmd.MarkFlagsMutuallyExclusive(append(colorFormatFlags(), "json")...)
+
+	colorContrastCmd.Flags().Bool("json", false, "Output as JSON")
+
+	colorHistoryCmd.Flags().BoolVar(&colorHistoryJSON, "json", false, "Output as JSON")
+	colorHistoryCmd.Flags().IntVarP(&colorHistoryLimit, "limit", "n", 0, "Max entries (0 for all)")
+	colorPaletteCmd.Flags().BoolVar(&colorHistoryJSON, "json", false, "Output as JSON")
+	for _, cmd := range []*cobra.Command{colorHistoryExportCmd, colorPaletteExportCmd} {
+		cmd.Flags().StringVarP(&colorExportFormat, "format", "f", "json", "Export format (gpl, css, json)")
+		cmd.Flags().StringVarP(&colorExportFile, "output", "O", "", "Write to file instead of stdout")
+	}
+
+	colorHistoryCmd.AddCommand(colorHistoryRemoveCmd)
+	colorHistoryCmd.AddCommand(colorHistoryClearCmd)
+	colorHistoryCmd.AddCommand(colorHistoryExportCmd)
+
+	colorPaletteCmd.AddCommand(colorPaletteShowCmd)
+	colorPaletteCmd.AddCommand(colorPaletteCreateCmd)
+	colorPaletteCmd.AddCommand(colorPaletteDeleteCmd)
+	colorPaletteCmd.AddCommand(colorPaletteRenameCmd)
+	colorPaletteCmd.AddCommand(colorPaletteAddCmd)
+	colorPaletteCmd.AddCommand(colorPaletteRemoveCmd)
+	colorPaletteCmd.AddCommand(colorPaletteExportCmd)
 
 	colorCmd.AddCommand(colorPickCmd)
+	colorCmd.AddCommand(colorContrastCmd)
+	colorCmd.AddCommand(colorHistoryCmd)
+	colorCmd.AddCommand(colorPaletteCmd)
+}
+
+// colorFormatFlags are the output format flags of `dms color pick`, named
+// after the formats colorpicker.ParseFormat accepts.
+func colorFormatFlags() []string {
+	return []string{"hex", "rgb", "hsl", "hsv", "cmyk", "oklch", "oklab", "lab", "hexa", "css-rgb", "css-hsl", "qcolor", "name"}
 }
 
 func runColorPick(cmd *cobra.Command, args []string) {
 	format := colorpicker.FormatHex // default
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 
-	if rgb, _ := cmd.Flags().GetBool("rgb"); rgb {
-		format = colorpicker.FormatRGB
-	} else if hsl, _ := cmd.Flags().GetBool("hsl"); hsl {
-		format = colorpicker.FormatHSL
-	} else if hsv, _ := cmd.Flags().GetBool("hsv"); hsv {
-		format = colorpicker.FormatHSV
-	} else if cmyk, _ := cmd.Flags().GetBool("cmyk"); cmyk {
-		format = colorpicker.FormatCMYK
+	for _, name := range colorFormatFlags() {
+		if set, _ := cmd.Flags().GetBool(name); set {
+			format = colorpicker.ParseFormat(name)
+		}
+	}
+
+	sampleMode, ok := colorpicker.ParseSampleMode(colorSample)
+	if !ok {
+		exitOnError(fmt.Errorf("invalid sample mode: %s (use average, median or dominant)", colorSample))
 	}
 
 	config := colorpicker.Config{
@@ -82,6 +294,15 @@ func runColorPick(cmd *cobra.Command, args []string) {
 		Lowercase:    colorLowercase,
 		Autocopy:     colorAutocopy,
 		Notify:       colorNotify,
+		Contrast:     colorContrast,
+		SampleRadius: colorRadius,
+		SampleMode:   sampleMode,
+	}
+
+	if colorFile != "" {
+		img, err := colorpicker.LoadImageFile(colorFile)
+		exitOnError(err)
+		config.Image = img
 	}
 
 	picker := colorpicker.New(config)
@@ -95,6 +316,23 @@ func runColorPick(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
+	if !colorNoHistory {
+		picked := []colorpicker.Color{*color}
+		if ref := picker.Reference(); ref != nil {
+			picked = []colorpicker.Color{*ref, *color}
+		}
+		for _, c := range picked {
+			if _, err := colorpicker.RecordHistory(c, picker.Output()); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to record color history:", err)
+			}
+		}
+	}
+
+	if ref := picker.Reference(); ref != nil {
+		printContrast(colorpicker.Contrast(*ref, *color), jsonOutput)
+		return
+	}
+
 	var output string
 	if jsonOutput {
 		jsonStr, err := color.ToJSON()
@@ -113,11 +351,174 @@ func runColorPick(cmd *cobra.Command, args []string) {
 
 	if jsonOutput {
 		fmt.Println(output)
-	} else if color.IsDark() {
-		fmt.Printf("\033[48;2;%d;%d;%dm\033[97m %s \033[0m\n", color.R, color.G, color.B, output)
 	} else {
-		fmt.Printf("\033[48;2;%d;%d;%dm\033[30m %s \033[0m\n", color.R, color.G, color.B, output)
+		fmt.Println(colorSwatch(*color, output))
+	}
+}
+
+func colorSwatch(c colorpicker.Color, text string) string {
+	fg := "30"
+	if c.IsDark() {
+		fg = "97"
+	}
+	return fmt.Sprintf("\033[48;2;%d;%d;%dm\033[%sm %s \033[0m", c.R, c.G, c.B, fg, text)
+}
+
+func runColorContrast(cmd *cobra.Command, args []string) {
+	fg, err := colorpicker.ParseHex(args[0])
+	exitOnError(err)
+	bg, err := colorpicker.ParseHex(args[1])
+	exitOnError(err)
+
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	printContrast(colorpicker.Contrast(fg, bg), jsonOutput)
+}
+
+func printContrast(r colorpicker.ContrastReport, jsonOutput bool) {
+	if jsonOutput {
+		out, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	fg, _ := colorpicker.ParseHex(r.Foreground)
+	bg, _ := colorpicker.ParseHex(r.Background)
+	check := func(ok bool) string {
+		if ok {
+			return "pass"
+		}
+		return "fail"
+	}
+
+	fmt.Printf("Foreground  %s\n", colorSwatch(fg, r.Foreground))
+	fmt.Printf("Background  %s\n", colorSwatch(bg, r.Background))
+	fmt.Printf("Sample      \033[48;2;%d;%d;%dm\033[38;2;%d;%d;%dm The quick brown fox \033[0m\n",
+		bg.R, bg.G, bg.B, fg.R, fg.G, fg.B)
+	fmt.Printf("WCAG 2      %.2f:1  AA %s  AA large %s  AAA %s  AAA large %s\n",
+		r.Ratio, check(r.AA), check(r.AALarge), check(r.AAA), check(r.AAALarge))
+	fmt.Printf("APCA        Lc %.1f (%s)\n", r.APCA, r.APCALevel)
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runColorHistory(cmd *cobra.Command, args []string) {
+	entries, err := colorpicker.ListHistory(colorHistoryLimit)
+	exitOnError(err)
+
+	if colorHistoryJSON {
+		out, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No colors in history")
+		return
+	}
+
+	for _, e := range entries {
+		c, err := e.Color()
+		if err != nil {
+			continue
+		}
+		where := e.Output
+		if where == "" {
+			where = "-"
+		}
+		fmt.Printf("%s  %s  %s\n", colorSwatch(c, e.Hex), e.Time.Format("2006-01-02 15:04:05"), where)
+	}
+}
+
+func runColorHistoryExport(cmd *cobra.Command, args []string) {
+	entries, err := colorpicker.ListHistory(0)
+	exitOnError(err)
+
+	colors := make([]colorpicker.Color, 0, len(entries))
+	for _, e := range entries {
+		if c, err := e.Color(); err == nil {
+			colors = append(colors, c)
+		}
+	}
+	writeColorExport("history", colors)
+}
+
+func runColorPaletteList(cmd *cobra.Command, args []string) {
+	palettes, err := colorpicker.LoadPalettes()
+	exitOnError(err)
+
+	if colorHistoryJSON {
+		out, _ := json.MarshalIndent(palettes.Palettes, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	if len(palettes.Palettes) == 0 {
+		fmt.Println("No palettes")
+		return
+	}
+
+	for _, p := range palettes.Palettes {
+		fmt.Printf("%-20s ", p.Name)
+		for _, c := range p.ParsedColors() {
+			fmt.Print(colorSwatch(c, " "))
+		}
+		fmt.Println()
+	}
+}
+
+func runColorPaletteShow(cmd *cobra.Command, args []string) {
+	palette, err := colorpicker.GetPalette(args[0])
+	exitOnError(err)
+
+	for _, c := range palette.ParsedColors() {
+		fmt.Println(colorSwatch(c, c.ToHex(false)))
+	}
+}
+
+func runColorPaletteAdd(cmd *cobra.Command, args []string) {
+	colors := args[1:]
+	if len(colors) == 0 {
+		entries, err := colorpicker.ListHistory(1)
+		exitOnError(err)
+		if len(entries) == 0 {
+			exitOnError(fmt.Errorf("no color given and the color history is empty"))
+		}
+		colors = []string{entries[0].Hex}
+	}
+
+	for _, hex := range colors {
+		exitOnError(colorpicker.AddPaletteColor(args[0], hex))
+	}
+}
+
+func runColorPaletteExport(cmd *cobra.Command, args []string) {
+	palette, err := colorpicker.GetPalette(args[0])
+	exitOnError(err)
+	writeColorExport(palette.Name, palette.ParsedColors())
+}
+
+func writeColorExport(name string, colors []colorpicker.Color) {
+	format, ok := colorpicker.ParseExportFormat(colorExportFormat)
+	if !ok {
+		exitOnError(fmt.Errorf("unknown export format: %s (use gpl, css or json)", colorExportFormat))
+	}
+
+	out, err := colorpicker.Export(name, colors, format)
+	exitOnError(err)
+
+	if colorExportFile == "" {
+		fmt.Print(out)
+		if format == colorpicker.ExportJSON {
+			fmt.Println()
+		}
+		return
 	}
+	exitOnError(os.WriteFile(colorExportFile, []byte(out), 0o644))
 }
 
 func copyToClipboard(text string) {
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
@@ -81,6 +88,16 @@ func init() {
 		_ = findConfig(cmd, args)
 		printIPCHelp()
 	})
+	pluginsInstallCmd.Flags().Bool("allow-untrusted", false, "Install from a registry that is not marked trusted")
+	pluginsInstallCmd.Flags().BoolP("yes", "y", false, "Install missing dependencies and grant requested permissions without asking")
+	pluginsInstallCmd.Flags().Bool("force", false, "Install even if the plugin doesn't support this DMS version, compositor or distribution")
+	pluginsInstallCmd.Flags().Bool("locked", false, "Install the plugins in plugins.lock.json at their locked commits")
+	pluginsInstallCmd.Flags().Bool("allow-unverified", false, "Install even if the plugin doesn't match its published commit or signature")
+	pluginsUninstallCmd.Flags().BoolP("yes", "y", false, "Also uninstall plugins that depend on it without asking")
+	pluginsUpdateCmd.Flags().BoolP("yes", "y", false, "Update without showing the incoming commits for confirmation")
+	pluginsUpdateCmd.Flags().Bool("allow-unverified", false, "Update even if the plugin doesn't match its published commit or signature")
+	pluginsPermissionsCmd.AddCommand(pluginsPermissionsGrantCmd, pluginsPermissionsRevokeCmd)
+	pluginsConfigCmd.Flags().Bool("unset", false, "Reset the key to its default")
 }
 
 var debugSrvCmd = &cobra.Command{
@@ -97,7 +114,10 @@ var debugSrvCmd = &cobra.Command{
 var pluginsCmd = &cobra.Command{
 	Use:   "plugins",
 	Short: "Manage DMS plugins",
-	Long:  "Browse and manage DMS plugins from the registry",
+	Long: `Browse and manage DMS plugins from the registry.
+
+Additional registries (git URLs, file:// URLs or local directories) can be
+listed with a priority and trust flag in ~/.config/DankMaterialShell/registries.json.`,
 }
 
 var pluginsBrowseCmd = &cobra.Command{
@@ -125,8 +145,23 @@ var pluginsListCmd = &cobra.Command{
 var pluginsInstallCmd = &cobra.Command{
 	Use:   "install <plugin-id>",
 	Short: "Install a plugin by ID",
-	Long:  "Install a DMS plugin from the registry using its ID (e.g., 'myPlugin'). Plugin names with spaces are also supported for backward compatibility.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Install a DMS plugin from the registry using its ID (e.g., 'myPlugin'). Plugin names with spaces are also supported for backward compatibility.
+
+Plugins it depends on that aren't installed yet are installed first, after
+confirmation or with --yes. Plugins whose requires_dms, compositors or distro
+rule out this system are refused unless --force is given.
+
+Permissions the plugins request for the DMS server, like clipboard.read or
+dbus.system, are granted after confirmation or with --yes.
+
+Plugins are checked against the commit or signature their registry
+publishes before they are activated; plugins from local registries must
+match what was seen when they were first installed. --allow-unverified
+installs them anyway.
+
+With --locked and no ID, every plugin in plugins.lock.json is installed and
+checked out at its locked commit.`,
+	Args: cobra.RangeArgs(0, 1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -134,7 +169,25 @@ var pluginsInstallCmd = &cobra.Command{
 		return getAvailablePluginIDs(), cobra.ShellCompDirectiveNoFileComp
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := installPluginCLI(args[0]); err != nil {
+		if locked, _ := cmd.Flags().GetBool("locked"); locked {
+			if len(args) != 0 {
+				log.Fatal("--locked installs the plugins in the lockfile and takes no plugin ID")
+			}
+			allowUnverified, _ := cmd.Flags().GetBool("allow-unverified")
+			if err := installLockedCLI(allowUnverified); err != nil {
+				log.Fatalf("Error installing locked plugins: %v", err)
+			}
+			return
+		}
+		if len(args) != 1 {
+			log.Fatal("Specify a plugin ID, or --locked to install from the lockfile")
+		}
+		var opts pluginInstallOptions
+		opts.allowUntrusted, _ = cmd.Flags().GetBool("allow-untrusted")
+		opts.allowUnverified, _ = cmd.Flags().GetBool("allow-unverified")
+		opts.yes, _ = cmd.Flags().GetBool("yes")
+		opts.force, _ = cmd.Flags().GetBool("force")
+		if err := installPluginCLI(args[0], opts); err != nil {
 			log.Fatalf("Error installing plugin: %v", err)
 		}
 	},
@@ -143,8 +196,11 @@ var pluginsInstallCmd = &cobra.Command{
 var pluginsUninstallCmd = &cobra.Command{
 	Use:   "uninstall <plugin-id>",
 	Short: "Uninstall a plugin by ID",
-	Long:  "Uninstall a DMS plugin using its ID (e.g., 'myPlugin'). Plugin names with spaces are also supported for backward compatibility.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Uninstall a DMS plugin using its ID (e.g., 'myPlugin'). Plugin names with spaces are also supported for backward compatibility.
+
+Installed plugins that depend on it are uninstalled along with it, after
+confirmation or with --yes.`,
+	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -152,7 +208,8 @@ var pluginsUninstallCmd = &cobra.Command{
 		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := uninstallPluginCLI(args[0]); err != nil {
+		yes, _ := cmd.Flags().GetBool("yes")
+		if err := uninstallPluginCLI(args[0], yes); err != nil {
 			log.Fatalf("Error uninstalling plugin: %v", err)
 		}
 	},
@@ -161,8 +218,15 @@ var pluginsUninstallCmd = &cobra.Command{
 var pluginsUpdateCmd = &cobra.Command{
 	Use:   "update <plugin-id>",
 	Short: "Update a plugin by ID",
-	Long:  "Update an installed DMS plugin using its ID (e.g., 'myPlugin'). Plugin names are also supported.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Update an installed DMS plugin using its ID (e.g., 'myPlugin'). Plugin names are also supported.
+
+The commits the update brings in are listed for confirmation first, unless
+--yes is given. Pinned plugins are not updated. Permissions the new version
+requests are never granted without confirmation.
+
+A plugin from a local registry that changed since it was trusted is only
+trusted again after confirmation.`,
+	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -170,12 +234,153 @@ var pluginsUpdateCmd = &cobra.Command{
 		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := updatePluginCLI(args[0]); err != nil {
+		yes, _ := cmd.Flags().GetBool("yes")
+		allowUnverified, _ := cmd.Flags().GetBool("allow-unverified")
+		if err := updatePluginCLI(args[0], yes, allowUnverified); err != nil {
 			log.Fatalf("Error updating plugin: %v", err)
 		}
 	},
 }
 
+var pluginsDigestCmd = &cobra.Command{
+	Use:   "digest <plugin-dir>",
+	Short: "Print the digest plugin signatures are made over",
+	Long: `Print the digest of a plugin directory for signing. Registries publish
+the signature in the plugin's "signature" field:
+
+  dms plugins digest ./my-plugin > digest.txt
+  minisign -S -m digest.txt
+  ssh-keygen -Y sign -n dms-plugin -f ~/.ssh/id_ed25519 digest.txt`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		digest, err := plugins.Digest(afero.NewOsFs(), args[0])
+		if err != nil {
+			log.Fatalf("Error hashing plugin: %v", err)
+		}
+		fmt.Println(digest)
+	},
+}
+
+var pluginsPinCmd = &cobra.Command{
+	Use:   "pin <plugin-id>",
+	Short: "Keep a plugin at its current commit",
+	Long:  "Pin an installed plugin at the commit recorded in plugins.lock.json. Pinned plugins are skipped by updates until unpinned.",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := pinPluginCLI(args[0], true); err != nil {
+			log.Fatalf("Error pinning plugin: %v", err)
+		}
+	},
+}
+
+var pluginsUnpinCmd = &cobra.Command{
+	Use:   "unpin <plugin-id>",
+	Short: "Let a pinned plugin update again",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := pinPluginCLI(args[0], false); err != nil {
+			log.Fatalf("Error unpinning plugin: %v", err)
+		}
+	},
+}
+
+var pluginsPermissionsCmd = &cobra.Command{
+	Use:   "permissions [plugin-id]",
+	Short: "Show the permissions plugins request and have been granted",
+	Long: `Show the DMS server permissions installed plugins request in their
+plugin.json and the ones they have been granted. Plugin connections to the
+server can only call methods covered by their granted permissions.`,
+	Args: cobra.RangeArgs(0, 1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listPermissionsCLI(args); err != nil {
+			log.Fatalf("Error listing permissions: %v", err)
+		}
+	},
+}
+
+var pluginsConfigCmd = &cobra.Command{
+	Use:   "config <plugin-id> [key] [value]",
+	Short: "Show or change a plugin's settings",
+	Long: `Show a plugin's settings, one of them, or change one. Values are parsed as
+JSON when they can be and taken as strings otherwise, and are checked against
+the plugin's settings schema. Running shells pick up changes immediately.
+
+  dms plugins config weather
+  dms plugins config weather interval 30
+  dms plugins config weather cities '["Oslo", "Bergen"]'
+  dms plugins config weather interval --unset`,
+	Args: cobra.RangeArgs(1, 3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return pluginSettingKeys(args[0]), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		unset, _ := cmd.Flags().GetBool("unset")
+		if err := pluginConfigCLI(args, unset); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	},
+}
+
+var pluginsPermissionsGrantCmd = &cobra.Command{
+	Use:   "grant <plugin-id> [permission...]",
+	Short: "Grant permissions to a plugin",
+	Long:  "Grant permissions to an installed plugin, or every permission it requests when none are given.",
+	Args:  cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return permissionNames(), cobra.ShellCompDirectiveNoFileComp
+		}
+		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := changePermissionsCLI(args[0], args[1:], true); err != nil {
+			log.Fatalf("Error granting permissions: %v", err)
+		}
+	},
+}
+
+var pluginsPermissionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <plugin-id> [permission...]",
+	Short: "Revoke permissions from a plugin",
+	Long:  "Revoke permissions from a plugin, or all of them when none are given. Revoking takes effect immediately.",
+	Args:  cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return permissionNames(), cobra.ShellCompDirectiveNoFileComp
+		}
+		return getInstalledPluginIDs(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := changePermissionsCLI(args[0], args[1:], false); err != nil {
+			log.Fatalf("Error revoking permissions: %v", err)
+		}
+	},
+}
+
 func runVersion(cmd *cobra.Command, args []string) {
 	fmt.Printf("%s\n", formatVersion(Version))
 }
@@ -269,6 +474,13 @@ func browsePlugins() error {
 		fmt.Printf("    Author: %s\n", plugin.Author)
 		fmt.Printf("    Description: %s\n", plugin.Description)
 		fmt.Printf("    Repository: %s\n", plugin.Repo)
+		if plugin.Source != registries.OfficialName {
+			trust := "trusted"
+			if !plugin.Trusted {
+				trust = "untrusted"
+			}
+			fmt.Printf("    Registry: %s (%s)\n", plugin.Source, trust)
+		}
 		if len(plugin.Capabilities) > 0 {
 			fmt.Printf("    Capabilities: %s\n", strings.Join(plugin.Capabilities, ", "))
 		}
@@ -315,23 +527,36 @@ func listInstalledPlugins() error {
 		pluginMap[p.ID] = p
 	}
 
+	env := plugins.DetectEnvironment()
 	fmt.Printf("\nInstalled Plugins (%d):\n\n", len(installedNames))
 	for _, id := range installedNames {
+		var registered *plugins.Plugin
 		if plugin, ok := pluginMap[id]; ok {
+			registered = &plugin
 			fmt.Printf("  %s\n", plugin.Name)
 			fmt.Printf("    ID: %s\n", plugin.ID)
 			fmt.Printf("    Category: %s\n", plugin.Category)
 			fmt.Printf("    Author: %s\n", plugin.Author)
-			fmt.Println()
 		} else {
-			fmt.Printf("  %s (not in registry)\n\n", id)
+			fmt.Printf("  %s (not in registry)\n", id)
+		}
+		for _, reason := range manager.CheckInstalled(id, registered, env) {
+			fmt.Printf("    Incompatible: %s\n", reason)
 		}
+		fmt.Println()
 	}
 
 	return nil
 }
 
-func installPluginCLI(idOrName string) error {
+type pluginInstallOptions struct {
+	allowUntrusted  bool
+	allowUnverified bool
+	yes             bool
+	force           bool
+}
+
+func installPluginCLI(idOrName string, opts pluginInstallOptions) error {
 	registry, err := plugins.NewRegistry()
 	if err != nil {
 		return fmt.Errorf("failed to create registry: %w", err)
@@ -379,12 +604,55 @@ func installPluginCLI(idOrName string) error {
 		return fmt.Errorf("plugin already installed: %s", plugin.Name)
 	}
 
+	installedIDs, err := manager.ListInstalled()
+	if err != nil {
+		return fmt.Errorf("failed to list installed plugins: %w", err)
+	}
+	plan, err := plugins.PlanInstall(*plugin, pluginList, installedIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range plan.Install {
+		if err := plugins.CheckTrust(p, opts.allowUntrusted); err != nil {
+			return fmt.Errorf("%w (--allow-untrusted)", err)
+		}
+	}
+
+	env := plugins.DetectEnvironment()
+	incompatible := false
+	for _, p := range plan.Install {
+		for _, reason := range plugins.CheckCompatibility(p, env) {
+			fmt.Printf("Warning: %s %s\n", p.ID, reason)
+			incompatible = true
+		}
+	}
+	if incompatible && !opts.force {
+		return fmt.Errorf("%s is not compatible with this system (--force)", plugin.Name)
+	}
+
+	if deps := plan.Dependencies(); len(deps) > 0 {
+		fmt.Printf("%s requires:\n", plugin.Name)
+		for _, dep := range deps {
+			fmt.Printf("  %s (ID: %s)\n", dep.Name, dep.ID)
+		}
+		if !opts.yes && !confirmPrompt("Install them too?") {
+			return fmt.Errorf("missing dependencies not installed (--yes)")
+		}
+	}
+
 	fmt.Printf("Installing plugin: %s (ID: %s)\n", plugin.Name, plugin.ID)
-	if err := manager.Install(*plugin); err != nil {
-		return fmt.Errorf("failed to install plugin: %w", err)
+	manager.AllowUnverified(opts.allowUnverified)
+	if err := manager.InstallPlan(plan); err != nil {
+		return unverifiedHint(fmt.Errorf("failed to install plugin: %w", err))
 	}
 
 	fmt.Printf("Plugin installed successfully: %s\n", plugin.Name)
+	for _, id := range plan.IDs() {
+		if err := approvePermissionsCLI(manager, id, opts.yes, true); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -420,7 +688,7 @@ func getInstalledPluginIDs() []string {
 	return installed
 }
 
-func uninstallPluginCLI(idOrName string) error {
+func uninstallPluginCLI(idOrName string, yes bool) error {
 	manager, err := plugins.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create manager: %w", err)
@@ -434,36 +702,94 @@ func uninstallPluginCLI(idOrName string) error {
 	pluginList, _ := registry.List()
 	plugin := plugins.FindByIDOrName(idOrName, pluginList)
 
+	id := idOrName
 	if plugin != nil {
-		installed, err := manager.IsInstalled(*plugin)
-		if err != nil {
-			return fmt.Errorf("failed to check install status: %w", err)
-		}
-		if !installed {
-			return fmt.Errorf("plugin not installed: %s", plugin.Name)
-		}
+		id = plugin.ID
+	}
+	id, err = manager.InstalledID(id)
+	if err != nil {
+		return fmt.Errorf("failed to check install status: %w", err)
+	}
+	if id == "" {
+		return fmt.Errorf("plugin not installed: %s", idOrName)
+	}
 
-		fmt.Printf("Uninstalling plugin: %s (ID: %s)\n", plugin.Name, plugin.ID)
-		if err := manager.Uninstall(*plugin); err != nil {
-			return fmt.Errorf("failed to uninstall plugin: %w", err)
-		}
-		fmt.Printf("Plugin uninstalled successfully: %s\n", plugin.Name)
-		return nil
+	ids, err := confirmDependents(manager, id, pluginList, yes)
+	if err != nil {
+		return err
 	}
+	ids = append(ids, id)
 
-	fmt.Printf("Uninstalling plugin: %s\n", idOrName)
-	if err := manager.UninstallByIDOrName(idOrName); err != nil {
+	fmt.Printf("Uninstalling: %s\n", strings.Join(ids, ", "))
+	stopBackendsCLI(ids)
+	if err := manager.UninstallAll(ids, pluginList); err != nil {
 		return err
 	}
 	fmt.Printf("Plugin uninstalled successfully: %s\n", idOrName)
 	return nil
 }
 
-func updatePluginCLI(idOrName string) error {
+// stopBackendsCLI asks a running server to stop the backends of plugins
+// about to be removed. Without a server nothing is running.
+func stopBackendsCLI(ids []string) {
+	for _, id := range ids {
+		tryServerRequest(models.Request{
+			ID:     1,
+			Method: "plugins.backend.stop",
+			Params: map[string]any{"plugin": id},
+		})
+	}
+}
+
+// confirmDependents returns the installed plugins that need id, once the
+// user agrees to remove them too, so none is left broken.
+func confirmDependents(manager *plugins.Manager, id string, pluginList []plugins.Plugin, yes bool) ([]string, error) {
+	deps, err := manager.InstalledDependencies(pluginList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin dependencies: %w", err)
+	}
+	dependents := plugins.PlanUninstall(id, deps)
+	if len(dependents) == 0 {
+		return nil, nil
+	}
+
+	fmt.Printf("Installed plugins that depend on %s: %s\n", id, strings.Join(dependents, ", "))
+	if !yes && !confirmPrompt("Uninstall them too?") {
+		return nil, fmt.Errorf("%s is required by %s (--yes)", id, strings.Join(dependents, ", "))
+	}
+	return dependents, nil
+}
+
+func confirmPrompt(question string) bool {
+	fmt.Printf("%s (y/N): ", question)
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// unverifiedHint points out the escape hatch when a plugin failed
+// verification.
+func unverifiedHint(err error) error {
+	var verr *plugins.VerificationError
+	if errors.As(err, &verr) {
+		return fmt.Errorf("%w (--allow-unverified)", err)
+	}
+	return err
+}
+
+func updatePluginCLI(idOrName string, yes, allowUnverified bool) error {
 	manager, err := plugins.NewManager()
 	if err != nil {
 		return fmt.Errorf("failed to create manager: %w", err)
 	}
+	manager.AllowUnverified(allowUnverified)
+	manager.ConfirmTrustChange(func(plugin plugins.Plugin, reason string) bool {
+		fmt.Printf("Plugin %s from %s: %s\n", plugin.ID, plugin.Repo, reason)
+		return confirmPrompt("Trust the new version?")
+	})
 
 	registry, err := plugins.NewRegistry()
 	if err != nil {
@@ -482,12 +808,22 @@ func updatePluginCLI(idOrName string) error {
 			return fmt.Errorf("plugin not installed: %s", plugin.Name)
 		}
 
+		if !yes {
+			proceed, err := previewUpdate(manager, *plugin)
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				return nil
+			}
+		}
+
 		fmt.Printf("Updating plugin: %s (ID: %s)\n", plugin.Name, plugin.ID)
 		if err := manager.Update(*plugin); err != nil {
-			return fmt.Errorf("failed to update plugin: %w", err)
+			return unverifiedHint(fmt.Errorf("failed to update plugin: %w", err))
 		}
 		fmt.Printf("Plugin updated successfully: %s\n", plugin.Name)
-		return nil
+		return approvePermissionsCLI(manager, plugin.ID, false, !yes)
 	}
 
 	fmt.Printf("Updating plugin: %s\n", idOrName)
@@ -498,6 +834,192 @@ func updatePluginCLI(idOrName string) error {
 	return nil
 }
 
+// previewUpdate shows the commits an update would bring in and asks
+// whether to go ahead.
+func previewUpdate(manager *plugins.Manager, plugin plugins.Plugin) (bool, error) {
+	lock, err := manager.ReadLock()
+	if err != nil {
+		return false, err
+	}
+	if entry := lock.Plugins[plugin.ID]; entry.Pinned {
+		return false, fmt.Errorf("plugin %s is pinned at %s; unpin it to update", plugin.ID, plugins.ShortCommit(entry.Commit))
+	}
+
+	current, err := manager.Commit(plugin)
+	if err != nil {
+		return false, fmt.Errorf("failed to read current commit: %w", err)
+	}
+	target, commits, err := manager.PendingCommits(plugin)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch updates: %w", err)
+	}
+	if target == "" {
+		return true, nil
+	}
+	if len(commits) == 0 {
+		fmt.Printf("Plugin already up to date: %s (%s)\n", plugin.Name, plugins.ShortCommit(current))
+		return false, nil
+	}
+
+	fmt.Printf("Updating %s: %s..%s (%d commits)\n", plugin.Name, plugins.ShortCommit(current), plugins.ShortCommit(target), len(commits))
+	for _, c := range commits {
+		fmt.Printf("  %s %s (%s)\n", plugins.ShortCommit(c.Hash), c.Summary, c.Author)
+	}
+	return confirmPrompt("Apply update?"), nil
+}
+
+func installLockedCLI(allowUnverified bool) error {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+	manager.AllowUnverified(allowUnverified)
+
+	fmt.Printf("Installing plugins from %s\n", manager.LockPath())
+	restored, err := manager.InstallLocked()
+	for _, id := range restored {
+		fmt.Printf("  %s\n", id)
+	}
+	fmt.Printf("Restored %d plugin(s)\n", len(restored))
+	return err
+}
+
+func pinPluginCLI(id string, pin bool) error {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+
+	if !pin {
+		if _, err := manager.Unpin(id); err != nil {
+			return err
+		}
+		fmt.Printf("Unpinned %s\n", id)
+		return nil
+	}
+
+	entry, err := manager.Pin(id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pinned %s at %s\n", id, plugins.ShortCommit(entry.Commit))
+	return nil
+}
+
+// approvePermissionsCLI lists the permissions a plugin requests but hasn't
+// been granted, and grants them if grant is set or the user agrees.
+func approvePermissionsCLI(manager *plugins.Manager, id string, grant, ask bool) error {
+	requested, err := manager.Requested(id)
+	if err != nil {
+		return err
+	}
+	granted, err := manager.Granted(id)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, name := range requested {
+		if !slices.Contains(granted, name) {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	fmt.Printf("%s requests permission to:\n", id)
+	for _, name := range pending {
+		permission, _ := plugins.LookupPermission(name)
+		fmt.Printf("  %-20s %s\n", name, permission.Description)
+	}
+	if !grant && !(ask && confirmPrompt("Grant these permissions?")) {
+		fmt.Printf("Not granted; grant them later with: dms plugins permissions grant %s\n", id)
+		return nil
+	}
+	if _, err := manager.Grant(id, pending...); err != nil {
+		return fmt.Errorf("failed to grant permissions: %w", err)
+	}
+	fmt.Printf("Granted %s\n", strings.Join(pending, ", "))
+	return nil
+}
+
+func listPermissionsCLI(ids []string) error {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+	if len(ids) == 0 {
+		if ids, err = manager.ListInstalled(); err != nil {
+			return fmt.Errorf("failed to list installed plugins: %w", err)
+		}
+	}
+	grants, err := manager.Grants()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		requested, err := manager.Requested(id)
+		if err != nil {
+			return err
+		}
+		if len(requested) == 0 && len(grants[id]) == 0 {
+			continue
+		}
+		fmt.Printf("%s\n", id)
+		for _, name := range requested {
+			status := "not granted"
+			if slices.Contains(grants[id], name) {
+				status = "granted"
+			}
+			fmt.Printf("  %-20s %s\n", name, status)
+		}
+		for _, name := range grants[id] {
+			if !slices.Contains(requested, name) {
+				fmt.Printf("  %-20s granted, not requested\n", name)
+			}
+		}
+	}
+	return nil
+}
+
+func changePermissionsCLI(id string, names []string, grant bool) error {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+
+	var granted []string
+	if grant {
+		if len(names) == 0 {
+			if names, err = manager.Requested(id); err != nil {
+				return err
+			}
+		}
+		granted, err = manager.Grant(id, names...)
+	} else {
+		granted, err = manager.Revoke(id, names...)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(granted) == 0 {
+		fmt.Printf("%s has no permissions\n", id)
+		return nil
+	}
+	fmt.Printf("%s has %s\n", id, strings.Join(granted, ", "))
+	return nil
+}
+
+func permissionNames() []string {
+	names := make([]string, len(plugins.Permissions))
+	for i, p := range plugins.Permissions {
+		names[i] = p.Name
+	}
+	return names
+}
+
 func getCommonCommands() []*cobra.Command {
 	return []*cobra.Command{
 		versionCmd,
@@ -509,6 +1031,7 @@ func getCommonCommands() []*cobra.Command {
 		debugSrvCmd,
 		pluginsCmd,
 		dank16Cmd,
+		themesCmd,
 		brightnessCmd,
 		dpmsCmd,
 		keybindsCmd,
@@ -527,3 +1050,81 @@ func getCommonCommands() []*cobra.Command {
 		dlCmd,
 	}
 }
+
+func pluginConfigCLI(args []string, unset bool) error {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+	id := args[0]
+
+	switch {
+	case unset:
+		if len(args) != 2 {
+			return fmt.Errorf("--unset takes a key and no value")
+		}
+		settings, err := manager.UpdateSettings(id, map[string]any{args[1]: nil})
+		if err != nil {
+			return err
+		}
+		return printSetting(settings[args[1]])
+	case len(args) == 3:
+		var value any
+		if err := json.Unmarshal([]byte(args[2]), &value); err != nil || value == nil {
+			value = args[2]
+		}
+		_, err := manager.UpdateSettings(id, map[string]any{args[1]: value})
+		return err
+	}
+
+	settings, err := manager.Settings(id)
+	if err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		value, ok := settings[args[1]]
+		if !ok {
+			return fmt.Errorf("%s has no setting %s", id, args[1])
+		}
+		return printSetting(value)
+	}
+	return printSetting(settings)
+}
+
+// printSetting prints strings as they are and everything else as JSON.
+func printSetting(value any) error {
+	if s, ok := value.(string); ok {
+		fmt.Println(s)
+		return nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func pluginSettingKeys(id string) []string {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return nil
+	}
+	settings, err := manager.Settings(id)
+	if err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	if schema, err := manager.SettingsSchema(id); err == nil && schema != nil {
+		for key := range schema.Properties {
+			if _, ok := settings[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -26,14 +27,23 @@ func init() {
 	dank16Cmd.Flags().Bool("alacritty", false, "Output in Alacritty terminal format")
 	dank16Cmd.Flags().Bool("ghostty", false, "Output in Ghostty terminal format")
 	dank16Cmd.Flags().Bool("wezterm", false, "Output in Wezterm terminal format")
+	dank16Cmd.Flags().String("format", "", "Output format: "+strings.Join(dank16.Formats(), ", "))
 	dank16Cmd.Flags().String("background", "", "Custom background color")
 	dank16Cmd.Flags().String("contrast", "dps", "Contrast algorithm: dps (Delta Phi Star, default) or wcag")
+	dank16Cmd.Flags().String("cvd", "", "Separate ANSI colors for a color vision deficiency: protanopia, deuteranopia or tritanopia")
+	dank16Cmd.Flags().Bool("report", false, "Print contrast and color-vision-deficiency distances instead of a theme")
 	dank16Cmd.Flags().Bool("variants", false, "Output all variants (dark/light/default) in JSON")
 	dank16Cmd.Flags().String("primary-dark", "", "Primary color for dark mode (use with --variants)")
 	dank16Cmd.Flags().String("primary-light", "", "Primary color for light mode (use with --variants)")
+	_ = dank16Cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return dank16.Formats(), cobra.ShellCompDirectiveNoFileComp
+	})
 	_ = dank16Cmd.RegisterFlagCompletionFunc("contrast", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"dps", "wcag"}, cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = dank16Cmd.RegisterFlagCompletionFunc("cvd", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"protanopia", "deuteranopia", "tritanopia"}, cobra.ShellCompDirectiveNoFileComp
+	})
 }
 
 func runDank16(cmd *cobra.Command, args []string) {
@@ -45,11 +55,14 @@ func runDank16(cmd *cobra.Command, args []string) {
 	isAlacritty, _ := cmd.Flags().GetBool("alacritty")
 	isGhostty, _ := cmd.Flags().GetBool("ghostty")
 	isWezterm, _ := cmd.Flags().GetBool("wezterm")
+	format, _ := cmd.Flags().GetString("format")
 	background, _ := cmd.Flags().GetString("background")
 	contrastAlgo, _ := cmd.Flags().GetString("contrast")
 	useVariants, _ := cmd.Flags().GetBool("variants")
 	primaryDark, _ := cmd.Flags().GetString("primary-dark")
 	primaryLight, _ := cmd.Flags().GetString("primary-light")
+	cvdName, _ := cmd.Flags().GetString("cvd")
+	report, _ := cmd.Flags().GetBool("report")
 
 	if background != "" && !strings.HasPrefix(background, "#") {
 		background = "#" + background
@@ -65,6 +78,10 @@ func runDank16(cmd *cobra.Command, args []string) {
 	if contrastAlgo != "dps" && contrastAlgo != "wcag" {
 		log.Fatalf("Invalid contrast algorithm: %s (must be 'dps' or 'wcag')", contrastAlgo)
 	}
+	cvd, err := dank16.ParseCVD(cvdName)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	if useVariants {
 		if primaryDark == "" || primaryLight == "" {
@@ -84,8 +101,17 @@ func runDank16(cmd *cobra.Command, args []string) {
 			Background:   background,
 			UseDPS:       contrastAlgo == "dps",
 			IsLightMode:  isLight,
+			CVD:          cvd,
 		}
 		variantColors := dank16.GenerateVariantPalette(variantOpts)
+		if report {
+			printDank16Report(variantColors.Resolve("default"), isLight, isJson)
+			return
+		}
+		if format != "" {
+			printDank16Format(format, variantColors.Resolve("default"))
+			return
+		}
 		fmt.Print(dank16.GenerateVariantJSON(variantColors))
 		return
 	}
@@ -102,10 +128,21 @@ func runDank16(cmd *cobra.Command, args []string) {
 		IsLight:    isLight,
 		Background: background,
 		UseDPS:     contrastAlgo == "dps",
+		CVD:        cvd,
 	}
 
 	colors := dank16.GeneratePalette(primaryColor, opts)
 
+	if report {
+		printDank16Report(colors, isLight, isJson)
+		return
+	}
+
+	if format != "" {
+		printDank16Format(format, colors)
+		return
+	}
+
 	if isJson {
 		fmt.Print(dank16.GenerateJSON(colors))
 	} else if isKitty {
@@ -124,3 +161,40 @@ func runDank16(cmd *cobra.Command, args []string) {
 		fmt.Print(dank16.GenerateGhosttyTheme(colors))
 	}
 }
+
+func printDank16Format(format string, colors dank16.Palette) {
+	out, err := dank16.Export(format, colors)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Print(out)
+}
+
+func printDank16Report(colors dank16.Palette, isLight, asJSON bool) {
+	report := dank16.Report(colors, isLight)
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Contrast against background %s\n", colors.Color0.Hex)
+	fmt.Printf("  %-6s %-8s %7s %7s\n", "slot", "color", "wcag", "dps")
+	for _, s := range report.Slots {
+		fmt.Printf("  %-6d %-8s %7.2f %7.1f\n", s.Slot, s.Hex, s.ContrastRatio, s.DeltaPhiStar)
+	}
+
+	for _, vision := range []string{"normal", "protanopia", "deuteranopia", "tritanopia"} {
+		fmt.Printf("\nColor distance (CIEDE2000), %s vision\n", vision)
+		for _, pair := range report.Distances[vision] {
+			flag := ""
+			if pair.Conflict {
+				flag = "  too close"
+			}
+			fmt.Printf("  %2d/%-2d %6.1f%s\n", pair.A, pair.B, pair.Distance, flag)
+		}
+	}
+}
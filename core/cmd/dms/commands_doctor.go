@@ -716,7 +716,6 @@ func checkOptionalDependencies() []checkResult {
 		name, cmd, desc string
 		important       bool
 	}{
-		{"matugen", "matugen", "Dynamic theming", true},
 		{"dgop", "dgop", "System monitoring", true},
 		{"cava", "cava", "Audio visualizer", true},
 		{"khal", "khal", "Calendar events", false},
@@ -15,6 +15,7 @@ import (
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/distros"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/errdefs"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/version"
 	"github.com/spf13/cobra"
@@ -96,9 +97,34 @@ func runUpdate() {
 	}
 
 	log.Info("Update complete! Restarting DMS...")
+	warnIncompatiblePlugins()
 	restartShell()
 }
 
+// warnIncompatiblePlugins re-checks installed plugins after an update, as
+// a new DMS version can fall outside their requires_dms.
+func warnIncompatiblePlugins() {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return
+	}
+	installed, err := manager.ListInstalled()
+	if err != nil {
+		return
+	}
+
+	var available []plugins.Plugin
+	if registry, err := plugins.NewRegistry(); err == nil {
+		available, _ = registry.List()
+	}
+	env := plugins.DetectEnvironment()
+	for _, id := range installed {
+		for _, reason := range manager.CheckInstalled(id, plugins.FindByIDOrName(id, available), env) {
+			log.Warnf("Plugin %s is incompatible: %s", id, reason)
+		}
+	}
+}
+
 func updateArchLinux() error {
 	homeDir, err := os.UserHomeDir()
 	if err == nil {
@@ -4,18 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/matugen"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 var matugenCmd = &cobra.Command{
 	Use:   "matugen",
 	Short: "Generate Material Design themes",
-	Long:  "Generate Material Design themes using matugen with dank16 color integration",
+	Long: `Generate Material Design themes with dank16 color integration.
+
+Colors are generated from a wallpaper or hex color and every theme template
+(GTK, Qt, terminals, browsers and so on) is rendered in-process; the matugen
+binary is not needed. Templates use matugen's syntax, so custom templates in
+~/.config/matugen/config.toml keep working.
+
+More apps can be themed by dropping target definitions (JSON with id,
+commands, template and output, or a matugen configFile, plus an optional
+reloadCommand or reloadSignal) into ~/.config/matugen/dms/targets.`,
 }
 
 var matugenGenerateCmd = &cobra.Command{
@@ -30,6 +42,16 @@ var matugenQueueCmd = &cobra.Command{
 	Run:   runMatugenQueue,
 }
 
+var matugenRollbackCmd = &cobra.Command{
+	Use:   "rollback [n]",
+	Short: "Restore the theme files from n generations ago (default 1)",
+	Long: `Restore the files written by the last n theme generations and reload GTK,
+Qt and terminals. Every generation snapshots the files it overwrites; the
+newest 10 snapshots are kept. Use --list to see them.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMatugenRollback,
+}
+
 var matugenCheckCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check which template apps are detected",
@@ -40,6 +62,7 @@ func init() {
 	matugenCmd.AddCommand(matugenGenerateCmd)
 	matugenCmd.AddCommand(matugenQueueCmd)
 	matugenCmd.AddCommand(matugenCheckCmd)
+	matugenCmd.AddCommand(matugenRollbackCmd)
 
 	for _, cmd := range []*cobra.Command{matugenGenerateCmd, matugenQueueCmd} {
 		cmd.Flags().String("state-dir", "", "State directory for cache files")
@@ -55,8 +78,15 @@ func init() {
 		cmd.Flags().Bool("sync-mode-with-portal", false, "Sync color scheme with GNOME portal")
 		cmd.Flags().Bool("terminals-always-dark", false, "Force terminal themes to dark variant")
 		cmd.Flags().String("skip-templates", "", "Comma-separated list of templates to skip")
+		cmd.Flags().StringArray("output-palette", nil, "Also generate a palette for an output from its own wallpaper or hex color (OUTPUT=VALUE, repeatable)")
 	}
 
+	matugenGenerateCmd.Flags().Bool("dry-run", false, "Show the files each template would write and diff them against disk")
+
+	matugenRollbackCmd.Flags().String("state-dir", filepath.Join(utils.XDGCacheHome(), "DankMaterialShell"), "State directory for cache files")
+	matugenRollbackCmd.Flags().String("config-dir", utils.XDGConfigHome(), "User config directory")
+	matugenRollbackCmd.Flags().Bool("list", false, "List snapshots instead of restoring")
+
 	matugenQueueCmd.Flags().Bool("wait", true, "Wait for completion")
 	matugenQueueCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for waiting")
 }
@@ -75,6 +105,12 @@ func buildMatugenOptions(cmd *cobra.Command) matugen.Options {
 	syncModeWithPortal, _ := cmd.Flags().GetBool("sync-mode-with-portal")
 	terminalsAlwaysDark, _ := cmd.Flags().GetBool("terminals-always-dark")
 	skipTemplates, _ := cmd.Flags().GetString("skip-templates")
+	outputPalettes, _ := cmd.Flags().GetStringArray("output-palette")
+
+	outputs, err := matugen.ParseOutputSources(outputPalettes)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	return matugen.Options{
 		StateDir:            stateDir,
@@ -90,16 +126,76 @@ func buildMatugenOptions(cmd *cobra.Command) matugen.Options {
 		SyncModeWithPortal:  syncModeWithPortal,
 		TerminalsAlwaysDark: terminalsAlwaysDark,
 		SkipTemplates:       skipTemplates,
+		Outputs:             outputs,
 	}
 }
 
 func runMatugenGenerate(cmd *cobra.Command, args []string) {
 	opts := buildMatugenOptions(cmd)
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		printMatugenDryRun(opts)
+		return
+	}
 	if err := matugen.Run(opts); err != nil {
 		log.Fatalf("Theme generation failed: %v", err)
 	}
 }
 
+func printMatugenDryRun(opts matugen.Options) {
+	planned, err := matugen.DryRun(opts)
+	if err != nil {
+		log.Fatalf("Theme generation failed: %v", err)
+	}
+
+	counts := map[matugen.FileStatus]int{}
+	for _, f := range planned {
+		counts[f.Status]++
+		fmt.Printf("%-9s %s (%s)\n", f.Status, f.Path, f.Template)
+		switch {
+		case f.Error != "":
+			fmt.Printf("  %s\n", f.Error)
+		case f.Diff != "":
+			fmt.Println(f.Diff)
+		}
+	}
+	fmt.Printf("\n%d new, %d changed, %d unchanged, %d failed\n",
+		counts[matugen.FileNew], counts[matugen.FileChanged], counts[matugen.FileUnchanged], counts[matugen.FileFailed])
+}
+
+func runMatugenRollback(cmd *cobra.Command, args []string) {
+	stateDir, _ := cmd.Flags().GetString("state-dir")
+	configDir, _ := cmd.Flags().GetString("config-dir")
+
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		snaps, err := matugen.ListSnapshots(stateDir)
+		if err != nil {
+			log.Fatalf("Failed to list snapshots: %v", err)
+		}
+		if len(snaps) == 0 {
+			fmt.Println("No snapshots")
+			return
+		}
+		for i, snap := range snaps {
+			fmt.Printf("%2d  %s  %-5s  %d files\n", i+1, snap.Created.Format("2006-01-02 15:04:05"), snap.Mode, len(snap.Files))
+		}
+		return
+	}
+
+	n := 1
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			log.Fatalf("Invalid snapshot number: %s", args[0])
+		}
+	}
+
+	snap, err := matugen.Rollback(matugen.Options{StateDir: stateDir, ConfigDir: configDir}, n)
+	if err != nil {
+		log.Fatalf("Rollback failed: %v", err)
+	}
+	fmt.Printf("Restored theme from %s\n", snap.Created.Format("2006-01-02 15:04:05"))
+}
+
 func runMatugenQueue(cmd *cobra.Command, args []string) {
 	opts := buildMatugenOptions(cmd)
 	wait, _ := cmd.Flags().GetBool("wait")
@@ -122,6 +218,7 @@ func runMatugenQueue(cmd *cobra.Command, args []string) {
 			"syncModeWithPortal":  opts.SyncModeWithPortal,
 			"terminalsAlwaysDark": opts.TerminalsAlwaysDark,
 			"skipTemplates":       opts.SkipTemplates,
+			"outputs":             opts.Outputs,
 			"wait":                wait,
 		},
 	}
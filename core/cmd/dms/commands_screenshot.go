@@ -1,13 +1,15 @@
 package main
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/AvengeMedia/DankMaterialShell/core/internal/clipboard"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/screenshot"
 	"github.com/spf13/cobra"
 )
@@ -19,10 +21,23 @@ var (
 	ssQuality       int
 	ssOutputDir     string
 	ssFilename      string
+	ssTemplate      string
+	ssCompression   string
 	ssNoClipboard   bool
 	ssNoFile        bool
 	ssNoNotify      bool
 	ssStdout        bool
+	ssNoHistory     bool
+	ssHistoryJSON   bool
+	ssHistoryLimit  int
+	ssHistoryKeep   bool
+	ssScrollAuto    bool
+	ssScrollSteps   int
+	ssScrollDelay   int
+	ssScrollIdle    int
+	ssScrollMax     int
+	ssDelay         float64
+	ssNoCountdown   bool
 )
 
 var screenshotCmd = &cobra.Command{
@@ -37,12 +52,34 @@ Modes:
   output      - Capture a specific output by name
   window      - Capture the focused window (Hyprland/DWL)
   last        - Capture the last selected region
+  scroll      - Select a region and stitch a long capture while it scrolls
+  history     - List and manage previous captures
+
+Region selector keys:
+  Space/Enter - Capture the selection     Esc   - Cancel
+  Arrows      - Move selection (Shift: 10px, Ctrl: resize)
+  M           - Toggle measure mode (drag to measure a distance)
+  S           - Toggle snapping to window and image edges
+  A           - Cycle aspect ratio lock (free, 1:1, 4:3, 3:2, 16:9, 21:9)
+  G           - Toggle guides     P - Toggle captured cursor
+  Shift       - Square selection / straight measurement while dragging
 
 Output format (--format):
-  png         - PNG format (default)
+  png         - PNG format (default, --png-compression fast|default|best|none)
   jpg/jpeg    - JPEG format
+  webp        - Lossless WebP
+  qoi         - QOI format
   ppm         - PPM format
 
+Filename templates (--filename-template):
+  strftime conversions (%Y %m %d %H %M %S %j %s ...) plus the tokens
+  {output}, {app}, {title}, {mode}, {count} and {count:N} (zero padded).
+  Slashes create subdirectories, the extension is added automatically.
+  Defaults to "screenshot_%Y-%m-%d_%H-%M-%S".
+
+Defaults for format, quality, directory and template are read from
+~/.config/DankMaterialShell/screenshot.json; flags override them.
+
 Examples:
   dms screenshot                     # Region select, save file + clipboard
   dms screenshot full                # Full screen of focused output
@@ -53,7 +90,11 @@ Examples:
   dms screenshot --no-clipboard      # Save file only
   dms screenshot --no-file           # Clipboard only
   dms screenshot --cursor            # Include cursor
-  dms screenshot -f jpg -q 85        # JPEG with quality 85`,
+  dms screenshot -f jpg -q 85        # JPEG with quality 85
+  dms screenshot full --delay 3      # Countdown, then capture (menus etc.)
+  dms screenshot window --filename-template '{app}/%F_%H%M%S'
+  dms screenshot scroll --auto       # Auto-scroll and stitch a long page
+  dms screenshot history             # Recent captures`,
 }
 
 var ssRegionCmd = &cobra.Command{
@@ -95,12 +136,100 @@ var ssWindowCmd = &cobra.Command{
 	Run:   runScreenshotWindow,
 }
 
+var ssScrollCmd = &cobra.Command{
+	Use:   "scroll",
+	Short: "Capture a scrolling region into one tall image",
+	Long: `Select a region, then scroll its contents. Frames are captured repeatedly
+and stitched together by matching the rows they share.
+
+By default you scroll yourself. With --auto, wheel events are injected through
+a virtual pointer (requires wlr-virtual-pointer-unstable-v1).
+
+The capture stops when:
+  - Enter is pressed in the terminal, or Ctrl+C
+  - 'dms screenshot scroll stop' is run (bind it to a compositor key)
+  - no new rows appear for --idle frames (default 2 with --auto)
+  - --max-frames is reached`,
+	Run: runScreenshotScroll,
+}
+
+var ssScrollStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Finish a running scroll capture",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := screenshot.StopScrollCapture(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
 var ssListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available outputs",
 	Run:   runScreenshotList,
 }
 
+var ssHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous captures",
+	Long: `List screenshots saved to disk, newest first.
+
+Examples:
+  dms screenshot history                  # List recent captures
+  dms screenshot history --json -n 10     # Last 10 as JSON
+  dms screenshot history copy <id>        # Copy a capture to the clipboard
+  dms screenshot history open <id>        # Open with the default viewer
+  dms screenshot history reveal <id>      # Show in file manager
+  dms screenshot history delete <id>      # Delete file and entry`,
+	Run: runScreenshotHistory,
+}
+
+var ssHistoryCopyCmd = &cobra.Command{
+	Use:   "copy <id>",
+	Short: "Copy a previous capture to the clipboard",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScreenshotHistoryAction(screenshot.CopyHistoryEntry(args[0]))
+	},
+}
+
+var ssHistoryOpenCmd = &cobra.Command{
+	Use:   "open <id>",
+	Short: "Open a previous capture",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScreenshotHistoryAction(screenshot.OpenHistoryEntry(args[0]))
+	},
+}
+
+var ssHistoryRevealCmd = &cobra.Command{
+	Use:   "reveal <id>",
+	Short: "Show a previous capture in the file manager",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScreenshotHistoryAction(screenshot.RevealHistoryEntry(args[0]))
+	},
+}
+
+var ssHistoryDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a previous capture",
+	Long:  "Delete a capture from the history. The file is removed too unless --keep-file is given.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScreenshotHistoryAction(screenshot.DeleteHistoryEntry(args[0], !ssHistoryKeep))
+	},
+}
+
+var ssHistoryClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the capture history (files are kept)",
+	Run: func(cmd *cobra.Command, args []string) {
+		runScreenshotHistoryAction(screenshot.ClearHistory())
+	},
+}
+
 var notifyActionCmd = &cobra.Command{
 	Use:    "notify-action",
 	Hidden: true,
@@ -112,14 +241,36 @@ var notifyActionCmd = &cobra.Command{
 func init() {
 	screenshotCmd.PersistentFlags().StringVarP(&ssOutputName, "output", "o", "", "Output name for 'output' mode")
 	screenshotCmd.PersistentFlags().BoolVar(&ssIncludeCursor, "cursor", false, "Include cursor in screenshot")
-	screenshotCmd.PersistentFlags().StringVarP(&ssFormat, "format", "f", "png", "Output format (png, jpg, ppm)")
+	screenshotCmd.PersistentFlags().StringVarP(&ssFormat, "format", "f", "png", "Output format (png, jpg, webp, qoi, ppm)")
 	screenshotCmd.PersistentFlags().IntVarP(&ssQuality, "quality", "q", 90, "JPEG quality (1-100)")
 	screenshotCmd.PersistentFlags().StringVarP(&ssOutputDir, "dir", "d", "", "Output directory")
 	screenshotCmd.PersistentFlags().StringVar(&ssFilename, "filename", "", "Output filename (auto-generated if empty)")
+	screenshotCmd.PersistentFlags().StringVar(&ssTemplate, "filename-template", "", "Filename template with strftime conversions and {output}, {app}, {title}, {mode}, {count} tokens")
+	screenshotCmd.PersistentFlags().StringVar(&ssCompression, "png-compression", "fast", "PNG compression level (fast, default, best, none)")
 	screenshotCmd.PersistentFlags().BoolVar(&ssNoClipboard, "no-clipboard", false, "Don't copy to clipboard")
 	screenshotCmd.PersistentFlags().BoolVar(&ssNoFile, "no-file", false, "Don't save to file")
 	screenshotCmd.PersistentFlags().BoolVar(&ssNoNotify, "no-notify", false, "Don't show notification")
 	screenshotCmd.PersistentFlags().BoolVar(&ssStdout, "stdout", false, "Output image to stdout (for piping to swappy, etc.)")
+	screenshotCmd.PersistentFlags().Float64Var(&ssDelay, "delay", 0, "Wait N seconds before capturing")
+	screenshotCmd.PersistentFlags().BoolVar(&ssNoCountdown, "no-countdown", false, "Don't show the on-screen countdown with --delay")
+	screenshotCmd.PersistentFlags().BoolVar(&ssNoHistory, "no-history", false, "Don't record the capture in the screenshot history")
+
+	ssHistoryCmd.Flags().BoolVar(&ssHistoryJSON, "json", false, "Output as JSON")
+	ssHistoryCmd.Flags().IntVarP(&ssHistoryLimit, "limit", "n", 0, "Max entries (0 for all)")
+	ssHistoryDeleteCmd.Flags().BoolVar(&ssHistoryKeep, "keep-file", false, "Only remove the history entry, keep the file")
+
+	ssScrollCmd.Flags().BoolVar(&ssScrollAuto, "auto", false, "Scroll automatically with a virtual pointer")
+	ssScrollCmd.Flags().IntVar(&ssScrollSteps, "steps", 3, "Wheel clicks per frame with --auto")
+	ssScrollCmd.Flags().IntVar(&ssScrollDelay, "interval", 300, "Delay between frames in milliseconds")
+	ssScrollCmd.Flags().IntVar(&ssScrollIdle, "idle", 0, "Stop after this many frames without new rows (0: manual stop, 2 with --auto)")
+	ssScrollCmd.Flags().IntVar(&ssScrollMax, "max-frames", 200, "Maximum number of frames to capture")
+	ssScrollCmd.AddCommand(ssScrollStopCmd)
+
+	ssHistoryCmd.AddCommand(ssHistoryCopyCmd)
+	ssHistoryCmd.AddCommand(ssHistoryOpenCmd)
+	ssHistoryCmd.AddCommand(ssHistoryRevealCmd)
+	ssHistoryCmd.AddCommand(ssHistoryDeleteCmd)
+	ssHistoryCmd.AddCommand(ssHistoryClearCmd)
 
 	screenshotCmd.AddCommand(ssRegionCmd)
 	screenshotCmd.AddCommand(ssFullCmd)
@@ -127,7 +278,9 @@ func init() {
 	screenshotCmd.AddCommand(ssOutputCmd)
 	screenshotCmd.AddCommand(ssLastCmd)
 	screenshotCmd.AddCommand(ssWindowCmd)
+	screenshotCmd.AddCommand(ssScrollCmd)
 	screenshotCmd.AddCommand(ssListCmd)
+	screenshotCmd.AddCommand(ssHistoryCmd)
 
 	screenshotCmd.Run = runScreenshotRegion
 }
@@ -141,30 +294,42 @@ func getScreenshotConfig(mode screenshot.Mode) screenshot.Config {
 	config.SaveFile = !ssNoFile
 	config.Notify = !ssNoNotify
 	config.Stdout = ssStdout
+	config.History = !ssNoHistory
+	config.Delay = time.Duration(ssDelay * float64(time.Second))
+	config.Countdown = !ssNoCountdown
+
+	screenshot.LoadSettings().Apply(&config)
 
+	flags := screenshotCmd.PersistentFlags()
 	if ssOutputDir != "" {
 		config.OutputDir = ssOutputDir
 	}
 	if ssFilename != "" {
 		config.Filename = ssFilename
 	}
+	if ssTemplate != "" {
+		config.Template = ssTemplate
+	}
 
-	switch strings.ToLower(ssFormat) {
-	case "jpg", "jpeg":
-		config.Format = screenshot.FormatJPEG
-	case "ppm":
-		config.Format = screenshot.FormatPPM
-	default:
-		config.Format = screenshot.FormatPNG
+	if flags.Changed("format") {
+		format, ok := screenshot.ParseFormat(ssFormat)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Warning: unknown format %q, using png\n", ssFormat)
+		}
+		config.Format = format
 	}
 
-	if ssQuality < 1 {
-		ssQuality = 1
+	if flags.Changed("png-compression") {
+		compression, ok := screenshot.ParsePNGCompression(ssCompression)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Warning: unknown PNG compression %q, using fast\n", ssCompression)
+		}
+		config.Compression = compression
 	}
-	if ssQuality > 100 {
-		ssQuality = 100
+
+	if flags.Changed("quality") {
+		config.Quality = min(max(ssQuality, 1), 100)
 	}
-	config.Quality = ssQuality
 
 	return config
 }
@@ -185,150 +350,34 @@ func runScreenshot(config screenshot.Config) {
 
 	if result.YInverted {
 		result.Buffer.FlipVertical()
+		result.YInverted = false
 	}
 
 	if config.Stdout {
-		if err := writeImageToStdout(result.Buffer, config.Format, config.Quality, result.Format); err != nil {
+		if err := writeImageToStdout(result.Buffer, config.EncodeOptions(), result.Format); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to stdout: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	var filePath string
-
-	if config.SaveFile {
-		outputDir := config.OutputDir
-		if outputDir == "" {
-			outputDir = screenshot.GetOutputDir()
-		}
-
-		filename := config.Filename
-		if filename == "" {
-			filename = screenshot.GenerateFilename(config.Format)
-		}
-
-		filePath = filepath.Join(outputDir, filename)
-		if err := screenshot.WriteToFileWithFormat(result.Buffer, filePath, config.Format, config.Quality, result.Format); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println(filePath)
-	}
-
-	if config.Clipboard {
-		if err := copyImageToClipboard(result.Buffer, config.Format, config.Quality, result.Format); err != nil {
-			fmt.Fprintf(os.Stderr, "Error copying to clipboard: %v\n", err)
-			os.Exit(1)
-		}
-		if !config.SaveFile {
-			fmt.Println("Copied to clipboard")
-		}
-	}
-
-	if config.Notify {
-		thumbData, thumbW, thumbH := bufferToRGBThumbnail(result.Buffer, 256, result.Format)
-		screenshot.SendNotification(screenshot.NotifyResult{
-			FilePath:  filePath,
-			Clipboard: config.Clipboard,
-			ImageData: thumbData,
-			Width:     thumbW,
-			Height:    thumbH,
-		})
+	saved, err := screenshot.Save(config, result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-}
 
-func copyImageToClipboard(buf *screenshot.ShmBuffer, format screenshot.Format, quality int, pixelFormat uint32) error {
-	var mimeType string
-	var data bytes.Buffer
-
-	img := screenshot.BufferToImageWithFormat(buf, pixelFormat)
-
-	switch format {
-	case screenshot.FormatJPEG:
-		mimeType = "image/jpeg"
-		if err := screenshot.EncodeJPEG(&data, img, quality); err != nil {
-			return err
-		}
-	default:
-		mimeType = "image/png"
-		if err := screenshot.EncodePNG(&data, img); err != nil {
-			return err
-		}
+	switch {
+	case saved.Path != "":
+		fmt.Println(saved.Path)
+	case saved.Clipboard:
+		fmt.Println("Copied to clipboard")
 	}
-
-	return clipboard.Copy(data.Bytes(), mimeType)
 }
 
-func writeImageToStdout(buf *screenshot.ShmBuffer, format screenshot.Format, quality int, pixelFormat uint32) error {
+func writeImageToStdout(buf *screenshot.ShmBuffer, opts screenshot.EncodeOptions, pixelFormat uint32) error {
 	img := screenshot.BufferToImageWithFormat(buf, pixelFormat)
-
-	switch format {
-	case screenshot.FormatJPEG:
-		return screenshot.EncodeJPEG(os.Stdout, img, quality)
-	default:
-		return screenshot.EncodePNG(os.Stdout, img)
-	}
-}
-
-func bufferToRGBThumbnail(buf *screenshot.ShmBuffer, maxSize int, pixelFormat uint32) ([]byte, int, int) {
-	srcW, srcH := buf.Width, buf.Height
-	scale := 1.0
-	if srcW > maxSize || srcH > maxSize {
-		if srcW > srcH {
-			scale = float64(maxSize) / float64(srcW)
-		} else {
-			scale = float64(maxSize) / float64(srcH)
-		}
-	}
-
-	dstW := int(float64(srcW) * scale)
-	dstH := int(float64(srcH) * scale)
-	if dstW < 1 {
-		dstW = 1
-	}
-	if dstH < 1 {
-		dstH = 1
-	}
-
-	data := buf.Data()
-	rgb := make([]byte, dstW*dstH*3)
-
-	var swapRB bool
-	switch pixelFormat {
-	case uint32(screenshot.FormatABGR8888), uint32(screenshot.FormatXBGR8888):
-		swapRB = false
-	default:
-		swapRB = true
-	}
-
-	for y := 0; y < dstH; y++ {
-		srcY := int(float64(y) / scale)
-		if srcY >= srcH {
-			srcY = srcH - 1
-		}
-		for x := 0; x < dstW; x++ {
-			srcX := int(float64(x) / scale)
-			if srcX >= srcW {
-				srcX = srcW - 1
-			}
-			si := srcY*buf.Stride + srcX*4
-			di := (y*dstW + x) * 3
-			if si+3 >= len(data) {
-				continue
-			}
-			if swapRB {
-				rgb[di+0] = data[si+2]
-				rgb[di+1] = data[si+1]
-				rgb[di+2] = data[si+0]
-			} else {
-				rgb[di+0] = data[si+0]
-				rgb[di+1] = data[si+1]
-				rgb[di+2] = data[si+2]
-			}
-		}
-	}
-	return rgb, dstW, dstH
+	return screenshot.EncodeImage(os.Stdout, img, opts)
 }
 
 func runScreenshotRegion(cmd *cobra.Command, args []string) {
@@ -368,6 +417,81 @@ func runScreenshotWindow(cmd *cobra.Command, args []string) {
 	runScreenshot(config)
 }
 
+func runScreenshotScroll(cmd *cobra.Command, args []string) {
+	config := getScreenshotConfig(screenshot.ModeScroll)
+	config.Scroll.Auto = ssScrollAuto
+	config.Scroll.Steps = ssScrollSteps
+	config.Scroll.Interval = time.Duration(ssScrollDelay) * time.Millisecond
+	config.Scroll.IdleFrames = ssScrollIdle
+	config.Scroll.MaxFrames = ssScrollMax
+
+	stop := make(chan struct{})
+	config.Scroll.Stop = stop
+	var once sync.Once
+	finish := func() { once.Do(func() { close(stop) }) }
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		if _, ok := <-sigCh; ok {
+			finish()
+		}
+	}()
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Select a region, then press Enter here to finish")
+		go func() {
+			buf := make([]byte, 1)
+			if _, err := os.Stdin.Read(buf); err == nil {
+				finish()
+			}
+		}()
+	}
+
+	if err := screenshot.WriteScrollPID(); err != nil {
+		log.Debug("failed to write scroll pid file", "err", err)
+	}
+	defer screenshot.RemoveScrollPID()
+
+	runScreenshot(config)
+}
+
+func runScreenshotHistory(cmd *cobra.Command, args []string) {
+	entries, err := screenshot.ListHistory(ssHistoryLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if ssHistoryJSON {
+		out, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No screenshots in history")
+		return
+	}
+
+	for _, e := range entries {
+		where := e.Output
+		if where == "" {
+			where = "-"
+		}
+		fmt.Printf("%s  %s  %-6s %-10s %dx%d  %s\n",
+			e.ID, e.Time.Format("2006-01-02 15:04:05"), e.Mode, where, e.Width, e.Height, e.Path)
+	}
+}
+
+func runScreenshotHistoryAction(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func runScreenshotList(cmd *cobra.Command, args []string) {
 	outputs, err := screenshot.ListOutputs()
 	if err != nil {
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/themes"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var themesCmd = &cobra.Command{
+	Use:   "themes",
+	Short: "Author registry themes",
+	Long:  "Validate, export and pack themes for the DMS theme registry",
+}
+
+var themesValidateCmd = &cobra.Command{
+	Use:   "validate <dir>",
+	Short: "Check a theme directory before publishing",
+	Long: `Check theme.json against the registry schema (metadata, dark and light
+color schemes, variants, flavors and accents), the contrast of text colors
+on the backgrounds they are drawn on, and the preview images the theme
+browser shows. Exits non-zero when there are errors.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runThemesValidate,
+}
+
+var themesExportCmd = &cobra.Command{
+	Use:   "export <dir>",
+	Short: "Export the current dynamic colors as a theme",
+	Long:  "Write the colors generated from the current wallpaper or color as a registry-ready theme directory with theme.json and previews.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runThemesExport,
+}
+
+var themesPackCmd = &cobra.Command{
+	Use:   "pack <dir>",
+	Short: "Lay out a theme for the registry",
+	Long:  "Validate a theme directory and copy it into the registry layout, themes/<id>/ under --output, ready to be added to the registry repository.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runThemesPack,
+}
+
+func init() {
+	themesValidateCmd.Flags().Bool("json", false, "Output issues as JSON")
+
+	themesExportCmd.Flags().String("state-dir", filepath.Join(utils.XDGCacheHome(), "DankMaterialShell"), "State directory containing dms-colors.json")
+	themesExportCmd.Flags().String("id", "", "Theme ID (defaults to the directory name)")
+	themesExportCmd.Flags().String("name", "", "Theme name (defaults to the ID)")
+	themesExportCmd.Flags().String("author", os.Getenv("USER"), "Theme author")
+	themesExportCmd.Flags().String("description", "Exported from DankMaterialShell dynamic colors", "Theme description")
+	themesExportCmd.Flags().String("version", "1.0.0", "Theme version")
+
+	themesPackCmd.Flags().StringP("output", "o", ".", "Registry root to write themes/<id>/ into")
+
+	themesCmd.AddCommand(themesValidateCmd, themesExportCmd, themesPackCmd)
+}
+
+func printThemeIssues(issues []themes.Issue) {
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+}
+
+func runThemesValidate(cmd *cobra.Command, args []string) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	_, issues, err := themes.Validate(afero.NewOsFs(), args[0])
+	if err != nil {
+		log.Fatalf("Validation failed: %v", err)
+	}
+
+	if asJSON {
+		if issues == nil {
+			issues = []themes.Issue{}
+		}
+		data, _ := json.MarshalIndent(issues, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printThemeIssues(issues)
+		if len(issues) == 0 {
+			fmt.Println("Theme is valid")
+		}
+	}
+	if themes.HasErrors(issues) {
+		os.Exit(1)
+	}
+}
+
+func runThemesExport(cmd *cobra.Command, args []string) {
+	stateDir, _ := cmd.Flags().GetString("state-dir")
+	meta := themes.Theme{}
+	meta.ID, _ = cmd.Flags().GetString("id")
+	meta.Name, _ = cmd.Flags().GetString("name")
+	meta.Author, _ = cmd.Flags().GetString("author")
+	meta.Description, _ = cmd.Flags().GetString("description")
+	meta.Version, _ = cmd.Flags().GetString("version")
+
+	outDir := args[0]
+	if meta.ID == "" {
+		meta.ID = filepath.Base(filepath.Clean(outDir))
+	}
+	if meta.Name == "" {
+		meta.Name = meta.ID
+	}
+
+	fs := afero.NewOsFs()
+	if _, err := themes.ExportDynamic(fs, filepath.Join(stateDir, "dms-colors.json"), outDir, meta); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	fmt.Printf("Exported theme %s to %s\n", meta.ID, outDir)
+
+	_, issues, err := themes.Validate(fs, outDir)
+	if err != nil {
+		log.Fatalf("Validation failed: %v", err)
+	}
+	printThemeIssues(issues)
+}
+
+func runThemesPack(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+
+	dir, issues, err := themes.Pack(afero.NewOsFs(), args[0], output)
+	printThemeIssues(issues)
+	if err != nil {
+		log.Fatalf("Pack failed: %v", err)
+	}
+	fmt.Printf("Packed theme into %s\n", dir)
+}
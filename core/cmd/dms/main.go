@@ -26,7 +26,7 @@ func init() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	// Add subcommands to plugins
-	pluginsCmd.AddCommand(pluginsBrowseCmd, pluginsListCmd, pluginsInstallCmd, pluginsUninstallCmd, pluginsUpdateCmd)
+	pluginsCmd.AddCommand(pluginsBrowseCmd, pluginsListCmd, pluginsInstallCmd, pluginsUninstallCmd, pluginsUpdateCmd, pluginsPinCmd, pluginsUnpinCmd, pluginsDigestCmd, pluginsPermissionsCmd, pluginsConfigCmd)
 
 	// Add common commands to root
 	rootCmd.AddCommand(getCommonCommands()...)
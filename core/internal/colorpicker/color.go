@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +20,14 @@ const (
 	FormatHSL
 	FormatHSV
 	FormatCMYK
+	FormatOKLCH
+	FormatOKLab
+	FormatLab
+	FormatHexAlpha
+	FormatCSSRGB
+	FormatCSSHSL
+	FormatQColor
+	FormatName
 )
 
 func ParseFormat(s string) OutputFormat {
@@ -31,11 +40,47 @@ func ParseFormat(s string) OutputFormat {
 		return FormatHSV
 	case "cmyk":
 		return FormatCMYK
+	case "oklch":
+		return FormatOKLCH
+	case "oklab":
+		return FormatOKLab
+	case "lab":
+		return FormatLab
+	case "hexa", "hex-alpha":
+		return FormatHexAlpha
+	case "css-rgb":
+		return FormatCSSRGB
+	case "css-hsl":
+		return FormatCSSHSL
+	case "qcolor":
+		return FormatQColor
+	case "name":
+		return FormatName
 	default:
 		return FormatHex
 	}
 }
 
+// ParseHex parses #RGB, #RRGGBB or #RRGGBBAA, with or without the leading #.
+func ParseHex(s string) (Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return Color{}, fmt.Errorf("invalid hex color: %q", s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid hex color: %q", s)
+	}
+	return Color{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
 func (c Color) ToHex(lowercase bool) string {
 	if lowercase {
 		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
@@ -76,6 +121,22 @@ func (c Color) Format(format OutputFormat, lowercase bool, customFmt string) str
 		return c.ToHSV()
 	case FormatCMYK:
 		return c.ToCMYK()
+	case FormatOKLCH:
+		return c.ToOKLCH()
+	case FormatOKLab:
+		return c.ToOKLab()
+	case FormatLab:
+		return c.ToLab()
+	case FormatHexAlpha:
+		return c.ToHexAlpha(lowercase)
+	case FormatCSSRGB:
+		return c.ToCSSRGB()
+	case FormatCSSHSL:
+		return c.ToCSSHSL()
+	case FormatQColor:
+		return c.ToQColor()
+	case FormatName:
+		return c.ToCSSName()
 	default:
 		return c.ToHex(lowercase)
 	}
@@ -94,6 +155,25 @@ func (c Color) formatCustom(format OutputFormat, customFmt string) string {
 	case FormatCMYK:
 		cy, m, y, k := rgbToCMYK(c.R, c.G, c.B)
 		return replaceArgs4(customFmt, cy, m, y, k)
+	case FormatOKLCH:
+		l, ch, h := c.OKLCH()
+		return replaceArgsStr(customFmt, trimFloat(l*100, 1), trimFloat(ch, 3), trimFloat(h, 1))
+	case FormatOKLab:
+		l, a, b := c.OKLab()
+		return replaceArgsStr(customFmt, trimFloat(l*100, 1), trimFloat(a, 3), trimFloat(b, 3))
+	case FormatLab:
+		l, a, b := c.Lab()
+		return replaceArgsStr(customFmt, trimFloat(l, 1), trimFloat(a, 1), trimFloat(b, 1))
+	case FormatHexAlpha:
+		return replaceArgs4(customFmt,
+			fmt.Sprintf("%02X", c.R), fmt.Sprintf("%02X", c.G), fmt.Sprintf("%02X", c.B), fmt.Sprintf("%02X", c.A))
+	case FormatCSSRGB, FormatQColor:
+		return replaceArgs4(customFmt, c.R, c.G, c.B, c.A)
+	case FormatCSSHSL:
+		h, s, l := rgbToHSL(c.R, c.G, c.B)
+		return replaceArgs(customFmt, h, s, l)
+	case FormatName:
+		return strings.ReplaceAll(customFmt, "{0}", c.ToCSSName())
 	default:
 		if strings.Contains(customFmt, "{0}") {
 			r := fmt.Sprintf("%02X", c.R)
@@ -276,7 +356,7 @@ type ColorJSON struct {
 	} `json:"cmyk"`
 }
 
-func (c Color) ToJSON() (string, error) {
+func (c Color) ToColorJSON() ColorJSON {
 	h, s, l := rgbToHSL(c.R, c.G, c.B)
 	hv, sv, v := rgbToHSV(c.R, c.G, c.B)
 	cy, m, y, k := rgbToCMYK(c.R, c.G, c.B)
@@ -297,8 +377,11 @@ func (c Color) ToJSON() (string, error) {
 	data.CMYK.M = m
 	data.CMYK.Y = y
 	data.CMYK.K = k
+	return data
+}
 
-	bytes, err := json.MarshalIndent(data, "", "  ")
+func (c Color) ToJSON() (string, error) {
+	bytes, err := json.MarshalIndent(c.ToColorJSON(), "", "  ")
 	if err != nil {
 		return "", err
 	}
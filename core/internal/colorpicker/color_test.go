@@ -0,0 +1,102 @@
+package colorpicker
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatModernColorSpaces(t *testing.T) {
+	red := Color{R: 255, A: 255}
+	blue := Color{R: 30, G: 102, B: 245, A: 128}
+	gray := Color{R: 128, G: 128, B: 128, A: 255}
+
+	tests := []struct {
+		c      Color
+		format OutputFormat
+		want   string
+	}{
+		{red, FormatOKLCH, "oklch(62.8% 0.258 29.2)"},
+		{red, FormatOKLab, "oklab(62.8% 0.225 0.126)"},
+		{red, FormatLab, "lab(53.2% 80.1 67.2)"},
+		{gray, FormatOKLCH, "oklch(60% 0 0)"},
+		{red, FormatHexAlpha, "#FF0000FF"},
+		{blue, FormatHexAlpha, "#1E66F580"},
+		{red, FormatCSSRGB, "rgb(255 0 0)"},
+		{blue, FormatCSSRGB, "rgb(30 102 245 / 0.5)"},
+		{red, FormatCSSHSL, "hsl(0 100% 50%)"},
+		{red, FormatQColor, "QColor(255, 0, 0)"},
+		{blue, FormatQColor, "QColor(30, 102, 245, 128)"},
+		{red, FormatName, "red"},
+		{blue, FormatName, "royalblue"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.c.Format(tt.format, false, ""))
+	}
+
+	assert.Equal(t, "#1e66f580", blue.Format(FormatHexAlpha, true, ""))
+	assert.Equal(t, "L=62.8 C=0.258 H=29.2", red.Format(FormatOKLCH, false, "L={0} C={1} H={2}"))
+	assert.Equal(t, "0xFF0000FF", red.Format(FormatHexAlpha, false, "0x{0}{1}{2}{3}"))
+}
+
+func TestParseFormatModern(t *testing.T) {
+	assert.Equal(t, FormatOKLCH, ParseFormat("OKLCH"))
+	assert.Equal(t, FormatHexAlpha, ParseFormat("hex-alpha"))
+	assert.Equal(t, FormatCSSRGB, ParseFormat("css-rgb"))
+	assert.Equal(t, FormatName, ParseFormat("name"))
+	assert.Equal(t, FormatHex, ParseFormat("bogus"))
+}
+
+func TestNearestCSSName(t *testing.T) {
+	name, exact := Color{R: 100, G: 149, B: 237, A: 255}.NearestCSSName()
+	assert.Equal(t, "cornflowerblue", name)
+	assert.True(t, exact)
+
+	name, exact = Color{R: 101, G: 150, B: 230, A: 255}.NearestCSSName()
+	assert.Equal(t, "cornflowerblue", name)
+	assert.False(t, exact)
+
+	name, _ = Color{R: 0, G: 255, B: 255, A: 255}.NearestCSSName()
+	assert.Equal(t, "cyan", name)
+}
+
+func TestFormatColorForPreviewHasGlyphs(t *testing.T) {
+	c := Color{R: 30, G: 102, B: 245, A: 128}
+	for format := FormatHex; format <= FormatName; format++ {
+		text := formatColorForPreview(c, format, false)
+		for _, r := range text {
+			_, ok := fontGlyphs[r]
+			assert.True(t, ok, "format %d: missing glyph %q in %q", format, r, text)
+		}
+	}
+
+	label := Contrast(Color{A: 255}, Color{R: 255, G: 255, B: 255, A: 255}).Label()
+	for _, r := range label {
+		_, ok := fontGlyphs[r]
+		assert.True(t, ok, "missing glyph %q in %q", r, label)
+	}
+}
+
+func TestContrast(t *testing.T) {
+	white := Color{R: 255, G: 255, B: 255, A: 255}
+	black := Color{A: 255}
+
+	r := Contrast(white, black)
+	assert.Equal(t, 21.0, r.Ratio)
+	assert.True(t, r.AA && r.AAA && r.AALarge && r.AAALarge)
+	assert.False(t, math.IsNaN(r.APCA))
+	assert.Equal(t, "body", r.APCALevel)
+	assert.Equal(t, "#FFFFFF", r.Foreground)
+	assert.Equal(t, "#000000", r.Background)
+
+	r = Contrast(Color{R: 0x77, G: 0x77, B: 0x77, A: 255}, white)
+	assert.Equal(t, 4.48, r.Ratio)
+	assert.False(t, r.AA)
+	assert.True(t, r.AALarge)
+	assert.False(t, r.AAALarge)
+
+	r = Contrast(white, white)
+	assert.Equal(t, 1.0, r.Ratio)
+	assert.Equal(t, "fail", r.APCALevel)
+}
@@ -0,0 +1,90 @@
+package colorpicker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// achromaticChroma is the OKLCH chroma below which the hue is meaningless
+// and reported as zero.
+const achromaticChroma = 0.0004
+
+func (c Color) colorful() colorful.Color {
+	return colorful.Color{R: float64(c.R) / 255, G: float64(c.G) / 255, B: float64(c.B) / 255}
+}
+
+// OKLab returns lightness in 0-1 and the a/b axes in their natural range.
+func (c Color) OKLab() (l, a, b float64) {
+	return c.colorful().OkLab()
+}
+
+// OKLCH returns lightness in 0-1, chroma and hue in degrees.
+func (c Color) OKLCH() (l, ch, h float64) {
+	l, ch, h = c.colorful().OkLch()
+	if ch < achromaticChroma {
+		h = 0
+	}
+	return l, ch, h
+}
+
+// Lab returns CIELAB (D65) with lightness in 0-100.
+func (c Color) Lab() (l, a, b float64) {
+	l, a, b = c.colorful().Lab()
+	return l * 100, a * 100, b * 100
+}
+
+func (c Color) ToOKLCH() string {
+	l, ch, h := c.OKLCH()
+	return fmt.Sprintf("oklch(%s%% %s %s)", trimFloat(l*100, 1), trimFloat(ch, 3), trimFloat(h, 1))
+}
+
+func (c Color) ToOKLab() string {
+	l, a, b := c.OKLab()
+	return fmt.Sprintf("oklab(%s%% %s %s)", trimFloat(l*100, 1), trimFloat(a, 3), trimFloat(b, 3))
+}
+
+func (c Color) ToLab() string {
+	l, a, b := c.Lab()
+	return fmt.Sprintf("lab(%s%% %s %s)", trimFloat(l, 1), trimFloat(a, 1), trimFloat(b, 1))
+}
+
+func (c Color) ToHexAlpha(lowercase bool) string {
+	if lowercase {
+		return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+func (c Color) ToCSSRGB() string {
+	if c.A < 255 {
+		return fmt.Sprintf("rgb(%d %d %d / %s)", c.R, c.G, c.B, trimFloat(float64(c.A)/255, 2))
+	}
+	return fmt.Sprintf("rgb(%d %d %d)", c.R, c.G, c.B)
+}
+
+func (c Color) ToCSSHSL() string {
+	h, s, l := rgbToHSL(c.R, c.G, c.B)
+	if c.A < 255 {
+		return fmt.Sprintf("hsl(%d %d%% %d%% / %s)", h, s, l, trimFloat(float64(c.A)/255, 2))
+	}
+	return fmt.Sprintf("hsl(%d %d%% %d%%)", h, s, l)
+}
+
+func (c Color) ToQColor() string {
+	if c.A < 255 {
+		return fmt.Sprintf("QColor(%d, %d, %d, %d)", c.R, c.G, c.B, c.A)
+	}
+	return fmt.Sprintf("QColor(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+// trimFloat formats v with at most prec decimals and no trailing zeros.
+func trimFloat(v float64, prec int) string {
+	pow := math.Pow(10, float64(prec))
+	v = math.Round(v*pow) / pow
+	if v == 0 {
+		v = 0 // drop negative zero
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,71 @@
+package colorpicker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/dank16"
+)
+
+// APCA lightness-contrast thresholds for the usual use cases.
+const (
+	apcaBodyText    = 75
+	apcaContentText = 60
+	apcaLargeText   = 45
+	apcaNonText     = 30
+)
+
+type ContrastReport struct {
+	Foreground string  `json:"foreground"`
+	Background string  `json:"background"`
+	Ratio      float64 `json:"ratio"`
+	AA         bool    `json:"aa"`
+	AALarge    bool    `json:"aaLarge"`
+	AAA        bool    `json:"aaa"`
+	AAALarge   bool    `json:"aaaLarge"`
+	APCA       float64 `json:"apca"`
+	APCALevel  string  `json:"apcaLevel"`
+}
+
+// Contrast compares a foreground (text) color against a background using
+// the WCAG 2 ratio and the APCA-style lightness contrast from dank16.
+func Contrast(fg, bg Color) ContrastReport {
+	fgHex, bgHex := fg.ToHex(false), bg.ToHex(false)
+	ratio := dank16.ContrastRatio(fgHex, bgHex)
+
+	// Light text on a darker background is negative polarity.
+	negative := fg.Luminance() > bg.Luminance()
+	lc := math.Max(dank16.DeltaPhiStar(fgHex, bgHex, negative), 0)
+
+	return ContrastReport{
+		Foreground: fgHex,
+		Background: bgHex,
+		Ratio:      math.Round(ratio*100) / 100,
+		AA:         ratio >= 4.5,
+		AALarge:    ratio >= 3,
+		AAA:        ratio >= 7,
+		AAALarge:   ratio >= 4.5,
+		APCA:       math.Round(lc*10) / 10,
+		APCALevel:  apcaLevel(lc),
+	}
+}
+
+func apcaLevel(lc float64) string {
+	switch {
+	case lc >= apcaBodyText:
+		return "body"
+	case lc >= apcaContentText:
+		return "content"
+	case lc >= apcaLargeText:
+		return "large"
+	case lc >= apcaNonText:
+		return "non-text"
+	default:
+		return "fail"
+	}
+}
+
+// Label is the short summary shown in the picker preview.
+func (r ContrastReport) Label() string {
+	return fmt.Sprintf("%s:1 LC %s", trimFloat(r.Ratio, 2), trimFloat(r.APCA, 0))
+}
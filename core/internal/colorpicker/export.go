@@ -0,0 +1,112 @@
+package colorpicker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type ExportFormat string
+
+const (
+	ExportGPL  ExportFormat = "gpl"
+	ExportCSS  ExportFormat = "css"
+	ExportJSON ExportFormat = "json"
+)
+
+func ParseExportFormat(s string) (ExportFormat, bool) {
+	switch ExportFormat(strings.ToLower(s)) {
+	case ExportGPL:
+		return ExportGPL, true
+	case ExportCSS:
+		return ExportCSS, true
+	case ExportJSON:
+		return ExportJSON, true
+	default:
+		return "", false
+	}
+}
+
+type PaletteJSON struct {
+	Name   string      `json:"name"`
+	Colors []ColorJSON `json:"colors"`
+}
+
+// ParsedColors parses the palette's hex values, skipping entries that were
+// edited into something unparseable by hand.
+func (p Palette) ParsedColors() []Color {
+	colors := make([]Color, 0, len(p.Colors))
+	for _, hex := range p.Colors {
+		if c, err := ParseHex(hex); err == nil {
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
+
+// Export renders a named list of colors as a GIMP palette, a CSS rule of
+// custom properties, or JSON built from ColorJSON.
+func Export(name string, colors []Color, format ExportFormat) (string, error) {
+	switch format {
+	case ExportGPL:
+		return exportGPL(name, colors), nil
+	case ExportCSS:
+		return exportCSS(name, colors), nil
+	case ExportJSON:
+		out := PaletteJSON{Name: name, Colors: make([]ColorJSON, 0, len(colors))}
+		for _, c := range colors {
+			out.Colors = append(out.Colors, c.ToColorJSON())
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+func exportGPL(name string, colors []Color) string {
+	var sb strings.Builder
+	sb.WriteString("GIMP Palette\n")
+	fmt.Fprintf(&sb, "Name: %s\n", name)
+	sb.WriteString("Columns: 0\n#\n")
+	for _, c := range colors {
+		fmt.Fprintf(&sb, "%3d %3d %3d\t%s\n", c.R, c.G, c.B, c.ToHex(false))
+	}
+	return sb.String()
+}
+
+func exportCSS(name string, colors []Color) string {
+	prefix := cssIdent(name)
+	var sb strings.Builder
+	sb.WriteString(":root {\n")
+	for i, c := range colors {
+		fmt.Fprintf(&sb, "  --%s-%d: %s;\n", prefix, i+1, c.ToHex(true))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// cssIdent turns a palette name into something usable in a custom property
+// name: lowercase ASCII letters, digits and single dashes.
+func cssIdent(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+			dash = false
+		case !dash && sb.Len() > 0:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	ident := strings.TrimSuffix(sb.String(), "-")
+	if ident == "" {
+		return "color"
+	}
+	return ident
+}
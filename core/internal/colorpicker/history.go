@@ -0,0 +1,161 @@
+package colorpicker
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+)
+
+const maxHistoryEntries = 100
+
+// storeMu serializes read-modify-write cycles on the history and palette
+// files within one process; writes go through a rename so other processes
+// never see a partial file.
+var storeMu sync.Mutex
+
+type HistoryEntry struct {
+	Hex    string    `json:"hex"`
+	Time   time.Time `json:"time"`
+	Output string    `json:"output,omitempty"`
+}
+
+func (e HistoryEntry) Color() (Color, error) {
+	return ParseHex(e.Hex)
+}
+
+type History struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// HistoryPath is the color history file, shared by the picker CLI and the
+// server.
+func HistoryPath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = path.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cacheDir, "dms", "color-history.json")
+}
+
+func LoadHistory() (*History, error) {
+	data, err := os.ReadFile(HistoryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &History{}, nil
+		}
+		return nil, err
+	}
+
+	var history History
+	if err := json.Unmarshal(data, &history); err != nil {
+		log.Warn("color history corrupted, starting fresh", "err", err)
+		return &History{}, nil
+	}
+	return &history, nil
+}
+
+func saveHistory(history *History) error {
+	return writeJSONFile(HistoryPath(), history)
+}
+
+// RecordHistory puts a picked color at the top of the history. Picking a
+// color that is already in the history moves it up instead of adding a
+// duplicate, so the list doubles as "recent colors".
+func RecordHistory(c Color, output string) (*HistoryEntry, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := HistoryEntry{
+		Hex:    c.ToHex(false),
+		Time:   time.Now(),
+		Output: output,
+	}
+
+	entries := make([]HistoryEntry, 0, len(history.Entries)+1)
+	entries = append(entries, entry)
+	for _, e := range history.Entries {
+		if e.Hex != entry.Hex {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > maxHistoryEntries {
+		entries = entries[:maxHistoryEntries]
+	}
+	history.Entries = entries
+
+	if err := saveHistory(history); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListHistory returns entries newest first. A limit of zero or less returns
+// everything.
+func ListHistory(limit int) ([]HistoryEntry, error) {
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(history.Entries) > limit {
+		return history.Entries[:limit], nil
+	}
+	return history.Entries, nil
+}
+
+func RemoveHistoryEntry(hex string) error {
+	c, err := ParseHex(hex)
+	if err != nil {
+		return err
+	}
+	want := c.ToHex(false)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	history, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	entries := history.Entries[:0]
+	for _, e := range history.Entries {
+		if e.Hex != want {
+			entries = append(entries, e)
+		}
+	}
+	history.Entries = entries
+	return saveHistory(history)
+}
+
+func ClearHistory() error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return saveHistory(&History{})
+}
+
+func writeJSONFile(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,145 @@
+package colorpicker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Color
+	}{
+		{"#ff8000", Color{R: 255, G: 128, B: 0, A: 255}},
+		{"FF8000", Color{R: 255, G: 128, B: 0, A: 255}},
+		{"#f80", Color{R: 255, G: 136, B: 0, A: 255}},
+		{"#11223344", Color{R: 0x11, G: 0x22, B: 0x33, A: 0x44}},
+	}
+	for _, tt := range tests {
+		got, err := ParseHex(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "#12", "#12345", "#gggggg", "#12zz56"} {
+		_, err := ParseHex(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	red := Color{R: 255, A: 255}
+	blue := Color{B: 255, A: 255}
+
+	_, err := RecordHistory(red, "DP-1")
+	require.NoError(t, err)
+	_, err = RecordHistory(blue, "HDMI-A-1")
+	require.NoError(t, err)
+	_, err = RecordHistory(red, "HDMI-A-1")
+	require.NoError(t, err)
+
+	entries, err := ListHistory(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "#FF0000", entries[0].Hex)
+	assert.Equal(t, "HDMI-A-1", entries[0].Output)
+	assert.Equal(t, "#0000FF", entries[1].Hex)
+
+	limited, err := ListHistory(1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	require.NoError(t, RemoveHistoryEntry("#ff0000"))
+	entries, err = ListHistory(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "#0000FF", entries[0].Hex)
+
+	require.NoError(t, ClearHistory())
+	entries, err = ListHistory(0)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRecordHistoryCap(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for i := 0; i < maxHistoryEntries+10; i++ {
+		_, err := RecordHistory(Color{R: uint8(i), G: uint8(i >> 8), A: 255}, "")
+		require.NoError(t, err)
+	}
+
+	entries, err := ListHistory(0)
+	require.NoError(t, err)
+	assert.Len(t, entries, maxHistoryEntries)
+}
+
+func TestPalettes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	require.NoError(t, CreatePalette("brand"))
+	assert.Error(t, CreatePalette("brand"))
+	assert.Error(t, CreatePalette("  "))
+
+	require.NoError(t, AddPaletteColor("brand", "#1e66f5"))
+	require.NoError(t, AddPaletteColor("brand", "#1E66F5"))
+	require.NoError(t, AddPaletteColor("brand", "#40a02b"))
+	require.NoError(t, AddPaletteColor("new", "#000"))
+
+	palette, err := GetPalette("brand")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"#1E66F5", "#40A02B"}, palette.Colors)
+
+	_, err = GetPalette("new")
+	require.NoError(t, err)
+
+	require.NoError(t, RenamePalette("brand", "acme"))
+	assert.Error(t, RenamePalette("acme", "new"))
+	_, err = GetPalette("brand")
+	assert.Error(t, err)
+
+	require.NoError(t, RemovePaletteColor("acme", "#1e66f5"))
+	assert.Error(t, RemovePaletteColor("acme", "#1e66f5"))
+	palette, err = GetPalette("acme")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"#40A02B"}, palette.Colors)
+
+	require.NoError(t, DeletePalette("acme"))
+	assert.Error(t, DeletePalette("acme"))
+
+	palettes, err := LoadPalettes()
+	require.NoError(t, err)
+	require.Len(t, palettes.Palettes, 1)
+	assert.Equal(t, "new", palettes.Palettes[0].Name)
+}
+
+func TestExport(t *testing.T) {
+	colors := []Color{{R: 255, A: 255}, {R: 30, G: 102, B: 245, A: 255}}
+
+	gpl, err := Export("My Brand", colors, ExportGPL)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(gpl, "GIMP Palette\nName: My Brand\n"))
+	assert.Contains(t, gpl, "255   0   0\t#FF0000\n")
+	assert.Contains(t, gpl, " 30 102 245\t#1E66F5\n")
+
+	css, err := Export("My Brand!", colors, ExportCSS)
+	require.NoError(t, err)
+	assert.Equal(t, ":root {\n  --my-brand-1: #ff0000;\n  --my-brand-2: #1e66f5;\n}\n", css)
+
+	out, err := Export("My Brand", colors, ExportJSON)
+	require.NoError(t, err)
+	var decoded PaletteJSON
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, "My Brand", decoded.Name)
+	require.Len(t, decoded.Colors, 2)
+	assert.Equal(t, colors[1].ToColorJSON(), decoded.Colors[1])
+
+	_, err = Export("x", colors, "ase")
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package colorpicker
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+)
+
+// imageBackground fills the area around an image that is smaller than the
+// output, and shows through transparent pixels.
+var imageBackground = color.RGBA{R: 0x1e, G: 0x1e, B: 0x1e, A: 0xff}
+
+func LoadImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// showImageOnSurface puts the configured image on an output in place of a
+// screen capture.
+func (p *Picker) showImageOnSurface(ls *LayerSurface) {
+	width, height := int(ls.output.width), int(ls.output.height)
+	if ls.output.transform%2 == 1 {
+		// 90 and 270 degree transforms: the mode is reported unrotated.
+		width, height = height, width
+	}
+	if width <= 0 || height <= 0 {
+		logicalW, logicalH := ls.state.LogicalSize()
+		scale := int(max(ls.output.scale, 1))
+		width, height = logicalW*scale, logicalH*scale
+	}
+
+	buf, err := renderImageBuffer(p.config.Image, width, height)
+	if err != nil {
+		log.Error("failed to render image", "err", err)
+		p.err = err
+		p.running = false
+		return
+	}
+	ls.state.LoadImage(buf)
+
+	if logicalW, _ := ls.state.LogicalSize(); logicalW > 0 {
+		ls.output.fractionalScale = float64(width) / float64(logicalW)
+	}
+	p.redrawSurface(ls)
+}
+
+// renderImageBuffer centers img in a width x height buffer. Images larger
+// than the buffer are scaled down with nearest-neighbour sampling so every
+// visible pixel keeps a color that exists in the file.
+func renderImageBuffer(img image.Image, width, height int) (*ShmBuffer, error) {
+	buf, err := CreateShmBuffer(width, height, width*4)
+	if err != nil {
+		return nil, err
+	}
+	buf.Format = FormatARGB8888
+
+	bounds := img.Bounds()
+	imgW, imgH := bounds.Dx(), bounds.Dy()
+	scale := 1.0
+	if imgW > width || imgH > height {
+		scale = min(float64(width)/float64(imgW), float64(height)/float64(imgH))
+	}
+	dstW := max(int(float64(imgW)*scale), 1)
+	dstH := max(int(float64(imgH)*scale), 1)
+	ox, oy := (width-dstW)/2, (height-dstH)/2
+
+	data := buf.Data()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := imageBackground
+			if x >= ox && x < ox+dstW && y >= oy && y < oy+dstH {
+				sx := bounds.Min.X + min(int(float64(x-ox)/scale), imgW-1)
+				sy := bounds.Min.Y + min(int(float64(y-oy)/scale), imgH-1)
+				c = overBackground(img.At(sx, sy))
+			}
+			off := y*buf.Stride + x*4
+			data[off+0] = c.B
+			data[off+1] = c.G
+			data[off+2] = c.R
+			data[off+3] = 255
+		}
+	}
+	return buf, nil
+}
+
+func overBackground(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	if a == 0xffff {
+		return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255}
+	}
+	// RGBA() is premultiplied, so the background only needs the inverse alpha.
+	inv := 0xffff - a
+	blend := func(v uint32, bg uint8) uint8 {
+		return uint8((v + uint32(bg)*0x101*inv/0xffff) >> 8)
+	}
+	return color.RGBA{R: blend(r, imageBackground.R), G: blend(g, imageBackground.G), B: blend(b, imageBackground.B), A: 255}
+}
@@ -0,0 +1,198 @@
+package colorpicker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+)
+
+type Palette struct {
+	Name   string   `json:"name"`
+	Colors []string `json:"colors"`
+}
+
+type Palettes struct {
+	Palettes []Palette `json:"palettes"`
+}
+
+func PalettesPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "DankMaterialShell", "color-palettes.json"), nil
+}
+
+func LoadPalettes() (*Palettes, error) {
+	path, err := PalettesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Palettes{}, nil
+		}
+		return nil, err
+	}
+
+	var palettes Palettes
+	if err := json.Unmarshal(data, &palettes); err != nil {
+		log.Warn("color palettes corrupted, starting fresh", "err", err)
+		return &Palettes{}, nil
+	}
+	return &palettes, nil
+}
+
+func savePalettes(palettes *Palettes) error {
+	path, err := PalettesPath()
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(path, palettes)
+}
+
+func (p *Palettes) find(name string) int {
+	for i := range p.Palettes {
+		if p.Palettes[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func GetPalette(name string) (*Palette, error) {
+	palettes, err := LoadPalettes()
+	if err != nil {
+		return nil, err
+	}
+	idx := palettes.find(name)
+	if idx < 0 {
+		return nil, fmt.Errorf("palette %q not found", name)
+	}
+	return &palettes.Palettes[idx], nil
+}
+
+// updatePalettes loads the palettes, applies fn and writes the result back
+// when fn succeeds.
+func updatePalettes(fn func(*Palettes) error) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	palettes, err := LoadPalettes()
+	if err != nil {
+		return err
+	}
+	if err := fn(palettes); err != nil {
+		return err
+	}
+	return savePalettes(palettes)
+}
+
+func validatePaletteName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("palette name cannot be empty")
+	}
+	return name, nil
+}
+
+func CreatePalette(name string) error {
+	name, err := validatePaletteName(name)
+	if err != nil {
+		return err
+	}
+	return updatePalettes(func(p *Palettes) error {
+		if p.find(name) >= 0 {
+			return fmt.Errorf("palette %q already exists", name)
+		}
+		p.Palettes = append(p.Palettes, Palette{Name: name, Colors: []string{}})
+		return nil
+	})
+}
+
+func DeletePalette(name string) error {
+	return updatePalettes(func(p *Palettes) error {
+		idx := p.find(name)
+		if idx < 0 {
+			return fmt.Errorf("palette %q not found", name)
+		}
+		p.Palettes = append(p.Palettes[:idx], p.Palettes[idx+1:]...)
+		return nil
+	})
+}
+
+func RenamePalette(oldName, newName string) error {
+	newName, err := validatePaletteName(newName)
+	if err != nil {
+		return err
+	}
+	return updatePalettes(func(p *Palettes) error {
+		idx := p.find(oldName)
+		if idx < 0 {
+			return fmt.Errorf("palette %q not found", oldName)
+		}
+		if newName != oldName && p.find(newName) >= 0 {
+			return fmt.Errorf("palette %q already exists", newName)
+		}
+		p.Palettes[idx].Name = newName
+		return nil
+	})
+}
+
+// AddPaletteColor appends a color to a palette, creating the palette when it
+// does not exist yet. Colors already in the palette are not added twice.
+func AddPaletteColor(name, hex string) error {
+	name, err := validatePaletteName(name)
+	if err != nil {
+		return err
+	}
+	c, err := ParseHex(hex)
+	if err != nil {
+		return err
+	}
+	hex = c.ToHex(false)
+
+	return updatePalettes(func(p *Palettes) error {
+		idx := p.find(name)
+		if idx < 0 {
+			p.Palettes = append(p.Palettes, Palette{Name: name})
+			idx = len(p.Palettes) - 1
+		}
+		for _, existing := range p.Palettes[idx].Colors {
+			if existing == hex {
+				return nil
+			}
+		}
+		p.Palettes[idx].Colors = append(p.Palettes[idx].Colors, hex)
+		return nil
+	})
+}
+
+func RemovePaletteColor(name, hex string) error {
+	c, err := ParseHex(hex)
+	if err != nil {
+		return err
+	}
+	hex = c.ToHex(false)
+
+	return updatePalettes(func(p *Palettes) error {
+		idx := p.find(name)
+		if idx < 0 {
+			return fmt.Errorf("palette %q not found", name)
+		}
+		colors := p.Palettes[idx].Colors
+		for i, existing := range colors {
+			if existing == hex {
+				p.Palettes[idx].Colors = append(colors[:i], colors[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("color %s not in palette %q", hex, name)
+	})
+}
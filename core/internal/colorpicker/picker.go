@@ -2,6 +2,7 @@ package colorpicker
 
 import (
 	"fmt"
+	"image"
 	"sync"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
@@ -19,6 +20,15 @@ type Config struct {
 	Lowercase    bool
 	Autocopy     bool
 	Notify       bool
+	// Contrast picks two colors, foreground first, and shows their contrast
+	// while choosing the second.
+	Contrast bool
+	// SampleRadius and SampleMode set the initial sampling area; the radius
+	// can be changed with the scroll wheel and the mode with M.
+	SampleRadius int
+	SampleMode   SampleMode
+	// Image is shown on every output instead of a screen capture.
+	Image image.Image
 }
 
 type Output struct {
@@ -74,9 +84,11 @@ type Picker struct {
 	surfaces      []*LayerSurface
 	activeSurface *LayerSurface
 
-	running     bool
-	pickedColor *Color
-	err         error
+	running      bool
+	pickedColor  *Color
+	pickedOutput string
+	reference    *Color
+	err          error
 }
 
 func New(config Config) *Picker {
@@ -100,7 +112,7 @@ func (p *Picker) Run() (*Color, error) {
 		return nil, fmt.Errorf("roundtrip: %w", err)
 	}
 
-	if p.screencopy == nil {
+	if p.screencopy == nil && p.config.Image == nil {
 		return nil, fmt.Errorf("compositor does not support wlr-screencopy-unstable-v1")
 	}
 
@@ -146,6 +158,16 @@ func (p *Picker) Run() (*Color, error) {
 	return p.pickedColor, nil
 }
 
+// Output returns the name of the output the color was picked from.
+func (p *Picker) Output() string {
+	return p.pickedOutput
+}
+
+// Reference returns the first color of a contrast pick.
+func (p *Picker) Reference() *Color {
+	return p.reference
+}
+
 func (p *Picker) checkDone() {
 	for _, ls := range p.surfaces {
 		picked, cancelled := ls.state.IsDone()
@@ -155,8 +177,17 @@ func (p *Picker) checkDone() {
 			return
 		case picked:
 			color, ok := ls.state.PickColor()
+			if ok && p.config.Contrast && p.reference == nil {
+				p.reference = &color
+				for _, other := range p.surfaces {
+					other.state.SetReference(color)
+				}
+				p.redrawSurface(ls)
+				return
+			}
 			if ok {
 				p.pickedColor = &color
+				p.pickedOutput = ls.output.name
 			}
 			p.running = false
 			return
@@ -346,6 +377,8 @@ func (p *Picker) createLayerSurface(output *Output) (*LayerSurface, error) {
 		hidden:    true, // Start hidden, will show overlay when pointer enters
 	}
 
+	ls.state.SetSampling(p.config.SampleMode, p.config.SampleRadius)
+
 	if p.viewporter != nil {
 		vp, err := p.viewporter.GetViewport(surface)
 		if err == nil {
@@ -380,9 +413,12 @@ func (p *Picker) createLayerSurface(output *Output) (*LayerSurface, error) {
 		scale := p.computeSurfaceScale(ls)
 		ls.state.SetScale(scale)
 
-		if !ls.state.IsReady() {
+		switch {
+		case !ls.state.IsReady() && p.config.Image != nil:
+			p.showImageOnSurface(ls)
+		case !ls.state.IsReady():
 			p.captureForSurface(ls)
-		} else {
+		default:
 			p.redrawSurface(ls)
 		}
 
@@ -658,6 +694,23 @@ func (p *Picker) setupPointerHandlers() {
 		p.redrawSurface(p.activeSurface)
 	})
 
+	p.pointer.SetAxisHandler(func(e client.PointerAxisEvent) {
+		if p.activeSurface == nil || e.Axis != uint32(client.PointerAxisVerticalScroll) {
+			return
+		}
+		if !p.activeSurface.state.OnAxis(e.Value) {
+			return
+		}
+		// Keep the radius the same on every output.
+		mode, radius := p.activeSurface.state.Sampling()
+		for _, ls := range p.surfaces {
+			if ls != p.activeSurface {
+				ls.state.SetSampling(mode, radius)
+			}
+		}
+		p.redrawSurface(p.activeSurface)
+	})
+
 	p.pointer.SetButtonHandler(func(e client.PointerButtonEvent) {
 		if p.activeSurface == nil {
 			return
@@ -671,6 +724,9 @@ func (p *Picker) setupKeyboardHandlers() {
 		for _, ls := range p.surfaces {
 			ls.state.OnKey(e.Key, e.State)
 		}
+		if p.activeSurface != nil && !p.activeSurface.hidden {
+			p.redrawSurface(p.activeSurface)
+		}
 	})
 }
 
@@ -0,0 +1,157 @@
+package colorpicker
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxSampleRadius keeps the corners of the sampled square inside the
+	// magnifier circle.
+	maxSampleRadius = 6
+	// axisStep is the scroll distance per radius step; one wheel click is
+	// usually 15 and touchpads send many small values.
+	axisStep = 10.0
+)
+
+type SampleMode int
+
+const (
+	SampleAverage SampleMode = iota
+	SampleMedian
+	SampleDominant
+)
+
+func ParseSampleMode(s string) (SampleMode, bool) {
+	switch strings.ToLower(s) {
+	case "average", "avg", "mean":
+		return SampleAverage, true
+	case "median":
+		return SampleMedian, true
+	case "dominant":
+		return SampleDominant, true
+	default:
+		return SampleAverage, false
+	}
+}
+
+func (m SampleMode) String() string {
+	switch m {
+	case SampleMedian:
+		return "median"
+	case SampleDominant:
+		return "dominant"
+	default:
+		return "average"
+	}
+}
+
+// previewLabel is the short mode name shown next to the color preview.
+func (m SampleMode) previewLabel(radius int) string {
+	abbrev := "AVG"
+	switch m {
+	case SampleMedian:
+		abbrev = "MED"
+	case SampleDominant:
+		abbrev = "DOM"
+	}
+	size := radius*2 + 1
+	return fmt.Sprintf("%dX%d %s", size, size, abbrev)
+}
+
+// sampleArea combines the pixels in the square of the given radius around
+// (x, y). A radius of zero is a single pixel. The square is clipped to the
+// buffer.
+func sampleArea(buf *ShmBuffer, x, y, radius int, mode SampleMode, format PixelFormat) Color {
+	if radius <= 0 {
+		return GetPixelColorWithFormat(buf, x, y, format)
+	}
+
+	x0, x1 := max(x-radius, 0), min(x+radius, buf.Width-1)
+	y0, y1 := max(y-radius, 0), min(y+radius, buf.Height-1)
+	if x0 > x1 || y0 > y1 {
+		return GetPixelColorWithFormat(buf, x, y, format)
+	}
+
+	pixels := make([]Color, 0, (x1-x0+1)*(y1-y0+1))
+	for sy := y0; sy <= y1; sy++ {
+		for sx := x0; sx <= x1; sx++ {
+			pixels = append(pixels, GetPixelColorWithFormat(buf, sx, sy, format))
+		}
+	}
+
+	switch mode {
+	case SampleMedian:
+		return medianColor(pixels)
+	case SampleDominant:
+		return dominantColor(pixels)
+	default:
+		return averageColor(pixels)
+	}
+}
+
+func averageColor(pixels []Color) Color {
+	var r, g, b int
+	for _, p := range pixels {
+		r += int(p.R)
+		g += int(p.G)
+		b += int(p.B)
+	}
+	n := len(pixels)
+	return Color{R: uint8((r + n/2) / n), G: uint8((g + n/2) / n), B: uint8((b + n/2) / n), A: 255}
+}
+
+// medianColor takes the median of each channel separately.
+func medianColor(pixels []Color) Color {
+	var hist [3][256]int
+	for _, p := range pixels {
+		hist[0][p.R]++
+		hist[1][p.G]++
+		hist[2][p.B]++
+	}
+
+	mid := len(pixels) / 2
+	var out [3]uint8
+	for ch := range hist {
+		seen := 0
+		for v, count := range hist[ch] {
+			seen += count
+			if seen > mid {
+				out[ch] = uint8(v)
+				break
+			}
+		}
+	}
+	return Color{R: out[0], G: out[1], B: out[2], A: 255}
+}
+
+// dominantColor buckets pixels by their top four bits per channel and
+// returns the mean of the most populated bucket, so a thin line of text
+// color doesn't shift the result the way averaging does.
+func dominantColor(pixels []Color) Color {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint16]*bucket)
+
+	var best *bucket
+	for _, p := range pixels {
+		key := uint16(p.R>>4)<<8 | uint16(p.G>>4)<<4 | uint16(p.B>>4)
+		bk := buckets[key]
+		if bk == nil {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.count++
+		bk.r += int(p.R)
+		bk.g += int(p.G)
+		bk.b += int(p.B)
+		if best == nil || bk.count > best.count {
+			best = bk
+		}
+	}
+
+	n := best.count
+	return Color{R: uint8((best.r + n/2) / n), G: uint8((best.g + n/2) / n), B: uint8((best.b + n/2) / n), A: 255}
+}
@@ -0,0 +1,142 @@
+package colorpicker
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBuffer(t *testing.T, w, h int, fill func(x, y int) Color) *ShmBuffer {
+	t.Helper()
+	buf, err := CreateShmBuffer(w, h, w*4)
+	require.NoError(t, err)
+	t.Cleanup(func() { buf.Close() })
+
+	data := buf.Data()
+	for y := range h {
+		for x := range w {
+			c := fill(x, y)
+			off := y*buf.Stride + x*4
+			data[off+0] = c.B
+			data[off+1] = c.G
+			data[off+2] = c.R
+			data[off+3] = 255
+		}
+	}
+	return buf
+}
+
+func TestSampleArea(t *testing.T) {
+	white := Color{R: 255, G: 255, B: 255, A: 255}
+	black := Color{A: 255}
+	// 3x3 area: six white pixels, three black ones in the middle column.
+	buf := newTestBuffer(t, 3, 3, func(x, y int) Color {
+		if x == 1 {
+			return black
+		}
+		return white
+	})
+
+	assert.Equal(t, black, sampleArea(buf, 1, 1, 0, SampleAverage, FormatARGB8888))
+	assert.Equal(t, Color{R: 170, G: 170, B: 170, A: 255}, sampleArea(buf, 1, 1, 1, SampleAverage, FormatARGB8888))
+	assert.Equal(t, white, sampleArea(buf, 1, 1, 1, SampleMedian, FormatARGB8888))
+	assert.Equal(t, white, sampleArea(buf, 1, 1, 1, SampleDominant, FormatARGB8888))
+
+	// Clipped at the corner: (0,0), (1,0), (0,1), (1,1).
+	assert.Equal(t, Color{R: 128, G: 128, B: 128, A: 255}, sampleArea(buf, 0, 0, 1, SampleAverage, FormatARGB8888))
+}
+
+func TestDominantColorAveragesBucket(t *testing.T) {
+	pixels := []Color{
+		{R: 200, G: 10, B: 10, A: 255},
+		{R: 202, G: 12, B: 10, A: 255},
+		{R: 10, G: 10, B: 200, A: 255},
+	}
+	assert.Equal(t, Color{R: 201, G: 11, B: 10, A: 255}, dominantColor(pixels))
+}
+
+func TestParseSampleMode(t *testing.T) {
+	mode, ok := ParseSampleMode("Median")
+	assert.True(t, ok)
+	assert.Equal(t, SampleMedian, mode)
+
+	mode, ok = ParseSampleMode("avg")
+	assert.True(t, ok)
+	assert.Equal(t, SampleAverage, mode)
+
+	_, ok = ParseSampleMode("mode")
+	assert.False(t, ok)
+
+	assert.Equal(t, "5X5 DOM", SampleDominant.previewLabel(2))
+}
+
+func TestSurfaceState_OnAxis(t *testing.T) {
+	s := NewSurfaceState(FormatHex, false)
+
+	assert.False(t, s.OnAxis(-5))
+	assert.True(t, s.OnAxis(-5))
+	_, radius := s.Sampling()
+	assert.Equal(t, 1, radius)
+
+	assert.True(t, s.OnAxis(-1000))
+	_, radius = s.Sampling()
+	assert.Equal(t, maxSampleRadius, radius)
+
+	assert.True(t, s.OnAxis(1000))
+	_, radius = s.Sampling()
+	assert.Equal(t, 0, radius)
+	assert.False(t, s.OnAxis(axisStep))
+
+	s.SetSampling(SampleMedian, 99)
+	mode, radius := s.Sampling()
+	assert.Equal(t, SampleMedian, mode)
+	assert.Equal(t, maxSampleRadius, radius)
+}
+
+func TestRenderImageBuffer(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	small := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := range 2 {
+		for x := range 2 {
+			small.Set(x, y, red)
+		}
+	}
+	buf, err := renderImageBuffer(small, 6, 4)
+	require.NoError(t, err)
+	defer buf.Close()
+
+	bg := Color{R: 0x1e, G: 0x1e, B: 0x1e, A: 255}
+	assert.Equal(t, bg, GetPixelColor(buf, 0, 0))
+	assert.Equal(t, Color{R: 255, A: 255}, GetPixelColor(buf, 2, 1))
+	assert.Equal(t, Color{R: 255, A: 255}, GetPixelColor(buf, 3, 2))
+	assert.Equal(t, bg, GetPixelColor(buf, 4, 1))
+
+	large := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			if x < 20 {
+				large.Set(x, y, red)
+			} else {
+				large.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	buf, err = renderImageBuffer(large, 4, 4)
+	require.NoError(t, err)
+	defer buf.Close()
+
+	assert.Equal(t, bg, GetPixelColor(buf, 0, 0))
+	assert.Equal(t, Color{R: 255, A: 255}, GetPixelColor(buf, 0, 1))
+	assert.Equal(t, Color{B: 255, A: 255}, GetPixelColor(buf, 3, 2))
+}
+
+func TestOverBackground(t *testing.T) {
+	assert.Equal(t, imageBackground, overBackground(color.RGBA{}))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, overBackground(color.White))
+	half := overBackground(color.NRGBA{R: 255, G: 255, B: 255, A: 128})
+	assert.InDelta(t, 0x8e, int(half.R), 1)
+}
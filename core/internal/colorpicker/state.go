@@ -43,6 +43,14 @@ type SurfaceState struct {
 	displayFormat OutputFormat
 	lowercase     bool
 
+	// reference is the first color in contrast mode; the preview then shows
+	// the contrast of the color under the pointer against it.
+	reference *Color
+
+	sampleMode   SampleMode
+	sampleRadius int
+	axisAccum    float64
+
 	readyForDisplay bool
 	colorPicked     bool
 	cancelled       bool
@@ -129,6 +137,24 @@ func (s *SurfaceState) ReplaceScreenBuffer(newBuf *ShmBuffer) {
 	s.ensureRenderBuffers()
 }
 
+// LoadImage shows a prepared buffer instead of a screen capture, for
+// picking from image files.
+func (s *SurfaceState) LoadImage(buf *ShmBuffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.screenBuf != nil {
+		s.screenBuf.Close()
+	}
+	s.screenBuf = buf
+	s.screenFormat = buf.Format
+	s.yInverted = false
+
+	s.recomputeScale()
+	s.ensureRenderBuffers()
+	s.readyForDisplay = true
+}
+
 func (s *SurfaceState) OnScreencopyFlags(flags uint32) {
 	s.mu.Lock()
 	s.yInverted = (flags & 1) != 0
@@ -222,6 +248,40 @@ func (s *SurfaceState) OnPointerMotion(x, y float64) {
 	s.mu.Unlock()
 }
 
+func (s *SurfaceState) SetSampling(mode SampleMode, radius int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sampleMode = mode
+	s.sampleRadius = clamp(radius, 0, maxSampleRadius)
+}
+
+func (s *SurfaceState) Sampling() (SampleMode, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sampleMode, s.sampleRadius
+}
+
+// OnAxis grows the sampling radius when scrolling up and shrinks it when
+// scrolling down. It reports whether the radius changed.
+func (s *SurfaceState) OnAxis(value float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.axisAccum += value
+	steps := int(s.axisAccum / axisStep)
+	if steps == 0 {
+		return false
+	}
+	s.axisAccum -= float64(steps) * axisStep
+
+	radius := clamp(s.sampleRadius-steps, 0, maxSampleRadius)
+	if radius == s.sampleRadius {
+		return false
+	}
+	s.sampleRadius = radius
+	return true
+}
+
 func (s *SurfaceState) OnPointerButton(button, state uint32) {
 	if state != 1 {
 		return
@@ -249,6 +309,8 @@ func (s *SurfaceState) OnKey(key, state uint32) {
 	switch key {
 	case 1: // KEY_ESC
 		s.cancelled = true
+	case 50: // KEY_M
+		s.sampleMode = (s.sampleMode + 1) % (SampleDominant + 1)
 	case 28: // KEY_ENTER
 		if s.readyForDisplay && s.screenBuf != nil {
 			s.colorPicked = true
@@ -262,6 +324,14 @@ func (s *SurfaceState) IsDone() (picked, cancelled bool) {
 	return s.colorPicked, s.cancelled
 }
 
+// SetReference starts the second pick of contrast mode.
+func (s *SurfaceState) SetReference(c Color) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reference = &c
+	s.colorPicked = false
+}
+
 func (s *SurfaceState) IsReady() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -306,15 +376,25 @@ func (s *SurfaceState) Redraw() *ShmBuffer {
 		sampleY = s.screenBuf.Height - 1 - py
 	}
 
-	picked := GetPixelColorWithFormat(s.screenBuf, px, sampleY, s.screenFormat)
+	picked := sampleArea(s.screenBuf, px, sampleY, s.sampleRadius, s.sampleMode, s.screenFormat)
 
 	drawMagnifierWithInversion(
 		dst.Data(), dst.Stride, dst.Width, dst.Height,
 		s.screenBuf.Data(), s.screenBuf.Stride, s.screenBuf.Width, s.screenBuf.Height,
 		px, py, picked, s.yInverted, s.screenFormat,
 	)
+	if s.sampleRadius > 0 {
+		drawSampleArea(dst.Data(), dst.Stride, dst.Width, dst.Height, px, py, s.sampleRadius)
+	}
 
-	drawColorPreview(dst.Data(), dst.Stride, dst.Width, dst.Height, px, py, picked, s.displayFormat, s.lowercase, s.screenFormat)
+	text := formatColorForPreview(picked, s.displayFormat, s.lowercase)
+	if s.sampleRadius > 0 {
+		text += "  " + s.sampleMode.previewLabel(s.sampleRadius)
+	}
+	if s.reference != nil {
+		text += "  " + Contrast(*s.reference, picked).Label()
+	}
+	drawColorPreview(dst.Data(), dst.Stride, dst.Width, dst.Height, px, py, picked, text, s.screenFormat)
 
 	return dst
 }
@@ -356,7 +436,7 @@ func (s *SurfaceState) PickColor() (Color, bool) {
 		sy = s.screenBuf.Height - 1 - sy
 	}
 
-	return GetPixelColorWithFormat(s.screenBuf, sx, sy, s.screenFormat), true
+	return sampleArea(s.screenBuf, sx, sy, s.sampleRadius, s.sampleMode, s.screenFormat), true
 }
 
 func (s *SurfaceState) Destroy() {
@@ -540,6 +620,42 @@ func drawMagnifierWithInversion(
 	drawMagnifierCrosshair(dst, dstStride, dstW, dstH, cx, cy, int(innerRadius), crossThickness, crossInnerRadius, format)
 }
 
+// drawSampleArea outlines the sampled square inside the magnifier. It must
+// match the magnifier zoom, where each source pixel covers zoom dst pixels
+// centered on its offset from the pointer.
+func drawSampleArea(data []byte, stride, width, height, cx, cy, radius int) {
+	const zoom = 8
+
+	half := radius*zoom + zoom/2
+	setPixel := func(x, y int, v uint8) {
+		if x < 0 || x >= width || y < 0 || y >= height {
+			return
+		}
+		off := y*stride + x*4
+		if off+4 > len(data) {
+			return
+		}
+		data[off+0] = v
+		data[off+1] = v
+		data[off+2] = v
+		data[off+3] = 255
+	}
+
+	// White inner line with a black line outside it, visible on any content.
+	for i, v := range []uint8{255, 0} {
+		x0, y0 := cx-half-i, cy-half-i
+		x1, y1 := cx+half-1+i, cy+half-1+i
+		for x := x0; x <= x1; x++ {
+			setPixel(x, y0, v)
+			setPixel(x, y1, v)
+		}
+		for y := y0; y <= y1; y++ {
+			setPixel(x0, y, v)
+			setPixel(x1, y, v)
+		}
+	}
+}
+
 func drawMagnifierCrosshair(
 	data []byte, stride, width, height, cx, cy, radius, thickness, innerRadius int,
 	format PixelFormat,
@@ -1024,6 +1140,216 @@ var fontGlyphs = map[rune][fontH]uint8{
 		0b00000000,
 		0b00000000,
 	},
+	'I': {
+		0b00111100,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00111100,
+		0b00000000,
+		0b00000000,
+	},
+	'J': {
+		0b00011110,
+		0b00001100,
+		0b00001100,
+		0b00001100,
+		0b00001100,
+		0b00001100,
+		0b01101100,
+		0b01101100,
+		0b01101100,
+		0b00111000,
+		0b00000000,
+		0b00000000,
+	},
+	'N': {
+		0b01100110,
+		0b01110110,
+		0b01110110,
+		0b01111110,
+		0b01111110,
+		0b01101110,
+		0b01101110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b00000000,
+		0b00000000,
+	},
+	'O': {
+		0b00111100,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b00111100,
+		0b00000000,
+		0b00000000,
+	},
+	'P': {
+		0b01111100,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01111100,
+		0b01100000,
+		0b01100000,
+		0b01100000,
+		0b01100000,
+		0b01100000,
+		0b00000000,
+		0b00000000,
+	},
+	'Q': {
+		0b00111100,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01101110,
+		0b01101100,
+		0b00110110,
+		0b00000000,
+		0b00000000,
+	},
+	'T': {
+		0b01111110,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00011000,
+		0b00000000,
+		0b00000000,
+	},
+	'U': {
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b01100110,
+		0b00111100,
+		0b00000000,
+		0b00000000,
+	},
+	'W': {
+		0b01100011,
+		0b01100011,
+		0b01100011,
+		0b01100011,
+		0b01100011,
+		0b01100011,
+		0b01101011,
+		0b01111111,
+		0b01110111,
+		0b01100011,
+		0b00000000,
+		0b00000000,
+	},
+	'X': {
+		0b01100110,
+		0b01100110,
+		0b00111100,
+		0b00111100,
+		0b00011000,
+		0b00011000,
+		0b00111100,
+		0b00111100,
+		0b01100110,
+		0b01100110,
+		0b00000000,
+		0b00000000,
+	},
+	'Z': {
+		0b01111110,
+		0b00000110,
+		0b00000110,
+		0b00001100,
+		0b00011000,
+		0b00110000,
+		0b01100000,
+		0b01100000,
+		0b01100000,
+		0b01111110,
+		0b00000000,
+		0b00000000,
+	},
+	'.': {
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00011000,
+		0b00011000,
+		0b00000000,
+		0b00000000,
+	},
+	'-': {
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b01111110,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+	},
+	':': {
+		0b00000000,
+		0b00000000,
+		0b00011000,
+		0b00011000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+		0b00011000,
+		0b00011000,
+		0b00000000,
+		0b00000000,
+		0b00000000,
+	},
+	'/': {
+		0b00000110,
+		0b00000110,
+		0b00001100,
+		0b00001100,
+		0b00011000,
+		0b00011000,
+		0b00110000,
+		0b00110000,
+		0b01100000,
+		0b01100000,
+		0b00000000,
+		0b00000000,
+	},
 	' ': {
 		0b00000000,
 		0b00000000,
@@ -1040,8 +1366,7 @@ var fontGlyphs = map[rune][fontH]uint8{
 	},
 }
 
-func drawColorPreview(data []byte, stride, width, height int, cx, cy int, c Color, format OutputFormat, lowercase bool, pixelFormat PixelFormat) {
-	text := formatColorForPreview(c, format, lowercase)
+func drawColorPreview(data []byte, stride, width, height int, cx, cy int, c Color, text string, pixelFormat PixelFormat) {
 	if len(text) == 0 {
 		return
 	}
@@ -1097,6 +1422,10 @@ func formatColorForPreview(c Color, format OutputFormat, lowercase bool) string
 		return strings.ToUpper(c.ToHSV())
 	case FormatCMYK:
 		return strings.ToUpper(c.ToCMYK())
+	case FormatOKLCH, FormatOKLab, FormatLab, FormatCSSRGB, FormatCSSHSL, FormatQColor, FormatName:
+		return strings.ToUpper(c.Format(format, false, ""))
+	case FormatHexAlpha:
+		return c.ToHexAlpha(lowercase)
 	default:
 		if lowercase {
 			return c.ToHex(true)
@@ -0,0 +1,312 @@
+package dank16
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Colors returns the palette in ANSI order.
+func (p Palette) Colors() [16]ColorInfo {
+	return [16]ColorInfo{
+		p.Color0, p.Color1, p.Color2, p.Color3, p.Color4, p.Color5, p.Color6, p.Color7,
+		p.Color8, p.Color9, p.Color10, p.Color11, p.Color12, p.Color13, p.Color14, p.Color15,
+	}
+}
+
+// Resolve picks one variant ("dark", "light" or "default") out of a
+// variant palette.
+func (v VariantPalette) Resolve(variant string) Palette {
+	pick := func(c VariantColorInfo) ColorInfo {
+		switch variant {
+		case "dark":
+			return NewColorInfo(c.Dark.Hex)
+		case "light":
+			return NewColorInfo(c.Light.Hex)
+		default:
+			return NewColorInfo(c.Default.Hex)
+		}
+	}
+	return Palette{
+		Color0: pick(v.Color0), Color1: pick(v.Color1), Color2: pick(v.Color2), Color3: pick(v.Color3),
+		Color4: pick(v.Color4), Color5: pick(v.Color5), Color6: pick(v.Color6), Color7: pick(v.Color7),
+		Color8: pick(v.Color8), Color9: pick(v.Color9), Color10: pick(v.Color10), Color11: pick(v.Color11),
+		Color12: pick(v.Color12), Color13: pick(v.Color13), Color14: pick(v.Color14), Color15: pick(v.Color15),
+	}
+}
+
+var exporters = map[string]func(Palette) string{
+	"json":       GenerateJSON,
+	"kitty":      GenerateKittyTheme,
+	"foot":       GenerateFootTheme,
+	"alacritty":  GenerateAlacrittyTheme,
+	"ghostty":    GenerateGhosttyTheme,
+	"wezterm":    GenerateWeztermTheme,
+	"neovim":     GenerateNeovimTheme,
+	"tmux":       GenerateTmuxTheme,
+	"helix":      GenerateHelixTheme,
+	"btop":       GenerateBtopTheme,
+	"xresources": GenerateXresources,
+	"konsole":    GenerateKonsoleTheme,
+	"tmtheme":    GenerateTmTheme,
+}
+
+// Formats lists the names Export accepts.
+func Formats() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export renders p in the named format. "bat" is accepted as an alias for
+// tmtheme.
+func Export(format string, p Palette) (string, error) {
+	format = strings.ToLower(format)
+	if format == "bat" {
+		format = "tmtheme"
+	}
+	gen, ok := exporters[format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return gen(p), nil
+}
+
+// GenerateTmuxTheme styles the status line, panes and messages. Source it
+// from tmux.conf.
+func GenerateTmuxTheme(p Palette) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "set -g status-style 'bg=%s,fg=%s'\n", p.Color0.Hex, p.Color7.Hex)
+	fmt.Fprintf(&result, "set -g status-left-style 'bg=%s,fg=%s,bold'\n", p.Color4.Hex, p.Color0.Hex)
+	fmt.Fprintf(&result, "set -g status-right-style 'bg=%s,fg=%s'\n", p.Color0.Hex, p.Color8.Hex)
+	fmt.Fprintf(&result, "set -g window-status-style 'bg=%s,fg=%s'\n", p.Color0.Hex, p.Color8.Hex)
+	fmt.Fprintf(&result, "set -g window-status-current-style 'bg=%s,fg=%s,bold'\n", p.Color5.Hex, p.Color7.Hex)
+	fmt.Fprintf(&result, "set -g window-status-activity-style 'bg=%s,fg=%s'\n", p.Color0.Hex, p.Color3.Hex)
+	fmt.Fprintf(&result, "set -g pane-border-style 'fg=%s'\n", p.Color8.Hex)
+	fmt.Fprintf(&result, "set -g pane-active-border-style 'fg=%s'\n", p.Color4.Hex)
+	fmt.Fprintf(&result, "set -g message-style 'bg=%s,fg=%s'\n", p.Color5.Hex, p.Color7.Hex)
+	fmt.Fprintf(&result, "set -g message-command-style 'bg=%s,fg=%s'\n", p.Color0.Hex, p.Color4.Hex)
+	fmt.Fprintf(&result, "set -g mode-style 'bg=%s,fg=%s'\n", p.Color5.Hex, p.Color7.Hex)
+	fmt.Fprintf(&result, "set -g clock-mode-colour '%s'\n", p.Color4.Hex)
+	return result.String()
+}
+
+// GenerateHelixTheme writes a complete theme for ~/.config/helix/themes.
+func GenerateHelixTheme(p Palette) string {
+	var result strings.Builder
+	styles := []struct{ key, value string }{
+		{"ui.background", `{ bg = "bg" }`},
+		{"ui.text", `"fg"`},
+		{"ui.text.focus", `{ fg = "fg", modifiers = ["bold"] }`},
+		{"ui.cursor", `{ fg = "bg", bg = "accent" }`},
+		{"ui.cursor.primary", `{ fg = "bg", bg = "primary" }`},
+		{"ui.cursor.match", `{ bg = "container", modifiers = ["bold"] }`},
+		{"ui.selection", `{ bg = "container" }`},
+		{"ui.linenr", `"dim"`},
+		{"ui.linenr.selected", `{ fg = "accent", modifiers = ["bold"] }`},
+		{"ui.statusline", `{ fg = "fg", bg = "container" }`},
+		{"ui.statusline.inactive", `{ fg = "dim", bg = "bg" }`},
+		{"ui.popup", `{ fg = "fg", bg = "bg" }`},
+		{"ui.window", `"dim"`},
+		{"ui.help", `{ fg = "fg", bg = "bg" }`},
+		{"ui.menu", `{ fg = "fg", bg = "bg" }`},
+		{"ui.menu.selected", `{ fg = "bg", bg = "accent" }`},
+		{"ui.virtual.whitespace", `"dim"`},
+		{"ui.virtual.ruler", `{ bg = "container" }`},
+		{"comment", `{ fg = "dim", modifiers = ["italic"] }`},
+		{"keyword", `"magenta"`},
+		{"function", `"blue"`},
+		{"type", `"yellow"`},
+		{"constant", `"cyan"`},
+		{"string", `"green"`},
+		{"variable", `"fg"`},
+		{"variable.parameter", `"bright_cyan"`},
+		{"operator", `"bright_blue"`},
+		{"punctuation", `"dim"`},
+		{"namespace", `"bright_magenta"`},
+		{"attribute", `"bright_yellow"`},
+		{"label", `"bright_blue"`},
+		{"markup.heading", `{ fg = "blue", modifiers = ["bold"] }`},
+		{"markup.link.url", `{ fg = "cyan", modifiers = ["underlined"] }`},
+		{"diff.plus", `"green"`},
+		{"diff.minus", `"red"`},
+		{"diff.delta", `"yellow"`},
+		{"error", `"red"`},
+		{"warning", `"yellow"`},
+		{"info", `"blue"`},
+		{"hint", `"dim"`},
+		{"diagnostic.error", `{ underline = { color = "red", style = "curl" } }`},
+		{"diagnostic.warning", `{ underline = { color = "yellow", style = "curl" } }`},
+	}
+	for _, s := range styles {
+		fmt.Fprintf(&result, "%q = %s\n", s.key, s.value)
+	}
+
+	result.WriteString("\n[palette]\n")
+	names := []string{
+		"bg", "red", "green", "yellow", "blue", "magenta", "cyan", "fg",
+		"dim", "bright_red", "bright_green", "bright_yellow", "bright_blue", "bright_magenta", "bright_cyan", "bright_white",
+	}
+	for i, c := range p.Colors() {
+		fmt.Fprintf(&result, "%s = %q\n", names[i], c.Hex)
+	}
+	fmt.Fprintf(&result, "accent = %q\n", p.Color4.Hex)
+	fmt.Fprintf(&result, "primary = %q\n", p.Color6.Hex)
+	fmt.Fprintf(&result, "container = %q\n", p.Color5.Hex)
+	return result.String()
+}
+
+// GenerateBtopTheme writes a theme for ~/.config/btop/themes.
+func GenerateBtopTheme(p Palette) string {
+	var result strings.Builder
+	entries := []struct{ key, value string }{
+		{"main_bg", p.Color0.Hex},
+		{"main_fg", p.Color7.Hex},
+		{"title", p.Color7.Hex},
+		{"hi_fg", p.Color4.Hex},
+		{"selected_bg", p.Color5.Hex},
+		{"selected_fg", p.Color7.Hex},
+		{"inactive_fg", p.Color8.Hex},
+		{"graph_text", p.Color7.Hex},
+		{"meter_bg", p.Color8.Hex},
+		{"proc_misc", p.Color12.Hex},
+		{"cpu_box", p.Color4.Hex},
+		{"mem_box", p.Color2.Hex},
+		{"net_box", p.Color5.Hex},
+		{"proc_box", p.Color6.Hex},
+		{"div_line", p.Color8.Hex},
+		{"temp_start", p.Color2.Hex},
+		{"temp_mid", p.Color3.Hex},
+		{"temp_end", p.Color1.Hex},
+		{"cpu_start", p.Color4.Hex},
+		{"cpu_mid", p.Color12.Hex},
+		{"cpu_end", p.Color1.Hex},
+		{"free_start", p.Color2.Hex},
+		{"free_mid", p.Color10.Hex},
+		{"free_end", p.Color10.Hex},
+		{"cached_start", p.Color6.Hex},
+		{"cached_mid", p.Color14.Hex},
+		{"cached_end", p.Color14.Hex},
+		{"available_start", p.Color3.Hex},
+		{"available_mid", p.Color11.Hex},
+		{"available_end", p.Color11.Hex},
+		{"used_start", p.Color1.Hex},
+		{"used_mid", p.Color9.Hex},
+		{"used_end", p.Color9.Hex},
+		{"download_start", p.Color4.Hex},
+		{"download_mid", p.Color12.Hex},
+		{"download_end", p.Color13.Hex},
+		{"upload_start", p.Color5.Hex},
+		{"upload_mid", p.Color13.Hex},
+		{"upload_end", p.Color14.Hex},
+		{"process_start", p.Color4.Hex},
+		{"process_mid", p.Color12.Hex},
+		{"process_end", p.Color1.Hex},
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&result, "theme[%s]=%q\n", e.key, e.value)
+	}
+	return result.String()
+}
+
+// GenerateXresources writes the classic X color resources for XWayland
+// apps such as xterm and urxvt.
+func GenerateXresources(p Palette) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "*.background: %s\n", p.Color0.Hex)
+	fmt.Fprintf(&result, "*.foreground: %s\n", p.Color7.Hex)
+	fmt.Fprintf(&result, "*.cursorColor: %s\n", p.Color6.Hex)
+	for i, c := range p.Colors() {
+		fmt.Fprintf(&result, "*.color%d: %s\n", i, c.Hex)
+	}
+	return result.String()
+}
+
+// GenerateKonsoleTheme writes a Konsole .colorscheme.
+func GenerateKonsoleTheme(p Palette) string {
+	var result strings.Builder
+	rgb := func(c ColorInfo) string { return fmt.Sprintf("%d,%d,%d", c.R, c.G, c.B) }
+	section := func(name string, c ColorInfo) {
+		fmt.Fprintf(&result, "[%s]\nColor=%s\n\n", name, rgb(c))
+	}
+
+	colors := p.Colors()
+	section("Background", colors[0])
+	section("BackgroundIntense", colors[0])
+	section("BackgroundFaint", colors[0])
+	for i := range 8 {
+		section(fmt.Sprintf("Color%d", i), colors[i])
+		section(fmt.Sprintf("Color%dIntense", i), colors[i+8])
+		section(fmt.Sprintf("Color%dFaint", i), colors[i])
+	}
+	section("Foreground", colors[7])
+	section("ForegroundIntense", colors[15])
+	section("ForegroundFaint", colors[8])
+
+	result.WriteString("[General]\nDescription=DankShell\nOpacity=1\nWallpaper=\n")
+	return result.String()
+}
+
+// GenerateTmTheme writes a TextMate theme, as used by bat and Sublime.
+func GenerateTmTheme(p Palette) string {
+	var result strings.Builder
+	result.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>name</key>
+	<string>DankShell</string>
+	<key>settings</key>
+	<array>
+		<dict>
+			<key>settings</key>
+			<dict>
+`)
+	general := []struct{ key, value string }{
+		{"background", p.Color0.Hex},
+		{"foreground", p.Color7.Hex},
+		{"caret", p.Color6.Hex},
+		{"lineHighlight", p.Color5.Hex},
+		{"selection", p.Color5.Hex},
+		{"gutterForeground", p.Color8.Hex},
+	}
+	for _, g := range general {
+		fmt.Fprintf(&result, "\t\t\t\t<key>%s</key>\n\t\t\t\t<string>%s</string>\n", g.key, g.value)
+	}
+	result.WriteString("\t\t\t</dict>\n\t\t</dict>\n")
+
+	scopes := []struct{ name, scope, color, style string }{
+		{"Comment", "comment", p.Color8.Hex, "italic"},
+		{"String", "string", p.Color2.Hex, ""},
+		{"Number", "constant.numeric", p.Color6.Hex, ""},
+		{"Constant", "constant", p.Color14.Hex, ""},
+		{"Keyword", "keyword, storage", p.Color5.Hex, ""},
+		{"Type", "entity.name.type, support.type, storage.type", p.Color3.Hex, ""},
+		{"Function", "entity.name.function, support.function", p.Color4.Hex, ""},
+		{"Variable", "variable", p.Color7.Hex, ""},
+		{"Parameter", "variable.parameter", p.Color14.Hex, ""},
+		{"Tag", "entity.name.tag", p.Color12.Hex, ""},
+		{"Attribute", "entity.other.attribute-name", p.Color11.Hex, ""},
+		{"Heading", "markup.heading", p.Color4.Hex, "bold"},
+		{"Inserted", "markup.inserted", p.Color2.Hex, ""},
+		{"Deleted", "markup.deleted", p.Color1.Hex, ""},
+		{"Changed", "markup.changed", p.Color3.Hex, ""},
+		{"Invalid", "invalid", p.Color9.Hex, ""},
+	}
+	for _, s := range scopes {
+		fmt.Fprintf(&result, "\t\t<dict>\n\t\t\t<key>name</key>\n\t\t\t<string>%s</string>\n", s.name)
+		fmt.Fprintf(&result, "\t\t\t<key>scope</key>\n\t\t\t<string>%s</string>\n", s.scope)
+		result.WriteString("\t\t\t<key>settings</key>\n\t\t\t<dict>\n")
+		fmt.Fprintf(&result, "\t\t\t\t<key>foreground</key>\n\t\t\t\t<string>%s</string>\n", s.color)
+		if s.style != "" {
+			fmt.Fprintf(&result, "\t\t\t\t<key>fontStyle</key>\n\t\t\t\t<string>%s</string>\n", s.style)
+		}
+		result.WriteString("\t\t\t</dict>\n\t\t</dict>\n")
+	}
+
+	result.WriteString("\t</array>\n</dict>\n</plist>\n")
+	return result.String()
+}
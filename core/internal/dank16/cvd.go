@@ -0,0 +1,218 @@
+package dank16
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// CVD is a color vision deficiency to simulate or design a palette for.
+type CVD string
+
+const (
+	CVDNone         CVD = ""
+	CVDProtanopia   CVD = "protanopia"
+	CVDDeuteranopia CVD = "deuteranopia"
+	CVDTritanopia   CVD = "tritanopia"
+)
+
+// CVDTypes are the deficiencies palettes can be adjusted for.
+var CVDTypes = []CVD{CVDProtanopia, CVDDeuteranopia, CVDTritanopia}
+
+func ParseCVD(s string) (CVD, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return CVDNone, nil
+	case "protanopia", "protan":
+		return CVDProtanopia, nil
+	case "deuteranopia", "deutan":
+		return CVDDeuteranopia, nil
+	case "tritanopia", "tritan":
+		return CVDTritanopia, nil
+	}
+	return CVDNone, fmt.Errorf("unknown color vision deficiency %q (protanopia, deuteranopia or tritanopia)", s)
+}
+
+// Machado, Oliveira and Fernandes (2009) matrices at full severity. They
+// operate on linear RGB and, unlike Brettel's two half-planes, are a
+// single linear map, which keeps simulation cheap enough to run inside
+// the palette search.
+var cvdMatrices = map[CVD][3][3]float64{
+	CVDProtanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	CVDDeuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	CVDTritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// SimulateCVD returns how hex looks to someone with the given deficiency.
+func SimulateCVD(hex string, cvd CVD) string {
+	m, ok := cvdMatrices[cvd]
+	if !ok {
+		return hex
+	}
+	rgb := HexToRGB(hex)
+	c := colorful.Color{R: rgb.R, G: rgb.G, B: rgb.B}
+	r, g, b := c.LinearRgb()
+	sim := colorful.LinearRgb(
+		m[0][0]*r+m[0][1]*g+m[0][2]*b,
+		m[1][0]*r+m[1][1]*g+m[1][2]*b,
+		m[2][0]*r+m[2][1]*g+m[2][2]*b,
+	)
+	return sim.Clamped().Hex()
+}
+
+// ColorDistance is the CIEDE2000 difference between two colors, on the
+// usual 0-100 scale, as seen with the given deficiency.
+func ColorDistance(hexA, hexB string, cvd CVD) float64 {
+	a, _ := colorful.Hex(SimulateCVD(hexA, cvd))
+	b, _ := colorful.Hex(SimulateCVD(hexB, cvd))
+	return a.DistanceCIEDE2000(b) * 100
+}
+
+// minCVDDistance is the simulated CIEDE2000 difference CVD-aware palettes
+// keep between conflicting ANSI colors; around 15 they read as clearly
+// different hues rather than shades of one.
+const minCVDDistance = 15.0
+
+// cvdGroups are the ANSI slots that carry meaning by hue (red, green,
+// yellow, blue and their bright versions) and so must stay apart. The
+// primary-derived slots 5, 6, 13 and 14 keep their exact colors.
+var cvdGroups = [][]int{{1, 2, 3, 4}, {9, 10, 11, 12}}
+
+func paletteContrast(hex, bg string, opts PaletteOptions) float64 {
+	if opts.UseDPS {
+		return DeltaPhiStarContrast(hex, bg, opts.IsLight)
+	}
+	return ContrastRatio(hex, bg)
+}
+
+// separateForCVD nudges the hue-coded ANSI colors until each one is at
+// least minCVDDistance from the others in its group under the simulated
+// deficiency, changing them as little as possible and never dropping
+// contrast against the background below what the slot started with.
+func separateForCVD(p *Palette, bg string, opts PaletteOptions) {
+	colors := [16]*ColorInfo{
+		&p.Color0, &p.Color1, &p.Color2, &p.Color3, &p.Color4, &p.Color5, &p.Color6, &p.Color7,
+		&p.Color8, &p.Color9, &p.Color10, &p.Color11, &p.Color12, &p.Color13, &p.Color14, &p.Color15,
+	}
+	for _, group := range cvdGroups {
+		for i, slot := range group {
+			fixed := group[:i]
+			original := colors[slot].Hex
+			if minDistance(original, colors, fixed, opts.CVD) >= minCVDDistance {
+				continue
+			}
+
+			minContrast := paletteContrast(original, bg, opts)
+			best, bestScore := original, math.Inf(-1)
+			orig, _ := colorful.Hex(original)
+			h, c, l := orig.Hcl()
+
+			for dl := -0.30; dl <= 0.30001; dl += 0.02 {
+				for dh := -90.0; dh <= 90.0; dh += 6 {
+					for _, dc := range []float64{1, 0.6, 1.4} {
+						cand := colorful.Hcl(math.Mod(h+dh+360, 360), c*dc, clamp01(l+dl)).Clamped()
+						hex := cand.Hex()
+						if paletteContrast(hex, bg, opts) < minContrast {
+							continue
+						}
+
+						// Meeting the target matters most; among candidates
+						// that do, prefer the smallest visible change.
+						change := orig.DistanceCIEDE2000(cand) * 100
+						sep := minDistance(hex, colors, fixed, opts.CVD)
+						score := sep*10 - change
+						if sep >= minCVDDistance {
+							score = 1000 - change
+						}
+						if score > bestScore {
+							best, bestScore = hex, score
+						}
+					}
+				}
+			}
+			*colors[slot] = NewColorInfo(best)
+		}
+	}
+}
+
+func minDistance(hex string, colors [16]*ColorInfo, slots []int, cvd CVD) float64 {
+	d := math.Inf(1)
+	for _, s := range slots {
+		d = math.Min(d, ColorDistance(hex, colors[s].Hex, cvd))
+	}
+	return d
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// SlotReport is one palette color judged against the background.
+type SlotReport struct {
+	Slot          int     `json:"slot"`
+	Hex           string  `json:"hex"`
+	ContrastRatio float64 `json:"contrastRatio"`
+	DeltaPhiStar  float64 `json:"deltaPhiStar"`
+}
+
+// PairReport is the simulated distance between two palette colors.
+type PairReport struct {
+	A        int     `json:"a"`
+	B        int     `json:"b"`
+	Distance float64 `json:"distance"`
+	Conflict bool    `json:"conflict"`
+}
+
+type CVDReport struct {
+	Slots     []SlotReport            `json:"slots"`
+	Distances map[string][]PairReport `json:"distances"`
+}
+
+// Report measures every slot's contrast against the background and, for
+// normal vision and each deficiency, the distance between every pair of
+// hue-coded slots.
+func Report(p Palette, isLight bool) CVDReport {
+	colors := p.Colors()
+	report := CVDReport{Distances: make(map[string][]PairReport)}
+
+	for i, c := range colors[1:] {
+		report.Slots = append(report.Slots, SlotReport{
+			Slot:          i + 1,
+			Hex:           c.Hex,
+			ContrastRatio: ContrastRatio(c.Hex, p.Color0.Hex),
+			DeltaPhiStar:  DeltaPhiStarContrast(c.Hex, p.Color0.Hex, isLight),
+		})
+	}
+
+	for _, cvd := range append([]CVD{CVDNone}, CVDTypes...) {
+		name := string(cvd)
+		if cvd == CVDNone {
+			name = "normal"
+		}
+		var pairs []PairReport
+		for _, group := range cvdGroups {
+			for i, a := range group {
+				for _, b := range group[i+1:] {
+					d := ColorDistance(colors[a].Hex, colors[b].Hex, cvd)
+					pairs = append(pairs, PairReport{A: a, B: b, Distance: d, Conflict: d < minCVDDistance})
+				}
+			}
+		}
+		report.Distances[name] = pairs
+	}
+	return report
+}
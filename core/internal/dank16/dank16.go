@@ -189,7 +189,9 @@ func getLstar(hex string) float64 {
 	rgb := HexToRGB(hex)
 	col := colorful.Color{R: rgb.R, G: rgb.G, B: rgb.B}
 	L, _, _ := col.Lab()
-	return L * 100.0 // go-colorful uses 0-1, we need 0-100 for DPS
+	// go-colorful uses 0-1, we need 0-100 for DPS. Black can come out a hair
+	// below zero, which would turn the fractional powers in DPS into NaN.
+	return math.Max(L*100.0, 0)
 }
 
 // Lab to hex, clamping if needed
@@ -388,6 +390,9 @@ type PaletteOptions struct {
 	IsLight    bool
 	Background string
 	UseDPS     bool
+	// CVD, when set, pushes apart the ANSI colors that would look alike
+	// to someone with that color vision deficiency.
+	CVD CVD
 }
 
 func ensureContrastAuto(hexColor, hexBg string, target float64, opts PaletteOptions) string {
@@ -596,6 +601,10 @@ func GeneratePalette(primaryColor string, opts PaletteOptions) Palette {
 		palette.Color15 = NewColorInfo(ensureContrastAuto(RGBToHex(HSVToRGB(HSV{H: hsv.H, S: white15S, V: white15V})), bgColor, normalTextTarget, opts))
 	}
 
+	if opts.CVD != CVDNone {
+		separateForCVD(&palette, bgColor, opts)
+	}
+
 	return palette
 }
 
@@ -605,6 +614,7 @@ type VariantOptions struct {
 	Background   string
 	UseDPS       bool
 	IsLightMode  bool
+	CVD          CVD
 }
 
 func mergeColorInfo(dark, light ColorInfo, isLightMode bool) VariantColorInfo {
@@ -624,8 +634,8 @@ func mergeColorInfo(dark, light ColorInfo, isLightMode bool) VariantColorInfo {
 }
 
 func GenerateVariantPalette(opts VariantOptions) VariantPalette {
-	darkOpts := PaletteOptions{IsLight: false, Background: opts.Background, UseDPS: opts.UseDPS}
-	lightOpts := PaletteOptions{IsLight: true, Background: opts.Background, UseDPS: opts.UseDPS}
+	darkOpts := PaletteOptions{IsLight: false, Background: opts.Background, UseDPS: opts.UseDPS, CVD: opts.CVD}
+	lightOpts := PaletteOptions{IsLight: true, Background: opts.Background, UseDPS: opts.UseDPS, CVD: opts.CVD}
 
 	dark := GeneratePalette(opts.PrimaryDark, darkOpts)
 	light := GeneratePalette(opts.PrimaryLight, lightOpts)
@@ -1,7 +1,9 @@
 package dank16
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -679,3 +681,94 @@ func TestContrastAlgorithmComparison(t *testing.T) {
 
 	t.Logf("WCAG and DPS palettes differ in %d/16 colors", differentCount)
 }
+
+func TestExport(t *testing.T) {
+	palette := GeneratePalette("#625690", PaletteOptions{UseDPS: true})
+
+	for _, format := range Formats() {
+		out, err := Export(format, palette)
+		if err != nil {
+			t.Fatalf("Export(%s) failed: %v", format, err)
+		}
+		if format != "json" && !strings.Contains(out, palette.Color4.HexStripped) && !strings.Contains(out, fmt.Sprintf("%d,%d,%d", palette.Color4.R, palette.Color4.G, palette.Color4.B)) {
+			t.Errorf("Export(%s) does not contain color4", format)
+		}
+	}
+
+	if out, _ := Export("bat", palette); !strings.Contains(out, "<plist") {
+		t.Errorf("bat alias did not produce a tmTheme")
+	}
+	if out := GenerateXresources(palette); !strings.Contains(out, "*.color15: "+palette.Color15.Hex) {
+		t.Errorf("Xresources missing color15:\n%s", out)
+	}
+	if out := GenerateKonsoleTheme(palette); !strings.Contains(out, "[Color4Intense]\nColor="+fmt.Sprintf("%d,%d,%d", palette.Color12.R, palette.Color12.G, palette.Color12.B)) {
+		t.Errorf("Konsole Color4Intense is not color12:\n%s", out)
+	}
+	if _, err := Export("vim9", palette); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestVariantPaletteResolve(t *testing.T) {
+	variants := GenerateVariantPalette(VariantOptions{PrimaryDark: "#ccc2ff", PrimaryLight: "#625690", IsLightMode: true})
+
+	if got := variants.Resolve("dark").Color4.Hex; got != variants.Color4.Dark.Hex {
+		t.Errorf("dark color4 = %s, expected %s", got, variants.Color4.Dark.Hex)
+	}
+	if got := variants.Resolve("default").Color0.Hex; got != variants.Color0.Light.Hex {
+		t.Errorf("default color0 = %s, expected light %s", got, variants.Color0.Light.Hex)
+	}
+}
+
+func TestSimulateCVD(t *testing.T) {
+	if got := SimulateCVD("#808080", CVDDeuteranopia); got != "#808080" {
+		t.Errorf("gray under deuteranopia = %s, expected unchanged", got)
+	}
+	if got := SimulateCVD("#ff0000", CVDNone); got != "#ff0000" {
+		t.Errorf("normal vision changed color to %s", got)
+	}
+
+	normal := ColorDistance("#d04040", "#40a040", CVDNone)
+	deutan := ColorDistance("#d04040", "#40a040", CVDDeuteranopia)
+	if deutan >= normal/2 {
+		t.Errorf("red/green distance under deuteranopia = %.1f, expected well below normal %.1f", deutan, normal)
+	}
+
+	if _, err := ParseCVD("deutan"); err != nil {
+		t.Errorf("ParseCVD(deutan) failed: %v", err)
+	}
+	if _, err := ParseCVD("achromatopsia"); err == nil {
+		t.Errorf("expected error for unsupported deficiency")
+	}
+}
+
+func TestGeneratePaletteCVD(t *testing.T) {
+	for _, primary := range []string{"#ff6b6b", "#625690", "#2e7d32"} {
+		for _, isLight := range []bool{false, true} {
+			for _, cvd := range CVDTypes {
+				opts := PaletteOptions{IsLight: isLight, UseDPS: true, CVD: cvd}
+				palette := GeneratePalette(primary, opts)
+				base := GeneratePalette(primary, PaletteOptions{IsLight: isLight, UseDPS: true})
+
+				for _, pair := range Report(palette, isLight).Distances[string(cvd)] {
+					if pair.Conflict {
+						t.Errorf("%s light=%v %s: color%d/color%d only %.1f apart", primary, isLight, cvd, pair.A, pair.B, pair.Distance)
+					}
+				}
+
+				got, want := palette.Colors(), base.Colors()
+				for i := range got {
+					if got[i].Hex == want[i].Hex {
+						continue
+					}
+					if c, orig := paletteContrast(got[i].Hex, palette.Color0.Hex, opts), paletteContrast(want[i].Hex, palette.Color0.Hex, opts); c < orig {
+						t.Errorf("%s light=%v %s: color%d contrast dropped from %.1f to %.1f", primary, isLight, cvd, i, orig, c)
+					}
+				}
+				if palette.Color6 != base.Color6 || palette.Color0 != base.Color0 {
+					t.Errorf("%s %s: background or primary changed", primary, cvd)
+				}
+			}
+		}
+	}
+}
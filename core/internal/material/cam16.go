@@ -0,0 +1,156 @@
+package material
+
+import "math"
+
+// viewingConditions describes the environment a color is seen in. Only the
+// standard conditions (D65, average surround, 50 L* background) are used.
+type viewingConditions struct {
+	n, aw, nbb, ncb, c, nc float64
+	rgbD                   [3]float64
+	fl, fLRoot, z          float64
+}
+
+var defaultViewingConditions = makeViewingConditions(
+	whitePointD65,
+	200/math.Pi*yFromLstar(50)/100,
+	50,
+	2,
+	false,
+)
+
+func makeViewingConditions(whitePoint [3]float64, adaptingLuminance, backgroundLstar, surround float64, discountingIlluminant bool) viewingConditions {
+	x, y, z := whitePoint[0], whitePoint[1], whitePoint[2]
+	rW := x*0.401288 + y*0.650173 + z*-0.051461
+	gW := x*-0.250268 + y*1.204414 + z*0.045854
+	bW := x*-0.002079 + y*0.048952 + z*0.953127
+
+	f := 0.8 + surround/10
+	var c float64
+	if f >= 0.9 {
+		c = lerp(0.59, 0.69, (f-0.9)*10)
+	} else {
+		c = lerp(0.525, 0.59, (f-0.8)*10)
+	}
+
+	d := 1.0
+	if !discountingIlluminant {
+		d = f * (1 - (1/3.6)*math.Exp((-adaptingLuminance-42)/92))
+	}
+	d = clampDouble(0, 1, d)
+
+	rgbD := [3]float64{
+		d*(100/rW) + 1 - d,
+		d*(100/gW) + 1 - d,
+		d*(100/bW) + 1 - d,
+	}
+
+	k := 1 / (5*adaptingLuminance + 1)
+	k4 := k * k * k * k
+	k4F := 1 - k4
+	fl := k4*adaptingLuminance + 0.1*k4F*k4F*math.Cbrt(5*adaptingLuminance)
+	n := yFromLstar(backgroundLstar) / whitePoint[1]
+	nbb := 0.725 / math.Pow(n, 0.2)
+
+	rgbAFactors := [3]float64{
+		math.Pow(fl*rgbD[0]*rW/100, 0.42),
+		math.Pow(fl*rgbD[1]*gW/100, 0.42),
+		math.Pow(fl*rgbD[2]*bW/100, 0.42),
+	}
+	var rgbA [3]float64
+	for i, af := range rgbAFactors {
+		rgbA[i] = 400 * af / (af + 27.13)
+	}
+	aw := (2*rgbA[0] + rgbA[1] + 0.05*rgbA[2]) * nbb
+
+	return viewingConditions{
+		n:      n,
+		aw:     aw,
+		nbb:    nbb,
+		ncb:    nbb,
+		c:      c,
+		nc:     f,
+		rgbD:   rgbD,
+		fl:     fl,
+		fLRoot: math.Pow(fl, 0.25),
+		z:      1.48 + math.Sqrt(n),
+	}
+}
+
+// cam16 is a color in the CAM16 appearance model. HCT uses its hue and
+// chroma.
+type cam16 struct {
+	hue, chroma, j, q, m, s float64
+	jstar, astar, bstar     float64
+}
+
+func cam16FromARGB(argb ARGB) cam16 {
+	vc := defaultViewingConditions
+
+	red := linearized(argb.Red())
+	green := linearized(argb.Green())
+	blue := linearized(argb.Blue())
+	x := 0.41233895*red + 0.35762064*green + 0.18051042*blue
+	y := 0.2126*red + 0.7152*green + 0.0722*blue
+	z := 0.01932141*red + 0.11916382*green + 0.95034478*blue
+
+	rC := 0.401288*x + 0.650173*y - 0.051461*z
+	gC := -0.250268*x + 1.204414*y + 0.045854*z
+	bC := -0.002079*x + 0.048952*y + 0.953127*z
+
+	rD := vc.rgbD[0] * rC
+	gD := vc.rgbD[1] * gC
+	bD := vc.rgbD[2] * bC
+
+	rAF := math.Pow(vc.fl*math.Abs(rD)/100, 0.42)
+	gAF := math.Pow(vc.fl*math.Abs(gD)/100, 0.42)
+	bAF := math.Pow(vc.fl*math.Abs(bD)/100, 0.42)
+
+	rA := signum(rD) * 400 * rAF / (rAF + 27.13)
+	gA := signum(gD) * 400 * gAF / (gAF + 27.13)
+	bA := signum(bD) * 400 * bAF / (bAF + 27.13)
+
+	a := (11*rA + -12*gA + bA) / 11
+	b := (rA + gA - 2*bA) / 9
+	u := (20*rA + 20*gA + 21*bA) / 20
+	p2 := (40*rA + 20*gA + bA) / 20
+
+	hue := math.Atan2(b, a) * 180 / math.Pi
+	switch {
+	case hue < 0:
+		hue += 360
+	case hue >= 360:
+		hue -= 360
+	}
+	hueRadians := hue * math.Pi / 180
+
+	ac := p2 * vc.nbb
+	j := 100 * math.Pow(ac/vc.aw, vc.c*vc.z)
+	q := 4 / vc.c * math.Sqrt(j/100) * (vc.aw + 4) * vc.fLRoot
+
+	huePrime := hue
+	if hue < 20.14 {
+		huePrime = hue + 360
+	}
+	eHue := 0.25 * (math.Cos(huePrime*math.Pi/180+2) + 3.8)
+	p1 := 50000.0 / 13.0 * eHue * vc.nc * vc.ncb
+	t := p1 * math.Sqrt(a*a+b*b) / (u + 0.305)
+	alpha := math.Pow(t, 0.9) * math.Pow(1.64-math.Pow(0.29, vc.n), 0.73)
+	c := alpha * math.Sqrt(j/100)
+	m := c * vc.fLRoot
+	s := 50 * math.Sqrt(alpha*vc.c/(vc.aw+4))
+
+	jstar := (1 + 100*0.007) * j / (1 + 0.007*j)
+	mstar := 1 / 0.0228 * math.Log(1+0.0228*m)
+
+	return cam16{
+		hue:    hue,
+		chroma: c,
+		j:      j,
+		q:      q,
+		m:      m,
+		s:      s,
+		jstar:  jstar,
+		astar:  mstar * math.Cos(hueRadians),
+		bstar:  mstar * math.Sin(hueRadians),
+	}
+}
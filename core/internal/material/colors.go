@@ -0,0 +1,427 @@
+package material
+
+// The scheme roles, named the way matugen names them in templates.
+var (
+	primaryPaletteKeyColor        *dynamicColor
+	secondaryPaletteKeyColor      *dynamicColor
+	tertiaryPaletteKeyColor       *dynamicColor
+	neutralPaletteKeyColor        *dynamicColor
+	neutralVariantPaletteKeyColor *dynamicColor
+
+	background              *dynamicColor
+	onBackground            *dynamicColor
+	surface                 *dynamicColor
+	surfaceDim              *dynamicColor
+	surfaceBright           *dynamicColor
+	surfaceContainerLowest  *dynamicColor
+	surfaceContainerLow     *dynamicColor
+	surfaceContainer        *dynamicColor
+	surfaceContainerHigh    *dynamicColor
+	surfaceContainerHighest *dynamicColor
+	onSurface               *dynamicColor
+	surfaceVariant          *dynamicColor
+	onSurfaceVariant        *dynamicColor
+	inverseSurface          *dynamicColor
+	inverseOnSurface        *dynamicColor
+	outline                 *dynamicColor
+	outlineVariant          *dynamicColor
+	shadow                  *dynamicColor
+	scrim                   *dynamicColor
+	surfaceTint             *dynamicColor
+
+	primary              *dynamicColor
+	onPrimary            *dynamicColor
+	primaryContainer     *dynamicColor
+	onPrimaryContainer   *dynamicColor
+	inversePrimary       *dynamicColor
+	secondary            *dynamicColor
+	onSecondary          *dynamicColor
+	secondaryContainer   *dynamicColor
+	onSecondaryContainer *dynamicColor
+	tertiary             *dynamicColor
+	onTertiary           *dynamicColor
+	tertiaryContainer    *dynamicColor
+	onTertiaryContainer  *dynamicColor
+	errorColor           *dynamicColor
+	onError              *dynamicColor
+	errorContainer       *dynamicColor
+	onErrorContainer     *dynamicColor
+
+	primaryFixed            *dynamicColor
+	primaryFixedDim         *dynamicColor
+	onPrimaryFixed          *dynamicColor
+	onPrimaryFixedVariant   *dynamicColor
+	secondaryFixed          *dynamicColor
+	secondaryFixedDim       *dynamicColor
+	onSecondaryFixed        *dynamicColor
+	onSecondaryFixedVariant *dynamicColor
+	tertiaryFixed           *dynamicColor
+	tertiaryFixedDim        *dynamicColor
+	onTertiaryFixed         *dynamicColor
+	onTertiaryFixedVariant  *dynamicColor
+
+	allColors []*dynamicColor
+)
+
+func primaryPalette(s *Scheme) *TonalPalette        { return s.Primary }
+func secondaryPalette(s *Scheme) *TonalPalette      { return s.Secondary }
+func tertiaryPalette(s *Scheme) *TonalPalette       { return s.Tertiary }
+func neutralPalette(s *Scheme) *TonalPalette        { return s.Neutral }
+func neutralVariantPalette(s *Scheme) *TonalPalette { return s.NeutralVariant }
+func errorPalette(s *Scheme) *TonalPalette          { return s.Error }
+
+func darkLight(dark, light float64) func(s *Scheme) float64 {
+	return func(s *Scheme) float64 {
+		if s.IsDark {
+			return dark
+		}
+		return light
+	}
+}
+
+func constTone(tone float64) func(s *Scheme) float64 {
+	return func(*Scheme) float64 { return tone }
+}
+
+func curveTone(dark, light func(s *Scheme) float64) func(s *Scheme) float64 {
+	return func(s *Scheme) float64 {
+		if s.IsDark {
+			return dark(s)
+		}
+		return light(s)
+	}
+}
+
+func curveAt(c contrastCurve) func(s *Scheme) float64 {
+	return func(s *Scheme) float64 { return c.get(s.ContrastLevel) }
+}
+
+func highestSurface(s *Scheme) *dynamicColor {
+	if s.IsDark {
+		return surfaceBright
+	}
+	return surfaceDim
+}
+
+func isFidelity(s *Scheme) bool {
+	return s.Variant == VariantFidelity || s.Variant == VariantContent
+}
+
+func isMonochrome(s *Scheme) bool {
+	return s.Variant == VariantMonochrome
+}
+
+// monoOr returns the monochrome tone pair for the monochrome variant and
+// the regular pair otherwise.
+func monoOr(monoDark, monoLight, dark, light float64) func(s *Scheme) float64 {
+	return func(s *Scheme) float64 {
+		if isMonochrome(s) {
+			return darkLight(monoDark, monoLight)(s)
+		}
+		return darkLight(dark, light)(s)
+	}
+}
+
+func bg(dc **dynamicColor) func(s *Scheme) *dynamicColor {
+	return func(*Scheme) *dynamicColor { return *dc }
+}
+
+func pairOf(a, b **dynamicColor, polarity tonePolarity, stayTogether bool) func(s *Scheme) toneDeltaPair {
+	return func(*Scheme) toneDeltaPair {
+		return toneDeltaPair{roleA: *a, roleB: *b, delta: 10, polarity: polarity, stayTogether: stayTogether}
+	}
+}
+
+// findDesiredChromaByTone walks tones from tone until the palette can
+// reach the requested chroma, for containers in the fidelity schemes.
+func findDesiredChromaByTone(hue, chroma, tone float64, byDecreasingTone bool) float64 {
+	answer := tone
+	closest := NewHCT(hue, chroma, tone)
+	if closest.Chroma >= chroma {
+		return answer
+	}
+
+	chromaPeak := closest.Chroma
+	for closest.Chroma < chroma {
+		if byDecreasingTone {
+			answer--
+		} else {
+			answer++
+		}
+		potential := NewHCT(hue, chroma, answer)
+		if chromaPeak > potential.Chroma {
+			break
+		}
+		if abs64(potential.Chroma-chroma) < 0.4 {
+			break
+		}
+		if abs64(potential.Chroma-chroma) < abs64(closest.Chroma-chroma) {
+			closest = potential
+		}
+		chromaPeak = max(chromaPeak, potential.Chroma)
+	}
+	return answer
+}
+
+func abs64(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+var (
+	accentCurve    = contrastCurve{3, 4.5, 7, 7}
+	onColorCurve   = contrastCurve{4.5, 7, 11, 21}
+	containerCurve = contrastCurve{1, 1, 3, 4.5}
+	variantCurve   = contrastCurve{3, 4.5, 7, 11}
+)
+
+func keyColorRole(name string, palette func(s *Scheme) *TonalPalette) *dynamicColor {
+	return &dynamicColor{
+		name:    name,
+		palette: palette,
+		tone:    func(s *Scheme) float64 { return palette(s).KeyColor.Tone },
+	}
+}
+
+func surfaceRole(name string, tone func(s *Scheme) float64) *dynamicColor {
+	return &dynamicColor{name: name, palette: neutralPalette, tone: tone, isBackground: true}
+}
+
+func init() {
+	primaryPaletteKeyColor = keyColorRole("primary_palette_key_color", primaryPalette)
+	secondaryPaletteKeyColor = keyColorRole("secondary_palette_key_color", secondaryPalette)
+	tertiaryPaletteKeyColor = keyColorRole("tertiary_palette_key_color", tertiaryPalette)
+	neutralPaletteKeyColor = keyColorRole("neutral_palette_key_color", neutralPalette)
+	neutralVariantPaletteKeyColor = keyColorRole("neutral_variant_palette_key_color", neutralVariantPalette)
+
+	background = surfaceRole("background", darkLight(6, 98))
+	onBackground = &dynamicColor{
+		name: "on_background", palette: neutralPalette, tone: darkLight(90, 10),
+		background: bg(&background), contrastCurve: contrastCurve{3, 3, 4.5, 7},
+	}
+	surface = surfaceRole("surface", darkLight(6, 98))
+	surfaceDim = surfaceRole("surface_dim", curveTone(constTone(6), curveAt(contrastCurve{87, 87, 80, 75})))
+	surfaceBright = surfaceRole("surface_bright", curveTone(curveAt(contrastCurve{24, 24, 29, 34}), constTone(98)))
+	surfaceContainerLowest = surfaceRole("surface_container_lowest", curveTone(curveAt(contrastCurve{4, 4, 2, 0}), constTone(100)))
+	surfaceContainerLow = surfaceRole("surface_container_low", curveTone(curveAt(contrastCurve{10, 10, 11, 12}), curveAt(contrastCurve{96, 96, 96, 95})))
+	surfaceContainer = surfaceRole("surface_container", curveTone(curveAt(contrastCurve{12, 12, 16, 20}), curveAt(contrastCurve{94, 94, 92, 90})))
+	surfaceContainerHigh = surfaceRole("surface_container_high", curveTone(curveAt(contrastCurve{17, 17, 21, 25}), curveAt(contrastCurve{92, 92, 88, 85})))
+	surfaceContainerHighest = surfaceRole("surface_container_highest", curveTone(curveAt(contrastCurve{22, 22, 26, 30}), curveAt(contrastCurve{90, 90, 84, 80})))
+
+	onSurface = &dynamicColor{
+		name: "on_surface", palette: neutralPalette, tone: darkLight(90, 10),
+		background: highestSurface, contrastCurve: onColorCurve,
+	}
+	surfaceVariant = &dynamicColor{name: "surface_variant", palette: neutralVariantPalette, tone: darkLight(30, 90), isBackground: true}
+	onSurfaceVariant = &dynamicColor{
+		name: "on_surface_variant", palette: neutralVariantPalette, tone: darkLight(80, 30),
+		background: highestSurface, contrastCurve: variantCurve,
+	}
+	inverseSurface = &dynamicColor{name: "inverse_surface", palette: neutralPalette, tone: darkLight(90, 20)}
+	inverseOnSurface = &dynamicColor{
+		name: "inverse_on_surface", palette: neutralPalette, tone: darkLight(20, 95),
+		background: bg(&inverseSurface), contrastCurve: onColorCurve,
+	}
+	outline = &dynamicColor{
+		name: "outline", palette: neutralVariantPalette, tone: darkLight(60, 50),
+		background: highestSurface, contrastCurve: contrastCurve{1.5, 3, 4.5, 7},
+	}
+	outlineVariant = &dynamicColor{
+		name: "outline_variant", palette: neutralVariantPalette, tone: darkLight(30, 80),
+		background: highestSurface, contrastCurve: containerCurve,
+	}
+	shadow = &dynamicColor{name: "shadow", palette: neutralPalette, tone: constTone(0)}
+	scrim = &dynamicColor{name: "scrim", palette: neutralPalette, tone: constTone(0)}
+	surfaceTint = &dynamicColor{name: "surface_tint", palette: primaryPalette, tone: darkLight(80, 40), isBackground: true}
+
+	primary = &dynamicColor{
+		name: "primary", palette: primaryPalette, tone: monoOr(100, 0, 80, 40), isBackground: true,
+		background: highestSurface, contrastCurve: accentCurve,
+		toneDeltaPair: pairOf(&primaryContainer, &primary, polarityNearer, false),
+	}
+	onPrimary = &dynamicColor{
+		name: "on_primary", palette: primaryPalette, tone: monoOr(10, 90, 20, 100),
+		background: bg(&primary), contrastCurve: onColorCurve,
+	}
+	primaryContainer = &dynamicColor{
+		name: "primary_container", palette: primaryPalette, isBackground: true,
+		tone: func(s *Scheme) float64 {
+			if isFidelity(s) {
+				return s.Source.Tone
+			}
+			return monoOr(85, 25, 30, 90)(s)
+		},
+		background: highestSurface, contrastCurve: containerCurve,
+		toneDeltaPair: pairOf(&primaryContainer, &primary, polarityNearer, false),
+	}
+	onPrimaryContainer = &dynamicColor{
+		name: "on_primary_container", palette: primaryPalette,
+		tone: func(s *Scheme) float64 {
+			if isFidelity(s) {
+				return foregroundTone(primaryContainer.tone(s), 4.5)
+			}
+			return monoOr(0, 100, 90, 10)(s)
+		},
+		background: bg(&primaryContainer), contrastCurve: onColorCurve,
+	}
+	inversePrimary = &dynamicColor{
+		name: "inverse_primary", palette: primaryPalette, tone: darkLight(40, 80),
+		background: bg(&inverseSurface), contrastCurve: accentCurve,
+	}
+
+	secondary = &dynamicColor{
+		name: "secondary", palette: secondaryPalette, tone: darkLight(80, 40), isBackground: true,
+		background: highestSurface, contrastCurve: accentCurve,
+		toneDeltaPair: pairOf(&secondaryContainer, &secondary, polarityNearer, false),
+	}
+	onSecondary = &dynamicColor{
+		name: "on_secondary", palette: secondaryPalette, tone: monoOr(10, 100, 20, 100),
+		background: bg(&secondary), contrastCurve: onColorCurve,
+	}
+	secondaryContainer = &dynamicColor{
+		name: "secondary_container", palette: secondaryPalette, isBackground: true,
+		tone: func(s *Scheme) float64 {
+			initial := darkLight(30, 90)(s)
+			if isMonochrome(s) {
+				return darkLight(30, 85)(s)
+			}
+			if !isFidelity(s) {
+				return initial
+			}
+			return findDesiredChromaByTone(s.Secondary.Hue, s.Secondary.Chroma, initial, !s.IsDark)
+		},
+		background: highestSurface, contrastCurve: containerCurve,
+		toneDeltaPair: pairOf(&secondaryContainer, &secondary, polarityNearer, false),
+	}
+	onSecondaryContainer = &dynamicColor{
+		name: "on_secondary_container", palette: secondaryPalette,
+		tone: func(s *Scheme) float64 {
+			if !isFidelity(s) {
+				return darkLight(90, 10)(s)
+			}
+			return foregroundTone(secondaryContainer.tone(s), 4.5)
+		},
+		background: bg(&secondaryContainer), contrastCurve: onColorCurve,
+	}
+
+	tertiary = &dynamicColor{
+		name: "tertiary", palette: tertiaryPalette, tone: monoOr(90, 25, 80, 40), isBackground: true,
+		background: highestSurface, contrastCurve: accentCurve,
+		toneDeltaPair: pairOf(&tertiaryContainer, &tertiary, polarityNearer, false),
+	}
+	onTertiary = &dynamicColor{
+		name: "on_tertiary", palette: tertiaryPalette, tone: monoOr(10, 90, 20, 100),
+		background: bg(&tertiary), contrastCurve: onColorCurve,
+	}
+	tertiaryContainer = &dynamicColor{
+		name: "tertiary_container", palette: tertiaryPalette, isBackground: true,
+		tone: func(s *Scheme) float64 {
+			if isMonochrome(s) {
+				return darkLight(60, 49)(s)
+			}
+			if !isFidelity(s) {
+				return darkLight(30, 90)(s)
+			}
+			return fixIfDisliked(s.Tertiary.HCT(s.Source.Tone)).Tone
+		},
+		background: highestSurface, contrastCurve: containerCurve,
+		toneDeltaPair: pairOf(&tertiaryContainer, &tertiary, polarityNearer, false),
+	}
+	onTertiaryContainer = &dynamicColor{
+		name: "on_tertiary_container", palette: tertiaryPalette,
+		tone: func(s *Scheme) float64 {
+			if isMonochrome(s) {
+				return darkLight(0, 100)(s)
+			}
+			if !isFidelity(s) {
+				return darkLight(90, 10)(s)
+			}
+			return foregroundTone(tertiaryContainer.tone(s), 4.5)
+		},
+		background: bg(&tertiaryContainer), contrastCurve: onColorCurve,
+	}
+
+	errorColor = &dynamicColor{
+		name: "error", palette: errorPalette, tone: darkLight(80, 40), isBackground: true,
+		background: highestSurface, contrastCurve: accentCurve,
+		toneDeltaPair: pairOf(&errorContainer, &errorColor, polarityNearer, false),
+	}
+	onError = &dynamicColor{
+		name: "on_error", palette: errorPalette, tone: darkLight(20, 100),
+		background: bg(&errorColor), contrastCurve: onColorCurve,
+	}
+	errorContainer = &dynamicColor{
+		name: "error_container", palette: errorPalette, tone: darkLight(30, 90), isBackground: true,
+		background: highestSurface, contrastCurve: containerCurve,
+		toneDeltaPair: pairOf(&errorContainer, &errorColor, polarityNearer, false),
+	}
+	onErrorContainer = &dynamicColor{
+		name: "on_error_container", palette: errorPalette, tone: darkLight(90, 10),
+		background: bg(&errorContainer), contrastCurve: onColorCurve,
+	}
+
+	primaryFixed, primaryFixedDim, onPrimaryFixed, onPrimaryFixedVariant = fixedRoles("primary", primaryPalette, [4]float64{40, 30, 100, 90})
+	secondaryFixed, secondaryFixedDim, onSecondaryFixed, onSecondaryFixedVariant = fixedRoles("secondary", secondaryPalette, [4]float64{80, 70, 10, 25})
+	tertiaryFixed, tertiaryFixedDim, onTertiaryFixed, onTertiaryFixedVariant = fixedRoles("tertiary", tertiaryPalette, [4]float64{40, 30, 100, 90})
+
+	allColors = []*dynamicColor{
+		primaryPaletteKeyColor, secondaryPaletteKeyColor, tertiaryPaletteKeyColor,
+		neutralPaletteKeyColor, neutralVariantPaletteKeyColor,
+		background, onBackground, surface, surfaceDim, surfaceBright,
+		surfaceContainerLowest, surfaceContainerLow, surfaceContainer,
+		surfaceContainerHigh, surfaceContainerHighest, onSurface,
+		surfaceVariant, onSurfaceVariant, inverseSurface, inverseOnSurface,
+		outline, outlineVariant, shadow, scrim, surfaceTint,
+		primary, onPrimary, primaryContainer, onPrimaryContainer, inversePrimary,
+		secondary, onSecondary, secondaryContainer, onSecondaryContainer,
+		tertiary, onTertiary, tertiaryContainer, onTertiaryContainer,
+		errorColor, onError, errorContainer, onErrorContainer,
+		primaryFixed, primaryFixedDim, onPrimaryFixed, onPrimaryFixedVariant,
+		secondaryFixed, secondaryFixedDim, onSecondaryFixed, onSecondaryFixedVariant,
+		tertiaryFixed, tertiaryFixedDim, onTertiaryFixed, onTertiaryFixedVariant,
+	}
+}
+
+// fixedRoles builds the four fixed roles of an accent. monoTones are the
+// monochrome tones of fixed, fixed_dim, on_fixed and on_fixed_variant; the
+// other variants use 90, 80, 10 and 30.
+func fixedRoles(accent string, palette func(s *Scheme) *TonalPalette, monoTones [4]float64) (fixed, fixedDim, onFixed, onFixedVariant *dynamicColor) {
+	toneFor := func(mono, regular float64) func(s *Scheme) float64 {
+		return func(s *Scheme) float64 {
+			if isMonochrome(s) {
+				return mono
+			}
+			return regular
+		}
+	}
+
+	fixed = &dynamicColor{
+		name: accent + "_fixed", palette: palette, tone: toneFor(monoTones[0], 90), isBackground: true,
+		background: highestSurface, contrastCurve: containerCurve,
+	}
+	fixedDim = &dynamicColor{
+		name: accent + "_fixed_dim", palette: palette, tone: toneFor(monoTones[1], 80), isBackground: true,
+		background: highestSurface, contrastCurve: containerCurve,
+	}
+	pair := func(*Scheme) toneDeltaPair {
+		return toneDeltaPair{roleA: fixed, roleB: fixedDim, delta: 10, polarity: polarityLighter, stayTogether: true}
+	}
+	fixed.toneDeltaPair = pair
+	fixedDim.toneDeltaPair = pair
+
+	onFixed = &dynamicColor{
+		name: "on_" + accent + "_fixed", palette: palette, tone: toneFor(monoTones[2], 10),
+		background:       func(*Scheme) *dynamicColor { return fixedDim },
+		secondBackground: func(*Scheme) *dynamicColor { return fixed },
+		contrastCurve:    onColorCurve,
+	}
+	onFixedVariant = &dynamicColor{
+		name: "on_" + accent + "_fixed_variant", palette: palette, tone: toneFor(monoTones[3], 30),
+		background:       func(*Scheme) *dynamicColor { return fixedDim },
+		secondBackground: func(*Scheme) *dynamicColor { return fixed },
+		contrastCurve:    variantCurve,
+	}
+	return fixed, fixedDim, onFixed, onFixedVariant
+}
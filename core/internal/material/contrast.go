@@ -0,0 +1,65 @@
+package material
+
+import "math"
+
+// ratioOfTones is the WCAG contrast ratio between two tones.
+func ratioOfTones(t1, t2 float64) float64 {
+	return ratioOfYs(yFromLstar(clampDouble(0, 100, t1)), yFromLstar(clampDouble(0, 100, t2)))
+}
+
+func ratioOfYs(y1, y2 float64) float64 {
+	lighter, darker := math.Max(y1, y2), math.Min(y1, y2)
+	return (lighter + 5) / (darker + 5)
+}
+
+// lighterTone returns a tone at least ratio lighter than tone, or -1 if
+// none exists.
+func lighterTone(tone, ratio float64) float64 {
+	if tone < 0 || tone > 100 {
+		return -1
+	}
+	darkY := yFromLstar(tone)
+	lightY := ratio*(darkY+5) - 5
+	realContrast := ratioOfYs(lightY, darkY)
+	if realContrast < ratio && math.Abs(realContrast-ratio) > 0.04 {
+		return -1
+	}
+	v := lstarFromY(lightY) + 0.4
+	if v < 0 || v > 100 {
+		return -1
+	}
+	return v
+}
+
+// darkerTone returns a tone at least ratio darker than tone, or -1 if none
+// exists.
+func darkerTone(tone, ratio float64) float64 {
+	if tone < 0 || tone > 100 {
+		return -1
+	}
+	lightY := yFromLstar(tone)
+	darkY := (lightY+5)/ratio - 5
+	realContrast := ratioOfYs(lightY, darkY)
+	if realContrast < ratio && math.Abs(realContrast-ratio) > 0.04 {
+		return -1
+	}
+	v := lstarFromY(darkY) - 0.4
+	if v < 0 || v > 100 {
+		return -1
+	}
+	return v
+}
+
+func lighterToneUnsafe(tone, ratio float64) float64 {
+	if v := lighterTone(tone, ratio); v >= 0 {
+		return v
+	}
+	return 100
+}
+
+func darkerToneUnsafe(tone, ratio float64) float64 {
+	if v := darkerTone(tone, ratio); v >= 0 {
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package material
+
+import "math"
+
+// Harmonize shifts the hue of design toward source by up to 15 degrees,
+// so a fixed brand color sits well next to a generated scheme.
+func Harmonize(design, source ARGB) ARGB {
+	from := HCTFromARGB(design)
+	to := HCTFromARGB(source)
+	rotation := math.Min(differenceDegrees(from.Hue, to.Hue)*0.5, 15)
+	direction := 1.0
+	if sanitizeDegreesDouble(to.Hue-from.Hue) > 180 {
+		direction = -1
+	}
+	return NewHCT(sanitizeDegreesDouble(from.Hue+rotation*direction), from.Chroma, from.Tone).ARGB()
+}
+
+// CustomColor returns the roles matugen derives from a custom color:
+// <name>, on_<name>, <name>_container and on_<name>_container, plus
+// <name>_source and <name>_value for the color as given and after
+// blending. With blend the color is harmonized with source first.
+func CustomColor(name string, value, source ARGB, blend, isDark bool) map[string]ARGB {
+	blended := value
+	if blend {
+		blended = Harmonize(value, source)
+	}
+	hct := HCTFromARGB(blended)
+	palette := TonalPaletteFromHueAndChroma(hct.Hue, math.Max(48, hct.Chroma))
+
+	tones := [4]float64{40, 100, 90, 10}
+	if isDark {
+		tones = [4]float64{80, 20, 30, 90}
+	}
+	return map[string]ARGB{
+		name + "_source":            value,
+		name + "_value":             blended,
+		name:                        palette.Tone(tones[0]),
+		"on_" + name:                palette.Tone(tones[1]),
+		name + "_container":         palette.Tone(tones[2]),
+		"on_" + name + "_container": palette.Tone(tones[3]),
+	}
+}
@@ -0,0 +1,216 @@
+package material
+
+import "math"
+
+// contrastCurve is the minimum contrast ratio at contrast levels -1, 0,
+// 0.5 and 1, interpolated in between.
+type contrastCurve struct {
+	low, normal, medium, high float64
+}
+
+func (c contrastCurve) get(level float64) float64 {
+	switch {
+	case level <= -1:
+		return c.low
+	case level < 0:
+		return lerp(c.low, c.normal, level+1)
+	case level < 0.5:
+		return lerp(c.normal, c.medium, level/0.5)
+	case level < 1:
+		return lerp(c.medium, c.high, (level-0.5)/0.5)
+	default:
+		return c.high
+	}
+}
+
+type tonePolarity int
+
+const (
+	polarityDarker tonePolarity = iota
+	polarityLighter
+	polarityNearer
+	polarityFarther
+)
+
+// toneDeltaPair keeps two colors at least delta tones apart, e.g. a
+// container and the accent drawn on top of it.
+type toneDeltaPair struct {
+	roleA, roleB *dynamicColor
+	delta        float64
+	polarity     tonePolarity
+	stayTogether bool
+}
+
+// dynamicColor is a named scheme role whose tone is adjusted to keep the
+// required contrast against its background.
+type dynamicColor struct {
+	name             string
+	palette          func(s *Scheme) *TonalPalette
+	tone             func(s *Scheme) float64
+	isBackground     bool
+	background       func(s *Scheme) *dynamicColor
+	secondBackground func(s *Scheme) *dynamicColor
+	contrastCurve    contrastCurve
+	toneDeltaPair    func(s *Scheme) toneDeltaPair
+}
+
+func (dc *dynamicColor) argb(s *Scheme) ARGB {
+	return dc.palette(s).Tone(dc.getTone(s))
+}
+
+func (dc *dynamicColor) getTone(s *Scheme) float64 {
+	if t, ok := s.tones[dc.name]; ok {
+		return t
+	}
+	t := dc.computeTone(s)
+	s.tones[dc.name] = t
+	return t
+}
+
+func (dc *dynamicColor) computeTone(s *Scheme) float64 {
+	decreasingContrast := s.ContrastLevel < 0
+
+	if dc.toneDeltaPair != nil {
+		pair := dc.toneDeltaPair(s)
+		bgTone := dc.background(s).getTone(s)
+
+		aIsNearer := pair.polarity == polarityNearer ||
+			(pair.polarity == polarityLighter && !s.IsDark) ||
+			(pair.polarity == polarityDarker && s.IsDark)
+		nearer, farther := pair.roleA, pair.roleB
+		if !aIsNearer {
+			nearer, farther = pair.roleB, pair.roleA
+		}
+		amNearer := dc.name == nearer.name
+		expansionDir := -1.0
+		if s.IsDark {
+			expansionDir = 1
+		}
+		delta := pair.delta
+
+		nContrast := nearer.contrastCurve.get(s.ContrastLevel)
+		fContrast := farther.contrastCurve.get(s.ContrastLevel)
+
+		nTone := nearer.tone(s)
+		if ratioOfTones(bgTone, nTone) < nContrast {
+			nTone = foregroundTone(bgTone, nContrast)
+		}
+		fTone := farther.tone(s)
+		if ratioOfTones(bgTone, fTone) < fContrast {
+			fTone = foregroundTone(bgTone, fContrast)
+		}
+		if decreasingContrast {
+			nTone = foregroundTone(bgTone, nContrast)
+			fTone = foregroundTone(bgTone, fContrast)
+		}
+
+		if (fTone-nTone)*expansionDir < delta {
+			fTone = clampDouble(0, 100, nTone+delta*expansionDir)
+			if (fTone-nTone)*expansionDir < delta {
+				nTone = clampDouble(0, 100, fTone-delta*expansionDir)
+			}
+		}
+
+		switch {
+		case 50 <= nTone && nTone < 60:
+			if expansionDir > 0 {
+				nTone = 60
+				fTone = math.Max(fTone, nTone+delta*expansionDir)
+			} else {
+				nTone = 49
+				fTone = math.Min(fTone, nTone+delta*expansionDir)
+			}
+		case 50 <= fTone && fTone < 60:
+			switch {
+			case pair.stayTogether && expansionDir > 0:
+				nTone = 60
+				fTone = math.Max(fTone, nTone+delta*expansionDir)
+			case pair.stayTogether:
+				nTone = 49
+				fTone = math.Min(fTone, nTone+delta*expansionDir)
+			case expansionDir > 0:
+				fTone = 60
+			default:
+				fTone = 49
+			}
+		}
+
+		if amNearer {
+			return nTone
+		}
+		return fTone
+	}
+
+	answer := dc.tone(s)
+	if dc.background == nil {
+		return answer
+	}
+
+	bgTone := dc.background(s).getTone(s)
+	desiredRatio := dc.contrastCurve.get(s.ContrastLevel)
+	if ratioOfTones(bgTone, answer) < desiredRatio {
+		answer = foregroundTone(bgTone, desiredRatio)
+	}
+	if decreasingContrast {
+		answer = foregroundTone(bgTone, desiredRatio)
+	}
+	if dc.isBackground && 50 <= answer && answer < 60 {
+		if ratioOfTones(49, bgTone) >= desiredRatio {
+			answer = 49
+		} else {
+			answer = 60
+		}
+	}
+
+	if dc.secondBackground == nil {
+		return answer
+	}
+
+	bgTone1 := dc.background(s).getTone(s)
+	bgTone2 := dc.secondBackground(s).getTone(s)
+	upper, lower := math.Max(bgTone1, bgTone2), math.Min(bgTone1, bgTone2)
+	if ratioOfTones(upper, answer) >= desiredRatio && ratioOfTones(lower, answer) >= desiredRatio {
+		return answer
+	}
+
+	lightOption := lighterTone(upper, desiredRatio)
+	darkOption := darkerTone(lower, desiredRatio)
+	if tonePrefersLightForeground(bgTone1) || tonePrefersLightForeground(bgTone2) {
+		if lightOption < 0 {
+			return 100
+		}
+		return lightOption
+	}
+	if lightOption >= 0 && darkOption < 0 {
+		return lightOption
+	}
+	if darkOption < 0 {
+		return 0
+	}
+	return darkOption
+}
+
+// foregroundTone picks a lighter or darker tone than bgTone that reaches
+// ratio, preferring the direction that reads best.
+func foregroundTone(bgTone, ratio float64) float64 {
+	lighter := lighterToneUnsafe(bgTone, ratio)
+	darker := darkerToneUnsafe(bgTone, ratio)
+	lighterRatio := ratioOfTones(lighter, bgTone)
+	darkerRatio := ratioOfTones(darker, bgTone)
+
+	if tonePrefersLightForeground(bgTone) {
+		negligibleDifference := math.Abs(lighterRatio-darkerRatio) < 0.1 && lighterRatio < ratio && darkerRatio < ratio
+		if lighterRatio >= ratio || lighterRatio >= darkerRatio || negligibleDifference {
+			return lighter
+		}
+		return darker
+	}
+	if darkerRatio >= ratio || darkerRatio >= lighterRatio {
+		return darker
+	}
+	return lighter
+}
+
+func tonePrefersLightForeground(tone float64) bool {
+	return math.Round(tone) < 60
+}
@@ -0,0 +1,284 @@
+package material
+
+import "math"
+
+// HCT is a color described by CAM16 hue and chroma and L* tone. Changing
+// tone keeps perceived hue and colorfulness, which is what tonal palettes
+// rely on.
+type HCT struct {
+	Hue    float64
+	Chroma float64
+	Tone   float64
+	argb   ARGB
+}
+
+// HCTFromARGB converts an sRGB color to HCT.
+func HCTFromARGB(argb ARGB) HCT {
+	cam := cam16FromARGB(argb)
+	return HCT{Hue: cam.hue, Chroma: cam.chroma, Tone: lstarFromARGB(argb), argb: argb}
+}
+
+// NewHCT returns the sRGB color closest to the requested hue, chroma and
+// tone. Chroma is reduced when the combination is out of gamut.
+func NewHCT(hue, chroma, tone float64) HCT {
+	return HCTFromARGB(solveToARGB(hue, chroma, tone))
+}
+
+func (h HCT) ARGB() ARGB { return h.argb }
+
+var (
+	scaledDiscountFromLinrgb = [3][3]float64{
+		{0.001200833568784504, 0.002389694492170889, 0.0002795742885861124},
+		{0.0005891086651375999, 0.0029785502573438758, 0.0003270666104008398},
+		{0.00010146692491640572, 0.0005364214359186694, 0.0032979401770712076},
+	}
+	linrgbFromScaledDiscount = [3][3]float64{
+		{1373.2198709594231, -1100.4251190754821, -7.278681089101213},
+		{-271.815969077903, 559.6580465940733, -32.46047482791194},
+		{1.9622899599665666, -57.173814538844006, 308.7233197812385},
+	}
+	yFromLinrgb = [3]float64{0.2126, 0.7152, 0.0722}
+
+	// criticalPlanes are the linear RGB values halfway between adjacent
+	// 8-bit sRGB values.
+	criticalPlanes = func() [255]float64 {
+		var planes [255]float64
+		for i := range planes {
+			normalized := (float64(i) + 0.5) / 255
+			if normalized <= 0.040449936 {
+				planes[i] = normalized / 12.92 * 100
+			} else {
+				planes[i] = math.Pow((normalized+0.055)/1.055, 2.4) * 100
+			}
+		}
+		return planes
+	}()
+)
+
+func sanitizeRadians(angle float64) float64 {
+	return math.Mod(angle+math.Pi*8, math.Pi*2)
+}
+
+func trueDelinearized(rgbComponent float64) float64 {
+	normalized := rgbComponent / 100
+	var d float64
+	if normalized <= 0.0031308 {
+		d = normalized * 12.92
+	} else {
+		d = 1.055*math.Pow(normalized, 1/2.4) - 0.055
+	}
+	return d * 255
+}
+
+func chromaticAdaptation(component float64) float64 {
+	af := math.Pow(math.Abs(component), 0.42)
+	return signum(component) * 400 * af / (af + 27.13)
+}
+
+func hueOf(linrgb [3]float64) float64 {
+	scaled := matrixMultiply(linrgb, scaledDiscountFromLinrgb)
+	rA := chromaticAdaptation(scaled[0])
+	gA := chromaticAdaptation(scaled[1])
+	bA := chromaticAdaptation(scaled[2])
+	a := (11*rA + -12*gA + bA) / 11
+	b := (rA + gA - 2*bA) / 9
+	return math.Atan2(b, a)
+}
+
+func areInCyclicOrder(a, b, c float64) bool {
+	return sanitizeRadians(b-a) < sanitizeRadians(c-a)
+}
+
+func setCoordinate(source [3]float64, coordinate float64, target [3]float64, axis int) [3]float64 {
+	t := (coordinate - source[axis]) / (target[axis] - source[axis])
+	return [3]float64{
+		source[0] + (target[0]-source[0])*t,
+		source[1] + (target[1]-source[1])*t,
+		source[2] + (target[2]-source[2])*t,
+	}
+}
+
+func isBounded(x float64) bool {
+	return 0 <= x && x <= 100
+}
+
+// nthVertex returns the nth of the 12 edges of the RGB cube intersected
+// with the plane of constant Y, or a negative point if it misses.
+func nthVertex(y float64, n int) [3]float64 {
+	kR, kG, kB := yFromLinrgb[0], yFromLinrgb[1], yFromLinrgb[2]
+	coordA := 0.0
+	if n%4 > 1 {
+		coordA = 100
+	}
+	coordB := 0.0
+	if n%2 != 0 {
+		coordB = 100
+	}
+	none := [3]float64{-1, -1, -1}
+
+	switch {
+	case n < 4:
+		g, b := coordA, coordB
+		r := (y - g*kG - b*kB) / kR
+		if isBounded(r) {
+			return [3]float64{r, g, b}
+		}
+	case n < 8:
+		b, r := coordA, coordB
+		g := (y - r*kR - b*kB) / kG
+		if isBounded(g) {
+			return [3]float64{r, g, b}
+		}
+	default:
+		r, g := coordA, coordB
+		b := (y - r*kR - g*kG) / kB
+		if isBounded(b) {
+			return [3]float64{r, g, b}
+		}
+	}
+	return none
+}
+
+func bisectToSegment(y, targetHue float64) ([3]float64, [3]float64) {
+	left := [3]float64{-1, -1, -1}
+	right := left
+	leftHue, rightHue := 0.0, 0.0
+	initialized := false
+	uncut := true
+
+	for n := range 12 {
+		mid := nthVertex(y, n)
+		if mid[0] < 0 {
+			continue
+		}
+		midHue := hueOf(mid)
+		if !initialized {
+			left, right = mid, mid
+			leftHue, rightHue = midHue, midHue
+			initialized = true
+			continue
+		}
+		if uncut || areInCyclicOrder(leftHue, midHue, rightHue) {
+			uncut = false
+			if areInCyclicOrder(leftHue, targetHue, midHue) {
+				right, rightHue = mid, midHue
+			} else {
+				left, leftHue = mid, midHue
+			}
+		}
+	}
+	return left, right
+}
+
+func criticalPlaneBelow(x float64) int { return int(math.Floor(x - 0.5)) }
+func criticalPlaneAbove(x float64) int { return int(math.Ceil(x - 0.5)) }
+
+func bisectToLimit(y, targetHue float64) [3]float64 {
+	left, right := bisectToSegment(y, targetHue)
+	leftHue := hueOf(left)
+
+	for axis := range 3 {
+		if left[axis] == right[axis] {
+			continue
+		}
+		var lPlane, rPlane int
+		if left[axis] < right[axis] {
+			lPlane = criticalPlaneBelow(trueDelinearized(left[axis]))
+			rPlane = criticalPlaneAbove(trueDelinearized(right[axis]))
+		} else {
+			lPlane = criticalPlaneAbove(trueDelinearized(left[axis]))
+			rPlane = criticalPlaneBelow(trueDelinearized(right[axis]))
+		}
+		for range 8 {
+			if abs(rPlane-lPlane) <= 1 {
+				break
+			}
+			mPlane := int(math.Floor(float64(lPlane+rPlane) / 2))
+			mid := setCoordinate(left, criticalPlanes[mPlane], right, axis)
+			midHue := hueOf(mid)
+			if areInCyclicOrder(leftHue, targetHue, midHue) {
+				right = mid
+				rPlane = mPlane
+			} else {
+				left, leftHue = mid, midHue
+				lPlane = mPlane
+			}
+		}
+	}
+	return [3]float64{(left[0] + right[0]) / 2, (left[1] + right[1]) / 2, (left[2] + right[2]) / 2}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func inverseChromaticAdaptation(adapted float64) float64 {
+	adaptedAbs := math.Abs(adapted)
+	base := math.Max(0, 27.13*adaptedAbs/(400-adaptedAbs))
+	return signum(adapted) * math.Pow(base, 1/0.42)
+}
+
+// findResultByJ iterates on CAM16 J to hit the requested Y. It returns 0
+// when the color is out of gamut.
+func findResultByJ(hueRadians, chroma, y float64) ARGB {
+	j := math.Sqrt(y) * 11
+	vc := defaultViewingConditions
+	tInnerCoeff := 1 / math.Pow(1.64-math.Pow(0.29, vc.n), 0.73)
+	eHue := 0.25 * (math.Cos(hueRadians+2) + 3.8)
+	p1 := eHue * (50000.0 / 13.0) * vc.nc * vc.ncb
+	hSin, hCos := math.Sin(hueRadians), math.Cos(hueRadians)
+
+	for round := range 5 {
+		jNormalized := j / 100
+		alpha := 0.0
+		if chroma != 0 && j != 0 {
+			alpha = chroma / math.Sqrt(jNormalized)
+		}
+		t := math.Pow(alpha*tInnerCoeff, 1/0.9)
+		ac := vc.aw * math.Pow(jNormalized, 1/vc.c/vc.z)
+		p2 := ac / vc.nbb
+		gamma := 23 * (p2 + 0.305) * t / (23*p1 + 11*t*hCos + 108*t*hSin)
+		a := gamma * hCos
+		b := gamma * hSin
+		rA := (460*p2 + 451*a + 288*b) / 1403
+		gA := (460*p2 - 891*a - 261*b) / 1403
+		bA := (460*p2 - 220*a - 6300*b) / 1403
+
+		linrgb := matrixMultiply([3]float64{
+			inverseChromaticAdaptation(rA),
+			inverseChromaticAdaptation(gA),
+			inverseChromaticAdaptation(bA),
+		}, linrgbFromScaledDiscount)
+		if linrgb[0] < 0 || linrgb[1] < 0 || linrgb[2] < 0 {
+			return 0
+		}
+
+		fnj := yFromLinrgb[0]*linrgb[0] + yFromLinrgb[1]*linrgb[1] + yFromLinrgb[2]*linrgb[2]
+		if fnj <= 0 {
+			return 0
+		}
+		if round == 4 || math.Abs(fnj-y) < 0.002 {
+			if linrgb[0] > 100.01 || linrgb[1] > 100.01 || linrgb[2] > 100.01 {
+				return 0
+			}
+			return argbFromLinrgb(linrgb)
+		}
+		j -= (fnj - y) * j / (2 * fnj)
+	}
+	return 0
+}
+
+func solveToARGB(hueDegrees, chroma, lstar float64) ARGB {
+	if chroma < 0.0001 || lstar < 0.0001 || lstar > 99.9999 {
+		return argbFromLstar(lstar)
+	}
+	hueRadians := sanitizeDegreesDouble(hueDegrees) / 180 * math.Pi
+	y := yFromLstar(lstar)
+	if exact := findResultByJ(hueRadians, chroma, y); exact != 0 {
+		return exact
+	}
+	return argbFromLinrgb(bisectToLimit(y, hueRadians))
+}
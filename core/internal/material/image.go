@@ -0,0 +1,77 @@
+package material
+
+import (
+	"image"
+)
+
+const (
+	// maxQuantizeColors is what matugen and Android use for wallpapers.
+	maxQuantizeColors = 128
+	// thumbnailSize bounds the longer image side before quantizing; the
+	// result barely changes and large wallpapers quantize much faster.
+	thumbnailSize = 128
+)
+
+// SourceColors returns candidate theme source colors for an image, best
+// first. There is always at least one color.
+func SourceColors(img image.Image, desired int) []ARGB {
+	return Score(Quantize(thumbnailPixels(img, thumbnailSize), maxQuantizeColors), desired)
+}
+
+// SourceColor is the best theme source color for an image.
+func SourceColor(img image.Image) ARGB {
+	return SourceColors(img, 4)[0]
+}
+
+// thumbnailPixels box-filters img so its longer side is at most size and
+// returns the pixels in row order.
+func thumbnailPixels(img image.Image, size int) []ARGB {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(h*size/w, 1)
+		} else {
+			tw, th = max(w*size/h, 1), size
+		}
+	}
+
+	pixels := make([]ARGB, 0, tw*th)
+	for ty := range th {
+		y0 := bounds.Min.Y + ty*h/th
+		y1 := max(bounds.Min.Y+(ty+1)*h/th, y0+1)
+		for tx := range tw {
+			x0 := bounds.Min.X + tx*w/tw
+			x1 := max(bounds.Min.X+(tx+1)*w/tw, x0+1)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			// RGBA() is premultiplied; undo it so translucent edges keep
+			// their hue, and let Quantize drop anything not fully opaque.
+			if a == 0 {
+				pixels = append(pixels, 0)
+				continue
+			}
+			alpha := uint8(a / n >> 8)
+			pixels = append(pixels, ARGB(uint32(alpha)<<24)|
+				ARGB(uint32(r*0xff/a)<<16)|
+				ARGB(uint32(g*0xff/a)<<8)|
+				ARGB(uint32(b*0xff/a)))
+		}
+	}
+	return pixels
+}
@@ -0,0 +1,149 @@
+package material
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHCTFromARGB(t *testing.T) {
+	blue := HCTFromARGB(0xff0000ff)
+	assert.InDelta(t, 282.79, blue.Hue, 0.01)
+	assert.InDelta(t, 87.23, blue.Chroma, 0.01)
+	assert.InDelta(t, 32.30, blue.Tone, 0.01)
+
+	red := HCTFromARGB(0xffff0000)
+	assert.InDelta(t, 27.41, red.Hue, 0.01)
+	assert.InDelta(t, 113.36, red.Chroma, 0.01)
+	assert.InDelta(t, 53.23, red.Tone, 0.01)
+}
+
+func TestNewHCTRoundTrip(t *testing.T) {
+	for _, c := range []ARGB{0xff0000ff, 0xffff0000, 0xff00ff00, 0xff4285f4, 0xff808080, 0xff123456} {
+		h := HCTFromARGB(c)
+		assert.Equal(t, c, NewHCT(h.Hue, h.Chroma, h.Tone).ARGB(), "color %s", c.Hex())
+	}
+
+	// Out of gamut chroma is reduced, tone is kept.
+	h := NewHCT(120, 200, 50)
+	assert.InDelta(t, 50, h.Tone, 0.5)
+	assert.Less(t, h.Chroma, 200.0)
+}
+
+func TestSchemeTonalSpot(t *testing.T) {
+	light := NewScheme(0xff0000ff, VariantTonalSpot, false, 0).Colors()
+	assert.Equal(t, ARGB(0xff555992), light["primary"])
+	assert.Equal(t, ARGB(0xffe0e0ff), light["primary_container"])
+	assert.Equal(t, ARGB(0xfffbf8ff), light["surface"])
+	assert.Equal(t, ARGB(0xff6e72ac), light["primary_palette_key_color"])
+	assert.Equal(t, ARGB(0xff0000ff), light["source_color"])
+
+	dark := NewScheme(0xff0000ff, VariantTonalSpot, true, 0).Colors()
+	assert.Equal(t, ARGB(0xffbec2ff), dark["primary"])
+	assert.Equal(t, ARGB(0xff3e4278), dark["primary_container"])
+	assert.Equal(t, ARGB(0xffe0e0ff), dark["on_primary_container"])
+	assert.Equal(t, ARGB(0xff131318), dark["surface"])
+}
+
+func TestSchemeContrast(t *testing.T) {
+	for v := range variantNames {
+		for _, dark := range []bool{false, true} {
+			s := NewScheme(0xff6750a4, v, dark, 0)
+			pairs := [][2]string{
+				{"on_primary", "primary"},
+				{"on_surface", "surface"},
+				{"on_secondary_container", "secondary_container"},
+				{"on_tertiary_container", "tertiary_container"},
+				{"on_error", "error"},
+			}
+			for _, p := range pairs {
+				fg, _ := s.Color(p[0])
+				bg, _ := s.Color(p[1])
+				ratio := ratioOfTones(HCTFromARGB(fg).Tone, HCTFromARGB(bg).Tone)
+				assert.GreaterOrEqual(t, ratio, 4.4, "%s dark=%v %s on %s", v, dark, p[0], p[1])
+			}
+		}
+	}
+}
+
+func TestHarmonize(t *testing.T) {
+	assert.Equal(t, ARGB(0xfffb0057), Harmonize(0xffff0000, 0xff0000ff))
+	assert.Equal(t, ARGB(0xffd85600), Harmonize(0xffff0000, 0xff00ff00))
+	assert.Equal(t, ARGB(0xff0000ff), Harmonize(0xff0000ff, 0xff0000ff))
+}
+
+func TestCustomColor(t *testing.T) {
+	light := CustomColor("green", 0xff00ff00, 0xff0000ff, false, false)
+	assert.Equal(t, ARGB(0xff00ff00), light["green_source"])
+	assert.Equal(t, ARGB(0xff00ff00), light["green_value"])
+	assert.Equal(t, 40.0, math.Round(HCTFromARGB(light["green"]).Tone))
+	assert.Equal(t, 90.0, math.Round(HCTFromARGB(light["green_container"]).Tone))
+	assert.Contains(t, light, "on_green")
+	assert.Contains(t, light, "on_green_container")
+
+	dark := CustomColor("green", 0xff00ff00, 0xff0000ff, true, true)
+	assert.NotEqual(t, dark["green_source"], dark["green_value"])
+	assert.Equal(t, 80.0, math.Round(HCTFromARGB(dark["green"]).Tone))
+}
+
+func TestParseVariant(t *testing.T) {
+	v, err := ParseVariant("scheme-tonal-spot")
+	require.NoError(t, err)
+	assert.Equal(t, VariantTonalSpot, v)
+
+	v, err = ParseVariant("fruit_salad")
+	require.NoError(t, err)
+	assert.Equal(t, VariantFruitSalad, v)
+
+	_, err = ParseVariant("scheme-bogus")
+	assert.Error(t, err)
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, []ARGB{FallbackColor}, Score([]ColorCount{{Color: 0xff000000, Count: 1}}, 4))
+
+	ranked := Score([]ColorCount{
+		{Color: 0xffff0000, Count: 1},
+		{Color: 0xff00ff00, Count: 1},
+		{Color: 0xff0000ff, Count: 1},
+	}, 4)
+	assert.Equal(t, []ARGB{0xffff0000, 0xff00ff00, 0xff0000ff}, ranked)
+}
+
+func TestQuantize(t *testing.T) {
+	pixels := make([]ARGB, 0, 300)
+	for range 200 {
+		pixels = append(pixels, 0xff0000ff)
+	}
+	for range 100 {
+		pixels = append(pixels, 0xffff0000)
+	}
+	pixels = append(pixels, 0x80ffffff)
+
+	result := Quantize(pixels, 128)
+	require.Len(t, result, 2)
+	counts := map[ARGB]int{}
+	for _, c := range result {
+		counts[c.Color] = c.Count
+	}
+	assert.Equal(t, 200, counts[0xff0000ff])
+	assert.Equal(t, 100, counts[0xffff0000])
+}
+
+func TestSourceColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := range 200 {
+		for x := range 300 {
+			c := color.RGBA{R: 0x30, G: 0x30, B: 0x30, A: 255}
+			if x < 200 {
+				c = color.RGBA{R: 0x1e, G: 0x66, B: 0xf5, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	assert.Equal(t, ARGB(0xff1e66f5), SourceColor(img))
+}
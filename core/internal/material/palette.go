@@ -0,0 +1,87 @@
+package material
+
+import (
+	"math"
+	"sync"
+)
+
+// TonalPalette is a fixed hue and chroma at every tone from 0 to 100.
+type TonalPalette struct {
+	Hue      float64
+	Chroma   float64
+	KeyColor HCT
+
+	mu    sync.Mutex
+	cache map[float64]ARGB
+}
+
+func TonalPaletteFromHCT(hct HCT) *TonalPalette {
+	return &TonalPalette{Hue: hct.Hue, Chroma: hct.Chroma, KeyColor: hct}
+}
+
+func TonalPaletteFromHueAndChroma(hue, chroma float64) *TonalPalette {
+	return &TonalPalette{Hue: hue, Chroma: chroma, KeyColor: keyColor(hue, chroma)}
+}
+
+func (p *TonalPalette) Tone(tone float64) ARGB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, ok := p.cache[tone]; ok {
+		return c
+	}
+	if p.cache == nil {
+		p.cache = make(map[float64]ARGB)
+	}
+	c := solveToARGB(p.Hue, p.Chroma, tone)
+	p.cache[tone] = c
+	return c
+}
+
+func (p *TonalPalette) HCT(tone float64) HCT {
+	return HCTFromARGB(p.Tone(tone))
+}
+
+// keyColor finds the tone closest to 50 that can still reach the requested
+// chroma, so the key color represents the palette well.
+func keyColor(hue, requestedChroma float64) HCT {
+	const (
+		pivotTone      = 50
+		toneStepSize   = 1
+		epsilon        = 0.01
+		maxChromaValue = 200.0
+	)
+
+	cache := make(map[int]float64)
+	maxChroma := func(tone int) float64 {
+		if c, ok := cache[tone]; ok {
+			return c
+		}
+		c := NewHCT(hue, maxChromaValue, float64(tone)).Chroma
+		cache[tone] = c
+		return c
+	}
+
+	lowerTone, upperTone := 0, 100
+	for lowerTone < upperTone {
+		midTone := (lowerTone + upperTone) / 2
+		isAscending := maxChroma(midTone) < maxChroma(midTone+toneStepSize)
+		sufficientChroma := maxChroma(midTone) >= requestedChroma-epsilon
+
+		if sufficientChroma {
+			if math.Abs(float64(lowerTone-pivotTone)) < math.Abs(float64(upperTone-pivotTone)) {
+				upperTone = midTone
+			} else {
+				if lowerTone == midTone {
+					return NewHCT(hue, requestedChroma, float64(lowerTone))
+				}
+				lowerTone = midTone
+			}
+		} else if isAscending {
+			lowerTone = midTone + toneStepSize
+		} else {
+			upperTone = midTone
+		}
+	}
+	return NewHCT(hue, requestedChroma, float64(lowerTone))
+}
@@ -0,0 +1,464 @@
+package material
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ColorCount is a quantized color and the number of pixels it represents.
+type ColorCount struct {
+	Color ARGB
+	Count int
+}
+
+// Quantize reduces pixels to at most maxColors representative colors: Wu's
+// quantizer picks starting clusters and weighted k-means refines them in
+// L*a*b*. Pixels that aren't fully opaque are ignored.
+func Quantize(pixels []ARGB, maxColors int) []ColorCount {
+	opaque := make([]ARGB, 0, len(pixels))
+	for _, p := range pixels {
+		if p.Alpha() == 0xff {
+			opaque = append(opaque, p)
+		}
+	}
+	return quantizeWsmeans(opaque, quantizeWu(opaque, maxColors), maxColors)
+}
+
+const (
+	wuIndexBits  = 5
+	wuSideLength = 33
+	wuTotalSize  = 35937
+)
+
+type wuBox struct {
+	r0, r1, g0, g1, b0, b1, vol int
+}
+
+type wuDirection int
+
+const (
+	wuRed wuDirection = iota
+	wuGreen
+	wuBlue
+)
+
+type wuQuantizer struct {
+	weights  []int
+	momentsR []int
+	momentsG []int
+	momentsB []int
+	moments  []float64
+	cubes    []wuBox
+}
+
+func wuIndex(r, g, b int) int {
+	return (r << (wuIndexBits * 2)) + (r << (wuIndexBits + 1)) + r + (g << wuIndexBits) + g + b
+}
+
+func quantizeWu(pixels []ARGB, maxColors int) []ARGB {
+	q := &wuQuantizer{
+		weights:  make([]int, wuTotalSize),
+		momentsR: make([]int, wuTotalSize),
+		momentsG: make([]int, wuTotalSize),
+		momentsB: make([]int, wuTotalSize),
+		moments:  make([]float64, wuTotalSize),
+	}
+	q.constructHistogram(pixels)
+	q.computeMoments()
+	count := q.createBoxes(maxColors)
+	return q.createResult(count)
+}
+
+func (q *wuQuantizer) constructHistogram(pixels []ARGB) {
+	counts := make(map[ARGB]int)
+	for _, p := range pixels {
+		counts[p]++
+	}
+
+	const bitsToRemove = 8 - wuIndexBits
+	for pixel, count := range counts {
+		red, green, blue := int(pixel.Red()), int(pixel.Green()), int(pixel.Blue())
+		index := wuIndex((red>>bitsToRemove)+1, (green>>bitsToRemove)+1, (blue>>bitsToRemove)+1)
+		q.weights[index] += count
+		q.momentsR[index] += count * red
+		q.momentsG[index] += count * green
+		q.momentsB[index] += count * blue
+		q.moments[index] += float64(count * (red*red + green*green + blue*blue))
+	}
+}
+
+func (q *wuQuantizer) computeMoments() {
+	for r := 1; r < wuSideLength; r++ {
+		var area, areaR, areaG, areaB [wuSideLength]int
+		var area2 [wuSideLength]float64
+
+		for g := 1; g < wuSideLength; g++ {
+			line, lineR, lineG, lineB := 0, 0, 0, 0
+			line2 := 0.0
+			for b := 1; b < wuSideLength; b++ {
+				index := wuIndex(r, g, b)
+				line += q.weights[index]
+				lineR += q.momentsR[index]
+				lineG += q.momentsG[index]
+				lineB += q.momentsB[index]
+				line2 += q.moments[index]
+
+				area[b] += line
+				areaR[b] += lineR
+				areaG[b] += lineG
+				areaB[b] += lineB
+				area2[b] += line2
+
+				prev := wuIndex(r-1, g, b)
+				q.weights[index] = q.weights[prev] + area[b]
+				q.momentsR[index] = q.momentsR[prev] + areaR[b]
+				q.momentsG[index] = q.momentsG[prev] + areaG[b]
+				q.momentsB[index] = q.momentsB[prev] + areaB[b]
+				q.moments[index] = q.moments[prev] + area2[b]
+			}
+		}
+	}
+}
+
+func (q *wuQuantizer) createBoxes(maxColors int) int {
+	q.cubes = make([]wuBox, maxColors)
+	q.cubes[0] = wuBox{r1: wuSideLength - 1, g1: wuSideLength - 1, b1: wuSideLength - 1}
+	volumeVariance := make([]float64, maxColors)
+
+	next := 0
+	generated := maxColors
+	for i := 1; i < maxColors; i++ {
+		if q.cut(&q.cubes[next], &q.cubes[i]) {
+			volumeVariance[next] = 0
+			if q.cubes[next].vol > 1 {
+				volumeVariance[next] = q.variance(q.cubes[next])
+			}
+			volumeVariance[i] = 0
+			if q.cubes[i].vol > 1 {
+				volumeVariance[i] = q.variance(q.cubes[i])
+			}
+		} else {
+			volumeVariance[next] = 0
+			i--
+		}
+
+		next = 0
+		temp := volumeVariance[0]
+		for j := 1; j <= i; j++ {
+			if volumeVariance[j] > temp {
+				temp = volumeVariance[j]
+				next = j
+			}
+		}
+		if temp <= 0 {
+			generated = i + 1
+			break
+		}
+	}
+	return generated
+}
+
+func (q *wuQuantizer) createResult(count int) []ARGB {
+	var colors []ARGB
+	for i := range count {
+		cube := q.cubes[i]
+		weight := wuVolume(cube, q.weights)
+		if weight <= 0 {
+			continue
+		}
+		r := int(math.Round(float64(wuVolume(cube, q.momentsR)) / float64(weight)))
+		g := int(math.Round(float64(wuVolume(cube, q.momentsG)) / float64(weight)))
+		b := int(math.Round(float64(wuVolume(cube, q.momentsB)) / float64(weight)))
+		colors = append(colors, ARGBFromRGB(uint8(r), uint8(g), uint8(b)))
+	}
+	return colors
+}
+
+func (q *wuQuantizer) variance(cube wuBox) float64 {
+	dr := float64(wuVolume(cube, q.momentsR))
+	dg := float64(wuVolume(cube, q.momentsG))
+	db := float64(wuVolume(cube, q.momentsB))
+	m := q.moments
+	xx := m[wuIndex(cube.r1, cube.g1, cube.b1)] -
+		m[wuIndex(cube.r1, cube.g1, cube.b0)] -
+		m[wuIndex(cube.r1, cube.g0, cube.b1)] +
+		m[wuIndex(cube.r1, cube.g0, cube.b0)] -
+		m[wuIndex(cube.r0, cube.g1, cube.b1)] +
+		m[wuIndex(cube.r0, cube.g1, cube.b0)] +
+		m[wuIndex(cube.r0, cube.g0, cube.b1)] -
+		m[wuIndex(cube.r0, cube.g0, cube.b0)]
+	hypotenuse := dr*dr + dg*dg + db*db
+	volume := float64(wuVolume(cube, q.weights))
+	return xx - hypotenuse/volume
+}
+
+func (q *wuQuantizer) cut(one, two *wuBox) bool {
+	wholeR := wuVolume(*one, q.momentsR)
+	wholeG := wuVolume(*one, q.momentsG)
+	wholeB := wuVolume(*one, q.momentsB)
+	wholeW := wuVolume(*one, q.weights)
+
+	maxRCut, maxR := q.maximize(*one, wuRed, one.r0+1, one.r1, wholeR, wholeG, wholeB, wholeW)
+	maxGCut, maxG := q.maximize(*one, wuGreen, one.g0+1, one.g1, wholeR, wholeG, wholeB, wholeW)
+	maxBCut, maxB := q.maximize(*one, wuBlue, one.b0+1, one.b1, wholeR, wholeG, wholeB, wholeW)
+
+	var direction wuDirection
+	switch {
+	case maxR >= maxG && maxR >= maxB:
+		if maxRCut < 0 {
+			return false
+		}
+		direction = wuRed
+	case maxG >= maxR && maxG >= maxB:
+		direction = wuGreen
+	default:
+		direction = wuBlue
+	}
+
+	two.r1, two.g1, two.b1 = one.r1, one.g1, one.b1
+	switch direction {
+	case wuRed:
+		one.r1 = maxRCut
+		two.r0, two.g0, two.b0 = one.r1, one.g0, one.b0
+	case wuGreen:
+		one.g1 = maxGCut
+		two.r0, two.g0, two.b0 = one.r0, one.g1, one.b0
+	case wuBlue:
+		one.b1 = maxBCut
+		two.r0, two.g0, two.b0 = one.r0, one.g0, one.b1
+	}
+
+	one.vol = (one.r1 - one.r0) * (one.g1 - one.g0) * (one.b1 - one.b0)
+	two.vol = (two.r1 - two.r0) * (two.g1 - two.g0) * (two.b1 - two.b0)
+	return true
+}
+
+func (q *wuQuantizer) maximize(cube wuBox, direction wuDirection, first, last, wholeR, wholeG, wholeB, wholeW int) (int, float64) {
+	bottomR := wuBottom(cube, direction, q.momentsR)
+	bottomG := wuBottom(cube, direction, q.momentsG)
+	bottomB := wuBottom(cube, direction, q.momentsB)
+	bottomW := wuBottom(cube, direction, q.weights)
+
+	maximum := 0.0
+	cut := -1
+	for i := first; i < last; i++ {
+		halfR := bottomR + wuTop(cube, direction, i, q.momentsR)
+		halfG := bottomG + wuTop(cube, direction, i, q.momentsG)
+		halfB := bottomB + wuTop(cube, direction, i, q.momentsB)
+		halfW := bottomW + wuTop(cube, direction, i, q.weights)
+		if halfW == 0 {
+			continue
+		}
+		temp := float64(halfR*halfR+halfG*halfG+halfB*halfB) / float64(halfW)
+
+		halfR = wholeR - halfR
+		halfG = wholeG - halfG
+		halfB = wholeB - halfB
+		halfW = wholeW - halfW
+		if halfW == 0 {
+			continue
+		}
+		temp += float64(halfR*halfR+halfG*halfG+halfB*halfB) / float64(halfW)
+
+		if temp > maximum {
+			maximum = temp
+			cut = i
+		}
+	}
+	return cut, maximum
+}
+
+func wuVolume(cube wuBox, moment []int) int {
+	return moment[wuIndex(cube.r1, cube.g1, cube.b1)] -
+		moment[wuIndex(cube.r1, cube.g1, cube.b0)] -
+		moment[wuIndex(cube.r1, cube.g0, cube.b1)] +
+		moment[wuIndex(cube.r1, cube.g0, cube.b0)] -
+		moment[wuIndex(cube.r0, cube.g1, cube.b1)] +
+		moment[wuIndex(cube.r0, cube.g1, cube.b0)] +
+		moment[wuIndex(cube.r0, cube.g0, cube.b1)] -
+		moment[wuIndex(cube.r0, cube.g0, cube.b0)]
+}
+
+func wuBottom(cube wuBox, direction wuDirection, moment []int) int {
+	switch direction {
+	case wuRed:
+		return -moment[wuIndex(cube.r0, cube.g1, cube.b1)] +
+			moment[wuIndex(cube.r0, cube.g1, cube.b0)] +
+			moment[wuIndex(cube.r0, cube.g0, cube.b1)] -
+			moment[wuIndex(cube.r0, cube.g0, cube.b0)]
+	case wuGreen:
+		return -moment[wuIndex(cube.r1, cube.g0, cube.b1)] +
+			moment[wuIndex(cube.r1, cube.g0, cube.b0)] +
+			moment[wuIndex(cube.r0, cube.g0, cube.b1)] -
+			moment[wuIndex(cube.r0, cube.g0, cube.b0)]
+	default:
+		return -moment[wuIndex(cube.r1, cube.g1, cube.b0)] +
+			moment[wuIndex(cube.r1, cube.g0, cube.b0)] +
+			moment[wuIndex(cube.r0, cube.g1, cube.b0)] -
+			moment[wuIndex(cube.r0, cube.g0, cube.b0)]
+	}
+}
+
+func wuTop(cube wuBox, direction wuDirection, position int, moment []int) int {
+	switch direction {
+	case wuRed:
+		return moment[wuIndex(position, cube.g1, cube.b1)] -
+			moment[wuIndex(position, cube.g1, cube.b0)] -
+			moment[wuIndex(position, cube.g0, cube.b1)] +
+			moment[wuIndex(position, cube.g0, cube.b0)]
+	case wuGreen:
+		return moment[wuIndex(cube.r1, position, cube.b1)] -
+			moment[wuIndex(cube.r1, position, cube.b0)] -
+			moment[wuIndex(cube.r0, position, cube.b1)] +
+			moment[wuIndex(cube.r0, position, cube.b0)]
+	default:
+		return moment[wuIndex(cube.r1, cube.g1, position)] -
+			moment[wuIndex(cube.r1, cube.g0, position)] -
+			moment[wuIndex(cube.r0, cube.g1, position)] +
+			moment[wuIndex(cube.r0, cube.g0, position)]
+	}
+}
+
+const (
+	wsmeansMaxIterations       = 10
+	wsmeansMinMovementDistance = 3.0
+	// wsmeansSeed matches the reference implementation so results are
+	// stable between runs.
+	wsmeansSeed = 0x42688
+)
+
+type distanceAndIndex struct {
+	distance float64
+	index    int
+}
+
+func labDistance(a, b [3]float64) float64 {
+	dL, dA, dB := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dL*dL + dA*dA + dB*dB
+}
+
+func quantizeWsmeans(inputPixels []ARGB, startingClusters []ARGB, maxColors int) []ColorCount {
+	pixelToCount := make(map[ARGB]int)
+	var points [][3]float64
+	var pixels []ARGB
+	for _, p := range inputPixels {
+		if _, ok := pixelToCount[p]; !ok {
+			points = append(points, labFromARGB(p))
+			pixels = append(pixels, p)
+		}
+		pixelToCount[p]++
+	}
+	pointCount := len(points)
+	if pointCount == 0 {
+		return nil
+	}
+	counts := make([]int, pointCount)
+	for i, p := range pixels {
+		counts[i] = pixelToCount[p]
+	}
+
+	clusterCount := min(maxColors, pointCount)
+	if len(startingClusters) > 0 {
+		clusterCount = min(clusterCount, len(startingClusters))
+	}
+	clusters := make([][3]float64, 0, clusterCount)
+	for _, c := range startingClusters[:clusterCount] {
+		clusters = append(clusters, labFromARGB(c))
+	}
+	rng := rand.New(rand.NewSource(wsmeansSeed))
+	for len(clusters) < clusterCount {
+		clusters = append(clusters, points[rng.Intn(pointCount)])
+	}
+
+	clusterIndices := make([]int, pointCount)
+	for i := range clusterIndices {
+		clusterIndices[i] = rng.Intn(clusterCount)
+	}
+
+	distanceToIndex := make([][]distanceAndIndex, clusterCount)
+	for i := range distanceToIndex {
+		distanceToIndex[i] = make([]distanceAndIndex, clusterCount)
+		for j := range distanceToIndex[i] {
+			distanceToIndex[i][j] = distanceAndIndex{distance: -1, index: -1}
+		}
+	}
+	pixelCountSums := make([]int, clusterCount)
+
+	for iteration := range wsmeansMaxIterations {
+		for i := range clusterCount {
+			for j := i + 1; j < clusterCount; j++ {
+				d := labDistance(clusters[i], clusters[j])
+				distanceToIndex[j][i] = distanceAndIndex{distance: d, index: i}
+				distanceToIndex[i][j] = distanceAndIndex{distance: d, index: j}
+			}
+			sort.SliceStable(distanceToIndex[i], func(a, b int) bool {
+				return distanceToIndex[i][a].distance < distanceToIndex[i][b].distance
+			})
+		}
+
+		pointsMoved := 0
+		for i, point := range points {
+			previousClusterIndex := clusterIndices[i]
+			previousDistance := labDistance(point, clusters[previousClusterIndex])
+			minimumDistance := previousDistance
+			newClusterIndex := -1
+			for j := range clusterCount {
+				if distanceToIndex[previousClusterIndex][j].distance >= 4*previousDistance {
+					continue
+				}
+				d := labDistance(point, clusters[j])
+				if d < minimumDistance {
+					minimumDistance = d
+					newClusterIndex = j
+				}
+			}
+			if newClusterIndex != -1 {
+				if math.Abs(math.Sqrt(minimumDistance)-math.Sqrt(previousDistance)) > wsmeansMinMovementDistance {
+					pointsMoved++
+					clusterIndices[i] = newClusterIndex
+				}
+			}
+		}
+		if pointsMoved == 0 && iteration != 0 {
+			break
+		}
+
+		sums := make([][3]float64, clusterCount)
+		for i := range pixelCountSums {
+			pixelCountSums[i] = 0
+		}
+		for i, point := range points {
+			ci := clusterIndices[i]
+			count := counts[i]
+			pixelCountSums[ci] += count
+			sums[ci][0] += point[0] * float64(count)
+			sums[ci][1] += point[1] * float64(count)
+			sums[ci][2] += point[2] * float64(count)
+		}
+		for i := range clusters {
+			count := float64(pixelCountSums[i])
+			if count == 0 {
+				clusters[i] = [3]float64{}
+				continue
+			}
+			clusters[i] = [3]float64{sums[i][0] / count, sums[i][1] / count, sums[i][2] / count}
+		}
+	}
+
+	seen := make(map[ARGB]bool)
+	var result []ColorCount
+	for i, cluster := range clusters {
+		count := pixelCountSums[i]
+		if count == 0 {
+			continue
+		}
+		c := argbFromLab(cluster[0], cluster[1], cluster[2])
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		result = append(result, ColorCount{Color: c, Count: count})
+	}
+	return result
+}
@@ -0,0 +1,190 @@
+package material
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Variant int
+
+const (
+	VariantTonalSpot Variant = iota
+	VariantVibrant
+	VariantExpressive
+	VariantFidelity
+	VariantContent
+	VariantMonochrome
+	VariantNeutral
+	VariantRainbow
+	VariantFruitSalad
+)
+
+var variantNames = map[Variant]string{
+	VariantTonalSpot:  "tonal-spot",
+	VariantVibrant:    "vibrant",
+	VariantExpressive: "expressive",
+	VariantFidelity:   "fidelity",
+	VariantContent:    "content",
+	VariantMonochrome: "monochrome",
+	VariantNeutral:    "neutral",
+	VariantRainbow:    "rainbow",
+	VariantFruitSalad: "fruit-salad",
+}
+
+func (v Variant) String() string {
+	return variantNames[v]
+}
+
+// ParseVariant accepts matugen's scheme type names, with or without the
+// "scheme-" prefix.
+func ParseVariant(s string) (Variant, error) {
+	name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "scheme-")
+	name = strings.ReplaceAll(name, "_", "-")
+	for v, n := range variantNames {
+		if n == name {
+			return v, nil
+		}
+	}
+	return VariantTonalSpot, fmt.Errorf("unknown scheme type: %s", s)
+}
+
+// Scheme is a complete dynamic color scheme for one source color, variant
+// and brightness.
+type Scheme struct {
+	Source        HCT
+	Variant       Variant
+	IsDark        bool
+	ContrastLevel float64
+
+	Primary        *TonalPalette
+	Secondary      *TonalPalette
+	Tertiary       *TonalPalette
+	Neutral        *TonalPalette
+	NeutralVariant *TonalPalette
+	Error          *TonalPalette
+
+	tones map[string]float64
+}
+
+var (
+	vibrantHues               = []float64{0, 41, 61, 101, 131, 181, 251, 301, 360}
+	vibrantSecondaryRotations = []float64{18, 15, 10, 12, 15, 18, 15, 12, 12}
+	vibrantTertiaryRotations  = []float64{35, 30, 20, 25, 30, 35, 30, 25, 25}
+
+	expressiveHues               = []float64{0, 21, 51, 121, 151, 191, 271, 321, 360}
+	expressiveSecondaryRotations = []float64{45, 95, 45, 20, 45, 90, 45, 45, 45}
+	expressiveTertiaryRotations  = []float64{120, 120, 20, 45, 20, 15, 20, 120, 120}
+)
+
+// NewScheme builds the palettes for a variant. contrastLevel ranges from
+// -1 (reduced) through 0 (standard) to 1 (high).
+func NewScheme(source ARGB, variant Variant, isDark bool, contrastLevel float64) *Scheme {
+	src := HCTFromARGB(source)
+	hue, chroma := src.Hue, src.Chroma
+	fromHC := TonalPaletteFromHueAndChroma
+
+	s := &Scheme{
+		Source:        src,
+		Variant:       variant,
+		IsDark:        isDark,
+		ContrastLevel: contrastLevel,
+		Error:         fromHC(25, 84),
+		tones:         make(map[string]float64),
+	}
+
+	switch variant {
+	case VariantVibrant:
+		s.Primary = fromHC(hue, 200)
+		s.Secondary = fromHC(rotatedHue(hue, vibrantHues, vibrantSecondaryRotations), 24)
+		s.Tertiary = fromHC(rotatedHue(hue, vibrantHues, vibrantTertiaryRotations), 32)
+		s.Neutral = fromHC(hue, 10)
+		s.NeutralVariant = fromHC(hue, 12)
+	case VariantExpressive:
+		s.Primary = fromHC(sanitizeDegreesDouble(hue+240), 40)
+		s.Secondary = fromHC(rotatedHue(hue, expressiveHues, expressiveSecondaryRotations), 24)
+		s.Tertiary = fromHC(rotatedHue(hue, expressiveHues, expressiveTertiaryRotations), 32)
+		s.Neutral = fromHC(sanitizeDegreesDouble(hue+15), 8)
+		s.NeutralVariant = fromHC(sanitizeDegreesDouble(hue+15), 12)
+	case VariantFidelity, VariantContent:
+		s.Primary = fromHC(hue, chroma)
+		s.Secondary = fromHC(hue, math.Max(chroma-32, chroma*0.5))
+		tc := newTemperatureCache(src)
+		if variant == VariantFidelity {
+			s.Tertiary = TonalPaletteFromHCT(fixIfDisliked(tc.complement()))
+		} else {
+			s.Tertiary = TonalPaletteFromHCT(fixIfDisliked(tc.analogous(3, 6)[2]))
+		}
+		s.Neutral = fromHC(hue, chroma/8)
+		s.NeutralVariant = fromHC(hue, chroma/8+4)
+	case VariantMonochrome:
+		s.Primary = fromHC(hue, 0)
+		s.Secondary = fromHC(hue, 0)
+		s.Tertiary = fromHC(hue, 0)
+		s.Neutral = fromHC(hue, 0)
+		s.NeutralVariant = fromHC(hue, 0)
+	case VariantNeutral:
+		s.Primary = fromHC(hue, 12)
+		s.Secondary = fromHC(hue, 8)
+		s.Tertiary = fromHC(hue, 16)
+		s.Neutral = fromHC(hue, 2)
+		s.NeutralVariant = fromHC(hue, 2)
+	case VariantRainbow:
+		s.Primary = fromHC(hue, 48)
+		s.Secondary = fromHC(hue, 16)
+		s.Tertiary = fromHC(sanitizeDegreesDouble(hue+60), 24)
+		s.Neutral = fromHC(hue, 0)
+		s.NeutralVariant = fromHC(hue, 0)
+	case VariantFruitSalad:
+		s.Primary = fromHC(sanitizeDegreesDouble(hue-50), 48)
+		s.Secondary = fromHC(sanitizeDegreesDouble(hue-50), 36)
+		s.Tertiary = fromHC(hue, 36)
+		s.Neutral = fromHC(hue, 10)
+		s.NeutralVariant = fromHC(hue, 16)
+	default:
+		s.Primary = fromHC(hue, 36)
+		s.Secondary = fromHC(hue, 16)
+		s.Tertiary = fromHC(sanitizeDegreesDouble(hue+60), 24)
+		s.Neutral = fromHC(hue, 6)
+		s.NeutralVariant = fromHC(hue, 8)
+	}
+	return s
+}
+
+// rotatedHue rotates the source hue by the rotation of the hue range it
+// falls in.
+func rotatedHue(sourceHue float64, hues, rotations []float64) float64 {
+	if len(rotations) == 1 {
+		return sanitizeDegreesDouble(sourceHue + rotations[0])
+	}
+	for i := 0; i < len(hues)-1; i++ {
+		if hues[i] < sourceHue && sourceHue < hues[i+1] {
+			return sanitizeDegreesDouble(sourceHue + rotations[i])
+		}
+	}
+	return sourceHue
+}
+
+// Colors returns every role in the scheme keyed by its matugen name, plus
+// source_color.
+func (s *Scheme) Colors() map[string]ARGB {
+	out := make(map[string]ARGB, len(allColors)+1)
+	for _, dc := range allColors {
+		out[dc.name] = dc.argb(s)
+	}
+	out["source_color"] = s.Source.ARGB()
+	return out
+}
+
+// Color returns a single role by its matugen name.
+func (s *Scheme) Color(name string) (ARGB, bool) {
+	if name == "source_color" {
+		return s.Source.ARGB(), true
+	}
+	for _, dc := range allColors {
+		if dc.name == name {
+			return dc.argb(s), true
+		}
+	}
+	return 0, false
+}
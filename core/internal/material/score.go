@@ -0,0 +1,100 @@
+package material
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	scoreTargetChroma            = 48.0
+	scoreWeightProportion        = 0.7
+	scoreWeightChromaAbove       = 0.3
+	scoreWeightChromaBelow       = 0.1
+	scoreCutoffChroma            = 5.0
+	scoreCutoffExcitedProportion = 0.01
+
+	// FallbackColor is Google Blue, used when an image has no color worth
+	// theming with.
+	FallbackColor ARGB = 0xff4285f4
+)
+
+// Score ranks quantized colors by how well they would work as a theme
+// source: colors covering a larger share of the image, and closer to a
+// useful chroma, score higher. At most desired colors are returned, each
+// at least 15 degrees of hue apart.
+func Score(colors []ColorCount, desired int) []ARGB {
+	type scored struct {
+		hct   HCT
+		score float64
+	}
+
+	hcts := make([]HCT, len(colors))
+	var huePopulation [360]int
+	populationSum := 0
+	for i, c := range colors {
+		hct := HCTFromARGB(c.Color)
+		hcts[i] = hct
+		huePopulation[int(math.Floor(hct.Hue))%360] += c.Count
+		populationSum += c.Count
+	}
+
+	var hueExcitedProportions [360]float64
+	if populationSum > 0 {
+		for i := range 360 {
+			proportion := float64(huePopulation[i]) / float64(populationSum)
+			for j := i - 14; j < i+16; j++ {
+				hueExcitedProportions[sanitizeDegreesInt(j)] += proportion
+			}
+		}
+	}
+
+	var scoredHCTs []scored
+	for _, hct := range hcts {
+		proportion := hueExcitedProportions[sanitizeDegreesInt(int(math.Round(hct.Hue)))]
+		if hct.Chroma < scoreCutoffChroma || proportion <= scoreCutoffExcitedProportion {
+			continue
+		}
+		proportionScore := proportion * 100 * scoreWeightProportion
+		chromaWeight := scoreWeightChromaAbove
+		if hct.Chroma < scoreTargetChroma {
+			chromaWeight = scoreWeightChromaBelow
+		}
+		chromaScore := (hct.Chroma - scoreTargetChroma) * chromaWeight
+		scoredHCTs = append(scoredHCTs, scored{hct: hct, score: proportionScore + chromaScore})
+	}
+	sort.SliceStable(scoredHCTs, func(i, j int) bool {
+		return scoredHCTs[i].score > scoredHCTs[j].score
+	})
+
+	var chosen []HCT
+	for minDifference := 90; minDifference >= 15; minDifference-- {
+		chosen = chosen[:0]
+		for _, s := range scoredHCTs {
+			duplicate := false
+			for _, c := range chosen {
+				if differenceDegrees(s.hct.Hue, c.Hue) < float64(minDifference) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				chosen = append(chosen, s.hct)
+			}
+			if len(chosen) >= desired {
+				break
+			}
+		}
+		if len(chosen) >= desired {
+			break
+		}
+	}
+
+	if len(chosen) == 0 {
+		return []ARGB{FallbackColor}
+	}
+	result := make([]ARGB, len(chosen))
+	for i, c := range chosen {
+		result[i] = c.ARGB()
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package material
+
+import (
+	"math"
+	"sort"
+)
+
+// temperatureCache finds warm/cool relationships between colors of the
+// same chroma and tone as the input, used for the fidelity and content
+// schemes' tertiary color.
+type temperatureCache struct {
+	input     HCT
+	hctsByHue []HCT
+	hctsByTmp []HCT
+	temps     map[ARGB]float64
+}
+
+func newTemperatureCache(input HCT) *temperatureCache {
+	tc := &temperatureCache{input: input, temps: make(map[ARGB]float64)}
+
+	tc.hctsByHue = make([]HCT, 0, 361)
+	for hue := 0; hue <= 360; hue++ {
+		tc.hctsByHue = append(tc.hctsByHue, NewHCT(float64(hue), input.Chroma, input.Tone))
+	}
+
+	all := append(append([]HCT(nil), tc.hctsByHue...), input)
+	for _, h := range all {
+		tc.temps[h.ARGB()] = rawTemperature(h)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return tc.temps[all[i].ARGB()] < tc.temps[all[j].ARGB()]
+	})
+	tc.hctsByTmp = all
+	return tc
+}
+
+func (tc *temperatureCache) coldest() HCT { return tc.hctsByTmp[0] }
+func (tc *temperatureCache) warmest() HCT { return tc.hctsByTmp[len(tc.hctsByTmp)-1] }
+
+func (tc *temperatureCache) relativeTemperature(h HCT) float64 {
+	coldestTemp := tc.temps[tc.coldest().ARGB()]
+	tempRange := tc.temps[tc.warmest().ARGB()] - coldestTemp
+	if tempRange == 0 {
+		return 0.5
+	}
+	return (tc.temps[h.ARGB()] - coldestTemp) / tempRange
+}
+
+// complement is the color at the opposite end of the temperature range,
+// walking the hue wheel in the direction that passes the input.
+func (tc *temperatureCache) complement() HCT {
+	coldestHue := tc.coldest().Hue
+	coldestTemp := tc.temps[tc.coldest().ARGB()]
+	warmestHue := tc.warmest().Hue
+	tempRange := tc.temps[tc.warmest().ARGB()] - coldestTemp
+
+	startIsColdest := isBetween(tc.input.Hue, coldestHue, warmestHue)
+	startHue, endHue := coldestHue, warmestHue
+	if startIsColdest {
+		startHue, endHue = warmestHue, coldestHue
+	}
+
+	smallestError := 1000.0
+	answer := tc.hctsByHue[int(math.Round(tc.input.Hue))]
+	complementRelativeTemp := 1 - tc.relativeTemperature(tc.input)
+
+	for hueAddend := 0.0; hueAddend <= 360; hueAddend++ {
+		hue := sanitizeDegreesDouble(startHue + hueAddend)
+		if !isBetween(hue, startHue, endHue) {
+			continue
+		}
+		possible := tc.hctsByHue[int(math.Round(hue))]
+		relativeTemp := 0.5
+		if tempRange != 0 {
+			relativeTemp = (tc.temps[possible.ARGB()] - coldestTemp) / tempRange
+		}
+		if e := math.Abs(complementRelativeTemp - relativeTemp); e < smallestError {
+			smallestError = e
+			answer = possible
+		}
+	}
+	return answer
+}
+
+// analogous returns count colors spread evenly in temperature around the
+// input, out of divisions steps around the hue wheel.
+func (tc *temperatureCache) analogous(count, divisions int) []HCT {
+	startHue := int(math.Round(tc.input.Hue))
+	startHCT := tc.hctsByHue[startHue]
+	lastTemp := tc.relativeTemperature(startHCT)
+
+	absoluteTotalTempDelta := 0.0
+	for i := range 360 {
+		h := tc.hctsByHue[sanitizeDegreesInt(startHue+i)]
+		temp := tc.relativeTemperature(h)
+		absoluteTotalTempDelta += math.Abs(temp - lastTemp)
+		lastTemp = temp
+	}
+
+	allColors := []HCT{startHCT}
+	tempStep := absoluteTotalTempDelta / float64(divisions)
+	totalTempDelta := 0.0
+	lastTemp = tc.relativeTemperature(startHCT)
+	for hueAddend := 1; len(allColors) < divisions; hueAddend++ {
+		h := tc.hctsByHue[sanitizeDegreesInt(startHue+hueAddend)]
+		temp := tc.relativeTemperature(h)
+		totalTempDelta += math.Abs(temp - lastTemp)
+
+		desired := float64(len(allColors)) * tempStep
+		indexSatisfied := totalTempDelta >= desired
+		indexAddend := 1
+		for indexSatisfied && len(allColors) < divisions {
+			allColors = append(allColors, h)
+			desired = float64(len(allColors)+indexAddend) * tempStep
+			indexSatisfied = totalTempDelta >= desired
+			indexAddend++
+		}
+		lastTemp = temp
+
+		if hueAddend+1 > 360 {
+			for len(allColors) < divisions {
+				allColors = append(allColors, h)
+			}
+			break
+		}
+	}
+
+	wrap := func(index int) HCT {
+		n := len(allColors)
+		return allColors[((index%n)+n)%n]
+	}
+
+	answers := []HCT{tc.input}
+	increaseHueCount := (count - 1) / 2
+	for i := 1; i <= increaseHueCount; i++ {
+		answers = append([]HCT{wrap(-i)}, answers...)
+	}
+	decreaseHueCount := count - increaseHueCount - 1
+	for i := 1; i <= decreaseHueCount; i++ {
+		answers = append(answers, wrap(i))
+	}
+	return answers
+}
+
+func isBetween(angle, a, b float64) bool {
+	if a < b {
+		return a <= angle && angle <= b
+	}
+	return a <= angle || angle <= b
+}
+
+// rawTemperature is Ou, Woodcock and Wright's warmth estimate in L*a*b*.
+func rawTemperature(h HCT) float64 {
+	lab := labFromARGB(h.ARGB())
+	hue := sanitizeDegreesDouble(math.Atan2(lab[2], lab[1]) * 180 / math.Pi)
+	chroma := math.Hypot(lab[1], lab[2])
+	return -0.5 + 0.02*math.Pow(chroma, 1.07)*math.Cos(sanitizeDegreesDouble(hue-50)*math.Pi/180)
+}
+
+// isDisliked reports dark yellow-greens, which are widely disliked.
+func isDisliked(h HCT) bool {
+	hue := math.Round(h.Hue)
+	return hue >= 90 && hue <= 111 && math.Round(h.Chroma) > 16 && math.Round(h.Tone) < 65
+}
+
+func fixIfDisliked(h HCT) HCT {
+	if isDisliked(h) {
+		return NewHCT(h.Hue, h.Chroma, 70)
+	}
+	return h
+}
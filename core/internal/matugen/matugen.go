@@ -3,13 +3,12 @@ package matugen
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -36,12 +35,20 @@ const (
 )
 
 type TemplateDef struct {
-	ID                 string
-	Commands           []string
-	Flatpaks           []string
-	ConfigFile         string
-	Kind               TemplateKind
-	RunUnconditionally bool
+	ID                 string       `json:"id"`
+	Commands           []string     `json:"commands,omitempty"`
+	Flatpaks           []string     `json:"flatpaks,omitempty"`
+	ConfigFile         string       `json:"configFile,omitempty"`
+	Template           string       `json:"template,omitempty"`
+	Format             string       `json:"format,omitempty"`
+	Output             string       `json:"output,omitempty"`
+	Kind               TemplateKind `json:"kind,omitempty"`
+	ReloadCommand      string       `json:"reloadCommand,omitempty"`
+	ReloadSignal       string       `json:"reloadSignal,omitempty"`
+	RunUnconditionally bool         `json:"runUnconditionally,omitempty"`
+
+	dir    string
+	custom bool
 }
 
 var templateRegistry = []TemplateDef{
@@ -66,6 +73,15 @@ var templateRegistry = []TemplateDef{
 	{ID: "kcolorscheme", ConfigFile: "kcolorscheme.toml", RunUnconditionally: true},
 	{ID: "vscode", Kind: TemplateKindVSCode},
 	{ID: "emacs", Commands: []string{"emacs"}, ConfigFile: "emacs.toml"},
+	{ID: "tmux", Commands: []string{"tmux"}, Format: "tmux", Output: "CONFIG_DIR/tmux/dank-theme.conf",
+		ReloadCommand: `f="${XDG_CONFIG_HOME:-$HOME/.config}/tmux/dank-theme.conf"; grep -qs dank-theme.conf ~/.tmux.conf "${XDG_CONFIG_HOME:-$HOME/.config}/tmux/tmux.conf" && tmux source-file "$f" 2>/dev/null; true`},
+	{ID: "helix", Commands: []string{"hx", "helix"}, Format: "helix", Output: "CONFIG_DIR/helix/themes/dankshell.toml"},
+	{ID: "btop", Commands: []string{"btop"}, Format: "btop", Output: "CONFIG_DIR/btop/themes/dankshell.theme"},
+	{ID: "bat", Commands: []string{"bat"}, Format: "tmtheme", Output: "CONFIG_DIR/bat/themes/DankShell.tmTheme",
+		ReloadCommand: "bat cache --build >/dev/null 2>&1; true"},
+	{ID: "xresources", Commands: []string{"xrdb"}, Format: "xresources", Output: "CONFIG_DIR/X11/dank.Xresources",
+		ReloadCommand: `f="${XDG_CONFIG_HOME:-$HOME/.config}/X11/dank.Xresources"; [ -n "$DISPLAY" ] && grep -qs dank.Xresources ~/.Xresources && xrdb -merge "$f" 2>/dev/null; true`},
+	{ID: "konsole", Commands: []string{"konsole"}, Format: "konsole", Output: "DATA_DIR/konsole/DankShell.colorscheme"},
 }
 
 func (c *ColorMode) GTKTheme() string {
@@ -77,12 +93,6 @@ func (c *ColorMode) GTKTheme() string {
 	}
 }
 
-var (
-	matugenVersionOnce sync.Once
-	matugenSupportsCOE bool
-	matugenIsV4        bool
-)
-
 type Options struct {
 	StateDir            string
 	ShellDir            string
@@ -98,13 +108,20 @@ type Options struct {
 	TerminalsAlwaysDark bool
 	SkipTemplates       string
 	AppChecker          utils.AppChecker
+	// Outputs maps output names to their own wallpaper (or hex color);
+	// each gets a palette under "outputs" in dms-colors.json.
+	Outputs map[string]string
+
+	targets []TemplateDef
 }
 
 type ColorsOutput struct {
+	Dank16 map[string]map[string]string `json:"dank16,omitempty"`
 	Colors struct {
 		Dark  map[string]string `json:"dark"`
 		Light map[string]string `json:"light"`
 	} `json:"colors"`
+	Outputs map[string]OutputPalette `json:"outputs,omitempty"`
 }
 
 func (o *Options) ColorsOutput() string {
@@ -123,7 +140,7 @@ func (o *Options) ShouldSkipTemplate(name string) bool {
 	return false
 }
 
-func Run(opts Options) error {
+func (opts *Options) normalize() error {
 	if opts.StateDir == "" {
 		return fmt.Errorf("state-dir is required")
 	}
@@ -151,6 +168,14 @@ func Run(opts Options) error {
 	if opts.AppChecker == nil {
 		opts.AppChecker = utils.DefaultAppChecker{}
 	}
+	opts.targets = templateTargets(opts.ConfigDir)
+	return nil
+}
+
+func Run(opts Options) error {
+	if err := opts.normalize(); err != nil {
+		return err
+	}
 
 	if err := os.MkdirAll(opts.StateDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create state dir: %w", err)
@@ -170,182 +195,213 @@ func Run(opts Options) error {
 	return nil
 }
 
-func buildOnce(opts *Options) error {
-	cfgFile, err := os.CreateTemp("", "matugen-config-*.toml")
-	if err != nil {
-		return fmt.Errorf("failed to create temp config: %w", err)
-	}
-	defer os.Remove(cfgFile.Name())
-	defer cfgFile.Close()
+// themeBuild is a theme ready to render: the template jobs from the
+// merged config and the colors they render with.
+type themeBuild struct {
+	jobs         []templateJob
+	ctx          *templateContext
+	primaryDark  string
+	primaryLight string
+	tmpDir       string
+}
+
+func (b *themeBuild) Close() {
+	os.RemoveAll(b.tmpDir)
+}
 
+func prepareBuild(opts *Options) (*themeBuild, error) {
 	tmpDir, err := os.MkdirTemp("", "matugen-templates-*")
 	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
-	defer os.RemoveAll(tmpDir)
+	b := &themeBuild{tmpDir: tmpDir}
 
-	if err := buildMergedConfig(opts, cfgFile, tmpDir); err != nil {
-		return fmt.Errorf("failed to build config: %w", err)
+	if err := b.prepare(opts); err != nil {
+		b.Close()
+		return nil, err
 	}
-	cfgFile.Close()
+	return b, nil
+}
 
-	var primaryDark, primaryLight, surface string
-	var dank16JSON string
-	var importArgs []string
+func (b *themeBuild) prepare(opts *Options) error {
+	configs := &templateConfigs{}
+	if err := buildMergedConfig(opts, configs, b.tmpDir); err != nil {
+		return fmt.Errorf("failed to build config: %w", err)
+	}
 
+	var surface string
+	var scheme *Scheme
+	var err error
 	if opts.StockColors != "" {
-		log.Info("Using stock/custom theme colors with matugen base")
-		primaryDark = extractNestedColor(opts.StockColors, "primary", "dark")
-		primaryLight = extractNestedColor(opts.StockColors, "primary", "light")
+		log.Info("Using stock/custom theme colors")
+		b.primaryDark = extractNestedColor(opts.StockColors, "primary", "dark")
+		b.primaryLight = extractNestedColor(opts.StockColors, "primary", "light")
 		surface = extractNestedColor(opts.StockColors, "surface", "dark")
 
-		if primaryDark == "" {
+		if b.primaryDark == "" {
 			return fmt.Errorf("failed to extract primary dark from stock colors")
 		}
-		if primaryLight == "" {
-			primaryLight = primaryDark
+		if b.primaryLight == "" {
+			b.primaryLight = b.primaryDark
 		}
 
-		dank16JSON = generateDank16Variants(primaryDark, primaryLight, surface, opts.Mode)
-		importData := fmt.Sprintf(`{"colors": %s, "dank16": %s}`, opts.StockColors, dank16JSON)
-		importArgs = []string{"--import-json-string", importData}
-
-		log.Info("Running matugen color hex with stock color overrides")
-		args := []string{"color", "hex", primaryDark, "-m", string(opts.Mode), "-t", opts.MatugenType, "-c", cfgFile.Name()}
-		args = append(args, importArgs...)
-		if err := runMatugen(args); err != nil {
+		// Roles the stock theme doesn't define come from a scheme built
+		// around its primary.
+		if scheme, err = GenerateScheme("hex", b.primaryDark, opts.MatugenType); err != nil {
+			return fmt.Errorf("color generation failed: %w", err)
+		}
+		stock, err := schemeFromStockColors(opts.StockColors)
+		if err != nil {
 			return err
 		}
+		maps.Copy(scheme.Dark, stock.Dark)
+		maps.Copy(scheme.Light, stock.Light)
 	} else {
 		log.Infof("Using dynamic theme from %s: %s", opts.Kind, opts.Value)
 
-		matJSON, err := runMatugenDryRun(opts)
+		scheme, err = GenerateScheme(opts.Kind, opts.Value, opts.MatugenType)
 		if err != nil {
-			return fmt.Errorf("matugen dry-run failed: %w", err)
+			return fmt.Errorf("color generation failed: %w", err)
 		}
 
-		primaryDark = extractMatugenColor(matJSON, "primary", "dark")
-		primaryLight = extractMatugenColor(matJSON, "primary", "light")
-		surface = extractMatugenColor(matJSON, "surface", "dark")
+		b.primaryDark = scheme.Dark["primary"]
+		b.primaryLight = scheme.Light["primary"]
+		surface = scheme.Dark["surface"]
+	}
 
-		if primaryDark == "" {
-			return fmt.Errorf("failed to extract primary color")
-		}
-		if primaryLight == "" {
-			primaryLight = primaryDark
-		}
+	custom, errs := parseCustomColors(configs)
+	for _, err := range errs {
+		log.Warnf("Skipping matugen custom color: %v", err)
+	}
+	if err := scheme.addCustomColors(custom); err != nil {
+		return err
+	}
 
-		dank16JSON = generateDank16Variants(primaryDark, primaryLight, surface, opts.Mode)
-		importData := fmt.Sprintf(`{"dank16": %s}`, dank16JSON)
-		importArgs = []string{"--import-json-string", importData}
+	outputs := generateOutputPalettes(opts.Outputs, opts.MatugenType)
 
-		log.Infof("Running matugen %s with dank16 injection", opts.Kind)
-		var args []string
-		switch opts.Kind {
-		case "hex":
-			args = []string{"color", "hex", opts.Value}
-		default:
-			args = []string{opts.Kind, opts.Value}
+	dank16JSON := generateDank16Variants(b.primaryDark, b.primaryLight, surface, opts.Mode)
+
+	var image string
+	if opts.Kind == "image" {
+		image = opts.Value
+	}
+	if b.ctx, err = newTemplateContext(scheme, dank16JSON, opts.Mode, image); err != nil {
+		return err
+	}
+
+	b.jobs, errs = parseTemplateJobs(configs)
+	for _, err := range errs {
+		log.Warnf("Skipping matugen template config: %v", err)
+	}
+	for i := range b.jobs {
+		if b.jobs[i].Name == "dank" {
+			// Written with the shell colors so the shell reloads once.
+			b.jobs[i].finish = func(data []byte) ([]byte, error) {
+				return addOutputPalettes(data, outputs)
+			}
 		}
-		args = append(args, "-m", string(opts.Mode), "-t", opts.MatugenType, "-c", cfgFile.Name())
-		args = append(args, importArgs...)
-		if err := runMatugen(args); err != nil {
-			return err
+	}
+
+	for _, def := range opts.targets {
+		if def.Format == "" || opts.ShouldSkipTemplate(def.ID) || !appExists(opts.AppChecker, def.Commands, def.Flatpaks) {
+			continue
 		}
+		b.jobs = append(b.jobs, templateJob{Name: def.ID, OutputPath: def.resolve(def.Output), Format: def.Format, target: def.ID})
+	}
+	return nil
+}
+
+func buildOnce(opts *Options) error {
+	b, err := prepareBuild(opts)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	if err := takeSnapshot(opts.StateDir, b.jobs, opts.Mode); err != nil {
+		log.Warnf("Failed to snapshot current theme: %v", err)
 	}
 
+	log.Infof("Rendering %d templates", len(b.jobs))
+	failed := renderTemplates(b.jobs, b.ctx)
+	if err, ok := failed["dank"]; ok {
+		return fmt.Errorf("failed to write shell colors: %w", err)
+	}
+	reloadTargets(opts.targets, b.jobs, failed)
+
 	if isDMSGTKActive(opts.ConfigDir) {
 		switch opts.Mode {
 		case ColorModeLight:
-			syncAccentColor(primaryLight)
+			syncAccentColor(b.primaryLight)
 		default:
-			syncAccentColor(primaryDark)
+			syncAccentColor(b.primaryDark)
 		}
 		refreshGTK(opts.Mode)
 		refreshGTK4()
 	}
 
+	refreshApps(opts)
+
+	return nil
+}
+
+// refreshApps tells running apps that don't watch their theme files to
+// reload them.
+func refreshApps(opts *Options) {
 	if !opts.ShouldSkipTemplate("qt6ct") && appExists(opts.AppChecker, []string{"qt6ct"}, nil) {
 		refreshQt6ct()
 	}
 
 	signalTerminals(opts)
-
-	return nil
 }
 
-func buildMergedConfig(opts *Options, cfgFile *os.File, tmpDir string) error {
-	userConfigPath := filepath.Join(opts.ConfigDir, "matugen", "config.toml")
-
-	wroteConfig := false
-	if opts.RunUserTemplates {
-		if data, err := os.ReadFile(userConfigPath); err == nil {
-			configSection := extractTOMLSection(string(data), "[config]", "[templates]")
-			if configSection != "" {
-				cfgFile.WriteString(configSection)
-				cfgFile.WriteString("\n")
-				wroteConfig = true
-			}
-		}
-	}
-	if !wroteConfig {
-		cfgFile.WriteString("[config]\n\n")
-	}
-
+func buildMergedConfig(opts *Options, configs *templateConfigs, tmpDir string) error {
 	baseConfigPath := filepath.Join(opts.ShellDir, "matugen", "configs", "base.toml")
 	if data, err := os.ReadFile(baseConfigPath); err == nil {
-		content := string(data)
-		lines := strings.Split(content, "\n")
-		for _, line := range lines {
-			if strings.TrimSpace(line) == "[config]" {
-				continue
-			}
-			cfgFile.WriteString(substituteVars(line, opts.ShellDir) + "\n")
-		}
-		cfgFile.WriteString("\n")
+		configs.add(baseConfigPath, substituteVars(string(data), opts.ShellDir))
 	}
 
-	fmt.Fprintf(cfgFile, `[templates.dank]
+	configs.add("dank", fmt.Sprintf(`[templates.dank]
 input_path = '%s/matugen/templates/dank.json'
 output_path = '%s'
-
-`, opts.ShellDir, opts.ColorsOutput())
+`, opts.ShellDir, opts.ColorsOutput()))
 
 	homeDir, _ := os.UserHomeDir()
-	for _, tmpl := range templateRegistry {
+	for _, tmpl := range opts.targets {
 		if opts.ShouldSkipTemplate(tmpl.ID) {
 			continue
 		}
+		if tmpl.custom || tmpl.Format != "" {
+			appendTargetConfig(opts, configs, tmpDir, tmpl)
+			continue
+		}
 
 		switch tmpl.Kind {
 		case TemplateKindGTK:
 			switch opts.Mode {
 			case ColorModeLight:
-				appendConfig(opts, cfgFile, nil, nil, "gtk3-light.toml")
+				appendConfig(opts, configs, nil, nil, "gtk3-light.toml")
 			default:
-				appendConfig(opts, cfgFile, nil, nil, "gtk3-dark.toml")
+				appendConfig(opts, configs, nil, nil, "gtk3-dark.toml")
 			}
 		case TemplateKindTerminal:
-			appendTerminalConfig(opts, cfgFile, tmpDir, tmpl.Commands, tmpl.Flatpaks, tmpl.ConfigFile)
+			appendTerminalConfig(opts, configs, tmpDir, tmpl.Commands, tmpl.Flatpaks, tmpl.ConfigFile)
 		case TemplateKindVSCode:
-			appendVSCodeConfig(cfgFile, "vscode", filepath.Join(homeDir, ".vscode/extensions"), opts.ShellDir)
-			appendVSCodeConfig(cfgFile, "codium", filepath.Join(homeDir, ".vscode-oss/extensions"), opts.ShellDir)
-			appendVSCodeConfig(cfgFile, "codeoss", filepath.Join(homeDir, ".config/Code - OSS/extensions"), opts.ShellDir)
-			appendVSCodeConfig(cfgFile, "cursor", filepath.Join(homeDir, ".cursor/extensions"), opts.ShellDir)
-			appendVSCodeConfig(cfgFile, "windsurf", filepath.Join(homeDir, ".windsurf/extensions"), opts.ShellDir)
-			appendVSCodeConfig(cfgFile, "vscode-insiders", filepath.Join(homeDir, ".vscode-insiders/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "vscode", filepath.Join(homeDir, ".vscode/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "codium", filepath.Join(homeDir, ".vscode-oss/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "codeoss", filepath.Join(homeDir, ".config/Code - OSS/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "cursor", filepath.Join(homeDir, ".cursor/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "windsurf", filepath.Join(homeDir, ".windsurf/extensions"), opts.ShellDir)
+			appendVSCodeConfig(configs, "vscode-insiders", filepath.Join(homeDir, ".vscode-insiders/extensions"), opts.ShellDir)
 		default:
-			appendConfig(opts, cfgFile, tmpl.Commands, tmpl.Flatpaks, tmpl.ConfigFile)
+			appendConfig(opts, configs, tmpl.Commands, tmpl.Flatpaks, tmpl.ConfigFile)
 		}
 	}
 
 	if opts.RunUserTemplates {
+		userConfigPath := filepath.Join(opts.ConfigDir, "matugen", "config.toml")
 		if data, err := os.ReadFile(userConfigPath); err == nil {
-			templatesSection := extractTOMLSection(string(data), "[templates]", "")
-			if templatesSection != "" {
-				cfgFile.WriteString(templatesSection)
-				cfgFile.WriteString("\n")
-			}
+			configs.add(userConfigPath, string(data))
 		}
 	}
 
@@ -355,9 +411,9 @@ output_path = '%s'
 			if !strings.HasSuffix(entry.Name(), ".toml") {
 				continue
 			}
-			if data, err := os.ReadFile(filepath.Join(userPluginConfigDir, entry.Name())); err == nil {
-				cfgFile.WriteString(string(data))
-				cfgFile.WriteString("\n")
+			path := filepath.Join(userPluginConfigDir, entry.Name())
+			if data, err := os.ReadFile(path); err == nil {
+				configs.add(path, string(data))
 			}
 		}
 	}
@@ -367,7 +423,7 @@ output_path = '%s'
 
 func appendConfig(
 	opts *Options,
-	cfgFile *os.File,
+	configs *templateConfigs,
 	checkCmd []string,
 	checkFlatpaks []string,
 	fileName string,
@@ -383,11 +439,10 @@ func appendConfig(
 	if err != nil {
 		return
 	}
-	cfgFile.WriteString(substituteVars(string(data), opts.ShellDir))
-	cfgFile.WriteString("\n")
+	configs.add(configPath, substituteVars(string(data), opts.ShellDir))
 }
 
-func appendTerminalConfig(opts *Options, cfgFile *os.File, tmpDir string, checkCmd []string, checkFlatpaks []string, fileName string) {
+func appendTerminalConfig(opts *Options, configs *templateConfigs, tmpDir string, checkCmd []string, checkFlatpaks []string, fileName string) {
 	configPath := filepath.Join(opts.ShellDir, "matugen", "configs", fileName)
 	if _, err := os.Stat(configPath); err != nil {
 		return
@@ -403,8 +458,7 @@ func appendTerminalConfig(opts *Options, cfgFile *os.File, tmpDir string, checkC
 	content := string(data)
 
 	if !opts.TerminalsAlwaysDark {
-		cfgFile.WriteString(substituteVars(content, opts.ShellDir))
-		cfgFile.WriteString("\n")
+		configs.add(configPath, substituteVars(content, opts.ShellDir))
 		return
 	}
 
@@ -441,8 +495,7 @@ func appendTerminalConfig(opts *Options, cfgFile *os.File, tmpDir string, checkC
 			fmt.Sprintf("'%s'", tmpPath))
 	}
 
-	cfgFile.WriteString(substituteVars(content, opts.ShellDir))
-	cfgFile.WriteString("\n")
+	configs.add(configPath, substituteVars(content, opts.ShellDir))
 }
 
 func appExists(checker utils.AppChecker, checkCmd []string, checkFlatpaks []string) bool {
@@ -459,7 +512,7 @@ func appExists(checker utils.AppChecker, checkCmd []string, checkFlatpaks []stri
 	return false
 }
 
-func appendVSCodeConfig(cfgFile *os.File, name, extBaseDir, shellDir string) {
+func appendVSCodeConfig(configs *templateConfigs, name, extBaseDir, shellDir string) {
 	pattern := filepath.Join(extBaseDir, "danklinux.dms-theme-*")
 	matches, err := filepath.Glob(pattern)
 	if err != nil || len(matches) == 0 {
@@ -468,7 +521,7 @@ func appendVSCodeConfig(cfgFile *os.File, name, extBaseDir, shellDir string) {
 
 	extDir := matches[0]
 	templateDir := filepath.Join(shellDir, "matugen", "templates")
-	fmt.Fprintf(cfgFile, `[templates.dms%sdefault]
+	configs.add(name, fmt.Sprintf(`[templates.dms%sdefault]
 input_path = '%s/vscode-color-theme-default.json'
 output_path = '%s/themes/dankshell-default.json'
 
@@ -479,10 +532,9 @@ output_path = '%s/themes/dankshell-dark.json'
 [templates.dms%slight]
 input_path = '%s/vscode-color-theme-light.json'
 output_path = '%s/themes/dankshell-light.json'
-
 `, name, templateDir, extDir,
 		name, templateDir, extDir,
-		name, templateDir, extDir)
+		name, templateDir, extDir))
 	log.Infof("Added %s theme config (extension found at %s)", name, extDir)
 }
 
@@ -494,123 +546,6 @@ func substituteVars(content, shellDir string) string {
 	return result
 }
 
-func extractTOMLSection(content, startMarker, endMarker string) string {
-	startIdx := strings.Index(content, startMarker)
-	if startIdx == -1 {
-		return ""
-	}
-
-	if endMarker == "" {
-		return content[startIdx:]
-	}
-
-	endIdx := strings.Index(content[startIdx:], endMarker)
-	if endIdx == -1 {
-		return content[startIdx:]
-	}
-	return content[startIdx : startIdx+endIdx]
-}
-
-func checkMatugenVersion() {
-	matugenVersionOnce.Do(func() {
-		cmd := exec.Command("matugen", "--version")
-		output, err := cmd.Output()
-		if err != nil {
-			return
-		}
-
-		versionStr := strings.TrimSpace(string(output))
-		versionStr = strings.TrimPrefix(versionStr, "matugen ")
-
-		parts := strings.Split(versionStr, ".")
-		if len(parts) < 2 {
-			return
-		}
-
-		major, err := strconv.Atoi(parts[0])
-		if err != nil {
-			return
-		}
-
-		minor, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return
-		}
-
-		matugenSupportsCOE = major > 3 || (major == 3 && minor >= 1)
-		matugenIsV4 = major >= 4
-		if matugenSupportsCOE {
-			log.Infof("Matugen %s supports --continue-on-error", versionStr)
-		}
-		if matugenIsV4 {
-			log.Infof("Matugen %s: using v4 flags", versionStr)
-		}
-	})
-}
-
-func runMatugen(args []string) error {
-	checkMatugenVersion()
-
-	if matugenSupportsCOE {
-		args = append([]string{"--continue-on-error"}, args...)
-	}
-	if matugenIsV4 {
-		args = append(args, "--source-color-index", "0")
-	}
-
-	cmd := exec.Command("matugen", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func runMatugenDryRun(opts *Options) (string, error) {
-	checkMatugenVersion()
-
-	var args []string
-	switch opts.Kind {
-	case "hex":
-		args = []string{"color", "hex", opts.Value}
-	default:
-		args = []string{opts.Kind, opts.Value}
-	}
-	args = append(args, "-m", "dark", "-t", opts.MatugenType, "--json", "hex", "--dry-run")
-	if matugenIsV4 {
-		args = append(args, "--source-color-index", "0", "--old-json-output")
-	}
-
-	cmd := exec.Command("matugen", args...)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.ReplaceAll(string(output), "\n", ""), nil
-}
-
-func extractMatugenColor(jsonStr, colorName, variant string) string {
-	var data map[string]any
-	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
-		return ""
-	}
-
-	colors, ok := data["colors"].(map[string]any)
-	if !ok {
-		return ""
-	}
-
-	colorData, ok := colors[colorName].(map[string]any)
-	if !ok {
-		return ""
-	}
-
-	variantData, ok := colorData[variant].(string)
-	if !ok {
-		return ""
-	}
-
-	return variantData
-}
-
 func extractNestedColor(jsonStr, colorName, variant string) string {
 	var data map[string]any
 	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
@@ -801,6 +736,7 @@ func syncAccentColor(primaryHex string) {
 type TemplateCheck struct {
 	ID       string `json:"id"`
 	Detected bool   `json:"detected"`
+	Custom   bool   `json:"custom,omitempty"`
 }
 
 func CheckTemplates(checker utils.AppChecker) []TemplateCheck {
@@ -809,9 +745,10 @@ func CheckTemplates(checker utils.AppChecker) []TemplateCheck {
 	}
 
 	homeDir, _ := os.UserHomeDir()
-	checks := make([]TemplateCheck, 0, len(templateRegistry))
+	targets := templateTargets(utils.XDGConfigHome())
+	checks := make([]TemplateCheck, 0, len(targets))
 
-	for _, tmpl := range templateRegistry {
+	for _, tmpl := range targets {
 		detected := false
 
 		switch {
@@ -823,7 +760,7 @@ func CheckTemplates(checker utils.AppChecker) []TemplateCheck {
 			detected = appExists(checker, tmpl.Commands, tmpl.Flatpaks)
 		}
 
-		checks = append(checks, TemplateCheck{ID: tmpl.ID, Detected: detected})
+		checks = append(checks, TemplateCheck{ID: tmpl.ID, Detected: detected, Custom: tmpl.custom})
 	}
 
 	return checks
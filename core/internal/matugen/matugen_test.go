@@ -25,25 +25,16 @@ func TestAppendConfigBinaryExists(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyCommandExists("sh").Return(true)
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, []string{"sh"}, nil, "test.toml")
+	appendConfig(opts, cfg, []string{"sh"}, nil, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) == 0 {
 		t.Errorf("expected config to be written when binary exists")
@@ -68,12 +59,7 @@ func TestAppendConfigBinaryDoesNotExist(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyCommandExists("nonexistent-binary-12345").Return(false)
@@ -81,13 +67,9 @@ func TestAppendConfigBinaryDoesNotExist(t *testing.T) {
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, []string{"nonexistent-binary-12345"}, []string{}, "test.toml")
+	appendConfig(opts, cfg, []string{"nonexistent-binary-12345"}, []string{}, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) != 0 {
 		t.Errorf("expected no config when binary doesn't exist, got: %q", string(output))
@@ -109,25 +91,16 @@ func TestAppendConfigFlatpakExists(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyFlatpakExists("app.zen_browser.zen").Return(true)
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, nil, []string{"app.zen_browser.zen"}, "test.toml")
+	appendConfig(opts, cfg, nil, []string{"app.zen_browser.zen"}, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) == 0 {
 		t.Errorf("expected config to be written when flatpak exists")
@@ -149,12 +122,7 @@ func TestAppendConfigFlatpakDoesNotExist(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyCommandExists().Return(false)
@@ -162,13 +130,9 @@ func TestAppendConfigFlatpakDoesNotExist(t *testing.T) {
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, []string{}, []string{"com.nonexistent.flatpak"}, "test.toml")
+	appendConfig(opts, cfg, []string{}, []string{"com.nonexistent.flatpak"}, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) != 0 {
 		t.Errorf("expected no config when flatpak doesn't exist, got: %q", string(output))
@@ -190,25 +154,16 @@ func TestAppendConfigBothExist(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyCommandExists("sh").Return(true)
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, []string{"sh"}, []string{"app.zen_browser.zen"}, "test.toml")
+	appendConfig(opts, cfg, []string{"sh"}, []string{"app.zen_browser.zen"}, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) == 0 {
 		t.Errorf("expected config to be written when both binary and flatpak exist")
@@ -230,12 +185,7 @@ func TestAppendConfigNeitherExists(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	mockChecker := mocks_utils.NewMockAppChecker(t)
 	mockChecker.EXPECT().AnyCommandExists("nonexistent-binary-12345").Return(false)
@@ -243,13 +193,9 @@ func TestAppendConfigNeitherExists(t *testing.T) {
 
 	opts := &Options{ShellDir: shellDir, AppChecker: mockChecker}
 
-	appendConfig(opts, cfgFile, []string{"nonexistent-binary-12345"}, []string{"com.nonexistent.flatpak"}, "test.toml")
+	appendConfig(opts, cfg, []string{"nonexistent-binary-12345"}, []string{"com.nonexistent.flatpak"}, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) != 0 {
 		t.Errorf("expected no config when neither exists, got: %q", string(output))
@@ -271,22 +217,13 @@ func TestAppendConfigNoChecks(t *testing.T) {
 		t.Fatalf("failed to write config: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	opts := &Options{ShellDir: shellDir}
 
-	appendConfig(opts, cfgFile, nil, nil, "test.toml")
+	appendConfig(opts, cfg, nil, nil, "test.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) == 0 {
 		t.Errorf("expected config to be written when no checks specified")
@@ -302,22 +239,13 @@ func TestAppendConfigFileDoesNotExist(t *testing.T) {
 		t.Fatalf("failed to create configs dir: %v", err)
 	}
 
-	outFile := filepath.Join(tempDir, "output.toml")
-	cfgFile, err := os.Create(outFile)
-	if err != nil {
-		t.Fatalf("failed to create output file: %v", err)
-	}
-	defer cfgFile.Close()
+	cfg := &templateConfigs{}
 
 	opts := &Options{ShellDir: shellDir}
 
-	appendConfig(opts, cfgFile, nil, nil, "nonexistent.toml")
+	appendConfig(opts, cfg, nil, nil, "nonexistent.toml")
 
-	cfgFile.Close()
-	output, err := os.ReadFile(outFile)
-	if err != nil {
-		t.Fatalf("failed to read output: %v", err)
-	}
+	output := cfg.String()
 
 	if len(output) != 0 {
 		t.Errorf("expected no config when file doesn't exist, got: %q", string(output))
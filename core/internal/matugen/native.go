@@ -0,0 +1,126 @@
+package matugen
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/material"
+)
+
+// Scheme holds every matugen color role for both brightnesses, as hex.
+type Scheme struct {
+	Dark  map[string]string
+	Light map[string]string
+	// Source is the color the scheme was generated from.
+	Source string
+}
+
+// GenerateScheme computes the Material You scheme for an image or hex
+// color in-process, the same way matugen does.
+func GenerateScheme(kind, value, matugenType string) (*Scheme, error) {
+	variant, err := material.ParseVariant(matugenType)
+	if err != nil {
+		return nil, err
+	}
+
+	var source material.ARGB
+	switch kind {
+	case "hex":
+		source, err = material.ParseHex(value)
+		if err != nil {
+			return nil, err
+		}
+	case "image":
+		img, err := loadImage(value)
+		if err != nil {
+			return nil, err
+		}
+		source = material.SourceColor(img)
+	default:
+		return nil, fmt.Errorf("unsupported source kind: %s", kind)
+	}
+
+	return schemeFromSource(source, variant), nil
+}
+
+func schemeFromSource(source material.ARGB, variant material.Variant) *Scheme {
+	toHex := func(colors map[string]material.ARGB) map[string]string {
+		out := make(map[string]string, len(colors))
+		for name, c := range colors {
+			out[name] = c.Hex()
+		}
+		return out
+	}
+	return &Scheme{
+		Dark:   toHex(material.NewScheme(source, variant, true, 0).Colors()),
+		Light:  toHex(material.NewScheme(source, variant, false, 0).Colors()),
+		Source: source.Hex(),
+	}
+}
+
+// addCustomColors adds the roles of the user's custom colors, the way
+// matugen does for [config.custom_colors].
+func (s *Scheme) addCustomColors(colors map[string]customColor) error {
+	source, err := material.ParseHex(s.Source)
+	if err != nil {
+		return fmt.Errorf("invalid source color: %w", err)
+	}
+	for name, custom := range colors {
+		value, err := material.ParseHex(custom.Color)
+		if err != nil {
+			return fmt.Errorf("custom color %s: %w", name, err)
+		}
+		for role, c := range material.CustomColor(name, value, source, custom.Blend, true) {
+			s.Dark[role] = c.Hex()
+		}
+		for role, c := range material.CustomColor(name, value, source, custom.Blend, false) {
+			s.Light[role] = c.Hex()
+		}
+	}
+	return nil
+}
+
+// schemeFromStockColors reads the stock theme JSON passed with
+// --stock-colors, shaped {"primary": {"dark": {"color": "#..."}, ...}}.
+func schemeFromStockColors(stockColors string) (*Scheme, error) {
+	var data map[string]map[string]struct {
+		Color string `json:"color"`
+	}
+	if err := json.Unmarshal([]byte(stockColors), &data); err != nil {
+		return nil, fmt.Errorf("invalid stock colors: %w", err)
+	}
+
+	scheme := &Scheme{Dark: make(map[string]string), Light: make(map[string]string)}
+	for name, variants := range data {
+		if c := variants["dark"].Color; c != "" {
+			scheme.Dark[name] = c
+		}
+		if c := variants["light"].Color; c != "" {
+			scheme.Light[name] = c
+		}
+	}
+	return scheme, nil
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return img, nil
+}
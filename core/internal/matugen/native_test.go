@@ -0,0 +1,59 @@
+package matugen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchemeHex(t *testing.T) {
+	scheme, err := GenerateScheme("hex", "#0000ff", "scheme-tonal-spot")
+	require.NoError(t, err)
+	assert.Equal(t, "#bec2ff", scheme.Dark["primary"])
+	assert.Equal(t, "#555992", scheme.Light["primary"])
+	assert.Equal(t, "#0000ff", scheme.Dark["source_color"])
+
+	_, err = GenerateScheme("hex", "#0000ff", "scheme-bogus")
+	assert.Error(t, err)
+	_, err = GenerateScheme("json", "colors.json", "scheme-tonal-spot")
+	assert.Error(t, err)
+}
+
+func TestSchemeFromStockColors(t *testing.T) {
+	scheme, err := schemeFromStockColors(`{"primary": {"dark": {"color": "#aabbcc"}, "light": {"color": "#112233"}}, "surface": {"dark": {"color": "#101010"}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "#aabbcc", scheme.Dark["primary"])
+	assert.Equal(t, "#112233", scheme.Light["primary"])
+	assert.Equal(t, "#101010", scheme.Dark["surface"])
+	_, ok := scheme.Light["surface"]
+	assert.False(t, ok)
+
+	_, err = schemeFromStockColors("not json")
+	assert.Error(t, err)
+}
+
+func TestOutputPalettes(t *testing.T) {
+	outputs, err := ParseOutputSources([]string{"DP-1=#0000ff", "HDMI-A-1=/missing.png"})
+	require.NoError(t, err)
+	_, err = ParseOutputSources([]string{"DP-1"})
+	assert.Error(t, err)
+
+	palettes := generateOutputPalettes(outputs, "scheme-tonal-spot")
+	require.Contains(t, palettes, "DP-1")
+	assert.NotContains(t, palettes, "HDMI-A-1")
+	assert.Equal(t, "#bec2ff", palettes["DP-1"].Dark["primary"])
+
+	stateDir := t.TempDir()
+	data, err := addOutputPalettes([]byte(`{"colors": {"dark": {"primary": "#ffffff"}, "light": {"primary": "#000000"}}}`), palettes)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(stateDir, "dms-colors.json"), data, 0o644))
+
+	colors, err := LoadColors(stateDir)
+	require.NoError(t, err)
+	assert.Equal(t, "#bec2ff", colors.Palette("DP-1").Dark["primary"])
+	assert.Equal(t, "#0000ff", colors.Palette("DP-1").Source)
+	assert.Equal(t, "#ffffff", colors.Palette("HDMI-A-1").Dark["primary"])
+}
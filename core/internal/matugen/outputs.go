@@ -0,0 +1,108 @@
+package matugen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+)
+
+// OutputPalette is the scheme generated from one output's wallpaper, so
+// the bar on that output can match it.
+type OutputPalette struct {
+	Source string            `json:"source"`
+	Dark   map[string]string `json:"dark"`
+	Light  map[string]string `json:"light"`
+}
+
+// SourceKind tells a wallpaper path from a solid color: "#rrggbb" is a
+// hex source, anything else an image.
+func SourceKind(value string) string {
+	if strings.HasPrefix(value, "#") {
+		return "hex"
+	}
+	return "image"
+}
+
+// ParseOutputSources parses NAME=VALUE pairs as given to --output-palette.
+func ParseOutputSources(pairs []string) (map[string]string, error) {
+	outputs := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid output palette %q, want OUTPUT=WALLPAPER", pair)
+		}
+		outputs[name] = value
+	}
+	return outputs, nil
+}
+
+// generateOutputPalettes builds a scheme per output. Outputs sharing a
+// wallpaper share the work; one whose wallpaper can't be read is left out
+// and falls back to the main palette.
+func generateOutputPalettes(outputs map[string]string, matugenType string) map[string]OutputPalette {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	schemes := make(map[string]*Scheme)
+	palettes := make(map[string]OutputPalette, len(outputs))
+	for name, value := range outputs {
+		scheme, ok := schemes[value]
+		if !ok {
+			var err error
+			if scheme, err = GenerateScheme(SourceKind(value), value, matugenType); err != nil {
+				log.Warnf("Skipping palette for %s: %v", name, err)
+			}
+			schemes[value] = scheme
+		}
+		if scheme == nil {
+			continue
+		}
+		palettes[name] = OutputPalette{Source: value, Dark: scheme.Dark, Light: scheme.Light}
+	}
+	return palettes
+}
+
+// addOutputPalettes adds the per-output palettes to the rendered colors
+// file. Everything else in it is kept as rendered.
+func addOutputPalettes(data []byte, palettes map[string]OutputPalette) ([]byte, error) {
+	if len(palettes) == 0 {
+		return data, nil
+	}
+
+	var colors map[string]json.RawMessage
+	if err := json.Unmarshal(data, &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse colors: %w", err)
+	}
+	var err error
+	if colors["outputs"], err = json.Marshal(palettes); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(colors, "", "    ")
+}
+
+// LoadColors reads the dms-colors.json last generated into stateDir.
+func LoadColors(stateDir string) (*ColorsOutput, error) {
+	data, err := os.ReadFile(filepath.Join(stateDir, "dms-colors.json"))
+	if err != nil {
+		return nil, err
+	}
+	var colors ColorsOutput
+	if err := json.Unmarshal(data, &colors); err != nil {
+		return nil, fmt.Errorf("failed to parse colors: %w", err)
+	}
+	return &colors, nil
+}
+
+// Palette returns the palette for an output: its own when one was
+// generated, the main one otherwise.
+func (c *ColorsOutput) Palette(output string) OutputPalette {
+	if p, ok := c.Outputs[output]; ok {
+		return p
+	}
+	return OutputPalette{Dark: c.Colors.Dark, Light: c.Colors.Light}
+}
@@ -0,0 +1,93 @@
+package matugen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type FileStatus string
+
+const (
+	FileNew       FileStatus = "new"
+	FileChanged   FileStatus = "changed"
+	FileUnchanged FileStatus = "unchanged"
+	FileFailed    FileStatus = "failed"
+)
+
+// PlannedFile is one output a generation would write.
+type PlannedFile struct {
+	Template string     `json:"template"`
+	Path     string     `json:"path"`
+	Status   FileStatus `json:"status"`
+	Diff     string     `json:"diff,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// DryRun renders every template Run would, without writing files or
+// running hooks, and diffs each output against what is on disk.
+func DryRun(opts Options) ([]PlannedFile, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	b, err := prepareBuild(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	planned := make([]PlannedFile, 0, len(b.jobs))
+	for _, job := range b.jobs {
+		planned = append(planned, planFile(job, b.ctx))
+	}
+	sort.SliceStable(planned, func(i, j int) bool { return planned[i].Path < planned[j].Path })
+	return planned, nil
+}
+
+func planFile(job templateJob, ctx *templateContext) PlannedFile {
+	file := PlannedFile{Template: job.Name, Path: expandHome(job.OutputPath)}
+
+	path, out, err := renderJobOutput(job, ctx)
+	if err != nil {
+		file.Status = FileFailed
+		file.Error = err.Error()
+		return file
+	}
+
+	current, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		file.Status = FileNew
+	case err != nil:
+		file.Status = FileFailed
+		file.Error = err.Error()
+		return file
+	case string(current) == string(out):
+		file.Status = FileUnchanged
+		return file
+	default:
+		file.Status = FileChanged
+	}
+
+	fromFile := path
+	if file.Status == FileNew {
+		fromFile = "/dev/null"
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(string(out)),
+		FromFile: fromFile,
+		ToFile:   path,
+		Context:  3,
+	})
+	if err != nil {
+		file.Diff = fmt.Sprintf("(diff unavailable: %v)\n", err)
+	} else {
+		file.Diff = diff
+	}
+	return file
+}
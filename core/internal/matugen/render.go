@@ -0,0 +1,305 @@
+package matugen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/dank16"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/material"
+	"github.com/BurntSushi/toml"
+)
+
+// templateJob is one [templates.<name>] entry of a matugen config.
+type templateJob struct {
+	Name       string
+	InputPath  string
+	OutputPath string
+	PreHook    string
+	PostHook   string
+	// Format renders the dank16 palette with a built-in exporter instead
+	// of a template file.
+	Format string
+	// finish, when set, changes the rendered output before it is written.
+	finish func([]byte) ([]byte, error)
+	// target is the ID of the theming target the template belongs to, if
+	// any.
+	target string
+}
+
+// templateConfigs are the matugen configs templates are read from, in the
+// order they apply: shipped configs first, then the user's.
+type templateConfigs struct {
+	sources []configSource
+}
+
+type configSource struct {
+	name string
+	data string
+	// target is set for the config of a custom theming target, whose
+	// relative paths are resolved against dir.
+	target string
+	dir    string
+}
+
+func (c *templateConfigs) add(name, data string) {
+	c.sources = append(c.sources, configSource{name: name, data: data})
+}
+
+func (c *templateConfigs) addTarget(def TemplateDef, data string) {
+	c.sources = append(c.sources, configSource{name: def.ID, data: data, target: def.ID, dir: def.dir})
+}
+
+func (c *templateConfigs) String() string {
+	var sb strings.Builder
+	for _, src := range c.sources {
+		sb.WriteString(src.data)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// parseTemplateJobs reads the templates tables out of each config. A
+// template with the same name as an earlier one replaces it, so user
+// configs can override the shipped ones. Templates of a theming target
+// remember it, and their relative paths are resolved like the target's
+// own. A config that doesn't parse, or a template without its paths, is
+// left out and reported in errs; the rest still render.
+func parseTemplateJobs(configs *templateConfigs) (jobs []templateJob, errs []error) {
+	index := make(map[string]int)
+	for _, src := range configs.sources {
+		var doc struct {
+			Templates map[string]any `toml:"templates"`
+		}
+		md, err := toml.Decode(src.data, &doc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.name, err))
+			continue
+		}
+
+		for _, key := range md.Keys() {
+			if len(key) != 2 || key[0] != "templates" {
+				continue
+			}
+			job, err := newTemplateJob(key[1], doc.Templates[key[1]])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", src.name, err))
+				continue
+			}
+			if src.target != "" {
+				job.target = src.target
+				job.InputPath = resolveTargetPath(src.dir, job.InputPath)
+				job.OutputPath = resolveTargetPath(src.dir, job.OutputPath)
+			}
+			if i, ok := index[job.Name]; ok {
+				jobs[i] = job
+			} else {
+				index[job.Name] = len(jobs)
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	return jobs, errs
+}
+
+// customColor is one entry of [config.custom_colors]: either a color or a
+// table with color and blend.
+type customColor struct {
+	Color string
+	Blend bool
+}
+
+// parseCustomColors reads [config.custom_colors] out of each config, later
+// configs replacing colors of the same name. Configs that don't parse are
+// already reported by parseTemplateJobs and are skipped here.
+func parseCustomColors(configs *templateConfigs) (colors map[string]customColor, errs []error) {
+	colors = make(map[string]customColor)
+	for _, src := range configs.sources {
+		var doc struct {
+			Config struct {
+				CustomColors map[string]any `toml:"custom_colors"`
+			} `toml:"config"`
+		}
+		if _, err := toml.Decode(src.data, &doc); err != nil {
+			continue
+		}
+		for name, value := range doc.Config.CustomColors {
+			custom := customColor{Blend: true}
+			switch v := value.(type) {
+			case string:
+				custom.Color = v
+			case map[string]any:
+				custom.Color, _ = v["color"].(string)
+				if blend, ok := v["blend"].(bool); ok {
+					custom.Blend = blend
+				}
+			}
+			if _, err := material.ParseHex(custom.Color); err != nil {
+				errs = append(errs, fmt.Errorf("%s: custom color %s: must be a color or a table with color", src.name, name))
+				continue
+			}
+			colors[name] = custom
+		}
+	}
+	return colors, errs
+}
+
+func newTemplateJob(name string, value any) (templateJob, error) {
+	table, ok := value.(map[string]any)
+	if !ok {
+		return templateJob{}, fmt.Errorf("template %s: must be a table", name)
+	}
+	job := templateJob{Name: name}
+	for key, field := range map[string]*string{
+		"input_path":  &job.InputPath,
+		"output_path": &job.OutputPath,
+		"pre_hook":    &job.PreHook,
+		"post_hook":   &job.PostHook,
+	} {
+		raw, ok := table[key]
+		if !ok {
+			continue
+		}
+		if *field, ok = raw.(string); !ok {
+			return templateJob{}, fmt.Errorf("template %s: %s must be a string", name, key)
+		}
+	}
+	if job.InputPath == "" || job.OutputPath == "" {
+		return templateJob{}, fmt.Errorf("template %s: input_path and output_path are required", name)
+	}
+	return job, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// renderTemplates renders every job in parallel and runs its hooks. A
+// failing template is logged and doesn't stop the others; the failures
+// are returned by template name.
+func renderTemplates(jobs []templateJob, ctx *templateContext) map[string]error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make(map[string]error)
+	)
+
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job templateJob) {
+			defer wg.Done()
+			if err := renderJob(job, ctx.clone()); err != nil {
+				log.Warnf("Template %s failed: %v", job.Name, err)
+				mu.Lock()
+				failed[job.Name] = err
+				mu.Unlock()
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	return failed
+}
+
+func renderJob(job templateJob, ctx *templateContext) error {
+	if job.PreHook != "" {
+		if err := runHook(job.PreHook, ctx); err != nil {
+			return fmt.Errorf("pre_hook: %w", err)
+		}
+	}
+
+	outputPath, out, err := renderJobOutput(job, ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(outputPath, out); err != nil {
+		return err
+	}
+
+	if job.PostHook != "" {
+		if err := runHook(job.PostHook, ctx); err != nil {
+			return fmt.Errorf("post_hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// renderJobOutput renders a job without touching the output file.
+func renderJobOutput(job templateJob, ctx *templateContext) (string, []byte, error) {
+	if job.Format != "" {
+		out, err := dank16.Export(job.Format, ctx.palette.Resolve("default"))
+		if err != nil {
+			return "", nil, err
+		}
+		return expandHome(job.OutputPath), []byte(out), nil
+	}
+
+	src, err := os.ReadFile(expandHome(job.InputPath))
+	if err != nil {
+		return "", nil, err
+	}
+	out, err := renderTemplate(string(src), ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", job.InputPath, err)
+	}
+	if job.finish == nil {
+		return expandHome(job.OutputPath), []byte(out), nil
+	}
+	data, err := job.finish([]byte(out))
+	if err != nil {
+		return "", nil, err
+	}
+	return expandHome(job.OutputPath), data, nil
+}
+
+func runHook(hook string, ctx *templateContext) error {
+	cmdline, err := renderTemplate(hook, ctx)
+	if err != nil {
+		return err
+	}
+	output, err := exec.Command("sh", "-c", cmdline).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path so readers watching it never see a
+// partially written file. Symlinked outputs are written through.
+func writeFileAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,223 @@
+package matugen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
+)
+
+// Before every generation the files it is about to overwrite are copied
+// into StateDir/matugen-snapshots/<id>, so a bad theme can be rolled back.
+// Only the newest maxSnapshots are kept.
+const maxSnapshots = 10
+
+type Snapshot struct {
+	ID      string         `json:"id"`
+	Created time.Time      `json:"created"`
+	Mode    ColorMode      `json:"mode"`
+	Files   []SnapshotFile `json:"files"`
+}
+
+type SnapshotFile struct {
+	Path string `json:"path"`
+	// Blob is the copy's name inside the snapshot, empty when the file
+	// didn't exist and rolling back removes it.
+	Blob string `json:"blob,omitempty"`
+}
+
+// currentState records the mode of the theme on disk, so a snapshot knows
+// which mode its files were generated for.
+type currentState struct {
+	Mode ColorMode `json:"mode"`
+}
+
+func snapshotsDir(stateDir string) string {
+	return filepath.Join(stateDir, "matugen-snapshots")
+}
+
+func currentStatePath(stateDir string) string {
+	return filepath.Join(snapshotsDir(stateDir), "current.json")
+}
+
+func takeSnapshot(stateDir string, jobs []templateJob, mode ColorMode) error {
+	prevMode := ColorModeDark
+	if data, err := os.ReadFile(currentStatePath(stateDir)); err == nil {
+		var state currentState
+		if json.Unmarshal(data, &state) == nil && state.Mode != "" {
+			prevMode = state.Mode
+		}
+	}
+
+	created := time.Now()
+	snap := Snapshot{ID: created.UTC().Format("20060102T150405.000000000"), Created: created, Mode: prevMode}
+	dir := filepath.Join(snapshotsDir(stateDir), snap.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		path := expandHome(job.OutputPath)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		file := SnapshotFile{Path: path}
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			file.Blob = strconv.Itoa(len(snap.Files))
+			if err := os.WriteFile(filepath.Join(dir, file.Blob), data, 0o644); err != nil {
+				return err
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+		snap.Files = append(snap.Files, file)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "snapshot.json"), data, 0o644); err != nil {
+		return err
+	}
+
+	state, _ := json.Marshal(currentState{Mode: mode})
+	if err := os.WriteFile(currentStatePath(stateDir), state, 0o644); err != nil {
+		return err
+	}
+
+	return pruneSnapshots(stateDir)
+}
+
+func pruneSnapshots(stateDir string) error {
+	snaps, err := ListSnapshots(stateDir)
+	if err != nil {
+		return err
+	}
+	for _, snap := range snaps[min(len(snaps), maxSnapshots):] {
+		if err := os.RemoveAll(filepath.Join(snapshotsDir(stateDir), snap.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSnapshots returns the saved snapshots, newest first.
+func ListSnapshots(stateDir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(snapshotsDir(stateDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(snapshotsDir(stateDir), entry.Name(), "snapshot.json"))
+		if err != nil {
+			continue
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil || snap.ID != entry.Name() {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	slices.SortFunc(snaps, func(a, b Snapshot) int { return strings.Compare(b.ID, a.ID) })
+	return snaps, nil
+}
+
+// Rollback restores the theme from n generations ago (1 is the one
+// before the current theme), drops the snapshots it went back past and
+// tells running apps to reload.
+func Rollback(opts Options, n int) (*Snapshot, error) {
+	if opts.StateDir == "" {
+		return nil, fmt.Errorf("state-dir is required")
+	}
+	if opts.ConfigDir == "" {
+		opts.ConfigDir = utils.XDGConfigHome()
+	}
+	if opts.AppChecker == nil {
+		opts.AppChecker = utils.DefaultAppChecker{}
+	}
+
+	snaps, err := ListSnapshots(opts.StateDir)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(snaps) {
+		return nil, fmt.Errorf("no snapshot %d (%d available)", n, len(snaps))
+	}
+
+	// Undo the generations newest first so files that only a later
+	// generation touched are restored too.
+	restored := make(map[string]bool)
+	for _, s := range snaps[:n] {
+		if err := restoreSnapshot(opts.StateDir, s); err != nil {
+			return nil, err
+		}
+		for _, f := range s.Files {
+			restored[f.Path] = true
+		}
+	}
+	snap := snaps[n-1]
+	log.Infof("Restored %d files from snapshot %s", len(restored), snap.ID)
+
+	for _, s := range snaps[:n] {
+		if err := os.RemoveAll(filepath.Join(snapshotsDir(opts.StateDir), s.ID)); err != nil {
+			return nil, err
+		}
+	}
+	state, _ := json.Marshal(currentState{Mode: snap.Mode})
+	if err := os.WriteFile(currentStatePath(opts.StateDir), state, 0o644); err != nil {
+		return nil, err
+	}
+
+	if isDMSGTKActive(opts.ConfigDir) {
+		refreshGTK(snap.Mode)
+		refreshGTK4()
+	}
+	refreshApps(&opts)
+
+	return &snap, nil
+}
+
+func restoreSnapshot(stateDir string, snap Snapshot) error {
+	dir := filepath.Join(snapshotsDir(stateDir), snap.ID)
+	for _, file := range snap.Files {
+		if file.Blob == "" {
+			if err := os.Remove(file.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Blob))
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0o755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(file.Path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
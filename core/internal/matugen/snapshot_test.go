@@ -0,0 +1,122 @@
+package matugen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRunOptions points every XDG directory into a temp dir and returns
+// options that render the shipped shell templates there.
+func newRunOptions(t *testing.T) Options {
+	t.Helper()
+	shellDir, err := filepath.Abs(filepath.Join("..", "..", "..", "quickshell"))
+	require.NoError(t, err)
+	if _, err := os.Stat(filepath.Join(shellDir, "matugen", "templates", "dank.json")); err != nil {
+		t.Skip("shell templates not available")
+	}
+
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmp, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+
+	return Options{
+		StateDir:   filepath.Join(tmp, "state"),
+		ShellDir:   shellDir,
+		ConfigDir:  filepath.Join(tmp, "config"),
+		Kind:       "hex",
+		Value:      "#6750a4",
+		AppChecker: fakeAppChecker{},
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	opts := newRunOptions(t)
+
+	planned, err := DryRun(opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, planned)
+	for _, f := range planned {
+		assert.Equal(t, FileNew, f.Status, f.Path)
+		assert.Contains(t, f.Diff, "--- /dev/null")
+	}
+	assert.NoFileExists(t, opts.ColorsOutput())
+
+	require.NoError(t, Run(opts))
+	planned, err = DryRun(opts)
+	require.NoError(t, err)
+	for _, f := range planned {
+		assert.Equal(t, FileUnchanged, f.Status, f.Path)
+	}
+
+	opts.Value = "#1e66f5"
+	planned, err = DryRun(opts)
+	require.NoError(t, err)
+	var colors *PlannedFile
+	for i := range planned {
+		if planned[i].Path == opts.ColorsOutput() {
+			colors = &planned[i]
+		}
+	}
+	require.NotNil(t, colors)
+	assert.Equal(t, FileChanged, colors.Status)
+	assert.Contains(t, colors.Diff, "+++ "+opts.ColorsOutput())
+}
+
+func TestRollback(t *testing.T) {
+	opts := newRunOptions(t)
+
+	require.NoError(t, Run(opts))
+	first, err := os.ReadFile(opts.ColorsOutput())
+	require.NoError(t, err)
+
+	opts.Value = "#1e66f5"
+	opts.Mode = ColorModeLight
+	require.NoError(t, Run(opts))
+	second, err := os.ReadFile(opts.ColorsOutput())
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	snaps, err := ListSnapshots(opts.StateDir)
+	require.NoError(t, err)
+	require.Len(t, snaps, 2)
+	assert.Equal(t, ColorModeDark, snaps[0].Mode)
+
+	snap, err := Rollback(opts, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ColorModeDark, snap.Mode)
+	restored, err := os.ReadFile(opts.ColorsOutput())
+	require.NoError(t, err)
+	assert.Equal(t, first, restored)
+
+	// Going back past the first generation removes what it created.
+	_, err = Rollback(opts, 1)
+	require.NoError(t, err)
+	assert.NoFileExists(t, opts.ColorsOutput())
+
+	_, err = Rollback(opts, 1)
+	assert.Error(t, err)
+}
+
+func TestSnapshotPruning(t *testing.T) {
+	stateDir := t.TempDir()
+	out := filepath.Join(stateDir, "out")
+	require.NoError(t, os.WriteFile(out, []byte("x"), 0o644))
+
+	jobs := []templateJob{{Name: "a", OutputPath: out}, {Name: "b", OutputPath: out}}
+	for range maxSnapshots + 3 {
+		require.NoError(t, takeSnapshot(stateDir, jobs, ColorModeDark))
+	}
+
+	snaps, err := ListSnapshots(stateDir)
+	require.NoError(t, err)
+	assert.Len(t, snaps, maxSnapshots)
+	require.Len(t, snaps[0].Files, 1)
+	assert.Equal(t, out, snaps[0].Files[0].Path)
+	assert.Greater(t, snaps[0].ID, snaps[1].ID)
+}
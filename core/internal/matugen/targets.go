@@ -0,0 +1,256 @@
+package matugen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/dank16"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
+)
+
+// Custom theming targets are JSON files in ~/.config/matugen/dms/targets,
+// one TemplateDef each:
+//
+//	{
+//	  "id": "zathura",
+//	  "commands": ["zathura"],
+//	  "template": "zathura-colors",
+//	  "output": "CONFIG_DIR/zathura/dank-colors",
+//	  "reloadSignal": "SIGUSR1"
+//	}
+//
+// A target either names a single template and its output, a dank16
+// export format (as in dms dank16 --format) and its output, or a matugen
+// TOML in configFile with any number of [templates.*] tables. Relative
+// paths, including the ones in configFile, are resolved against the
+// targets directory. A target is reloaded once all of its templates
+// rendered. A target with the ID of a built-in one replaces it.
+
+func TargetsDir(configDir string) string {
+	return filepath.Join(configDir, "matugen", "dms", "targets")
+}
+
+var templateKindNames = map[string]TemplateKind{
+	"":         TemplateKindNormal,
+	"normal":   TemplateKindNormal,
+	"terminal": TemplateKindTerminal,
+}
+
+func (k *TemplateKind) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	kind, ok := templateKindNames[name]
+	if !ok {
+		return fmt.Errorf("unsupported kind %q (want normal or terminal)", name)
+	}
+	*k = kind
+	return nil
+}
+
+var reloadSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+func parseReloadSignal(name string) (syscall.Signal, error) {
+	sig, ok := reloadSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported reload signal %q", name)
+	}
+	return sig, nil
+}
+
+// LoadTargets reads the custom target definitions. Invalid files are
+// logged and skipped so one bad drop-in can't break theming.
+func LoadTargets(configDir string) []TemplateDef {
+	dir := TargetsDir(configDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var targets []TemplateDef
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		def, err := loadTarget(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Warnf("Ignoring theme target %s: %v", entry.Name(), err)
+			continue
+		}
+		targets = append(targets, def)
+	}
+	return targets
+}
+
+func loadTarget(path string) (TemplateDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TemplateDef{}, err
+	}
+
+	var def TemplateDef
+	if err := json.Unmarshal(data, &def); err != nil {
+		return TemplateDef{}, err
+	}
+
+	sources := 0
+	for _, s := range []string{def.ConfigFile, def.Template, def.Format} {
+		if s != "" {
+			sources++
+		}
+	}
+	switch {
+	case def.ID == "":
+		return TemplateDef{}, fmt.Errorf("id is required")
+	case sources != 1:
+		return TemplateDef{}, fmt.Errorf("exactly one of configFile, template or format is required")
+	case def.ConfigFile == "" && def.Output == "":
+		return TemplateDef{}, fmt.Errorf("output is required")
+	}
+	if def.Format != "" {
+		if _, err := dank16.Export(def.Format, dank16.Palette{}); err != nil {
+			return TemplateDef{}, err
+		}
+	}
+	if def.ReloadSignal != "" {
+		if _, err := parseReloadSignal(def.ReloadSignal); err != nil {
+			return TemplateDef{}, err
+		}
+	}
+
+	def.dir = filepath.Dir(path)
+	def.custom = true
+	return def, nil
+}
+
+// templateTargets is the built-in registry with the custom targets merged
+// in.
+func templateTargets(configDir string) []TemplateDef {
+	targets := slices.Clone(templateRegistry)
+	for _, custom := range LoadTargets(configDir) {
+		i := slices.IndexFunc(targets, func(t TemplateDef) bool { return t.ID == custom.ID })
+		if i >= 0 {
+			targets[i] = custom
+			continue
+		}
+		targets = append(targets, custom)
+	}
+	return targets
+}
+
+func (t *TemplateDef) resolve(path string) string {
+	return resolveTargetPath(t.dir, path)
+}
+
+func resolveTargetPath(dir, path string) string {
+	path = expandTargetPath(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// expandTargetPath expands the same directory placeholders the shipped
+// configs use, plus ~.
+func expandTargetPath(path string) string {
+	for prefix, dir := range map[string]func() string{
+		"CONFIG_DIR/": utils.XDGConfigHome,
+		"DATA_DIR/":   utils.XDGDataHome,
+		"CACHE_DIR/":  utils.XDGCacheHome,
+	} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			return filepath.Join(dir(), rest)
+		}
+	}
+	return expandHome(path)
+}
+
+// appendTargetConfig adds a custom target's templates to the merged
+// config, mirroring appendConfig and appendTerminalConfig.
+func appendTargetConfig(opts *Options, configs *templateConfigs, tmpDir string, def TemplateDef) {
+	if !appExists(opts.AppChecker, def.Commands, def.Flatpaks) {
+		return
+	}
+
+	switch {
+	case def.Format != "":
+		// Rendered by prepare from the dank16 palette.
+		return
+	case def.ConfigFile != "":
+		data, err := os.ReadFile(def.resolve(def.ConfigFile))
+		if err != nil {
+			log.Warnf("Theme target %s: %v", def.ID, err)
+			return
+		}
+		configs.addTarget(def, substituteVars(string(data), opts.ShellDir))
+		return
+	}
+
+	input := def.resolve(def.Template)
+	if def.Kind == TemplateKindTerminal && opts.TerminalsAlwaysDark {
+		data, err := os.ReadFile(input)
+		if err != nil {
+			log.Warnf("Theme target %s: %v", def.ID, err)
+			return
+		}
+		input = filepath.Join(tmpDir, "target-"+def.ID+"-"+filepath.Base(input))
+		if err := os.WriteFile(input, []byte(strings.ReplaceAll(string(data), ".default.", ".dark.")), 0o644); err != nil {
+			log.Warnf("Theme target %s: %v", def.ID, err)
+			return
+		}
+	}
+
+	configs.addTarget(def, fmt.Sprintf("[templates.%q]\ninput_path = %q\noutput_path = %q\n", def.ID, input, def.resolve(def.Output)))
+}
+
+// reloadTargets runs the reload command or signal of every target whose
+// templates all rendered.
+func reloadTargets(targets []TemplateDef, jobs []templateJob, failed map[string]error) {
+	rendered := make(map[string]bool)
+	for _, job := range jobs {
+		if job.target == "" {
+			continue
+		}
+		if _, ok := rendered[job.target]; !ok {
+			rendered[job.target] = true
+		}
+		if failed[job.Name] != nil {
+			rendered[job.target] = false
+		}
+	}
+
+	for _, def := range targets {
+		if def.ReloadCommand == "" && def.ReloadSignal == "" {
+			continue
+		}
+		if !rendered[def.ID] {
+			continue
+		}
+
+		if def.ReloadSignal != "" {
+			sig, _ := parseReloadSignal(def.ReloadSignal)
+			for _, name := range def.Commands {
+				signalByName(name, sig)
+			}
+		}
+		if def.ReloadCommand != "" {
+			if output, err := exec.Command("sh", "-c", def.ReloadCommand).CombinedOutput(); err != nil {
+				log.Warnf("Theme target %s reload failed: %v: %s", def.ID, err, strings.TrimSpace(string(output)))
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package matugen
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAppChecker struct {
+	commands []string
+}
+
+func (f fakeAppChecker) CommandExists(cmd string) bool { return slices.Contains(f.commands, cmd) }
+
+func (f fakeAppChecker) AnyCommandExists(cmds ...string) bool {
+	return slices.ContainsFunc(cmds, f.CommandExists)
+}
+
+func (f fakeAppChecker) FlatpakExists(string) bool { return false }
+
+func (f fakeAppChecker) AnyFlatpakExists(...string) bool { return false }
+
+func writeTarget(t *testing.T, configDir, name, content string) {
+	t.Helper()
+	dir := TargetsDir(configDir)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestLoadTargets(t *testing.T) {
+	configDir := t.TempDir()
+	writeTarget(t, configDir, "zathura.json", `{"id": "zathura", "commands": ["zathura"], "template": "zathura", "output": "CONFIG_DIR/zathura/dank", "reloadSignal": "SIGUSR1"}`)
+	writeTarget(t, configDir, "kitty.json", `{"id": "kitty", "commands": ["kitty"], "configFile": "kitty.toml", "kind": "terminal"}`)
+	writeTarget(t, configDir, "noid.json", `{"template": "x", "output": "y"}`)
+	writeTarget(t, configDir, "nooutput.json", `{"id": "x", "template": "x"}`)
+	writeTarget(t, configDir, "badkind.json", `{"id": "x", "configFile": "x.toml", "kind": "gtk"}`)
+	writeTarget(t, configDir, "badsignal.json", `{"id": "x", "configFile": "x.toml", "reloadSignal": "SIGKILLALL"}`)
+	writeTarget(t, configDir, "notes.txt", `not a target`)
+
+	targets := LoadTargets(configDir)
+	require.Len(t, targets, 2)
+	ids := []string{targets[0].ID, targets[1].ID}
+	assert.ElementsMatch(t, []string{"kitty", "zathura"}, ids)
+
+	merged := templateTargets(configDir)
+	assert.Len(t, merged, len(templateRegistry)+1)
+	i := slices.IndexFunc(merged, func(d TemplateDef) bool { return d.ID == "kitty" })
+	require.GreaterOrEqual(t, i, 0)
+	assert.True(t, merged[i].custom)
+	assert.Equal(t, TemplateKindTerminal, merged[i].Kind)
+	assert.Equal(t, filepath.Join(TargetsDir(configDir), "kitty.toml"), merged[i].resolve(merged[i].ConfigFile))
+
+	assert.Empty(t, LoadTargets(t.TempDir()))
+}
+
+func TestCheckTemplatesIncludesTargets(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	writeTarget(t, configDir, "zathura.json", `{"id": "zathura", "commands": ["zathura"], "template": "z", "output": "o"}`)
+
+	checks := CheckTemplates(fakeAppChecker{commands: []string{"zathura"}})
+	i := slices.IndexFunc(checks, func(c TemplateCheck) bool { return c.ID == "zathura" })
+	require.GreaterOrEqual(t, i, 0)
+	assert.Equal(t, TemplateCheck{ID: "zathura", Detected: true, Custom: true}, checks[i])
+}
+
+func TestRunCustomTarget(t *testing.T) {
+	shellDir, err := filepath.Abs(filepath.Join("..", "..", "..", "quickshell"))
+	require.NoError(t, err)
+	if _, err := os.Stat(filepath.Join(shellDir, "matugen", "templates", "dank.json")); err != nil {
+		t.Skip("shell templates not available")
+	}
+
+	tmp := t.TempDir()
+	configDir := filepath.Join(tmp, "config")
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmp, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+
+	writeTarget(t, configDir, "zathura.json", `{"id": "zathura", "commands": ["zathura"], "template": "zathura.tmpl", "output": "CONFIG_DIR/zathura/dank", "kind": "terminal", "reloadCommand": "touch `+filepath.Join(tmp, "reloaded")+`"}`)
+	writeTarget(t, configDir, "zathura.tmpl", `bg={{colors.surface.default.hex}}`)
+	writeTarget(t, configDir, "skipped.json", `{"id": "skipped", "template": "zathura.tmpl", "output": "CONFIG_DIR/skipped"}`)
+
+	opts := Options{
+		StateDir:            filepath.Join(tmp, "state"),
+		ShellDir:            shellDir,
+		ConfigDir:           configDir,
+		Kind:                "hex",
+		Value:               "#6750a4",
+		Mode:                ColorModeLight,
+		TerminalsAlwaysDark: true,
+		SkipTemplates:       "skipped",
+		AppChecker:          fakeAppChecker{commands: []string{"zathura"}},
+	}
+	require.NoError(t, Run(opts))
+
+	scheme, err := GenerateScheme("hex", "#6750a4", "scheme-tonal-spot")
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(configDir, "zathura", "dank"))
+	require.NoError(t, err)
+	assert.Equal(t, "bg="+scheme.Dark["surface"], string(data))
+
+	assert.FileExists(t, filepath.Join(tmp, "reloaded"))
+	assert.NoFileExists(t, filepath.Join(configDir, "skipped"))
+	assert.FileExists(t, filepath.Join(tmp, "state", "dms-colors.json"))
+}
+
+func TestRunFormatTargets(t *testing.T) {
+	opts := newRunOptions(t)
+	opts.AppChecker = fakeAppChecker{commands: []string{"btop", "zathura"}}
+	writeTarget(t, opts.ConfigDir, "zathura.json", `{"id": "zathura", "commands": ["zathura"], "format": "xresources", "output": "CONFIG_DIR/zathura/dank.Xresources"}`)
+
+	require.NoError(t, Run(opts))
+
+	btop, err := os.ReadFile(filepath.Join(opts.ConfigDir, "btop", "themes", "dankshell.theme"))
+	require.NoError(t, err)
+	assert.Contains(t, string(btop), "theme[main_bg]=")
+	xres, err := os.ReadFile(filepath.Join(opts.ConfigDir, "zathura", "dank.Xresources"))
+	require.NoError(t, err)
+	assert.Contains(t, string(xres), "*.color4: #")
+	assert.NoFileExists(t, filepath.Join(opts.ConfigDir, "helix", "themes", "dankshell.toml"))
+
+	writeTarget(t, opts.ConfigDir, "bad.json", `{"id": "bad", "format": "vim9", "output": "x"}`)
+	assert.Len(t, LoadTargets(opts.ConfigDir), 1)
+}
+
+func TestRunConfigFileTarget(t *testing.T) {
+	opts := newRunOptions(t)
+	opts.AppChecker = fakeAppChecker{commands: []string{"zathura", "mpv"}}
+	home := filepath.Dir(opts.ConfigDir)
+	targets := TargetsDir(opts.ConfigDir)
+
+	writeTarget(t, opts.ConfigDir, "zathura.json", `{"id": "zathura", "commands": ["zathura"], "configFile": "zathura.toml", "reloadCommand": "touch `+filepath.Join(home, "zathura-reloaded")+`"}`)
+	writeTarget(t, opts.ConfigDir, "zathura.toml", "[templates.zathura-colors]\ninput_path = 'zathura.tmpl'\noutput_path = 'out/zathura'\n")
+	writeTarget(t, opts.ConfigDir, "zathura.tmpl", `bg={{colors.surface.default.hex}}`)
+
+	writeTarget(t, opts.ConfigDir, "mpv.json", `{"id": "mpv", "commands": ["mpv"], "configFile": "mpv.toml", "reloadCommand": "touch `+filepath.Join(home, "mpv-reloaded")+`"}`)
+	writeTarget(t, opts.ConfigDir, "mpv.toml", `[templates.mpv-good]
+input_path = 'zathura.tmpl'
+output_path = 'out/mpv'
+
+[templates.mpv-bad]
+input_path = 'mpv.tmpl'
+output_path = 'out/mpv-bad'
+`)
+	writeTarget(t, opts.ConfigDir, "mpv.tmpl", `{{colors.nope.default.hex}}`)
+
+	require.NoError(t, Run(opts))
+
+	assert.FileExists(t, filepath.Join(targets, "out", "zathura"))
+	assert.FileExists(t, filepath.Join(home, "zathura-reloaded"))
+	assert.FileExists(t, filepath.Join(targets, "out", "mpv"))
+	assert.NoFileExists(t, filepath.Join(home, "mpv-reloaded"), "a failed template must not reload its target")
+}
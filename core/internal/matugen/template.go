@@ -0,0 +1,656 @@
+package matugen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/dank16"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// The template language is the subset of matugen's that themes use in
+// practice:
+//
+//	{{colors.primary.default.hex}}          role, variant (dark/light/default), format
+//	{{dank16.color4.dark.hex_stripped}}
+//	{{colors.surface.default.rgba | set_alpha: 0.8}}
+//	<* if {{ is_dark_mode }} *>...<* else *>...<* endif *>
+//	<* for name, value in colors *>{{name}} = {{value.default.hex}}<* endfor *>
+//
+// plus {{image}} and {{mode}}. Custom colors from [config.custom_colors]
+// are under colors, with the roles matugen gives them.
+
+// templateColor is a color plus alpha, so set_alpha survives until it is
+// formatted.
+type templateColor struct {
+	c     colorful.Color
+	alpha float64
+}
+
+// colorVariants are the three variants every role exposes to templates.
+type colorVariants struct {
+	dark, light, def templateColor
+}
+
+// colorValue is a color waiting for its output format, so filters can
+// still change it.
+type colorValue struct {
+	color  templateColor
+	format string
+}
+
+type templateContext struct {
+	colors map[string]colorVariants
+	dank16 map[string]colorVariants
+	image  string
+	mode   ColorMode
+	locals map[string]any
+	// palette is the dank16 palette again, for the format exporters.
+	palette dank16.VariantPalette
+}
+
+func parseTemplateColor(hex string) (templateColor, error) {
+	c, err := colorful.Hex(hex)
+	if err != nil {
+		return templateColor{}, fmt.Errorf("invalid color %q", hex)
+	}
+	return templateColor{c: c, alpha: 1}, nil
+}
+
+func newVariants(dark, light string, mode ColorMode) (colorVariants, error) {
+	d, err := parseTemplateColor(dark)
+	if err != nil {
+		return colorVariants{}, err
+	}
+	l, err := parseTemplateColor(light)
+	if err != nil {
+		return colorVariants{}, err
+	}
+	v := colorVariants{dark: d, light: l, def: d}
+	if mode == ColorModeLight {
+		v.def = l
+	}
+	return v, nil
+}
+
+// newTemplateContext builds the data templates render against: the scheme
+// roles under colors and the dank16 palette under dank16.
+func newTemplateContext(scheme *Scheme, dank16JSON string, mode ColorMode, image string) (*templateContext, error) {
+	ctx := &templateContext{
+		colors: make(map[string]colorVariants, len(scheme.Dark)),
+		dank16: make(map[string]colorVariants, 16),
+		image:  image,
+		mode:   mode,
+	}
+
+	for name, dark := range scheme.Dark {
+		light, ok := scheme.Light[name]
+		if !ok {
+			light = dark
+		}
+		v, err := newVariants(dark, light, mode)
+		if err != nil {
+			return nil, fmt.Errorf("colors.%s: %w", name, err)
+		}
+		ctx.colors[name] = v
+	}
+
+	var palette map[string]struct {
+		Dark  struct{ Hex string } `json:"dark"`
+		Light struct{ Hex string } `json:"light"`
+	}
+	if err := json.Unmarshal([]byte(dank16JSON), &palette); err != nil {
+		return nil, fmt.Errorf("invalid dank16 palette: %w", err)
+	}
+	for name, p := range palette {
+		v, err := newVariants(p.Dark.Hex, p.Light.Hex, mode)
+		if err != nil {
+			return nil, fmt.Errorf("dank16.%s: %w", name, err)
+		}
+		ctx.dank16[name] = v
+	}
+	if err := json.Unmarshal([]byte(dank16JSON), &ctx.palette); err != nil {
+		return nil, fmt.Errorf("invalid dank16 palette: %w", err)
+	}
+	return ctx, nil
+}
+
+// clone returns a context safe to render with on another goroutine; the
+// color maps are shared read-only, loop variables are not.
+func (ctx *templateContext) clone() *templateContext {
+	c := *ctx
+	c.locals = nil
+	return &c
+}
+
+type nodeKind int
+
+const (
+	nodeText nodeKind = iota
+	nodeExpr
+	nodeIf
+	nodeFor
+)
+
+type templateNode struct {
+	kind     nodeKind
+	text     string // text, expression or condition
+	children []templateNode
+	elseBody []templateNode
+	vars     []string
+	iterable string
+}
+
+// parseTemplate turns template source into a tree of text, {{ }}
+// expressions and <* *> blocks.
+func parseTemplate(src string) ([]templateNode, error) {
+	nodes, rest, end, err := parseNodes(src)
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, fmt.Errorf("unexpected <* %s *>", end)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unparsed template content")
+	}
+	return nodes, nil
+}
+
+// parseNodes parses until the end of src or an else/endif/endfor tag,
+// which it returns along with the remaining source.
+func parseNodes(src string) ([]templateNode, string, string, error) {
+	var nodes []templateNode
+	for src != "" {
+		exprAt := strings.Index(src, "{{")
+		blockAt := strings.Index(src, "<*")
+
+		next := exprAt
+		if next < 0 || (blockAt >= 0 && blockAt < next) {
+			next = blockAt
+		}
+		if next < 0 {
+			nodes = append(nodes, templateNode{kind: nodeText, text: src})
+			return nodes, "", "", nil
+		}
+		if next > 0 {
+			nodes = append(nodes, templateNode{kind: nodeText, text: src[:next]})
+			src = src[next:]
+		}
+
+		if strings.HasPrefix(src, "{{") {
+			end := strings.Index(src, "}}")
+			if end < 0 {
+				return nil, "", "", fmt.Errorf("unclosed {{")
+			}
+			nodes = append(nodes, templateNode{kind: nodeExpr, text: strings.TrimSpace(src[2:end])})
+			src = src[end+2:]
+			continue
+		}
+
+		end := strings.Index(src, "*>")
+		if end < 0 {
+			return nil, "", "", fmt.Errorf("unclosed <*")
+		}
+		tag := strings.TrimSpace(src[2:end])
+		src = src[end+2:]
+		keyword, args, _ := strings.Cut(tag, " ")
+		args = strings.TrimSpace(args)
+
+		switch keyword {
+		case "else", "endif", "endfor":
+			return nodes, src, keyword, nil
+		case "if":
+			body, rest, closer, err := parseNodes(src)
+			if err != nil {
+				return nil, "", "", err
+			}
+			node := templateNode{kind: nodeIf, text: args, children: body}
+			if closer == "else" {
+				node.elseBody, rest, closer, err = parseNodes(rest)
+				if err != nil {
+					return nil, "", "", err
+				}
+			}
+			if closer != "endif" {
+				return nil, "", "", fmt.Errorf("<* if %s *> is missing <* endif *>", args)
+			}
+			nodes = append(nodes, node)
+			src = rest
+		case "for":
+			vars, iterable, ok := strings.Cut(args, " in ")
+			if !ok {
+				return nil, "", "", fmt.Errorf("invalid for loop: %s", args)
+			}
+			body, rest, closer, err := parseNodes(src)
+			if err != nil {
+				return nil, "", "", err
+			}
+			if closer != "endfor" {
+				return nil, "", "", fmt.Errorf("<* for %s *> is missing <* endfor *>", args)
+			}
+			node := templateNode{kind: nodeFor, children: body, iterable: strings.TrimSpace(iterable)}
+			for _, v := range strings.Split(vars, ",") {
+				node.vars = append(node.vars, strings.TrimSpace(v))
+			}
+			nodes = append(nodes, node)
+			src = rest
+		default:
+			return nil, "", "", fmt.Errorf("unknown block <* %s *>", tag)
+		}
+	}
+	return nodes, "", "", nil
+}
+
+// renderTemplate renders matugen template source.
+func renderTemplate(src string, ctx *templateContext) (string, error) {
+	nodes, err := parseTemplate(src)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := ctx.render(&sb, nodes); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (ctx *templateContext) render(sb *strings.Builder, nodes []templateNode) error {
+	for _, n := range nodes {
+		switch n.kind {
+		case nodeText:
+			sb.WriteString(n.text)
+		case nodeExpr:
+			out, err := ctx.evalString(n.text)
+			if err != nil {
+				return err
+			}
+			sb.WriteString(out)
+		case nodeIf:
+			ok, err := ctx.evalCondition(n.text)
+			if err != nil {
+				return err
+			}
+			body := n.elseBody
+			if ok {
+				body = n.children
+			}
+			if err := ctx.render(sb, body); err != nil {
+				return err
+			}
+		case nodeFor:
+			if err := ctx.renderFor(sb, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ctx *templateContext) renderFor(sb *strings.Builder, n templateNode) error {
+	var source map[string]colorVariants
+	switch n.iterable {
+	case "colors":
+		source = ctx.colors
+	case "dank16":
+		source = ctx.dank16
+	default:
+		return fmt.Errorf("cannot iterate over %s", n.iterable)
+	}
+
+	names := make([]string, 0, len(source))
+	for name := range source {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	saved := ctx.locals
+	defer func() { ctx.locals = saved }()
+	for _, name := range names {
+		ctx.locals = make(map[string]any, len(saved)+2)
+		for k, v := range saved {
+			ctx.locals[k] = v
+		}
+		switch len(n.vars) {
+		case 1:
+			ctx.locals[n.vars[0]] = name
+		default:
+			ctx.locals[n.vars[0]] = name
+			ctx.locals[n.vars[1]] = source[name]
+		}
+		if err := ctx.render(sb, n.children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctx *templateContext) evalCondition(cond string) (bool, error) {
+	cond = stripBraces(cond)
+	if rest, ok := strings.CutPrefix(cond, "not "); ok {
+		v, err := ctx.evalCondition(rest)
+		return !v, err
+	}
+	for _, op := range []string{"==", "!="} {
+		if lhs, rhs, ok := strings.Cut(cond, op); ok {
+			l, err := ctx.evalString(stripBraces(lhs))
+			if err != nil {
+				return false, err
+			}
+			r, err := ctx.evalString(stripBraces(rhs))
+			if err != nil {
+				return false, err
+			}
+			return (l == r) == (op == "=="), nil
+		}
+	}
+
+	v, err := ctx.eval(cond)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return v != "", nil
+	default:
+		return true, nil
+	}
+}
+
+// stripBraces unwraps a {{ }} operand inside a <* *> tag.
+func stripBraces(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{{") && strings.HasSuffix(s, "}}") {
+		s = strings.TrimSpace(s[2 : len(s)-2])
+	}
+	return s
+}
+
+func (ctx *templateContext) evalString(expr string) (string, error) {
+	v, err := ctx.eval(expr)
+	if err != nil {
+		return "", err
+	}
+	return stringify(v)
+}
+
+// eval resolves a path and applies its filters.
+func (ctx *templateContext) eval(expr string) (any, error) {
+	parts := splitOutsideQuotes(expr, '|')
+	v, err := ctx.resolve(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range parts[1:] {
+		name, rawArgs, _ := strings.Cut(strings.TrimSpace(f), ":")
+		var args []string
+		if strings.TrimSpace(rawArgs) != "" {
+			for _, a := range splitOutsideQuotes(rawArgs, ',') {
+				args = append(args, unquote(strings.TrimSpace(a)))
+			}
+		}
+		if v, err = applyFilter(strings.TrimSpace(name), args, v); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (ctx *templateContext) resolve(path string) (any, error) {
+	if len(path) >= 2 && (path[0] == '"' || path[0] == '\'') {
+		return unquote(path), nil
+	}
+
+	segments := strings.Split(path, ".")
+	var v any
+	switch root := segments[0]; {
+	case ctx.locals[root] != nil:
+		v = ctx.locals[root]
+	case root == "colors":
+		v = ctx.colors
+	case root == "dank16":
+		v = ctx.dank16
+	case root == "image":
+		v = ctx.image
+	case root == "mode":
+		v = string(ctx.mode)
+	case root == "is_dark_mode":
+		v = ctx.mode != ColorModeLight
+	case root == "true" || root == "false":
+		v = root == "true"
+	default:
+		return nil, fmt.Errorf("unknown variable %q", path)
+	}
+
+	for _, seg := range segments[1:] {
+		switch cur := v.(type) {
+		case map[string]colorVariants:
+			cv, ok := cur[seg]
+			if !ok {
+				return nil, fmt.Errorf("unknown color %q in %q", seg, path)
+			}
+			v = cv
+		case colorVariants:
+			switch seg {
+			case "dark":
+				v = colorValue{color: cur.dark}
+			case "light":
+				v = colorValue{color: cur.light}
+			case "default":
+				v = colorValue{color: cur.def}
+			default:
+				return nil, fmt.Errorf("unknown variant %q in %q", seg, path)
+			}
+		case colorValue:
+			if cur.format != "" || !isColorFormat(seg) {
+				return nil, fmt.Errorf("unknown format %q in %q", seg, path)
+			}
+			cur.format = seg
+			v = cur
+		default:
+			return nil, fmt.Errorf("cannot look up %q in %q", seg, path)
+		}
+	}
+	return v, nil
+}
+
+var colorFormats = []string{
+	"hex", "hex_stripped", "rgb", "rgba", "hsl", "hsla",
+	"red", "green", "blue", "alpha", "hue", "saturation", "lightness",
+}
+
+func isColorFormat(s string) bool {
+	for _, f := range colorFormats {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
+
+func stringify(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case colorValue:
+		if v.format == "" {
+			return "", fmt.Errorf("color needs a format such as .hex")
+		}
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("value is not printable, add a variant and format")
+	}
+}
+
+func (v colorValue) String() string {
+	r, g, b := v.color.c.Clamped().RGB255()
+	h, s, l := v.color.c.Clamped().Hsl()
+	a := v.color.alpha
+	pct := func(x float64) int { return int(math.Round(x * 100)) }
+
+	switch v.format {
+	case "hex_stripped":
+		return fmt.Sprintf("%02x%02x%02x", r, g, b)
+	case "rgb":
+		return fmt.Sprintf("rgb(%d, %d, %d)", r, g, b)
+	case "rgba":
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)", r, g, b, formatFloat(a))
+	case "hsl":
+		return fmt.Sprintf("hsl(%d, %d%%, %d%%)", int(math.Round(h)), pct(s), pct(l))
+	case "hsla":
+		return fmt.Sprintf("hsla(%d, %d%%, %d%%, %s)", int(math.Round(h)), pct(s), pct(l), formatFloat(a))
+	case "red":
+		return strconv.Itoa(int(r))
+	case "green":
+		return strconv.Itoa(int(g))
+	case "blue":
+		return strconv.Itoa(int(b))
+	case "alpha":
+		return formatFloat(a)
+	case "hue":
+		return strconv.Itoa(int(math.Round(h)))
+	case "saturation":
+		return strconv.Itoa(pct(s))
+	case "lightness":
+		return strconv.Itoa(pct(l))
+	default:
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+}
+
+// applyFilter implements the matugen filters. Color filters adjust HSL
+// values in percent (lighten: 10 adds 10% lightness); string filters
+// format the color first.
+func applyFilter(name string, args []string, v any) (any, error) {
+	num := func() (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s expects one argument", name)
+		}
+		f, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid number %q", name, args[0])
+		}
+		return f, nil
+	}
+
+	if cv, ok := v.(colorValue); ok {
+		h, s, l := cv.color.c.Hsl()
+		setHSL := func(h, s, l float64) (any, error) {
+			cv.color.c = colorful.Hsl(math.Mod(h+360, 360), clamp01(s), clamp01(l))
+			return cv, nil
+		}
+
+		switch name {
+		case "lighten", "darken", "saturate", "desaturate", "set_lightness", "set_saturation", "set_hue", "set_alpha", "set_red", "set_green", "set_blue":
+			amount, err := num()
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "lighten":
+				return setHSL(h, s, l+amount/100)
+			case "darken":
+				return setHSL(h, s, l-amount/100)
+			case "saturate":
+				return setHSL(h, s+amount/100, l)
+			case "desaturate":
+				return setHSL(h, s-amount/100, l)
+			case "set_lightness":
+				return setHSL(h, s, amount/100)
+			case "set_saturation":
+				return setHSL(h, amount/100, l)
+			case "set_hue":
+				return setHSL(amount, s, l)
+			case "set_alpha":
+				cv.color.alpha = clamp01(amount)
+				return cv, nil
+			default:
+				r, g, b := cv.color.c.Clamped().RGB255()
+				channel := uint8(math.Round(math.Max(0, math.Min(255, amount))))
+				switch name {
+				case "set_red":
+					r = channel
+				case "set_green":
+					g = channel
+				default:
+					b = channel
+				}
+				cv.color.c = colorful.Color{R: float64(r) / 255, G: float64(g) / 255, B: float64(b) / 255}
+				return cv, nil
+			}
+		case "grayscale":
+			return setHSL(h, 0, l)
+		case "invert":
+			c := cv.color.c.Clamped()
+			cv.color.c = colorful.Color{R: 1 - c.R, G: 1 - c.G, B: 1 - c.B}
+			return cv, nil
+		case "hex_stripped":
+			cv.format = "hex_stripped"
+			return cv, nil
+		}
+	}
+
+	s, err := stringify(v)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "upper_case", "to_upper":
+		return strings.ToUpper(s), nil
+	case "lower_case", "to_lower":
+		return strings.ToLower(s), nil
+	case "hex_stripped":
+		return strings.TrimPrefix(s, "#"), nil
+	case "replace":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("replace expects two arguments")
+		}
+		return strings.ReplaceAll(s, args[0], args[1]), nil
+	default:
+		return nil, fmt.Errorf("unknown filter %q", name)
+	}
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,237 @@
+package matugen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext(t *testing.T, mode ColorMode) *templateContext {
+	t.Helper()
+	scheme, err := GenerateScheme("hex", "#6750a4", "scheme-tonal-spot")
+	require.NoError(t, err)
+	dank16JSON := generateDank16Variants(scheme.Dark["primary"], scheme.Light["primary"], scheme.Dark["surface"], mode)
+	ctx, err := newTemplateContext(scheme, dank16JSON, mode, "/wall.png")
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestRenderTemplateFormats(t *testing.T) {
+	scheme := &Scheme{
+		Dark:  map[string]string{"primary": "#ff8000"},
+		Light: map[string]string{"primary": "#336699"},
+	}
+	ctx, err := newTemplateContext(scheme, "{}", ColorModeLight, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		tmpl, want string
+	}{
+		{"{{colors.primary.default.hex}}", "#336699"},
+		{"{{ colors.primary.dark.hex_stripped }}", "ff8000"},
+		{"{{colors.primary.dark.rgb}}", "rgb(255, 128, 0)"},
+		{"{{colors.primary.dark.rgba}}", "rgba(255, 128, 0, 1)"},
+		{"{{colors.primary.dark.red}},{{colors.primary.dark.green}},{{colors.primary.dark.blue}}", "255,128,0"},
+		{"{{colors.primary.dark.hsl}}", "hsl(30, 100%, 50%)"},
+		{"{{colors.primary.dark.hex | set_alpha: 0.5 | hex}}", ""},
+		{"{{colors.primary.dark.rgba | set_alpha: 0.5}}", "rgba(255, 128, 0, 0.5)"},
+		{"{{colors.primary.dark.hex | lighten: 20}}", "#ffb366"},
+		{"{{colors.primary.dark.hex | darken: 50}}", "#000000"},
+		{"{{colors.primary.dark.hex | grayscale}}", "#808080"},
+		{"{{colors.primary.dark.hex | invert}}", "#007fff"},
+		{"{{colors.primary.dark.hex | hex_stripped | upper_case}}", "FF8000"},
+		{"{{colors.primary.dark.hex | replace: '#', '0x'}}", "0xff8000"},
+		{"{{mode}}", "light"},
+		{"<* if {{ is_dark_mode }} *>dark<* else *>light<* endif *>", "light"},
+		{"<* if {{ mode }} == 'light' *>yes<* endif *>", "yes"},
+		{"<* for name, value in colors *>{{name}}={{value.dark.hex}};<* endfor *>", "primary=#ff8000;"},
+	}
+	for _, tt := range tests {
+		got, err := renderTemplate(tt.tmpl, ctx)
+		if tt.want == "" {
+			assert.Error(t, err, tt.tmpl)
+			continue
+		}
+		require.NoError(t, err, tt.tmpl)
+		assert.Equal(t, tt.want, got, tt.tmpl)
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	ctx := newTestContext(t, ColorModeDark)
+	for _, tmpl := range []string{
+		"{{colors.nope.default.hex}}",
+		"{{colors.primary.medium.hex}}",
+		"{{colors.primary.default.cmyk}}",
+		"{{colors.primary.default}}",
+		"{{colors.primary.default.hex | blur: 2}}",
+		"{{unknown}}",
+		"{{colors.primary.default.hex",
+		"<* if {{ is_dark_mode }} *>open",
+		"<* endfor *>",
+	} {
+		_, err := renderTemplate(tmpl, ctx)
+		assert.Error(t, err, tmpl)
+	}
+}
+
+func TestParseTemplateJobs(t *testing.T) {
+	configs := &templateConfigs{}
+	configs.add("shipped", `[config]
+version_check = false
+
+[templates.kitty]
+input_path = '/in/kitty.conf' # shipped
+output_path = "~/.config/kitty/\"dank\".conf"
+post_hook = 'sh -c "kill -USR1 $(pidof kitty)"'
+
+[templates.other]
+input_path = '/in/other'
+output_path = '/out/other'
+compare_to = [
+  "a",
+  "b",
+]
+`)
+	configs.add("user", `[templates.kitty]
+input_path = '/user/kitty.conf'
+output_path = '/out/kitty.conf'
+post_hook = '''
+pkill -USR1 kitty
+'''
+
+[templates]
+inline = { input_path = '/in/inline', output_path = '/out/inline' }
+`)
+
+	jobs, errs := parseTemplateJobs(configs)
+	require.Empty(t, errs)
+	require.Len(t, jobs, 3)
+	assert.Equal(t, templateJob{Name: "kitty", InputPath: "/user/kitty.conf", OutputPath: "/out/kitty.conf", PostHook: "pkill -USR1 kitty\n"}, jobs[0])
+	assert.Equal(t, "other", jobs[1].Name)
+	assert.Equal(t, templateJob{Name: "inline", InputPath: "/in/inline", OutputPath: "/out/inline"}, jobs[2])
+
+	configs = &templateConfigs{}
+	configs.add("shipped", "[templates.a]\ninput_path = '/in'\noutput_path = \"~/x/\\\"y\\\"\"\npost_hook = 'true'\n")
+	jobs, errs = parseTemplateJobs(configs)
+	require.Empty(t, errs)
+	assert.Equal(t, `~/x/"y"`, jobs[0].OutputPath)
+	assert.Equal(t, "true", jobs[0].PostHook)
+}
+
+func TestParseTemplateJobsIsolatesErrors(t *testing.T) {
+	configs := &templateConfigs{}
+	configs.add("shipped", "[templates.a]\ninput_path = '/in/a'\noutput_path = '/out/a'\n")
+	configs.add("broken", "[templates.b]\ninput_path = '/in\n")
+	configs.add("user", `[templates.c]
+input_path = '/in/c'
+
+[templates.d]
+input_path = '/in/d'
+output_path = 4
+
+[templates.e]
+input_path = '/in/e'
+output_path = '/out/e'
+`)
+
+	jobs, errs := parseTemplateJobs(configs)
+	assert.Len(t, errs, 3)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "a", jobs[0].Name)
+	assert.Equal(t, "e", jobs[1].Name)
+}
+
+func TestCustomColors(t *testing.T) {
+	configs := &templateConfigs{}
+	configs.add("shipped", "[templates.a]\ninput_path = '/in/a'\noutput_path = '/out/a'\n")
+	configs.add("user", `[config.custom_colors]
+green = "#00ff00"
+red = { color = "#ff0000", blend = false }
+bad = { blend = false }
+`)
+
+	colors, errs := parseCustomColors(configs)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, map[string]customColor{
+		"green": {Color: "#00ff00", Blend: true},
+		"red":   {Color: "#ff0000", Blend: false},
+	}, colors)
+
+	scheme, err := GenerateScheme("hex", "#6750a4", "scheme-tonal-spot")
+	require.NoError(t, err)
+	require.NoError(t, scheme.addCustomColors(colors))
+	ctx, err := newTemplateContext(scheme, "{}", ColorModeDark, "")
+	require.NoError(t, err)
+
+	out, err := renderTemplate("{{colors.red_source.default.hex}} {{colors.on_green_container.dark.hex}}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "#ff0000 "+scheme.Dark["on_green_container"], out)
+}
+
+func TestRenderTemplates(t *testing.T) {
+	dir := t.TempDir()
+	ctx := newTestContext(t, ColorModeDark)
+
+	var jobs []templateJob
+	for _, name := range []string{"a", "b", "c"} {
+		in := filepath.Join(dir, name+".in")
+		require.NoError(t, os.WriteFile(in, []byte(name+" {{colors.primary.default.hex}}"), 0o644))
+		jobs = append(jobs, templateJob{
+			Name:       name,
+			InputPath:  in,
+			OutputPath: filepath.Join(dir, "out", name),
+			PostHook:   "touch " + filepath.Join(dir, name+".hook-{{colors.primary.default.hex_stripped}}"),
+		})
+	}
+	jobs = append(jobs, templateJob{Name: "broken", InputPath: filepath.Join(dir, "missing"), OutputPath: filepath.Join(dir, "out", "broken")})
+
+	failed := renderTemplates(jobs, ctx)
+	require.Len(t, failed, 1)
+	assert.Contains(t, failed, "broken")
+
+	primary := ctx.colors["primary"].def
+	for _, name := range []string{"a", "b", "c"} {
+		data, err := os.ReadFile(filepath.Join(dir, "out", name))
+		require.NoError(t, err)
+		assert.Equal(t, name+" "+colorValue{color: primary, format: "hex"}.String(), string(data))
+		assert.FileExists(t, filepath.Join(dir, name+".hook-"+colorValue{color: primary, format: "hex_stripped"}.String()))
+	}
+}
+
+// The shipped templates must all render, and dank.json must produce the
+// dms-colors.json layout the shell reads.
+func TestRenderShippedTemplates(t *testing.T) {
+	templatesDir := filepath.Join("..", "..", "..", "quickshell", "matugen", "templates")
+	entries, err := os.ReadDir(templatesDir)
+	if err != nil {
+		t.Skip("shell templates not available")
+	}
+
+	ctx := newTestContext(t, ColorModeDark)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(templatesDir, entry.Name()))
+		require.NoError(t, err)
+		out, err := renderTemplate(string(data), ctx)
+		require.NoError(t, err, entry.Name())
+		assert.NotContains(t, out, "{{", entry.Name())
+
+		if entry.Name() != "dank.json" {
+			continue
+		}
+		var colors ColorsOutput
+		require.NoError(t, json.NewDecoder(strings.NewReader(out)).Decode(&colors))
+		assert.Equal(t, colorValue{color: ctx.colors["background"].dark}.String(), colors.Colors.Dark["surface"])
+		assert.Equal(t, colorValue{color: ctx.colors["primary"].light}.String(), colors.Colors.Light["primary"])
+		require.Len(t, colors.Dank16, 16)
+		assert.Regexp(t, `^#[0-9a-f]{6}$`, colors.Dank16["color4"]["dark"])
+	}
+}
@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Backend is a long-running process a plugin declares in its plugin.json,
+// either as a command or an object:
+//
+//	"backend": "./bin/weather-daemon"
+//	"backend": {"exec": "python3", "args": ["backend.py"], "env": {"INTERVAL": "60"}}
+//
+// The DMS server runs it in the plugin directory while the plugin is
+// loaded. It speaks line-delimited JSON on stdin and stdout.
+type Backend struct {
+	Exec string            `json:"exec"`
+	Args []string          `json:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+func (b *Backend) UnmarshalJSON(data []byte) error {
+	var exec string
+	if err := json.Unmarshal(data, &exec); err == nil {
+		*b = Backend{Exec: exec}
+		return nil
+	}
+	type backend Backend
+	return json.Unmarshal(data, (*backend)(b))
+}
+
+// Command resolves the backend's executable for a plugin in dir. Paths
+// are relative to the plugin directory and may not leave it; bare names
+// are looked up in PATH when the backend is started.
+func (b *Backend) Command(dir string) (string, error) {
+	exec := strings.TrimSpace(b.Exec)
+	switch {
+	case exec == "":
+		return "", fmt.Errorf("backend has no exec")
+	case filepath.IsAbs(exec):
+		return "", fmt.Errorf("backend exec must be relative to the plugin directory: %s", exec)
+	case !strings.ContainsRune(exec, '/'):
+		return exec, nil
+	}
+
+	path := filepath.Join(dir, exec)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("backend exec is outside the plugin directory: %s", exec)
+	}
+	return path, nil
+}
+
+// Backend returns the backend an installed plugin declares and the plugin
+// directory, or a nil backend if it has none.
+func (m *Manager) Backend(id string) (*Backend, string, error) {
+	path, err := m.findInstalledPath(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if path == "" {
+		return nil, "", fmt.Errorf("plugin not installed: %s", id)
+	}
+	manifest := m.getPluginManifest(path)
+	if manifest == nil {
+		return nil, path, nil
+	}
+	return manifest.Backend, path, nil
+}
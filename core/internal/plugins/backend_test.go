@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendUnmarshal(t *testing.T) {
+	var manifest pluginManifest
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "weather", "backend": "./bin/daemon"}`), &manifest))
+	require.NotNil(t, manifest.Backend)
+	assert.Equal(t, "./bin/daemon", manifest.Backend.Exec)
+
+	manifest = pluginManifest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "weather", "backend": {"exec": "python3", "args": ["backend.py"], "env": {"INTERVAL": "60"}}}`), &manifest))
+	require.NotNil(t, manifest.Backend)
+	assert.Equal(t, "python3", manifest.Backend.Exec)
+	assert.Equal(t, []string{"backend.py"}, manifest.Backend.Args)
+	assert.Equal(t, map[string]string{"INTERVAL": "60"}, manifest.Backend.Env)
+
+	manifest = pluginManifest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "weather"}`), &manifest))
+	assert.Nil(t, manifest.Backend)
+}
+
+func TestBackendCommand(t *testing.T) {
+	dir := "/plugins/weather"
+	tests := []struct {
+		exec    string
+		want    string
+		wantErr bool
+	}{
+		{"python3", "python3", false},
+		{"./bin/daemon", "/plugins/weather/bin/daemon", false},
+		{"bin/daemon", "/plugins/weather/bin/daemon", false},
+		{"", "", true},
+		{"/usr/bin/python3", "", true},
+		{"../other/daemon", "", true},
+		{"bin/../../other/daemon", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exec, func(t *testing.T) {
+			got, err := (&Backend{Exec: tt.exec}).Command(dir)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
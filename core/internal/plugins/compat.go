@@ -0,0 +1,284 @@
+package plugins
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/version"
+)
+
+// Environment is what a plugin's compatibility metadata is checked
+// against. Empty fields are unknown and not checked.
+type Environment struct {
+	DMSVersion string
+	Compositor string
+	Distro     string
+	DistroLike []string
+}
+
+var (
+	versionPattern = regexp.MustCompile(`v?(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+	// Release versions have at least one dot, unlike "master@1a2b3c4".
+	releasePattern = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?`)
+)
+
+var dmsVersionFiles = []string{
+	"/usr/share/quickshell/dms/VERSION",
+	"/usr/local/share/quickshell/dms/VERSION",
+	"/etc/xdg/quickshell/dms/VERSION",
+}
+
+// DetectEnvironment looks up the running DMS version, compositor and
+// distribution. It is not cached, so checks follow DMS updates.
+func DetectEnvironment() Environment {
+	env := Environment{
+		DMSVersion: detectDMSVersion(),
+		Compositor: detectCompositor(),
+	}
+	env.Distro, env.DistroLike = readOSRelease("/etc/os-release")
+	return env
+}
+
+func detectDMSVersion() string {
+	if current, err := version.GetCurrentDMSVersion(); err == nil {
+		if v := releasePattern.FindString(current); v != "" {
+			return v
+		}
+	}
+	for _, path := range dmsVersionFiles {
+		if data, err := os.ReadFile(path); err == nil {
+			if v := releasePattern.FindString(string(data)); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// compositorIDs maps XDG_CURRENT_DESKTOP entries to the compositor IDs
+// the shell and registry compositors lists use.
+var compositorIDs = map[string]string{
+	"hyprland": "hyprland",
+	"niri":     "niri",
+	"sway":     "sway",
+	"scroll":   "scroll",
+	"labwc":    "labwc",
+	"dwl":      "dwl",
+	"mango":    "dwl",
+	"mangowc":  "dwl",
+}
+
+func detectCompositor() string {
+	switch {
+	case os.Getenv("NIRI_SOCKET") != "":
+		return "niri"
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		return "hyprland"
+	case os.Getenv("SCROLLSOCK") != "":
+		return "scroll"
+	case os.Getenv("SWAYSOCK") != "":
+		return "sway"
+	case os.Getenv("LABWC_PID") != "":
+		return "labwc"
+	}
+	return compositorFromDesktop(os.Getenv("XDG_CURRENT_DESKTOP"))
+}
+
+// compositorFromDesktop returns the compositor ID of the first desktop in
+// a colon-separated XDG_CURRENT_DESKTOP, or "" when none is known, so the
+// compositor isn't checked.
+func compositorFromDesktop(desktop string) string {
+	for _, name := range strings.Split(desktop, ":") {
+		if id, ok := compositorIDs[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+func readOSRelease(path string) (string, []string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	var id string
+	var like []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			id = value
+		case "ID_LIKE":
+			like = strings.Fields(value)
+		}
+	}
+	return id, like
+}
+
+// CheckCompatibility returns why a plugin can't run in env, or nothing if
+// it can. requires_dms is a semver range; compositors and distro list what
+// the plugin supports, with an empty list or "any" meaning all.
+func CheckCompatibility(plugin Plugin, env Environment) []string {
+	var reasons []string
+
+	if plugin.RequiresDMS != "" && env.DMSVersion != "" {
+		ok, err := SatisfiesVersion(env.DMSVersion, plugin.RequiresDMS)
+		switch {
+		case err != nil:
+			reasons = append(reasons, fmt.Sprintf("invalid requires_dms %q: %v", plugin.RequiresDMS, err))
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("requires DMS %s, running %s", plugin.RequiresDMS, env.DMSVersion))
+		}
+	}
+
+	if env.Compositor != "" && !listAllows(plugin.Compositors, env.Compositor) {
+		reasons = append(reasons, fmt.Sprintf("supports %s, not %s", strings.Join(plugin.Compositors, ", "), env.Compositor))
+	}
+
+	if env.Distro != "" && !listAllows(plugin.Distro, append([]string{env.Distro}, env.DistroLike...)...) {
+		reasons = append(reasons, fmt.Sprintf("supports distributions %s, not %s", strings.Join(plugin.Distro, ", "), env.Distro))
+	}
+
+	return reasons
+}
+
+// CheckInstalled checks an installed plugin against env, preferring the
+// metadata in its plugin.json over the registry entry, which may be nil.
+func (m *Manager) CheckInstalled(id string, registered *Plugin, env Environment) []string {
+	plugin := Plugin{ID: id}
+	if registered != nil {
+		plugin = *registered
+	}
+	if path, _ := m.findInstalledPath(id); path != "" {
+		if manifest := m.getPluginManifest(path); manifest != nil {
+			if manifest.RequiresDMS != "" {
+				plugin.RequiresDMS = manifest.RequiresDMS
+			}
+			if manifest.Compositors != nil {
+				plugin.Compositors = manifest.Compositors
+			}
+			if manifest.Distro != nil {
+				plugin.Distro = manifest.Distro
+			}
+		}
+	}
+	return CheckCompatibility(plugin, env)
+}
+
+func listAllows(list []string, values ...string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(list, func(entry string) bool {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "any" || entry == "all" || entry == "*" {
+			return true
+		}
+		return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, entry) })
+	})
+}
+
+// SatisfiesVersion reports whether version is in the semver range
+// constraint. Alternatives are separated by "||" and hold comparators
+// (>=, >, <=, <, =, ^, ~) separated by spaces or commas, which must all
+// hold. A bare version means >=, as it always has for requires_dms.
+func SatisfiesVersion(version, constraint string) (bool, error) {
+	current, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	for alternative := range strings.SplitSeq(constraint, "||") {
+		comparators := strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(comparators) == 0 {
+			return false, fmt.Errorf("empty range")
+		}
+		ok := true
+		for _, comparator := range comparators {
+			match, err := matchComparator(current, comparator)
+			if err != nil {
+				return false, err
+			}
+			ok = ok && match
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchComparator(current [3]int, comparator string) (bool, error) {
+	op := comparator[:len(comparator)-len(strings.TrimLeft(comparator, "<>=^~"))]
+	want, err := parseVersion(comparator[len(op):])
+	if err != nil {
+		return false, err
+	}
+	cmp := compareParsed(current, want)
+
+	switch op {
+	case ">=", "":
+		return cmp >= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "=", "==":
+		return cmp == 0, nil
+	case "^":
+		upper := [3]int{want[0] + 1, 0, 0}
+		if want[0] == 0 {
+			upper = [3]int{0, want[1] + 1, 0}
+		}
+		return cmp >= 0 && compareParsed(current, upper) < 0, nil
+	case "~":
+		return cmp >= 0 && compareParsed(current, [3]int{want[0], want[1] + 1, 0}) < 0, nil
+	default:
+		return false, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+func parseVersion(v string) ([3]int, error) {
+	var parsed [3]int
+	v = strings.TrimSpace(v)
+	m := versionPattern.FindStringSubmatch(v)
+	if m == nil || !strings.HasPrefix(v, m[0]) {
+		return parsed, fmt.Errorf("invalid version %q", v)
+	}
+	// Pre-release and build suffixes are ignored.
+	if rest := v[len(m[0]):]; rest != "" && rest[0] != '-' && rest[0] != '+' {
+		return parsed, fmt.Errorf("invalid version %q", v)
+	}
+	for i, part := range m[1:] {
+		if part != "" {
+			parsed[i], _ = strconv.Atoi(part)
+		}
+	}
+	return parsed, nil
+}
+
+func compareParsed(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
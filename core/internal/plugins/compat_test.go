@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSatisfiesVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"0.6.2", ">=0.1.18", true},
+		{"0.6.2", "0.7", false},
+		{"0.6.2", ">0.6.2", false},
+		{"0.6.2", ">=0.5 <0.7", true},
+		{"0.7.0", ">=0.5, <0.7", false},
+		{"1.2.0", "<1.0 || >=1.2", true},
+		{"0.6.9", "^0.6.1", true},
+		{"0.7.0", "^0.6.1", false},
+		{"1.9.0", "^1.2", true},
+		{"1.3.0", "~1.2.4", false},
+		{"1.2.9", "~1.2.4", true},
+		{"1.0.2", "=v1.0.2", true},
+		{"1.0.2-rc1", ">=1.0.2", true},
+	}
+	for _, tt := range tests {
+		got, err := SatisfiesVersion(tt.version, tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		assert.Equal(t, tt.want, got, "%s in %s", tt.version, tt.constraint)
+	}
+
+	_, err := SatisfiesVersion("0.6.2", ">=banana")
+	assert.Error(t, err)
+	_, err = SatisfiesVersion("0.6.2", "!0.5")
+	assert.Error(t, err)
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	env := Environment{DMSVersion: "0.6.2", Compositor: "hyprland", Distro: "cachyos", DistroLike: []string{"arch"}}
+
+	assert.Empty(t, CheckCompatibility(Plugin{ID: "any"}, env))
+	assert.Empty(t, CheckCompatibility(Plugin{ID: "ok", RequiresDMS: ">=0.6", Compositors: []string{"Hyprland", "niri"}, Distro: []string{"arch"}}, env))
+	assert.Empty(t, CheckCompatibility(Plugin{ID: "all", Compositors: []string{"any"}}, env))
+
+	reasons := CheckCompatibility(Plugin{ID: "niri", RequiresDMS: ">=0.7", Compositors: []string{"niri"}, Distro: []string{"fedora"}}, env)
+	require.Len(t, reasons, 3)
+	assert.Contains(t, reasons[0], "requires DMS >=0.7")
+	assert.Contains(t, reasons[1], "not hyprland")
+	assert.Contains(t, reasons[2], "not cachyos")
+
+	// Unknown parts of the environment aren't held against a plugin.
+	assert.Empty(t, CheckCompatibility(Plugin{ID: "niri", RequiresDMS: ">=0.7", Compositors: []string{"niri"}}, Environment{}))
+}
+
+func TestCheckInstalledPrefersManifest(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "clock"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "clock", "plugin.json"), []byte(`{"id": "clock", "compositors": ["niri"]}`), 0o644))
+
+	env := Environment{Compositor: "sway"}
+	registered := &Plugin{ID: "clock", Compositors: []string{"sway"}, RequiresDMS: ">=9"}
+	reasons := manager.CheckInstalled("clock", registered, env)
+	assert.Equal(t, []string{"supports niri, not sway"}, reasons)
+}
+
+func TestCompositorFromDesktop(t *testing.T) {
+	assert.Equal(t, "hyprland", compositorFromDesktop("Hyprland"))
+	assert.Equal(t, "niri", compositorFromDesktop("niri:GNOME"))
+	assert.Equal(t, "niri", compositorFromDesktop("GNOME:niri"))
+	assert.Equal(t, "dwl", compositorFromDesktop("mango:wlroots"))
+	assert.Empty(t, compositorFromDesktop("GNOME"))
+	assert.Empty(t, compositorFromDesktop(""))
+}
+
+func TestReadOSRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	require.NoError(t, os.WriteFile(path, []byte("NAME=\"CachyOS\"\nID=cachyos\nID_LIKE=\"arch\"\n"), 0o644))
+	id, like := readOSRelease(path)
+	assert.Equal(t, "cachyos", id)
+	assert.Equal(t, []string{"arch"}, like)
+}
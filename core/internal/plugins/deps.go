@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// InstallPlan is what installing a plugin takes: the plugins to install,
+// dependencies before the plugins that need them and the target last.
+type InstallPlan struct {
+	Target  Plugin
+	Install []Plugin
+}
+
+// Dependencies returns the plugins the plan installs besides the target.
+func (p *InstallPlan) Dependencies() []Plugin {
+	return p.Install[:len(p.Install)-1]
+}
+
+func (p *InstallPlan) IDs() []string {
+	ids := make([]string, len(p.Install))
+	for i, plugin := range p.Install {
+		ids[i] = plugin.ID
+	}
+	return ids
+}
+
+// PlanInstall resolves the dependencies of target against the registry,
+// skipping those already installed. It fails on dependencies no registry
+// offers and on cycles.
+func PlanInstall(target Plugin, available []Plugin, installed []string) (*InstallPlan, error) {
+	byID := make(map[string]Plugin, len(available))
+	for _, p := range available {
+		byID[p.ID] = p
+	}
+
+	plan := &InstallPlan{Target: target}
+	done := make(map[string]bool)
+	var path []string
+
+	var visit func(p Plugin) error
+	visit = func(p Plugin) error {
+		if i := slices.Index(path, p.ID); i >= 0 {
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path[i:], p.ID), " -> "))
+		}
+		if done[p.ID] {
+			return nil
+		}
+		path = append(path, p.ID)
+		for _, id := range dependencyIDs(p.Dependencies) {
+			if slices.Contains(installed, id) && id != target.ID {
+				continue
+			}
+			dep, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("%s depends on %s, which is not in any registry", p.ID, id)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		done[p.ID] = true
+		plan.Install = append(plan.Install, p)
+		return nil
+	}
+
+	if err := visit(target); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanUninstall returns the installed plugins that depend on id, directly
+// or through others, in the order they have to go: dependents before the
+// plugins they need. deps maps installed plugin IDs to their dependencies.
+func PlanUninstall(id string, deps map[string][]string) []string {
+	var order []string
+	seen := map[string]bool{id: true}
+
+	var visit func(id string)
+	visit = func(id string) {
+		var dependents []string
+		for other, otherDeps := range deps {
+			if !seen[other] && slices.Contains(otherDeps, id) {
+				dependents = append(dependents, other)
+			}
+		}
+		slices.Sort(dependents)
+		for _, dependent := range dependents {
+			if seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			visit(dependent)
+			order = append(order, dependent)
+		}
+	}
+	visit(id)
+	return order
+}
+
+// InstalledDependencies maps every installed plugin to what it depends on,
+// from its plugin.json or, when that doesn't say, the registry.
+func (m *Manager) InstalledDependencies(available []Plugin) (map[string][]string, error) {
+	installed, err := m.ListInstalled()
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string][]string, len(installed))
+	for _, id := range installed {
+		if path, _ := m.findInstalledPath(id); path != "" {
+			if manifest := m.getPluginManifest(path); manifest != nil && manifest.Dependencies != nil {
+				deps[id] = dependencyIDs(manifest.Dependencies)
+				continue
+			}
+		}
+		if p := FindByIDOrName(id, available); p != nil {
+			deps[id] = dependencyIDs(p.Dependencies)
+		}
+	}
+	return deps, nil
+}
+
+// InstallPlan installs the plugins of a plan in order. If one fails, the
+// ones installed before it are removed again.
+func (m *Manager) InstallPlan(plan *InstallPlan) error {
+	for i, p := range plan.Install {
+		if err := m.Install(p); err != nil {
+			for _, done := range slices.Backward(plan.Install[:i]) {
+				if uerr := m.Uninstall(done); uerr != nil {
+					return fmt.Errorf("failed to install %s: %w (and failed to roll back %s: %v)", p.ID, err, done.ID, uerr)
+				}
+			}
+			return fmt.Errorf("failed to install %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// UninstallID uninstalls an installed plugin, using its registry entry
+// when there is one so shared repositories are cleaned up.
+func (m *Manager) UninstallID(id string, available []Plugin) error {
+	if p := FindByIDOrName(id, available); p != nil {
+		return m.Uninstall(*p)
+	}
+	return m.UninstallByIDOrName(id)
+}
+
+// InstalledID returns the ID of the installed plugin with ID or name
+// idOrName, or "" if there is none.
+func (m *Manager) InstalledID(idOrName string) (string, error) {
+	path, err := m.findInstalledPathByIDOrName(idOrName)
+	if err != nil || path == "" {
+		return "", err
+	}
+	if id := m.getPluginID(path); id != "" {
+		return id, nil
+	}
+	return filepath.Base(path), nil
+}
+
+// UninstallAll uninstalls plugins in order, usually the dependents of a
+// plugin followed by the plugin itself. All of them are checked first, so
+// one that isn't installed or can't be removed leaves the others alone.
+func (m *Manager) UninstallAll(ids []string, available []Plugin) error {
+	for _, id := range ids {
+		path, err := m.findInstalledPathByIDOrName(id)
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to find plugin %s: %w", id, err)
+		case path == "":
+			return fmt.Errorf("plugin not installed: %s", id)
+		case strings.HasPrefix(path, "/etc/xdg/quickshell/dms-plugins"):
+			return fmt.Errorf("cannot uninstall system plugin: %s", id)
+		}
+	}
+	for _, id := range ids {
+		if err := m.UninstallID(id, available); err != nil {
+			return fmt.Errorf("failed to uninstall %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func dependencyIDs(deps []string) []string {
+	ids := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if dep = strings.TrimSpace(dep); dep != "" {
+			ids = append(ids, dep)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,104 @@
+package plugins
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanInstall(t *testing.T) {
+	available := []Plugin{
+		{ID: "app", Dependencies: []string{"ui", "data"}},
+		{ID: "ui", Dependencies: []string{"core"}},
+		{ID: "data", Dependencies: []string{"core"}},
+		{ID: "core"},
+		{ID: "broken", Dependencies: []string{"nowhere"}},
+		{ID: "a", Dependencies: []string{"b"}},
+		{ID: "b", Dependencies: []string{"c"}},
+		{ID: "c", Dependencies: []string{"a"}},
+	}
+
+	plan, err := PlanInstall(available[0], available, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"core", "ui", "data", "app"}, plan.IDs())
+	assert.Len(t, plan.Dependencies(), 3)
+
+	plan, err = PlanInstall(available[0], available, []string{"core", "data"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ui", "app"}, plan.IDs())
+
+	_, err = PlanInstall(available[4], available, nil)
+	assert.ErrorContains(t, err, "nowhere")
+
+	_, err = PlanInstall(available[5], available, nil)
+	assert.ErrorContains(t, err, "a -> b -> c -> a")
+}
+
+func TestPlanUninstall(t *testing.T) {
+	deps := map[string][]string{
+		"app":  {"ui", "data"},
+		"ui":   {"core"},
+		"data": {"core"},
+		"core": nil,
+		"solo": nil,
+	}
+	assert.Equal(t, []string{"app", "data", "ui"}, PlanUninstall("core", deps))
+	assert.Equal(t, []string{"app"}, PlanUninstall("ui", deps))
+	assert.Empty(t, PlanUninstall("solo", deps))
+}
+
+func TestInstallPlanRollsBack(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			if url == "fail" {
+				return errors.New("clone failed")
+			}
+			if err := fs.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+			return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(`{"id": "`+filepath.Base(path)+`"}`), 0o644)
+		},
+	}
+
+	plan := &InstallPlan{Install: []Plugin{{ID: "core", Repo: "ok"}, {ID: "app", Repo: "fail"}}}
+	assert.Error(t, manager.InstallPlan(plan))
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, "core"))
+	assert.False(t, exists)
+
+	plan.Install[1].Repo = "ok"
+	require.NoError(t, manager.InstallPlan(plan))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "app", "plugin.json"), []byte(`{"id": "app", "dependencies": ["core"]}`), 0o644))
+
+	deps, err := manager.InstalledDependencies(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app"}, PlanUninstall("core", deps))
+}
+
+func TestUninstallAllChecksEveryPluginFirst(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	for _, id := range []string{"core", "app"} {
+		require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, id), 0o755))
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, id, "plugin.json"), []byte(`{"id": "`+id+`", "name": "`+id+` plugin"}`), 0o644))
+	}
+
+	id, err := manager.InstalledID("core plugin")
+	require.NoError(t, err)
+	assert.Equal(t, "core", id)
+	id, err = manager.InstalledID("missing")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	assert.ErrorContains(t, manager.UninstallAll([]string{"app", "missing"}, nil), "plugin not installed: missing")
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, "app"))
+	assert.True(t, exists, "nothing is removed when one plugin can't be")
+
+	require.NoError(t, manager.UninstallAll([]string{"app", "core"}, nil))
+	installed, err := manager.ListInstalled()
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+}
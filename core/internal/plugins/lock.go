@@ -0,0 +1,271 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
+	"github.com/spf13/afero"
+)
+
+const lockVersion = 1
+
+// LockEntry records where an installed plugin came from and the commit it
+// is at, so the same plugins can be restored elsewhere with --locked.
+type LockEntry struct {
+	Repo   string `json:"repo"`
+	Path   string `json:"path,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Pinned plugins are left at Commit by updates.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+type Lockfile struct {
+	Version int                  `json:"version"`
+	Plugins map[string]LockEntry `json:"plugins"`
+}
+
+func (m *Manager) LockPath() string {
+	return filepath.Join(filepath.Dir(m.pluginsDir), "plugins.lock.json")
+}
+
+// ReadLock reads the lockfile; a missing one is empty.
+func (m *Manager) ReadLock() (*Lockfile, error) {
+	lock := &Lockfile{Version: lockVersion, Plugins: map[string]LockEntry{}}
+	data, err := afero.ReadFile(m.fs, m.LockPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return lock, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	if lock.Plugins == nil {
+		lock.Plugins = map[string]LockEntry{}
+	}
+	return lock, nil
+}
+
+func (m *Manager) writeLock(lock *Lockfile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.LockPath()), 0o755); err != nil {
+		return err
+	}
+	return afero.WriteFile(m.fs, m.LockPath(), append(data, '\n'), 0o644)
+}
+
+func (m *Manager) updateLock(update func(*Lockfile)) {
+	lock, err := m.ReadLock()
+	if err == nil {
+		update(lock)
+		err = m.writeLock(lock)
+	}
+	if err != nil {
+		log.Warnf("Failed to update %s: %v", m.LockPath(), err)
+	}
+}
+
+// recordLock stores the commit an installed plugin is at, keeping its pin.
+func (m *Manager) recordLock(plugin Plugin) {
+	commit, err := m.Commit(plugin)
+	if err != nil {
+		log.Warnf("Failed to read commit of %s: %v", plugin.ID, err)
+	}
+	m.updateLock(func(lock *Lockfile) {
+		lock.Plugins[plugin.ID] = LockEntry{
+			Repo:   plugin.Repo,
+			Path:   plugin.Path,
+			Commit: commit,
+			Source: plugin.Source,
+			Pinned: lock.Plugins[plugin.ID].Pinned,
+		}
+	})
+}
+
+func (m *Manager) forgetLock(id string) {
+	m.updateLock(func(lock *Lockfile) { delete(lock.Plugins, id) })
+}
+
+// repoPath is the git checkout an installed plugin lives in: its own
+// clone, or the shared clone of a monorepo.
+func (m *Manager) repoPath(plugin Plugin) (string, error) {
+	pluginPath, err := m.findInstalledPath(plugin.ID)
+	if err != nil {
+		return "", err
+	}
+	if pluginPath == "" {
+		return "", fmt.Errorf("plugin not installed: %s", plugin.ID)
+	}
+	if exists, _ := afero.Exists(m.fs, pluginPath+".meta"); exists {
+		return m.monorepoDir(pluginPath, plugin), nil
+	}
+	return pluginPath, nil
+}
+
+// Commit returns the commit an installed plugin is at. Plugins copied from
+// a local registry have none.
+func (m *Manager) Commit(plugin Plugin) (string, error) {
+	if _, ok := registries.LocalPath(plugin.Repo); ok {
+		return "", nil
+	}
+	path, err := m.repoPath(plugin)
+	if err != nil {
+		return "", err
+	}
+	return m.gitClient.Head(path)
+}
+
+// PendingCommits lists what updating a plugin would bring in: the commit
+// it would move to and the commits in between, newest first. That is the
+// commit the registry pins, if it does, like the update itself.
+func (m *Manager) PendingCommits(plugin Plugin) (string, []Commit, error) {
+	if _, ok := registries.LocalPath(plugin.Repo); ok {
+		return "", nil, nil
+	}
+	path, err := m.repoPath(plugin)
+	if err != nil {
+		return "", nil, err
+	}
+	return m.gitClient.PendingCommits(path, plugin.Commit)
+}
+
+// pinned returns the lock entry of id if it is pinned.
+func (m *Manager) pinned(id string) (LockEntry, bool) {
+	lock, err := m.ReadLock()
+	if err != nil {
+		return LockEntry{}, false
+	}
+	entry, ok := lock.Plugins[id]
+	return entry, ok && entry.Pinned
+}
+
+// Pin keeps a plugin at its current commit until unpinned.
+func (m *Manager) Pin(id string) (LockEntry, error) {
+	return m.setPinned(id, true)
+}
+
+func (m *Manager) Unpin(id string) (LockEntry, error) {
+	return m.setPinned(id, false)
+}
+
+func (m *Manager) setPinned(id string, pinned bool) (LockEntry, error) {
+	lock, err := m.ReadLock()
+	if err != nil {
+		return LockEntry{}, err
+	}
+	entry, ok := lock.Plugins[id]
+	if !ok {
+		return LockEntry{}, fmt.Errorf("plugin not in lockfile: %s", id)
+	}
+	if pinned && entry.Commit == "" {
+		return LockEntry{}, fmt.Errorf("plugin %s has no commit to pin", id)
+	}
+	if installed, _ := m.IsInstalled(Plugin{ID: id}); pinned && installed {
+		if err := m.checkOwnCheckout(Plugin{ID: id, Repo: entry.Repo}); err != nil {
+			return LockEntry{}, err
+		}
+	}
+	entry.Pinned = pinned
+	lock.Plugins[id] = entry
+	return entry, m.writeLock(lock)
+}
+
+// InstallLocked installs the plugins in the lockfile that are missing and
+// moves every git plugin to its locked commit. The lockfile is left as it
+// was, so it can be committed to dotfiles and restored anywhere.
+func (m *Manager) InstallLocked() ([]string, error) {
+	lock, err := m.ReadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(lock.Plugins))
+	for id := range lock.Plugins {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var restored []string
+	var errs []error
+	for _, id := range ids {
+		entry := lock.Plugins[id]
+		plugin := Plugin{ID: id, Name: id, Repo: entry.Repo, Path: entry.Path, Source: entry.Source}
+		if err := m.restoreLocked(plugin, entry); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		restored = append(restored, id)
+	}
+
+	if err := m.writeLock(lock); err != nil {
+		errs = append(errs, fmt.Errorf("failed to write lockfile: %w", err))
+	}
+	return restored, errors.Join(errs...)
+}
+
+func (m *Manager) restoreLocked(plugin Plugin, entry LockEntry) error {
+	installed, err := m.IsInstalled(plugin)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		// The locked commit is checked out before the plugin is activated,
+		// so the shell never loads another version.
+		return m.install(plugin, entry.Commit)
+	}
+	if entry.Commit == "" {
+		return nil
+	}
+
+	current, err := m.Commit(plugin)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(current, entry.Commit) {
+		return nil
+	}
+	if err := m.checkOwnCheckout(plugin); err != nil {
+		return err
+	}
+	path, err := m.repoPath(plugin)
+	if err != nil {
+		return err
+	}
+	if err := m.gitClient.Checkout(path, entry.Commit); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", ShortCommit(entry.Commit), err)
+	}
+	return nil
+}
+
+// checkOwnCheckout refuses to hold a plugin at a commit when it shares its
+// checkout with other plugins, which would be moved along with it.
+func (m *Manager) checkOwnCheckout(plugin Plugin) error {
+	path, err := m.repoPath(plugin)
+	if err != nil {
+		return err
+	}
+	if shared := m.sharedCheckout(path, plugin.ID); len(shared) > 0 {
+		return fmt.Errorf("plugin %s shares its checkout with %s; reinstall it to give it its own", plugin.ID, strings.Join(shared, ", "))
+	}
+	return nil
+}
+
+// ShortCommit abbreviates a commit hash for display.
+func ShortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
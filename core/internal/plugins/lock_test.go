@@ -0,0 +1,243 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfile(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	// Plugins are cloned into a staging directory and then moved, so
+	// commits are tracked by plugin directory name.
+	heads := map[string]string{}
+	latest := "1111111111111111111111111111111111111111"
+	var checkedOut []string
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			heads[filepath.Base(path)] = latest
+			if err := fs.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+			return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(`{"id": "`+filepath.Base(path)+`"}`), 0o644)
+		},
+		headFunc: func(path string) (string, error) { return heads[filepath.Base(path)], nil },
+		checkoutFunc: func(path string, commit string) error {
+			checkedOut = append(checkedOut, filepath.Base(path)+"@"+commit)
+			heads[filepath.Base(path)] = commit
+			return nil
+		},
+	}
+
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "https://example.com/clock"}
+	require.NoError(t, manager.Install(plugin))
+	lock, err := manager.ReadLock()
+	require.NoError(t, err)
+	assert.Equal(t, "1111111111111111111111111111111111111111", lock.Plugins["clock"].Commit)
+	assert.Equal(t, plugin.Repo, lock.Plugins["clock"].Repo)
+
+	entry, err := manager.Pin("clock")
+	require.NoError(t, err)
+	assert.True(t, entry.Pinned)
+	assert.ErrorContains(t, manager.Update(plugin), "pinned at 1111111")
+	hasUpdates, err := manager.HasUpdates("clock", plugin)
+	require.NoError(t, err)
+	assert.False(t, hasUpdates)
+
+	_, err = manager.Unpin("clock")
+	require.NoError(t, err)
+	latest = "2222222222222222222222222222222222222222"
+	require.NoError(t, manager.Update(plugin))
+	lock, err = manager.ReadLock()
+	require.NoError(t, err)
+	assert.Equal(t, "2222222222222222222222222222222222222222", lock.Plugins["clock"].Commit)
+
+	// Restoring from the lockfile reinstalls missing plugins at their
+	// locked commit and leaves the lockfile untouched.
+	lock.Plugins["clock"] = LockEntry{Repo: plugin.Repo, Commit: "3333333333333333333333333333333333333333", Pinned: true}
+	require.NoError(t, manager.writeLock(lock))
+	require.NoError(t, fs.RemoveAll(filepath.Join(pluginsDir, "clock")))
+
+	restored, err := manager.InstallLocked()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clock"}, restored)
+	assert.Equal(t, []string{"clock@3333333333333333333333333333333333333333"}, checkedOut)
+	after, err := manager.ReadLock()
+	require.NoError(t, err)
+	assert.Equal(t, lock.Plugins, after.Plugins)
+
+	require.NoError(t, manager.Uninstall(plugin))
+	lock, err = manager.ReadLock()
+	require.NoError(t, err)
+	assert.Empty(t, lock.Plugins)
+}
+
+func TestLockedMonorepoPlugins(t *testing.T) {
+	// Monorepo plugins are symlinked, which MemMapFs can't do.
+	fs := afero.NewOsFs()
+	pluginsDir := filepath.Join(t.TempDir(), "plugins")
+	manager := &Manager{fs: fs, pluginsDir: pluginsDir}
+	heads := map[string]string{}
+	latest := "1111111111111111111111111111111111111111"
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			heads[path] = latest
+			for _, id := range []string{"clock", "weather"} {
+				if err := fs.MkdirAll(filepath.Join(path, id), 0o755); err != nil {
+					return err
+				}
+				if err := afero.WriteFile(fs, filepath.Join(path, id, "plugin.json"), []byte(`{"id": "`+id+`"}`), 0o644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		headFunc: func(path string) (string, error) {
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				path = resolved
+			}
+			return heads[path], nil
+		},
+		checkoutFunc: func(path string, commit string) error {
+			heads[path] = commit
+			return nil
+		},
+	}
+	// Checkouts move from staging into place, so heads follow renames.
+	manager.fs = &renameTracker{Fs: fs, heads: heads}
+
+	repo := "https://example.com/plugins"
+	clock := Plugin{ID: "clock", Name: "Clock", Repo: repo, Path: "clock"}
+	weather := Plugin{ID: "weather", Name: "Weather", Repo: repo, Path: "weather"}
+	require.NoError(t, manager.Install(clock))
+	require.NoError(t, manager.Install(weather))
+
+	clockRepo, err := manager.repoPath(clock)
+	require.NoError(t, err)
+	weatherRepo, err := manager.repoPath(weather)
+	require.NoError(t, err)
+	assert.NotEqual(t, clockRepo, weatherRepo)
+
+	// Updating a sibling leaves a pinned plugin where it was.
+	_, err = manager.Pin("clock")
+	require.NoError(t, err)
+	latest = "2222222222222222222222222222222222222222"
+	require.NoError(t, manager.Update(weather))
+	commit, err := manager.Commit(clock)
+	require.NoError(t, err)
+	assert.Equal(t, "1111111111111111111111111111111111111111", commit)
+	commit, err = manager.Commit(weather)
+	require.NoError(t, err)
+	assert.Equal(t, "2222222222222222222222222222222222222222", commit)
+
+	// Conflicting locked commits each get their checkout.
+	require.NoError(t, manager.Uninstall(weather))
+	lock, err := manager.ReadLock()
+	require.NoError(t, err)
+	lock.Plugins["clock"] = LockEntry{Repo: repo, Path: "clock", Commit: "3333333333333333333333333333333333333333"}
+	lock.Plugins["weather"] = LockEntry{Repo: repo, Path: "weather", Commit: "4444444444444444444444444444444444444444"}
+	require.NoError(t, manager.writeLock(lock))
+	_, err = manager.InstallLocked()
+	require.NoError(t, err)
+	commit, err = manager.Commit(clock)
+	require.NoError(t, err)
+	assert.Equal(t, "3333333333333333333333333333333333333333", commit)
+	commit, err = manager.Commit(weather)
+	require.NoError(t, err)
+	assert.Equal(t, "4444444444444444444444444444444444444444", commit)
+
+	// Uninstalling removes the plugin's checkout.
+	require.NoError(t, manager.Uninstall(clock))
+	exists, _ := afero.DirExists(fs, clockRepo)
+	assert.False(t, exists)
+}
+
+func TestSharedCheckoutRefusesPins(t *testing.T) {
+	fs := afero.NewOsFs()
+	pluginsDir := filepath.Join(t.TempDir(), "plugins")
+	manager := &Manager{fs: fs, pluginsDir: pluginsDir, gitClient: &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			if err := fs.MkdirAll(filepath.Join(path, "weather"), 0o755); err != nil {
+				return err
+			}
+			return afero.WriteFile(fs, filepath.Join(path, "weather", "plugin.json"), []byte(`{"id": "weather"}`), 0o644)
+		},
+	}}
+	manager.fs = &renameTracker{Fs: fs, heads: map[string]string{}}
+
+	// Installs from before every plugin got its own checkout share one.
+	repo := "https://example.com/plugins"
+	shared := filepath.Join(pluginsDir, ".repos", manager.getRepoName(repo))
+	for _, id := range []string{"clock", "weather"} {
+		require.NoError(t, fs.MkdirAll(filepath.Join(shared, id), 0o755))
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(shared, id, "plugin.json"), []byte(`{"id": "`+id+`"}`), 0o644))
+		require.NoError(t, os.Symlink(filepath.Join(shared, id), filepath.Join(pluginsDir, id)))
+		meta := "repo=" + repo + "\npath=" + id + "\nrepodir=" + manager.getRepoName(repo)
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, id+".meta"), []byte(meta), 0o644))
+	}
+	require.NoError(t, manager.writeLock(&Lockfile{Version: lockVersion, Plugins: map[string]LockEntry{
+		"clock": {Repo: repo, Path: "clock", Commit: "1111111111111111111111111111111111111111"},
+	}}))
+
+	_, err := manager.Pin("clock")
+	assert.ErrorContains(t, err, "shares its checkout with weather")
+
+	// Updating moves a plugin to a checkout of its own.
+	weather := Plugin{ID: "weather", Repo: repo, Path: "weather"}
+	require.NoError(t, manager.Update(weather))
+	weatherRepo, err := manager.repoPath(weather)
+	require.NoError(t, err)
+	assert.NotEqual(t, shared, weatherRepo)
+	exists, _ := afero.DirExists(fs, shared)
+	assert.True(t, exists)
+	_, err = manager.Pin("clock")
+	assert.NoError(t, err)
+}
+
+// renameTracker moves the heads recorded for a checkout along with it.
+type renameTracker struct {
+	afero.Fs
+	heads map[string]string
+}
+
+func (r *renameTracker) Rename(oldname, newname string) error {
+	if err := r.Fs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	if head, ok := r.heads[oldname]; ok {
+		r.heads[newname] = head
+		delete(r.heads, oldname)
+	}
+	return nil
+}
+
+func (r *renameTracker) SymlinkIfPossible(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func TestPendingCommitsTargetsPinnedCommit(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "clock"), 0o755))
+
+	var targets []string
+	manager.gitClient = &mockGitClient{
+		pendingFunc: func(path string, target string) (string, []Commit, error) {
+			targets = append(targets, target)
+			return target, nil, nil
+		},
+	}
+
+	pinned := strings.Repeat("ab", 20)
+	target, _, err := manager.PendingCommits(Plugin{ID: "clock", Repo: "https://example.com/clock", Commit: pinned})
+	require.NoError(t, err)
+	assert.Equal(t, pinned, target)
+
+	_, _, err = manager.PendingCommits(Plugin{ID: "clock", Repo: "https://example.com/clock"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{pinned, ""}, targets)
+}
@@ -10,13 +10,16 @@ import (
 	"strings"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
 	"github.com/spf13/afero"
 )
 
 type Manager struct {
-	fs         afero.Fs
-	pluginsDir string
-	gitClient  GitClient
+	fs              afero.Fs
+	pluginsDir      string
+	gitClient       GitClient
+	allowUnverified bool
+	confirmTrust    func(plugin Plugin, reason string) bool
 }
 
 func NewManager() (*Manager, error) {
@@ -104,7 +107,18 @@ func (m *Manager) findInDir(dir, pluginID string) (string, error) {
 	return "", nil
 }
 
+// Install installs a plugin and records its commit in the lockfile.
 func (m *Manager) Install(plugin Plugin) error {
+	if err := m.install(plugin, ""); err != nil {
+		return err
+	}
+	m.recordLock(plugin)
+	return nil
+}
+
+// install fetches a plugin into staging, checks out commit there if one is
+// given, verifies it and only then activates it.
+func (m *Manager) install(plugin Plugin, commit string) error {
 	pluginPath := filepath.Join(m.pluginsDir, plugin.ID)
 
 	exists, err := afero.DirExists(m.fs, pluginPath)
@@ -125,62 +139,176 @@ func (m *Manager) Install(plugin Plugin) error {
 		return fmt.Errorf("failed to create repos directory: %w", err)
 	}
 
-	if plugin.Path != "" {
-		repoName := m.getRepoName(plugin.Repo)
-		repoPath := filepath.Join(reposDir, repoName)
+	// Plugins are fetched and verified here and only then moved into the
+	// plugins directory, where the shell picks them up.
+	staging := filepath.Join(reposDir, ".staging", plugin.ID)
+	m.fs.RemoveAll(staging) //nolint:errcheck
 
-		repoExists, err := afero.DirExists(m.fs, repoPath)
+	if localRepo, ok := registries.LocalPath(plugin.Repo); ok {
+		var record *TrustRecord
+		err := m.installLocal(plugin, localRepo, staging)
+		if err == nil {
+			record, err = m.verifyLocal(plugin, staging, false)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to check if repo exists: %w", err)
+			m.fs.RemoveAll(staging) //nolint:errcheck
+			return err
+		}
+		if err := m.fs.Rename(staging, pluginPath); err != nil {
+			return err
 		}
+		return m.trust(plugin.ID, record)
+	}
 
-		if !repoExists {
-			if err := m.gitClient.PlainClone(repoPath, plugin.Repo); err != nil {
-				m.fs.RemoveAll(repoPath) //nolint:errcheck
-				return fmt.Errorf("failed to clone repository: %w", err)
-			}
-		} else {
-			// Pull latest changes if repo already exists
-			if err := m.gitClient.Pull(repoPath); err != nil {
-				// If pull fails (e.g., corrupted shallow clone), delete and re-clone
-				if err := m.fs.RemoveAll(repoPath); err != nil {
-					return fmt.Errorf("failed to remove corrupted repository: %w", err)
-				}
+	if err := m.cloneAt(staging, plugin, commit); err != nil {
+		m.fs.RemoveAll(staging) //nolint:errcheck
+		return err
+	}
 
-				if err := m.gitClient.PlainClone(repoPath, plugin.Repo); err != nil {
-					return fmt.Errorf("failed to re-clone repository: %w", err)
-				}
-			}
-		}
+	if plugin.Path != "" {
+		// Every monorepo plugin gets a checkout of its own, so pinning,
+		// locking or updating one never moves another.
+		repoName := m.getRepoName(plugin.Repo) + "-" + plugin.ID
+		repoPath := filepath.Join(reposDir, repoName)
 
-		sourcePath := filepath.Join(repoPath, plugin.Path)
-		sourceExists, err := afero.DirExists(m.fs, sourcePath)
+		sourceExists, err := afero.DirExists(m.fs, filepath.Join(staging, plugin.Path))
+		if err == nil && !sourceExists {
+			err = fmt.Errorf("plugin path does not exist in repository: %s", plugin.Path)
+		}
+		if err == nil {
+			err = m.verify(plugin, staging, filepath.Join(staging, plugin.Path))
+		}
+		if err == nil {
+			m.fs.RemoveAll(repoPath) //nolint:errcheck
+			err = m.fs.Rename(staging, repoPath)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to check plugin path: %w", err)
+			m.fs.RemoveAll(staging) //nolint:errcheck
+			return err
 		}
-		if !sourceExists {
-			return fmt.Errorf("plugin path does not exist in repository: %s", plugin.Path)
+
+		return m.activateMonorepo(plugin, repoName, pluginPath)
+	}
+
+	if err := m.verify(plugin, staging, staging); err != nil {
+		m.fs.RemoveAll(staging) //nolint:errcheck
+		return err
+	}
+	if err := m.fs.Rename(staging, pluginPath); err != nil {
+		m.fs.RemoveAll(staging) //nolint:errcheck
+		return fmt.Errorf("failed to move plugin into place: %w", err)
+	}
+	return nil
+}
+
+// activateMonorepo links a monorepo plugin from its checkout in .repos
+// into the plugins directory.
+func (m *Manager) activateMonorepo(plugin Plugin, repoName, pluginPath string) error {
+	repoPath := filepath.Join(m.pluginsDir, ".repos", repoName)
+	if err := m.createSymlink(filepath.Join(repoPath, plugin.Path), pluginPath); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	metaContent := fmt.Sprintf("repo=%s\npath=%s\nrepodir=%s", plugin.Repo, plugin.Path, repoName)
+	if err := afero.WriteFile(m.fs, pluginPath+".meta", []byte(metaContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return nil
+}
+
+// cloneAt clones a plugin's repository to path and checks out commit, if
+// one is given.
+func (m *Manager) cloneAt(path string, plugin Plugin, commit string) error {
+	if err := m.gitClient.PlainClone(path, plugin.Repo); err != nil {
+		return fmt.Errorf("failed to clone plugin: %w", err)
+	}
+	if commit == "" {
+		return nil
+	}
+	if err := m.gitClient.Checkout(path, commit); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", ShortCommit(commit), err)
+	}
+	return nil
+}
+
+// readMeta reads the .meta file written next to a monorepo plugin's
+// symlink.
+func (m *Manager) readMeta(pluginPath string) map[string]string {
+	meta := map[string]string{}
+	data, err := afero.ReadFile(m.fs, pluginPath+".meta")
+	if err != nil {
+		return meta
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			meta[key] = value
 		}
+	}
+	return meta
+}
 
-		if err := m.createSymlink(sourcePath, pluginPath); err != nil {
-			return fmt.Errorf("failed to create symlink: %w", err)
+// monorepoDir is the checkout a monorepo plugin lives in. Plugins
+// installed before each got their own share one per repository.
+func (m *Manager) monorepoDir(pluginPath string, plugin Plugin) string {
+	meta := m.readMeta(pluginPath)
+	name := filepath.Base(meta["repodir"])
+	if meta["repodir"] == "" {
+		repo := meta["repo"]
+		if repo == "" {
+			repo = plugin.Repo
 		}
+		name = m.getRepoName(repo)
+	}
+	return filepath.Join(m.pluginsDir, ".repos", name)
+}
 
-		metaPath := pluginPath + ".meta"
-		metaContent := fmt.Sprintf("repo=%s\npath=%s\nrepodir=%s", plugin.Repo, plugin.Path, repoName)
-		if err := afero.WriteFile(m.fs, metaPath, []byte(metaContent), 0o644); err != nil {
-			return fmt.Errorf("failed to write metadata: %w", err)
+// sharedCheckout lists the other installed plugins living in the
+// checkout at repoPath.
+func (m *Manager) sharedCheckout(repoPath, excludePlugin string) []string {
+	entries, err := afero.ReadDir(m.fs, m.pluginsDir)
+	if err != nil {
+		return nil
+	}
+	var shared []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".meta")
+		if !ok {
+			continue
 		}
-	} else {
-		if err := m.gitClient.PlainClone(pluginPath, plugin.Repo); err != nil {
-			m.fs.RemoveAll(pluginPath) //nolint:errcheck
-			return fmt.Errorf("failed to clone plugin: %w", err)
+		pluginPath := filepath.Join(m.pluginsDir, name)
+		id := m.getPluginID(pluginPath)
+		if id == "" {
+			id = name
+		}
+		if id != excludePlugin && m.monorepoDir(pluginPath, Plugin{}) == repoPath {
+			shared = append(shared, id)
 		}
 	}
+	return shared
+}
 
+// installLocal copies a plugin out of a local registry, so it keeps
+// working when the share it came from is unavailable.
+func (m *Manager) installLocal(plugin Plugin, repo, pluginPath string) error {
+	sourcePath := filepath.Join(repo, plugin.Path)
+	if exists, _ := afero.DirExists(m.fs, sourcePath); !exists {
+		return fmt.Errorf("plugin directory does not exist: %s", sourcePath)
+	}
+	if err := registries.CopyDir(m.fs, sourcePath, pluginPath); err != nil {
+		return fmt.Errorf("failed to copy plugin: %w", err)
+	}
 	return nil
 }
 
+// CheckTrust refuses plugins listed by a registry the user hasn't marked
+// trusted, unless they explicitly allow it.
+func CheckTrust(plugin Plugin, allowUntrusted bool) error {
+	if plugin.Source == "" || plugin.Trusted || allowUntrusted {
+		return nil
+	}
+	return fmt.Errorf("plugin %s comes from untrusted registry %q; allow untrusted sources to install it", plugin.ID, plugin.Source)
+}
+
 func (m *Manager) getRepoName(repoURL string) string {
 	hash := sha256.Sum256([]byte(repoURL))
 	return hex.EncodeToString(hash[:])[:16]
@@ -193,7 +321,20 @@ func (m *Manager) createSymlink(source, dest string) error {
 	return os.Symlink(source, dest)
 }
 
+// Update brings a plugin to the latest commit and moves its lock entry
+// along. Pinned plugins are refused.
 func (m *Manager) Update(plugin Plugin) error {
+	if entry, ok := m.pinned(plugin.ID); ok {
+		return fmt.Errorf("plugin %s is pinned at %s; unpin it to update", plugin.ID, ShortCommit(entry.Commit))
+	}
+	if err := m.update(plugin); err != nil {
+		return err
+	}
+	m.recordLock(plugin)
+	return nil
+}
+
+func (m *Manager) update(plugin Plugin) error {
 	pluginPath, err := m.findInstalledPath(plugin.ID)
 	if err != nil {
 		return fmt.Errorf("failed to find plugin: %w", err)
@@ -207,45 +348,113 @@ func (m *Manager) Update(plugin Plugin) error {
 		return fmt.Errorf("cannot update system plugin: %s", plugin.Name)
 	}
 
-	metaPath := pluginPath + ".meta"
-	metaExists, err := afero.Exists(m.fs, metaPath)
+	if localRepo, ok := registries.LocalPath(plugin.Repo); ok {
+		// Copy next to the old version first so a failed copy leaves the
+		// plugin as it was.
+		staging := pluginPath + ".new"
+		m.fs.RemoveAll(staging) //nolint:errcheck
+		var record *TrustRecord
+		err := m.installLocal(plugin, localRepo, staging)
+		if err == nil {
+			record, err = m.verifyLocal(plugin, staging, true)
+		}
+		if err != nil {
+			m.fs.RemoveAll(staging) //nolint:errcheck
+			return err
+		}
+		if err := m.fs.RemoveAll(pluginPath); err != nil {
+			return fmt.Errorf("failed to remove old plugin: %w", err)
+		}
+		if err := m.fs.Rename(staging, pluginPath); err != nil {
+			return err
+		}
+		return m.trust(plugin.ID, record)
+	}
+
+	metaExists, err := afero.Exists(m.fs, pluginPath+".meta")
 	if err != nil {
 		return fmt.Errorf("failed to check metadata: %w", err)
 	}
 
+	// The update is cloned and verified in staging, like an install; the
+	// plugin the shell has loaded is only replaced once it passes.
+	staging := filepath.Join(m.pluginsDir, ".repos", ".staging", plugin.ID)
+	m.fs.RemoveAll(staging) //nolint:errcheck
+	if err := m.fs.MkdirAll(filepath.Dir(staging), 0o755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	dir := staging
 	if metaExists {
-		reposDir := filepath.Join(m.pluginsDir, ".repos")
-		repoName := m.getRepoName(plugin.Repo)
-		repoPath := filepath.Join(reposDir, repoName)
+		dir = filepath.Join(staging, plugin.Path)
+	}
+	err = m.cloneAt(staging, plugin, "")
+	if err == nil {
+		err = m.verify(plugin, staging, dir)
+	}
+	if err != nil {
+		m.fs.RemoveAll(staging) //nolint:errcheck
+		return err
+	}
 
-		// Try to pull, if it fails (e.g., shallow clone corruption), delete and re-clone
-		if err := m.gitClient.Pull(repoPath); err != nil {
-			// Repository is likely corrupted or has issues, delete and re-clone
-			if err := m.fs.RemoveAll(repoPath); err != nil {
-				return fmt.Errorf("failed to remove corrupted repository: %w", err)
-			}
+	if !metaExists {
+		return m.replaceDir(staging, pluginPath)
+	}
 
-			if err := m.gitClient.PlainClone(repoPath, plugin.Repo); err != nil {
-				return fmt.Errorf("failed to re-clone repository: %w", err)
-			}
-		}
-	} else {
-		// Try to pull, if it fails, delete and re-clone
-		if err := m.gitClient.Pull(pluginPath); err != nil {
-			if err := m.fs.RemoveAll(pluginPath); err != nil {
-				return fmt.Errorf("failed to remove corrupted plugin: %w", err)
-			}
+	repoName := m.getRepoName(plugin.Repo) + "-" + plugin.ID
+	repoPath := filepath.Join(m.pluginsDir, ".repos", repoName)
+	oldRepo := m.monorepoDir(pluginPath, plugin)
+	if err := m.replaceDir(staging, repoPath); err != nil {
+		return err
+	}
+	if oldRepo == repoPath {
+		return nil
+	}
 
-			if err := m.gitClient.PlainClone(pluginPath, plugin.Repo); err != nil {
-				return fmt.Errorf("failed to re-clone plugin: %w", err)
-			}
-		}
+	// A plugin still sharing its repository's checkout moves to its own.
+	shared := len(m.sharedCheckout(oldRepo, plugin.ID)) > 0
+	if err := m.fs.Remove(pluginPath); err != nil {
+		return fmt.Errorf("failed to remove symlink: %w", err)
+	}
+	if err := m.activateMonorepo(plugin, repoName, pluginPath); err != nil {
+		return err
+	}
+	if !shared {
+		m.fs.RemoveAll(oldRepo) //nolint:errcheck
 	}
+	return nil
+}
 
+// replaceDir moves staging to path, putting the old directory back if
+// that fails.
+func (m *Manager) replaceDir(staging, path string) error {
+	backup := staging + ".old"
+	m.fs.RemoveAll(backup) //nolint:errcheck
+	if exists, _ := afero.Exists(m.fs, path); exists {
+		if err := m.fs.Rename(path, backup); err != nil {
+			m.fs.RemoveAll(staging) //nolint:errcheck
+			return fmt.Errorf("failed to move old plugin aside: %w", err)
+		}
+	}
+	if err := m.fs.Rename(staging, path); err != nil {
+		m.fs.Rename(backup, path) //nolint:errcheck
+		m.fs.RemoveAll(staging)   //nolint:errcheck
+		return fmt.Errorf("failed to move plugin into place: %w", err)
+	}
+	m.fs.RemoveAll(backup) //nolint:errcheck
 	return nil
 }
 
 func (m *Manager) Uninstall(plugin Plugin) error {
+	if err := m.uninstall(plugin); err != nil {
+		return err
+	}
+	m.forgetLock(plugin.ID)
+	m.forgetGrants(plugin.ID)
+	m.forgetTrust(plugin.ID)
+	return nil
+}
+
+func (m *Manager) uninstall(plugin Plugin) error {
 	pluginPath, err := m.findInstalledPath(plugin.ID)
 	if err != nil {
 		return fmt.Errorf("failed to find plugin: %w", err)
@@ -266,14 +475,8 @@ func (m *Manager) Uninstall(plugin Plugin) error {
 	}
 
 	if metaExists {
-		reposDir := filepath.Join(m.pluginsDir, ".repos")
-		repoName := m.getRepoName(plugin.Repo)
-		repoPath := filepath.Join(reposDir, repoName)
-
-		shouldCleanup, err := m.shouldCleanupRepo(repoPath, plugin.Repo, plugin.ID)
-		if err != nil {
-			return fmt.Errorf("failed to check repo cleanup: %w", err)
-		}
+		repoPath := m.monorepoDir(pluginPath, plugin)
+		shouldCleanup := len(m.sharedCheckout(repoPath, plugin.ID)) == 0
 
 		if err := m.fs.Remove(pluginPath); err != nil {
 			return fmt.Errorf("failed to remove symlink: %w", err)
@@ -297,37 +500,6 @@ func (m *Manager) Uninstall(plugin Plugin) error {
 	return nil
 }
 
-func (m *Manager) shouldCleanupRepo(repoPath, repoURL, excludePlugin string) (bool, error) {
-	installed, err := m.ListInstalled()
-	if err != nil {
-		return false, err
-	}
-
-	registry, err := NewRegistry()
-	if err != nil {
-		return false, err
-	}
-
-	allPlugins, err := registry.List()
-	if err != nil {
-		return false, err
-	}
-
-	for _, id := range installed {
-		if id == excludePlugin {
-			continue
-		}
-
-		for _, p := range allPlugins {
-			if p.ID == id && p.Repo == repoURL && p.Path != "" {
-				return false, nil
-			}
-		}
-	}
-
-	return true, nil
-}
-
 func (m *Manager) ListInstalled() ([]string, error) {
 	installedMap := make(map[string]bool)
 
@@ -423,8 +595,15 @@ func (m *Manager) getPluginManifest(pluginPath string) *pluginManifest {
 }
 
 type pluginManifest struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Dependencies   []string        `json:"dependencies"`
+	RequiresDMS    string          `json:"requires_dms"`
+	Compositors    []string        `json:"compositors"`
+	Distro         []string        `json:"distro"`
+	Permissions    []string        `json:"permissions"`
+	Backend        *Backend        `json:"backend"`
+	SettingsSchema json.RawMessage `json:"settings_schema"`
 }
 
 func (m *Manager) GetPluginsDir() string {
@@ -443,17 +622,29 @@ func (m *Manager) UninstallByIDOrName(idOrName string) error {
 	if strings.HasPrefix(pluginPath, "/etc/xdg/quickshell/dms-plugins") {
 		return fmt.Errorf("cannot uninstall system plugin: %s", idOrName)
 	}
+	if id := m.getPluginID(pluginPath); id != "" {
+		defer m.forgetLock(id)
+		defer m.forgetGrants(id)
+		defer m.forgetTrust(id)
+	}
 
 	metaPath := pluginPath + ".meta"
 	metaExists, _ := afero.Exists(m.fs, metaPath)
 
 	if metaExists {
+		repoPath := m.monorepoDir(pluginPath, Plugin{})
+		shouldCleanup := len(m.sharedCheckout(repoPath, m.getPluginID(pluginPath))) == 0
 		if err := m.fs.Remove(pluginPath); err != nil {
 			return fmt.Errorf("failed to remove symlink: %w", err)
 		}
 		if err := m.fs.Remove(metaPath); err != nil {
 			return fmt.Errorf("failed to remove metadata: %w", err)
 		}
+		if shouldCleanup {
+			if err := m.fs.RemoveAll(repoPath); err != nil {
+				return fmt.Errorf("failed to cleanup repository: %w", err)
+			}
+		}
 	} else {
 		if err := m.fs.RemoveAll(pluginPath); err != nil {
 			return fmt.Errorf("failed to remove plugin: %w", err)
@@ -475,6 +666,18 @@ func (m *Manager) UpdateByIDOrName(idOrName string) error {
 	if strings.HasPrefix(pluginPath, "/etc/xdg/quickshell/dms-plugins") {
 		return fmt.Errorf("cannot update system plugin: %s", idOrName)
 	}
+	if entry, ok := m.pinned(m.getPluginID(pluginPath)); ok {
+		return fmt.Errorf("plugin %s is pinned at %s; unpin it to update", idOrName, ShortCommit(entry.Commit))
+	}
+
+	// Without registry info the lockfile still knows where the plugin
+	// came from, so it can be updated through staging like any other.
+	if lock, err := m.ReadLock(); err == nil {
+		id := m.getPluginID(pluginPath)
+		if entry, ok := lock.Plugins[id]; ok && entry.Repo != "" {
+			return m.Update(Plugin{ID: id, Name: idOrName, Repo: entry.Repo, Path: entry.Path, Source: entry.Source})
+		}
+	}
 
 	metaPath := pluginPath + ".meta"
 	metaExists, _ := afero.Exists(m.fs, metaPath)
@@ -568,6 +771,15 @@ func (m *Manager) HasUpdates(pluginID string, plugin Plugin) (bool, error) {
 		return false, nil
 	}
 
+	// Local registries have no history to compare against; update
+	// re-copies them.
+	if _, ok := registries.LocalPath(plugin.Repo); ok {
+		return false, nil
+	}
+	if _, ok := m.pinned(pluginID); ok {
+		return false, nil
+	}
+
 	metaPath := pluginPath + ".meta"
 	metaExists, err := afero.Exists(m.fs, metaPath)
 	if err != nil {
@@ -576,11 +788,7 @@ func (m *Manager) HasUpdates(pluginID string, plugin Plugin) (bool, error) {
 
 	if metaExists {
 		// Plugin is from a monorepo, check the repo directory
-		reposDir := filepath.Join(m.pluginsDir, ".repos")
-		repoName := m.getRepoName(plugin.Repo)
-		repoPath := filepath.Join(reposDir, repoName)
-
-		return m.gitClient.HasUpdates(repoPath)
+		return m.gitClient.HasUpdates(m.monorepoDir(pluginPath, plugin))
 	}
 
 	// Plugin is a standalone repo
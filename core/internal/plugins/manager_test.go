@@ -98,7 +98,7 @@ func TestInstall(t *testing.T) {
 		mockGit := &mockGitClient{
 			cloneFunc: func(path string, url string) error {
 				cloneCalled = true
-				assert.Equal(t, filepath.Join(pluginsDir, plugin.ID), path)
+				assert.Equal(t, filepath.Join(pluginsDir, ".repos", ".staging", plugin.ID), path)
 				assert.Equal(t, plugin.Repo, url)
 				return fs.MkdirAll(path, 0o755)
 			},
@@ -135,24 +135,35 @@ func TestManagerUpdate(t *testing.T) {
 	t.Run("updates plugin successfully", func(t *testing.T) {
 		manager, fs, pluginsDir := setupTestManager(t)
 
-		plugin := Plugin{ID: "test-plugin", Name: "TestPlugin"}
+		plugin := Plugin{ID: "test-plugin", Name: "TestPlugin", Repo: "https://github.com/test/plugin"}
 		pluginPath := filepath.Join(pluginsDir, plugin.ID)
-		err := fs.MkdirAll(pluginPath, 0o755)
-		require.NoError(t, err)
+		require.NoError(t, fs.MkdirAll(pluginPath, 0o755))
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginPath, "old.qml"), nil, 0o644))
 
-		pullCalled := false
+		cloneCalled := false
 		mockGit := &mockGitClient{
+			cloneFunc: func(path string, url string) error {
+				cloneCalled = true
+				assert.Equal(t, filepath.Join(pluginsDir, ".repos", ".staging", plugin.ID), path)
+				return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(`{"id": "test-plugin"}`), 0o644)
+			},
 			pullFunc: func(path string) error {
-				pullCalled = true
-				assert.Equal(t, pluginPath, path)
+				t.Errorf("the installed plugin was pulled in place: %s", path)
 				return nil
 			},
 		}
 		manager.gitClient = mockGit
 
-		err = manager.Update(plugin)
+		err := manager.Update(plugin)
 		assert.NoError(t, err)
-		assert.True(t, pullCalled)
+		assert.True(t, cloneCalled)
+
+		exists, _ := afero.Exists(fs, filepath.Join(pluginPath, "plugin.json"))
+		assert.True(t, exists)
+		exists, _ = afero.Exists(fs, filepath.Join(pluginPath, "old.qml"))
+		assert.False(t, exists)
+		exists, _ = afero.DirExists(fs, filepath.Join(pluginsDir, ".repos", ".staging", plugin.ID+".old"))
+		assert.False(t, exists)
 	})
 
 	t.Run("returns error when plugin not installed", func(t *testing.T) {
@@ -0,0 +1,305 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Permission is a capability a plugin can request in the permissions list
+// of its plugin.json. Connections that authenticate as a plugin can only
+// call methods covered by the permissions the user granted. They tell the
+// user what a plugin means to do, but aren't a sandbox: plugin code runs in
+// the shell, which can reach the server without authenticating. Other
+// entries, like settings_write, are checked by the shell and aren't listed
+// here.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var Permissions = []Permission{
+	{"clipboard.read", "Read clipboard contents and history"},
+	{"clipboard.write", "Copy, paste and change clipboard history"},
+	{"network.read", "See network connections and VPN profiles"},
+	{"network.control", "Connect, disconnect and configure networks"},
+	{"network.credentials", "Enter and read network and VPN credentials"},
+	{"bluetooth.read", "See Bluetooth devices"},
+	{"bluetooth.control", "Pair, connect and configure Bluetooth devices"},
+	{"brightness.read", "See display and keyboard brightness"},
+	{"brightness.control", "Change brightness"},
+	{"display.read", "See outputs and night light settings"},
+	{"display.control", "Configure outputs and night light"},
+	{"workspaces.read", "See workspaces and tags"},
+	{"workspaces.control", "Switch and manage workspaces"},
+	{"session.read", "See session and lock state"},
+	{"session.control", "Lock, unlock and end the session"},
+	{"account.read", "See account details and the color scheme"},
+	{"account.control", "Change account details and the icon theme"},
+	{"printers.read", "See printers and print jobs"},
+	{"printers.control", "Manage printers and print jobs"},
+	{"theme.read", "See themes and automatic theme settings"},
+	{"theme.control", "Install themes and change automatic theme settings"},
+	{"theme.hooks", "Run commands when the theme changes"},
+	{"screenshot.read", "See screenshots"},
+	{"screenshot.control", "Take, copy and delete screenshots"},
+	{"colorpicker.read", "See picked colors and palettes"},
+	{"colorpicker.control", "Change picked colors and palettes"},
+	{"input.read", "See keyboard lock state"},
+	{"apps.open", "Open files and links in other applications"},
+	{"dbus.session", "Call any service on the D-Bus session bus"},
+	{"dbus.system", "Call any service on the D-Bus system bus"},
+}
+
+// LookupPermission returns the permission called name.
+func LookupPermission(name string) (Permission, bool) {
+	i := slices.IndexFunc(Permissions, func(p Permission) bool { return p.Name == name })
+	if i < 0 {
+		return Permission{}, false
+	}
+	return Permissions[i], true
+}
+
+// permissionRules map server methods to the permission calling them
+// requires. The first rule matching a method applies; patterns ending in
+// ".*" match everything under a prefix. An empty permission lets any
+// plugin call the method, and methods no rule matches can't be called by
+// plugins at all.
+var permissionRules = []struct {
+	permission string
+	methods    []string
+}{
+	{"", []string{"ping", "getServerInfo", "dbus.unsubscribe"}},
+
+	{"clipboard.read", []string{"clipboard.getState", "clipboard.getHistory", "clipboard.getEntry", "clipboard.search", "clipboard.getConfig", "clipboard.getPinnedEntries", "clipboard.getPinnedCount", "clipboard.subscribe"}},
+	{"clipboard.write", []string{"clipboard.*"}},
+
+	{"network.credentials", []string{"network.credentials.*", "network.vpn.setCredentials", "network.vpn.clearCredentials", "network.vpn.getConfig"}},
+	{"network.read", []string{"network.getState", "network.info", "network.ethernet.info", "network.wifi.networks", "network.vpn.profiles", "network.vpn.active", "network.vpn.plugins", "network.subscribe"}},
+	{"network.control", []string{"network.*"}},
+
+	{"bluetooth.read", []string{"bluetooth.getState", "bluetooth.subscribe"}},
+	{"bluetooth.control", []string{"bluetooth.*"}},
+
+	{"brightness.read", []string{"brightness.getState", "brightness.subscribe"}},
+	{"brightness.control", []string{"brightness.*"}},
+
+	{"display.read", []string{"wayland.gamma.getState", "wayland.gamma.subscribe", "wlroutput.getState", "wlroutput.subscribe"}},
+	{"display.control", []string{"wayland.gamma.*", "wlroutput.*"}},
+
+	{"workspaces.read", []string{"dwl.getState", "dwl.subscribe", "extworkspace.getState", "extworkspace.subscribe"}},
+	{"workspaces.control", []string{"dwl.*", "extworkspace.*"}},
+
+	{"session.read", []string{"loginctl.getState", "loginctl.subscribe"}},
+	{"session.control", []string{"loginctl.*"}},
+
+	{"account.read", []string{"freedesktop.getState", "freedesktop.accounts.getUserIconFile", "freedesktop.settings.getColorScheme"}},
+	{"account.control", []string{"freedesktop.*"}},
+
+	{"printers.read", []string{"cups.getPrinters", "cups.getJobs", "cups.getClasses", "cups.getDevices", "cups.getPPDs", "cups.subscribe"}},
+	{"printers.control", []string{"cups.*"}},
+
+	{"theme.hooks", []string{"theme.auto.setHooks"}},
+	{"theme.read", []string{"themes.list", "themes.listInstalled", "themes.search", "theme.auto.getState", "theme.auto.subscribe", "matugen.status", "matugen.palettes"}},
+	{"theme.control", []string{"themes.*", "theme.auto.*", "matugen.*"}},
+
+	{"screenshot.read", []string{"screenshot.getState", "screenshot.list", "screenshot.get", "screenshot.getConfig"}},
+	{"screenshot.control", []string{"screenshot.*"}},
+
+	{"colorpicker.read", []string{"colorpicker.getState", "colorpicker.history.list", "colorpicker.palettes.list", "colorpicker.palettes.get"}},
+	{"colorpicker.control", []string{"colorpicker.*"}},
+
+	{"input.read", []string{"evdev.*"}},
+	{"apps.open", []string{"browser.*", "apppicker.*"}},
+}
+
+// subscriptionPermissions are the permissions needed to receive events of
+// each service through subscribe.
+var subscriptionPermissions = map[string]string{
+	"server":                  "",
+	"dbus":                    "",
+	"network":                 "network.read",
+	"network.credentials":     "network.credentials",
+	"loginctl":                "session.read",
+	"freedesktop":             "account.read",
+	"freedesktop.screensaver": "session.read",
+	"gamma":                   "display.read",
+	"wlroutput":               "display.read",
+	"theme.auto":              "theme.read",
+	"bluetooth":               "bluetooth.read",
+	"bluetooth.pairing":       "bluetooth.control",
+	"browser":                 "apps.open",
+	"cups":                    "printers.read",
+	"dwl":                     "workspaces.read",
+	"extworkspace":            "workspaces.read",
+	"brightness":              "brightness.read",
+	"evdev":                   "input.read",
+	"clipboard":               "clipboard.read",
+	"screenshot":              "screenshot.read",
+	"colorpicker":             "colorpicker.read",
+}
+
+// RequiredPermission returns the permission a plugin needs to call method
+// with params. ok is false for methods plugins can't call, and an empty
+// permission means any plugin may call it. D-Bus methods need the
+// permission of the bus they're called on, and matugen.queue needs
+// theme.hooks when it is pointed at other directories, since the templates
+// found there can run commands.
+func RequiredPermission(method string, params map[string]any) (permission string, ok bool) {
+	if strings.HasPrefix(method, "dbus.") && method != "dbus.unsubscribe" {
+		if bus, _ := params["bus"].(string); bus == "system" {
+			return "dbus.system", true
+		}
+		return "dbus.session", true
+	}
+	if method == "matugen.queue" {
+		for _, key := range []string{"stateDir", "shellDir", "configDir"} {
+			if dir, _ := params[key].(string); dir != "" {
+				return "theme.hooks", true
+			}
+		}
+	}
+	for _, rule := range permissionRules {
+		for _, pattern := range rule.methods {
+			if prefix, ok := strings.CutSuffix(pattern, "*"); (ok && strings.HasPrefix(method, prefix)) || pattern == method {
+				return rule.permission, true
+			}
+		}
+	}
+	return "", false
+}
+
+// SubscriptionPermission returns the permission a plugin needs to receive
+// events of service.
+func SubscriptionPermission(service string) (permission string, ok bool) {
+	permission, ok = subscriptionPermissions[service]
+	return permission, ok
+}
+
+// RequestedPermissions returns the server permissions in a plugin.json
+// permissions list, leaving out the ones the shell enforces.
+func RequestedPermissions(list []string) []string {
+	var requested []string
+	for _, name := range list {
+		name = strings.TrimSpace(name)
+		if _, ok := LookupPermission(name); ok && !slices.Contains(requested, name) {
+			requested = append(requested, name)
+		}
+	}
+	return requested
+}
+
+func (m *Manager) GrantsPath() string {
+	return filepath.Join(filepath.Dir(m.pluginsDir), "plugins.permissions.json")
+}
+
+// Grants returns the permissions granted to each plugin.
+func (m *Manager) Grants() (map[string][]string, error) {
+	grants := map[string][]string{}
+	data, err := afero.ReadFile(m.fs, m.GrantsPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return grants, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read permissions: %w", err)
+	}
+	if err := json.Unmarshal(data, &grants); err != nil {
+		return nil, fmt.Errorf("failed to parse permissions: %w", err)
+	}
+	return grants, nil
+}
+
+// Granted returns the permissions granted to a plugin.
+func (m *Manager) Granted(id string) ([]string, error) {
+	grants, err := m.Grants()
+	if err != nil {
+		return nil, err
+	}
+	return grants[id], nil
+}
+
+func (m *Manager) writeGrants(grants map[string][]string) error {
+	data, err := json.MarshalIndent(grants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.GrantsPath()), 0o755); err != nil {
+		return err
+	}
+	return afero.WriteFile(m.fs, m.GrantsPath(), append(data, '\n'), 0o644)
+}
+
+// Requested returns the server permissions an installed plugin's
+// plugin.json asks for.
+func (m *Manager) Requested(id string) ([]string, error) {
+	path, err := m.findInstalledPath(id)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("plugin not installed: %s", id)
+	}
+	manifest := m.getPluginManifest(path)
+	if manifest == nil {
+		return nil, nil
+	}
+	return RequestedPermissions(manifest.Permissions), nil
+}
+
+// Grant grants permissions to a plugin and returns everything it has been
+// granted. Plugins can be granted permissions they didn't request.
+func (m *Manager) Grant(id string, permissions ...string) ([]string, error) {
+	for _, name := range permissions {
+		if _, ok := LookupPermission(name); !ok {
+			return nil, fmt.Errorf("unknown permission: %s", name)
+		}
+	}
+	grants, err := m.Grants()
+	if err != nil {
+		return nil, err
+	}
+	granted := grants[id]
+	for _, name := range permissions {
+		if !slices.Contains(granted, name) {
+			granted = append(granted, name)
+		}
+	}
+	slices.Sort(granted)
+	if len(granted) > 0 {
+		grants[id] = granted
+	}
+	return granted, m.writeGrants(grants)
+}
+
+// Revoke revokes permissions from a plugin, or all of them when none are
+// given, and returns what it is still granted.
+func (m *Manager) Revoke(id string, permissions ...string) ([]string, error) {
+	grants, err := m.Grants()
+	if err != nil {
+		return nil, err
+	}
+	granted := slices.DeleteFunc(grants[id], func(name string) bool {
+		return len(permissions) == 0 || slices.Contains(permissions, name)
+	})
+	if len(granted) == 0 {
+		delete(grants, id)
+	} else {
+		grants[id] = granted
+	}
+	return granted, m.writeGrants(grants)
+}
+
+func (m *Manager) forgetGrants(id string) {
+	grants, err := m.Grants()
+	if err != nil || grants[id] == nil {
+		return
+	}
+	delete(grants, id)
+	m.writeGrants(grants) //nolint:errcheck
+}
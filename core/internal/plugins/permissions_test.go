@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredPermission(t *testing.T) {
+	tests := []struct {
+		method     string
+		params     map[string]any
+		permission string
+		ok         bool
+	}{
+		{"ping", nil, "", true},
+		{"clipboard.getHistory", nil, "clipboard.read", true},
+		{"clipboard.paste", nil, "clipboard.write", true},
+		{"network.getState", nil, "network.read", true},
+		{"network.wifi.connect", nil, "network.control", true},
+		{"network.credentials.submit", nil, "network.credentials", true},
+		{"dbus.call", map[string]any{"bus": "system"}, "dbus.system", true},
+		{"dbus.call", map[string]any{"bus": "session"}, "dbus.session", true},
+		{"theme.auto.setMode", nil, "theme.control", true},
+		{"theme.auto.setHooks", nil, "theme.hooks", true},
+		{"matugen.queue", map[string]any{"kind": "image"}, "theme.control", true},
+		{"matugen.queue", map[string]any{"configDir": "/tmp/evil"}, "theme.hooks", true},
+		{"plugins.install", nil, "", false},
+		{"plugins.permissions.grant", nil, "", false},
+		{"unknown.method", nil, "", false},
+	}
+	for _, tt := range tests {
+		permission, ok := RequiredPermission(tt.method, tt.params)
+		assert.Equal(t, tt.ok, ok, tt.method)
+		assert.Equal(t, tt.permission, permission, tt.method)
+	}
+
+	for _, rule := range permissionRules {
+		if rule.permission != "" {
+			_, ok := LookupPermission(rule.permission)
+			assert.True(t, ok, rule.permission)
+		}
+	}
+	for service, permission := range subscriptionPermissions {
+		if permission != "" {
+			_, ok := LookupPermission(permission)
+			assert.True(t, ok, service)
+		}
+	}
+}
+
+func TestGrants(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "clock"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "clock", "plugin.json"),
+		[]byte(`{"id": "clock", "permissions": ["settings_write", "clipboard.read", "dbus.system"]}`), 0o644))
+
+	requested, err := manager.Requested("clock")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clipboard.read", "dbus.system"}, requested)
+
+	_, err = manager.Grant("clock", "root.access")
+	assert.ErrorContains(t, err, "unknown permission")
+
+	granted, err := manager.Grant("clock", requested...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clipboard.read", "dbus.system"}, granted)
+
+	granted, err = manager.Revoke("clock", "dbus.system")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clipboard.read"}, granted)
+
+	// Uninstalling a plugin forgets what it was granted.
+	require.NoError(t, manager.UninstallByIDOrName("clock"))
+	grants, err := manager.Grants()
+	require.NoError(t, err)
+	assert.Empty(t, grants)
+}
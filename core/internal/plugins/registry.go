@@ -2,16 +2,21 @@ package plugins
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
 	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
 	"github.com/spf13/afero"
 )
 
-const registryRepo = "https://github.com/AvengeMedia/dms-plugin-registry.git"
+const registryRepo = registries.OfficialURL
 
 type Plugin struct {
 	ID           string   `json:"id"`
@@ -28,14 +33,41 @@ type Plugin struct {
 	Screenshot   string   `json:"screenshot,omitempty"`
 	RequiresDMS  string   `json:"requires_dms,omitempty"`
 	Featured     bool     `json:"featured,omitempty"`
+	Permissions  []string `json:"permissions,omitempty"`
+
+	// Commit is the commit the registry vouches for and Signature a
+	// minisign or SSH signature over the plugin's Digest, checked against
+	// the key configured for the registry or, when it has none, PublicKey.
+	Commit    string `json:"commit,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+
+	// Source is the name of the registry the plugin was listed in and
+	// Trusted whether that registry is trusted.
+	Source  string `json:"-"`
+	Trusted bool   `json:"-"`
 }
 
 type GitClient interface {
 	PlainClone(path string, url string) error
 	Pull(path string) error
 	HasUpdates(path string) (bool, error)
+	Head(path string) (string, error)
+	Checkout(path string, commit string) error
+	PendingCommits(path string, target string) (string, []Commit, error)
 }
 
+// Commit is one entry of a plugin's history, as shown before updating.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Summary string    `json:"summary"`
+	Author  string    `json:"author"`
+	When    time.Time `json:"when"`
+}
+
+// maxPendingCommits bounds the history shown for an update.
+const maxPendingCommits = 50
+
 type realGitClient struct{}
 
 func (g *realGitClient) PlainClone(path string, url string) error {
@@ -57,6 +89,18 @@ func (g *realGitClient) Pull(path string) error {
 		return err
 	}
 
+	// A locked install leaves HEAD detached at its commit; go back to the
+	// branch before pulling.
+	if head, err := repo.Head(); err == nil && !head.Name().IsBranch() {
+		branch, err := defaultBranch(repo)
+		if err != nil {
+			return err
+		}
+		if err := worktree.Checkout(&git.CheckoutOptions{Branch: branch, Force: true}); err != nil {
+			return err
+		}
+	}
+
 	err = worktree.Pull(&git.PullOptions{})
 	if err != nil && err.Error() != "already up-to-date" {
 		return err
@@ -117,9 +161,109 @@ func (g *realGitClient) HasUpdates(path string) (bool, error) {
 	return head.Hash().String() != remoteHead, nil
 }
 
+func (g *realGitClient) Head(path string) (string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// Checkout moves the work tree to commit, fetching first if the clone
+// doesn't have it yet.
+func (g *realGitClient) Checkout(path string, commit string) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+	hash := plumbing.NewHash(commit)
+	if _, err := repo.CommitObject(hash); err != nil {
+		if err := repo.Fetch(&git.FetchOptions{}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return err
+		}
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Hash: hash, Force: true})
+}
+
+// PendingCommits fetches and returns the commit an update moves to with
+// the commits between it and the local HEAD, newest first. The update
+// moves to target when it is set and to the remote head otherwise.
+func (g *realGitClient) PendingCommits(path string, target string) (string, []Commit, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := repo.Fetch(&git.FetchOptions{}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return "", nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", nil, err
+	}
+	to := plumbing.NewHash(target)
+	if target != "" {
+		if _, err := repo.CommitObject(to); err != nil {
+			return "", nil, fmt.Errorf("commit %s is not available: %w", ShortCommit(target), err)
+		}
+	} else {
+		branch, err := defaultBranch(repo)
+		if err != nil {
+			return "", nil, err
+		}
+		remote, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch.Short()), true)
+		if err != nil {
+			return "", nil, err
+		}
+		to = remote.Hash()
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: to})
+	if err != nil {
+		return "", nil, err
+	}
+	defer iter.Close()
+
+	var commits []Commit
+	for len(commits) < maxPendingCommits {
+		c, err := iter.Next()
+		if err != nil || c.Hash == head.Hash() {
+			break
+		}
+		summary, _, _ := strings.Cut(c.Message, "\n")
+		commits = append(commits, Commit{
+			Hash:    c.Hash.String(),
+			Summary: summary,
+			Author:  c.Author.Name,
+			When:    c.Author.When,
+		})
+	}
+	return to.String(), commits, nil
+}
+
+// defaultBranch returns the local main or master branch.
+func defaultBranch(repo *git.Repository) (plumbing.ReferenceName, error) {
+	for _, name := range []string{"main", "master"} {
+		ref := plumbing.NewBranchReferenceName(name)
+		if _, err := repo.Reference(ref, false); err == nil {
+			return ref, nil
+		}
+	}
+	return "", errors.New("no main or master branch")
+}
+
 type Registry struct {
 	fs       afero.Fs
 	cacheDir string
+	sources  []registries.Source
 	plugins  []Plugin
 	git      GitClient
 }
@@ -133,6 +277,7 @@ func NewRegistryWithFs(fs afero.Fs) (*Registry, error) {
 	return &Registry{
 		fs:       fs,
 		cacheDir: cacheDir,
+		sources:  registries.Sources(),
 		git:      &realGitClient{},
 	}, nil
 }
@@ -141,51 +286,82 @@ func getCacheDir() string {
 	return filepath.Join(os.TempDir(), "dankdots-plugin-registry")
 }
 
-func (r *Registry) Update() error {
-	exists, err := afero.DirExists(r.fs, r.cacheDir)
-	if err != nil {
-		return fmt.Errorf("failed to check cache directory: %w", err)
+func (r *Registry) getSources() []registries.Source {
+	if r.sources == nil {
+		return []registries.Source{registries.Official()}
 	}
+	return r.sources
+}
 
-	if !exists {
-		if err := r.fs.MkdirAll(filepath.Dir(r.cacheDir), 0o755); err != nil {
-			return fmt.Errorf("failed to create cache directory: %w", err)
-		}
+func (r *Registry) sourceDir(source registries.Source) string {
+	if source.URL == registryRepo {
+		return r.cacheDir
+	}
+	return source.Dir()
+}
 
-		if err := r.git.PlainClone(r.cacheDir, registryRepo); err != nil {
-			return fmt.Errorf("failed to clone registry: %w", err)
+// Update syncs every registry source and reloads the merged plugin list.
+// A source that can't be reached is skipped as long as another one works,
+// so a local registry keeps working offline.
+func (r *Registry) Update() error {
+	sources := r.getSources()
+	var errs []error
+	for _, source := range sources {
+		if err := registries.Sync(r.fs, r.git, source, r.sourceDir(source)); err != nil {
+			errs = append(errs, fmt.Errorf("registry %s: %w", source.Name, err))
 		}
-	} else {
-		// Try to pull, if it fails (e.g., shallow clone corruption), delete and re-clone
-		if err := r.git.Pull(r.cacheDir); err != nil {
-			// Repository is likely corrupted or has issues, delete and re-clone
-			if err := r.fs.RemoveAll(r.cacheDir); err != nil {
-				return fmt.Errorf("failed to remove corrupted registry: %w", err)
-			}
+	}
+	if len(errs) == len(sources) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Warnf("Skipping %v", err)
+	}
 
-			if err := r.fs.MkdirAll(filepath.Dir(r.cacheDir), 0o755); err != nil {
-				return fmt.Errorf("failed to create cache directory: %w", err)
-			}
+	return r.loadPlugins()
+}
 
-			if err := r.git.PlainClone(r.cacheDir, registryRepo); err != nil {
-				return fmt.Errorf("failed to re-clone registry: %w", err)
+// loadPlugins merges the plugins of every source. Sources come in lookup
+// order, so the first one to offer an ID wins.
+func (r *Registry) loadPlugins() error {
+	r.plugins = []Plugin{}
+	seen := make(map[string]bool)
+
+	var errs []error
+	for _, source := range r.getSources() {
+		plugins, err := r.loadSource(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+			continue
+		}
+		for _, plugin := range plugins {
+			if seen[plugin.ID] {
+				continue
 			}
+			seen[plugin.ID] = true
+			r.plugins = append(r.plugins, plugin)
 		}
 	}
 
-	return r.loadPlugins()
+	if len(errs) == len(r.getSources()) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Warnf("Skipping registry %v", err)
+	}
+	return nil
 }
 
-func (r *Registry) loadPlugins() error {
-	pluginsDir := filepath.Join(r.cacheDir, "plugins")
+func (r *Registry) loadSource(source registries.Source) ([]Plugin, error) {
+	root := r.sourceDir(source)
+	pluginsDir := filepath.Join(root, "plugins")
 
 	entries, err := afero.ReadDir(r.fs, pluginsDir)
 	if err != nil {
-		return fmt.Errorf("failed to read plugins directory: %w", err)
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
 	}
 
-	r.plugins = []Plugin{}
-
+	var plugins []Plugin
 	for _, entry := range entries {
 		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
 			continue
@@ -204,11 +380,26 @@ func (r *Registry) loadPlugins() error {
 		if plugin.ID == "" {
 			plugin.ID = strings.TrimSuffix(entry.Name(), ".json")
 		}
+		plugin.Source = source.Name
+		plugin.Trusted = source.Trusted
+		plugin.Commit = strings.ToLower(strings.TrimSpace(plugin.Commit))
+		// A key configured for the source is the user's; an entry can't
+		// swap in its own to vouch for itself.
+		if key := strings.TrimSpace(source.PublicKey); key != "" {
+			if plugin.PublicKey != "" && strings.TrimSpace(plugin.PublicKey) != key {
+				log.Warnf("Ignoring public_key of %s in registry %s: it differs from the key configured for the registry", plugin.ID, source.Name)
+			}
+			plugin.PublicKey = key
+		}
 
-		r.plugins = append(r.plugins, plugin)
+		if source.IsLocal() {
+			plugin.Repo = registries.ResolveRepo(root, plugin.Repo)
+		}
+
+		plugins = append(plugins, plugin)
 	}
 
-	return nil
+	return plugins, nil
 }
 
 func (r *Registry) List() ([]Plugin, error) {
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +15,9 @@ type mockGitClient struct {
 	cloneFunc      func(path string, url string) error
 	pullFunc       func(path string) error
 	hasUpdatesFunc func(path string) (bool, error)
+	headFunc       func(path string) (string, error)
+	checkoutFunc   func(path string, commit string) error
+	pendingFunc    func(path string, target string) (string, []Commit, error)
 }
 
 func (m *mockGitClient) PlainClone(path string, url string) error {
@@ -30,6 +34,27 @@ func (m *mockGitClient) Pull(path string) error {
 	return nil
 }
 
+func (m *mockGitClient) Head(path string) (string, error) {
+	if m.headFunc != nil {
+		return m.headFunc(path)
+	}
+	return "", nil
+}
+
+func (m *mockGitClient) Checkout(path string, commit string) error {
+	if m.checkoutFunc != nil {
+		return m.checkoutFunc(path, commit)
+	}
+	return nil
+}
+
+func (m *mockGitClient) PendingCommits(path string, target string) (string, []Commit, error) {
+	if m.pendingFunc != nil {
+		return m.pendingFunc(path, target)
+	}
+	return "", nil, nil
+}
+
 func (m *mockGitClient) HasUpdates(path string) (bool, error) {
 	if m.hasUpdatesFunc != nil {
 		return m.hasUpdatesFunc(path)
@@ -324,3 +349,75 @@ func TestUpdate(t *testing.T) {
 		assert.Equal(t, "UpdatedPlugin", registry.plugins[0].Name)
 	})
 }
+
+func TestMultipleSources(t *testing.T) {
+	registry, fs, tmpDir := setupTestRegistry(t)
+	registry.sources = []registries.Source{
+		{Name: "team", URL: "/mnt/team", Priority: 10, Trusted: true, PublicKey: "team-key"},
+		registries.Official(),
+		{Name: "nfs", URL: "file:///mnt/nfs"},
+	}
+
+	createTestPlugin(t, fs, tmpDir, "clock.json", Plugin{Name: "Official Clock", Repo: "https://github.com/test/clock"})
+	createTestPlugin(t, fs, tmpDir, "weather.json", Plugin{Name: "Weather", Repo: "https://github.com/test/weather"})
+	createTestPlugin(t, fs, "/mnt/team", "clock.json", Plugin{Name: "Team Clock", Repo: "src/clock", PublicKey: "entry-key"})
+	createTestPlugin(t, fs, "/mnt/nfs", "weather.json", Plugin{Name: "NFS Weather", Repo: "/srv/weather"})
+	createTestPlugin(t, fs, "/mnt/nfs", "notes.json", Plugin{Name: "Notes", Repo: "notes", PublicKey: "entry-key"})
+
+	require.NoError(t, registry.Update())
+	byID := make(map[string]Plugin)
+	for _, p := range registry.plugins {
+		byID[p.ID] = p
+	}
+	require.Len(t, byID, 3)
+
+	assert.Equal(t, "Team Clock", byID["clock"].Name)
+	assert.Equal(t, "team", byID["clock"].Source)
+	assert.Equal(t, "/mnt/team/src/clock", byID["clock"].Repo)
+	assert.Equal(t, "official", byID["weather"].Source)
+	assert.Equal(t, "nfs", byID["notes"].Source)
+	assert.False(t, byID["notes"].Trusted)
+	assert.Error(t, CheckTrust(byID["notes"], false))
+	assert.NoError(t, CheckTrust(byID["notes"], true))
+	assert.NoError(t, CheckTrust(byID["clock"], false))
+
+	// The key configured for a registry wins over one its entries bring.
+	assert.Equal(t, "team-key", byID["clock"].PublicKey)
+	assert.Equal(t, "entry-key", byID["notes"].PublicKey)
+
+	// An unreachable source doesn't take the others down.
+	registry.sources = append(registry.sources, registries.Source{Name: "gone", URL: "/mnt/gone"})
+	require.NoError(t, registry.Update())
+	assert.Len(t, registry.plugins, 3)
+}
+
+func TestInstallFromLocalRegistry(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll("/mnt/team/src/clock/.git", 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/mnt/team/src/clock/plugin.json", []byte(`{"id": "clock", "name": "Clock"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/mnt/team/src/clock/Clock.qml", []byte("Item {}"), 0o644))
+
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "/mnt/team/src/clock", Source: "team", Trusted: true}
+	require.NoError(t, manager.Install(plugin))
+
+	data, err := afero.ReadFile(fs, filepath.Join(pluginsDir, "clock", "Clock.qml"))
+	require.NoError(t, err)
+	assert.Equal(t, "Item {}", string(data))
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, "clock", ".git"))
+	assert.False(t, exists)
+
+	// Unsigned local plugins are trusted as first seen; changes need
+	// explicit approval.
+	require.NoError(t, afero.WriteFile(fs, "/mnt/team/src/clock/Clock.qml", []byte("Item { id: v2 }"), 0o644))
+	var verr *VerificationError
+	require.ErrorAs(t, manager.Update(plugin), &verr)
+	data, err = afero.ReadFile(fs, filepath.Join(pluginsDir, "clock", "Clock.qml"))
+	require.NoError(t, err)
+	assert.Equal(t, "Item {}", string(data))
+
+	manager.AllowUnverified(true)
+	require.NoError(t, manager.Update(plugin))
+	data, err = afero.ReadFile(fs, filepath.Join(pluginsDir, "clock", "Clock.qml"))
+	require.NoError(t, err)
+	assert.Equal(t, "Item { id: v2 }", string(data))
+}
@@ -0,0 +1,249 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is the part of JSON Schema plugins can describe their settings
+// with: types, properties, items, enums, bounds, patterns and defaults.
+// Keywords outside it are ignored. Like in JSON Schema, true is a schema
+// anything matches and false one nothing does.
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 schemaType         `json:"type,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	never   bool
+	pattern *regexp.Regexp
+}
+
+// schemaType is a type name or a list of them.
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = schemaType{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = names
+	return nil
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{never: true}
+		return nil
+	}
+
+	type schema Schema
+	*s = Schema{}
+	if err := json.Unmarshal(data, (*schema)(s)); err != nil {
+		return err
+	}
+	for _, name := range s.Type {
+		if !slices.Contains([]string{"object", "array", "string", "number", "integer", "boolean", "null"}, name) {
+			return fmt.Errorf("unknown type: %s", name)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	return nil
+}
+
+// Validate checks a value decoded from JSON against the schema.
+func (s *Schema) Validate(value any) error {
+	return s.validate("", value)
+}
+
+func (s *Schema) validate(path string, value any) error {
+	if s == nil {
+		return nil
+	}
+	if s.never {
+		return fmt.Errorf("%s is not allowed", describePath(path))
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(name string) bool { return matchesType(name, value) }) {
+		return fmt.Errorf("%s must be %s", describePath(path), strings.Join(s.Type, " or "))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(option any) bool { return reflect.DeepEqual(option, value) }) {
+		return fmt.Errorf("%s must be one of %s", describePath(path), formatEnum(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return s.validateObject(path, v)
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s must have at least %d items", describePath(path), *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s must have at most %d items", describePath(path), *s.MaxItems)
+		}
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s must be at least %v", describePath(path), *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Errorf("%s must be at most %v", describePath(path), *s.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s must be at least %d characters", describePath(path), *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s must be at most %d characters", describePath(path), *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s must match %s", describePath(path), s.Pattern)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(path string, object map[string]any) error {
+	for _, key := range s.Required {
+		if _, ok := object[key]; !ok {
+			return fmt.Errorf("%s is required", describePath(joinPath(path, key)))
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		property, ok := s.Properties[key]
+		if !ok {
+			property = s.AdditionalProperties
+		}
+		if err := property.validate(joinPath(path, key), object[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyDefaults fills in the defaults of properties missing from an object,
+// descending into the objects that are present. Defaults are copied, so the
+// schema is never shared with the result.
+func (s *Schema) ApplyDefaults(object map[string]any) map[string]any {
+	if s == nil {
+		return object
+	}
+	for key, property := range s.Properties {
+		if property == nil {
+			continue
+		}
+		value, ok := object[key]
+		if !ok {
+			if property.Default != nil {
+				object[key] = copyValue(property.Default)
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			property.ApplyDefaults(nested)
+		}
+	}
+	return object
+}
+
+func matchesType(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "settings"
+	}
+	return path
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func formatEnum(options []any) string {
+	data, _ := json.Marshal(options)
+	return string(data)
+}
+
+func copyValue(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var copied any
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return value
+	}
+	return copied
+}
@@ -0,0 +1,236 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+	"github.com/spf13/afero"
+)
+
+// Plugin settings are stored in one JSON object per plugin, in
+// plugin-settings/<id>.json next to the plugins directory. A plugin can
+// describe them with a JSON Schema in the settings_schema field of its
+// plugin.json, either inline or as a path to a file in the plugin:
+//
+//	"settings_schema": {"type": "object", "properties": {"interval": {"type": "integer", "minimum": 10, "default": 60}}}
+//	"settings_schema": "./settings.schema.json"
+//
+// Values are validated against it when they are changed, and defaults fill
+// in what hasn't been set. Defaults are not written to the file.
+
+func (m *Manager) SettingsDir() string {
+	return filepath.Join(filepath.Dir(m.pluginsDir), "plugin-settings")
+}
+
+func (m *Manager) SettingsPath(id string) string {
+	return filepath.Join(m.SettingsDir(), id+".json")
+}
+
+// legacySettingsPath is where the shell kept the settings of every plugin
+// before they moved to the server. A plugin's settings are read from it
+// until the plugin has a file of its own, which the first change writes.
+func (m *Manager) legacySettingsPath() string {
+	return filepath.Join(filepath.Dir(m.pluginsDir), "plugin_settings.json")
+}
+
+// shellSettingKeys are kept in the legacy file by the shell itself rather
+// than by the plugin, so they aren't copied.
+var shellSettingKeys = []string{"enabled", "variants"}
+
+func validateSettingsID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid plugin id: %q", id)
+	}
+	return nil
+}
+
+// SettingsSchema returns the settings schema an installed plugin declares,
+// or nil if it has none.
+func (m *Manager) SettingsSchema(id string) (*Schema, error) {
+	if err := validateSettingsID(id); err != nil {
+		return nil, err
+	}
+	path, err := m.findInstalledPath(id)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, fmt.Errorf("plugin not installed: %s", id)
+	}
+	manifest := m.getPluginManifest(path)
+	if manifest == nil || len(manifest.SettingsSchema) == 0 || string(manifest.SettingsSchema) == "null" {
+		return nil, nil
+	}
+
+	data := []byte(manifest.SettingsSchema)
+	var file string
+	if err := json.Unmarshal(data, &file); err == nil {
+		schemaPath := filepath.Join(path, file)
+		if rel, err := filepath.Rel(path, schemaPath); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("settings schema is outside the plugin directory: %s", file)
+		}
+		if data, err = afero.ReadFile(m.fs, schemaPath); err != nil {
+			return nil, fmt.Errorf("failed to read settings schema: %w", err)
+		}
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid settings schema for %s: %w", id, err)
+	}
+	return &schema, nil
+}
+
+// StoredSettings returns the settings saved for a plugin, without defaults.
+func (m *Manager) StoredSettings(id string) (map[string]any, error) {
+	if err := validateSettingsID(id); err != nil {
+		return nil, err
+	}
+	settings := map[string]any{}
+	data, err := afero.ReadFile(m.fs, m.SettingsPath(id))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return m.legacySettings(id), nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+	return settings, nil
+}
+
+func (m *Manager) legacySettings(id string) map[string]any {
+	var all map[string]map[string]any
+	data, err := afero.ReadFile(m.fs, m.legacySettingsPath())
+	if err != nil || json.Unmarshal(data, &all) != nil || all[id] == nil {
+		return map[string]any{}
+	}
+	settings := all[id]
+	for _, key := range shellSettingKeys {
+		delete(settings, key)
+	}
+	return settings
+}
+
+// Settings returns a plugin's settings with the defaults of its schema
+// filled in.
+func (m *Manager) Settings(id string) (map[string]any, error) {
+	schema, err := m.SettingsSchema(id)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := m.StoredSettings(id)
+	if err != nil {
+		return nil, err
+	}
+	return schema.ApplyDefaults(settings), nil
+}
+
+// UpdateSettings changes a plugin's settings and returns them with defaults
+// filled in. A nil value removes the key, so it falls back to its default.
+// Nothing is written unless the result matches the plugin's schema.
+func (m *Manager) UpdateSettings(id string, values map[string]any) (map[string]any, error) {
+	schema, err := m.SettingsSchema(id)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := m.lockSettings(id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	settings, err := m.StoredSettings(id)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		if key == "" {
+			return nil, fmt.Errorf("setting key must not be empty")
+		}
+		if value == nil {
+			delete(settings, key)
+			continue
+		}
+		settings[key] = value
+	}
+
+	result := schema.ApplyDefaults(copyValue(settings).(map[string]any))
+	if err := schema.Validate(result); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := m.fs.MkdirAll(m.SettingsDir(), 0o755); err != nil {
+		return nil, err
+	}
+	// Write through a rename so watchers never see a partial file.
+	tmp, err := afero.TempFile(m.fs, m.SettingsDir(), "."+id+".json-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write settings: %w", err)
+	}
+	defer m.fs.Remove(tmp.Name()) //nolint:errcheck
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = m.fs.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = m.fs.Rename(tmp.Name(), m.SettingsPath(id))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write settings: %w", err)
+	}
+	return result, nil
+}
+
+// settingsLocks serializes changes to a plugin's settings within the
+// process, keyed by the settings file.
+var settingsLocks syncmap.Map[string, *sync.Mutex]
+
+// lockSettings holds a plugin's settings until the returned function is
+// called. On disk the lock is also taken on <id>.json.lock, so the CLI and
+// the server don't lose each other's changes.
+func (m *Manager) lockSettings(id string) (func(), error) {
+	mu, _ := settingsLocks.LoadOrStore(m.SettingsPath(id), &sync.Mutex{})
+	mu.Lock()
+	if _, ok := m.fs.(*afero.OsFs); !ok {
+		return mu.Unlock, nil
+	}
+
+	if err := os.MkdirAll(m.SettingsDir(), 0o755); err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+	f, err := os.OpenFile(m.SettingsPath(id)+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		mu.Unlock()
+		return nil, fmt.Errorf("failed to lock settings: %w", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck
+		f.Close()
+		mu.Unlock()
+	}, nil
+}
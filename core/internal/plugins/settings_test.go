@@ -0,0 +1,156 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const weatherSchema = `{
+	"type": "object",
+	"properties": {
+		"city": {"type": "string", "minLength": 1, "default": "Oslo"},
+		"interval": {"type": "integer", "minimum": 10, "default": 60},
+		"units": {"enum": ["metric", "imperial"]},
+		"display": {
+			"type": "object",
+			"properties": {"icon": {"type": "boolean", "default": true}},
+			"additionalProperties": false
+		}
+	}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	var schema Schema
+	require.NoError(t, json.Unmarshal([]byte(weatherSchema), &schema))
+
+	tests := []struct {
+		settings string
+		err      string
+	}{
+		{`{}`, ""},
+		{`{"city": "Bergen", "interval": 30, "units": "metric", "display": {"icon": false}, "extra": 1}`, ""},
+		{`[]`, "settings must be object"},
+		{`{"city": ""}`, "city must be at least 1 characters"},
+		{`{"interval": 5}`, "interval must be at least 10"},
+		{`{"interval": 10.5}`, "interval must be integer"},
+		{`{"units": "kelvin"}`, `units must be one of ["metric","imperial"]`},
+		{`{"display": {"theme": "dark"}}`, "display.theme is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.settings, func(t *testing.T) {
+			var value any
+			require.NoError(t, json.Unmarshal([]byte(tt.settings), &value))
+			err := schema.Validate(value)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestSchemaUnmarshalErrors(t *testing.T) {
+	var schema Schema
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"type": "decimal"}`), &schema), "unknown type")
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"pattern": "("}`), &schema), "invalid pattern")
+}
+
+func TestSettings(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "weather"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "weather", "plugin.json"),
+		[]byte(`{"id": "weather", "settings_schema": "./schema.json"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "weather", "schema.json"), []byte(weatherSchema), 0o644))
+
+	settings, err := manager.Settings("weather")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"city": "Oslo", "interval": float64(60)}, settings)
+
+	settings, err = manager.UpdateSettings("weather", map[string]any{"interval": 30, "display": map[string]any{}})
+	require.NoError(t, err)
+	assert.Equal(t, float64(30), settings["interval"])
+	assert.Equal(t, map[string]any{"icon": true}, settings["display"])
+
+	// Defaults are filled in but never stored.
+	stored, err := manager.StoredSettings("weather")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"interval": float64(30), "display": map[string]any{}}, stored)
+
+	_, err = manager.UpdateSettings("weather", map[string]any{"interval": 5})
+	assert.ErrorContains(t, err, "interval must be at least 10")
+	stored, err = manager.StoredSettings("weather")
+	require.NoError(t, err)
+	assert.Equal(t, float64(30), stored["interval"])
+
+	// Removing a key brings back its default.
+	settings, err = manager.UpdateSettings("weather", map[string]any{"interval": nil})
+	require.NoError(t, err)
+	assert.Equal(t, float64(60), settings["interval"])
+
+	_, err = manager.Settings("../weather")
+	assert.ErrorContains(t, err, "invalid plugin id")
+	_, err = manager.Settings("missing")
+	assert.ErrorContains(t, err, "plugin not installed")
+}
+
+func TestSettingsWithoutSchema(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "clock"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "clock", "plugin.json"), []byte(`{"id": "clock"}`), 0o644))
+
+	// Settings the shell stored for every plugin in one file are picked up
+	// until the plugin's own file is written.
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(filepath.Dir(pluginsDir), "plugin_settings.json"),
+		[]byte(`{"clock": {"format": "HH:mm", "enabled": true}, "other": {"x": 1}}`), 0o644))
+
+	settings, err := manager.Settings("clock")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"format": "HH:mm"}, settings)
+
+	settings, err = manager.UpdateSettings("clock", map[string]any{"seconds": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"format": "HH:mm", "seconds": true}, settings)
+
+	exists, err := afero.Exists(fs, manager.SettingsPath("clock"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestUpdateSettingsConcurrently(t *testing.T) {
+	pluginsDir := filepath.Join(t.TempDir(), "plugins")
+	fs := afero.NewOsFs()
+	require.NoError(t, fs.MkdirAll(filepath.Join(pluginsDir, "clock"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(pluginsDir, "clock", "plugin.json"), []byte(`{"id": "clock"}`), 0o644))
+
+	// Separate managers stand in for the CLI and the server.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manager := &Manager{fs: fs, pluginsDir: pluginsDir}
+			_, err := manager.UpdateSettings("clock", map[string]any{fmt.Sprintf("key%d", i): i})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	manager := &Manager{fs: fs, pluginsDir: pluginsDir}
+	settings, err := manager.StoredSettings("clock")
+	require.NoError(t, err)
+	assert.Len(t, settings, 10)
+	entries, err := afero.ReadDir(fs, manager.SettingsDir())
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".json-", "temporary file left behind")
+	}
+}
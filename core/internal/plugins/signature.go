@@ -0,0 +1,178 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHNamespace is the namespace plugin digests are signed under with
+// ssh-keygen -Y sign -n dms-plugin.
+const SSHNamespace = "dms-plugin"
+
+// VerifySignature checks signature over message with publicKey. Both
+// minisign and SSH signatures are accepted: publicKey is then a minisign
+// public key or an OpenSSH authorized_keys line.
+func VerifySignature(publicKey, signature string, message []byte) error {
+	publicKey = strings.TrimSpace(publicKey)
+	if publicKey == "" {
+		return errors.New("no public key to verify the signature with")
+	}
+	if strings.Contains(signature, "BEGIN SSH SIGNATURE") {
+		return verifySSH(publicKey, signature, message)
+	}
+	return verifyMinisign(publicKey, signature, message)
+}
+
+func verifyMinisign(publicKey, signature string, message []byte) error {
+	pk, err := decodeMinisign(lastLine(publicKey), 42)
+	if err != nil {
+		return fmt.Errorf("invalid minisign public key: %w", err)
+	}
+	if string(pk[:2]) != "Ed" {
+		return errors.New("unsupported minisign public key")
+	}
+	key := ed25519.PublicKey(pk[10:])
+
+	var lines []string
+	for line := range strings.SplitSeq(strings.TrimSpace(signature), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return errors.New("empty minisign signature")
+	}
+
+	sig, err := decodeMinisign(lines[0], 74)
+	if err != nil {
+		return fmt.Errorf("invalid minisign signature: %w", err)
+	}
+	if !bytes.Equal(sig[2:10], pk[2:10]) {
+		return errors.New("signature was made with a different key")
+	}
+
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	default:
+		return errors.New("unsupported minisign signature algorithm")
+	}
+	if !ed25519.Verify(key, message, sig[10:]) {
+		return errors.New("signature does not match")
+	}
+
+	// The trusted comment, when present, is covered by a second signature.
+	if len(lines) >= 3 && strings.HasPrefix(lines[1], "trusted comment: ") {
+		comment := strings.TrimPrefix(lines[1], "trusted comment: ")
+		global, err := base64.StdEncoding.DecodeString(lines[2])
+		if err != nil || len(global) != ed25519.SignatureSize {
+			return errors.New("invalid minisign trusted comment signature")
+		}
+		if !ed25519.Verify(key, append(sig[10:], comment...), global) {
+			return errors.New("trusted comment signature does not match")
+		}
+	}
+	return nil
+}
+
+func decodeMinisign(s string, size int) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+	return data, nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
+
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// verifySSH checks an armored signature from ssh-keygen -Y sign, see
+// PROTOCOL.sshsig in OpenSSH.
+func verifySSH(publicKey, signature string, message []byte) error {
+	trusted, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return fmt.Errorf("invalid SSH public key: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(strings.TrimSpace(signature)))
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return errors.New("invalid SSH signature")
+	}
+	blob, ok := bytes.CutPrefix(block.Bytes, []byte("SSHSIG"))
+	if !ok {
+		return errors.New("invalid SSH signature")
+	}
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob, &sig); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != SSHNamespace {
+		return fmt.Errorf("SSH signature is for %q, not %q", sig.Namespace, SSHNamespace)
+	}
+	if !bytes.Equal(sig.PublicKey, trusted.Marshal()) {
+		return errors.New("signature was made with a different key")
+	}
+
+	var hash []byte
+	switch sig.HashAlgorithm {
+	case "sha256":
+		sum := sha256.Sum256(message)
+		hash = sum[:]
+	case "sha512":
+		sum := sha512.Sum512(message)
+		hash = sum[:]
+	default:
+		return fmt.Errorf("unsupported SSH signature hash %q", sig.HashAlgorithm)
+	}
+
+	var inner ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &inner); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	signed := append([]byte("SSHSIG"), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          hash,
+	})...)
+	if err := trusted.Verify(signed, &inner); err != nil {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
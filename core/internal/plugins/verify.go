@@ -0,0 +1,266 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/spf13/afero"
+)
+
+// VerificationError is returned when a plugin doesn't match what its
+// registry published, or a local plugin changed since it was first
+// trusted. Installs and updates stop before the plugin is activated.
+type VerificationError struct {
+	Plugin string
+	Reason string
+	// Confirmable is set when the update can go ahead by trusting the
+	// changed plugin again; see ConfirmTrustChange.
+	Confirmable bool
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("plugin %s failed verification: %s", e.Plugin, e.Reason)
+}
+
+// AllowUnverified makes verification failures warnings instead of errors.
+func (m *Manager) AllowUnverified(allow bool) {
+	m.allowUnverified = allow
+}
+
+// Digest hashes the files of a plugin directory, leaving out version
+// control metadata. Signatures are made over the digest as a line of
+// text, the way dms plugins digest prints it:
+//
+//	dms plugins digest ./my-plugin > digest.txt
+//	minisign -S -m digest.txt
+func Digest(fs afero.Fs, dir string) (string, error) {
+	var files []string
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	slices.Sort(files)
+
+	h := sha256.New()
+	for _, rel := range files {
+		data, err := afero.ReadFile(fs, filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(h, "%s\x00%s\n", rel, hex.EncodeToString(sum[:]))
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verify checks a fetched plugin before it is activated. repoPath is its
+// git checkout and dir the plugin directory in it. Plugins from local
+// registries go through verifyLocal instead. A registry commit is
+// checked out and compared, and a signature checked against the digest
+// of dir. Plugins without either are only warned about.
+func (m *Manager) verify(plugin Plugin, repoPath, dir string) error {
+	verified := false
+	if plugin.Commit != "" {
+		if !isFullCommit(plugin.Commit) {
+			return m.verificationFailed(plugin, fmt.Sprintf("registry commit %q is not a full commit hash", plugin.Commit))
+		}
+		head, err := m.gitClient.Head(repoPath)
+		if err != nil {
+			return fmt.Errorf("failed to read commit: %w", err)
+		}
+		if !commitMatches(head, plugin.Commit) {
+			if err := m.gitClient.Checkout(repoPath, plugin.Commit); err != nil {
+				return m.verificationFailed(plugin, fmt.Sprintf("commit %s is not available: %v", ShortCommit(plugin.Commit), err))
+			}
+			if head, err = m.gitClient.Head(repoPath); err != nil {
+				return fmt.Errorf("failed to read commit: %w", err)
+			}
+		}
+		if !commitMatches(head, plugin.Commit) {
+			return m.verificationFailed(plugin, fmt.Sprintf("expected commit %s, got %s", ShortCommit(plugin.Commit), ShortCommit(head)))
+		}
+		verified = true
+	}
+
+	if plugin.Signature != "" {
+		digest, err := Digest(m.fs, dir)
+		if err != nil {
+			return fmt.Errorf("failed to hash plugin: %w", err)
+		}
+		if err := VerifySignature(plugin.PublicKey, plugin.Signature, []byte(digest+"\n")); err != nil {
+			return m.verificationFailed(plugin, err.Error())
+		}
+		verified = true
+	}
+
+	if !verified {
+		log.Warnf("Plugin %s has no published commit or signature; installing it unverified", plugin.ID)
+	}
+	return nil
+}
+
+func (m *Manager) verificationFailed(plugin Plugin, reason string) error {
+	err := &VerificationError{Plugin: plugin.ID, Reason: reason}
+	if m.allowUnverified {
+		log.Warnf("%v; continuing as unverified plugins are allowed", err)
+		return nil
+	}
+	return err
+}
+
+// fullCommitPattern is a full commit hash. Registries must pin commits
+// with one, since a short prefix can be brute-forced.
+var fullCommitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func isFullCommit(commit string) bool {
+	return fullCommitPattern.MatchString(commit)
+}
+
+func commitMatches(head, want string) bool {
+	return isFullCommit(want) && head == want
+}
+
+// TrustRecord is what was seen when a plugin from a local registry was
+// first installed. Local plugins have no commits, so later installs and
+// updates must match the record: signed by the same key or, if unsigned,
+// unchanged.
+type TrustRecord struct {
+	Repo      string `json:"repo"`
+	Digest    string `json:"digest"`
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+func (m *Manager) TrustPath() string {
+	return filepath.Join(filepath.Dir(m.pluginsDir), "plugins.trust.json")
+}
+
+func (m *Manager) readTrust() (map[string]TrustRecord, error) {
+	records := map[string]TrustRecord{}
+	data, err := afero.ReadFile(m.fs, m.TrustPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return records, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read trust records: %w", err)
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse trust records: %w", err)
+	}
+	return records, nil
+}
+
+func (m *Manager) writeTrust(records map[string]TrustRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.TrustPath()), 0o755); err != nil {
+		return err
+	}
+	return afero.WriteFile(m.fs, m.TrustPath(), append(data, '\n'), 0o644)
+}
+
+// verifyLocal checks a plugin copied from a local registry against its
+// signature, if any, and its trust-on-first-use record. It returns the
+// record to keep once the plugin is activated, or nil to leave the old
+// one. On an update, an unsigned plugin whose contents changed is trusted
+// again if the user confirms it.
+func (m *Manager) verifyLocal(plugin Plugin, dir string, updating bool) (*TrustRecord, error) {
+	digest, err := Digest(m.fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash plugin: %w", err)
+	}
+
+	key := ""
+	if plugin.Signature != "" {
+		if err := VerifySignature(plugin.PublicKey, plugin.Signature, []byte(digest+"\n")); err != nil {
+			return nil, m.verificationFailed(plugin, err.Error())
+		}
+		key = strings.TrimSpace(plugin.PublicKey)
+	}
+
+	records, err := m.readTrust()
+	if err != nil {
+		return nil, err
+	}
+	trusted := &TrustRecord{Repo: plugin.Repo, Digest: digest, PublicKey: key}
+	record, seen := records[plugin.ID]
+	switch {
+	case !seen:
+		log.Infof("Trusting plugin %s from %s on first use (%s)", plugin.ID, plugin.Repo, digest)
+		return trusted, nil
+	case record.Repo != plugin.Repo:
+		return nil, m.verificationFailed(plugin, fmt.Sprintf("first installed from %s, now from %s", record.Repo, plugin.Repo))
+	case record.PublicKey != "" && key != record.PublicKey:
+		return nil, m.verificationFailed(plugin, "not signed with the key it was first installed with")
+	case record.PublicKey == "" && key == "" && record.Digest != digest:
+		reason := "contents changed since it was first installed"
+		if updating && m.confirmTrust != nil && m.confirmTrust(plugin, reason) {
+			log.Infof("Trusting changed plugin %s (%s)", plugin.ID, digest)
+			return trusted, nil
+		}
+		err := m.verificationFailed(plugin, reason)
+		if verr, ok := err.(*VerificationError); ok {
+			verr.Confirmable = updating
+		}
+		return nil, err
+	}
+	return trusted, nil
+}
+
+// ConfirmTrustChange sets what is asked when an update changes a local
+// plugin that was trusted on first use. Without it the update fails
+// verification.
+func (m *Manager) ConfirmTrustChange(confirm func(plugin Plugin, reason string) bool) {
+	m.confirmTrust = confirm
+}
+
+// trust stores the record of a local plugin once it is activated.
+func (m *Manager) trust(id string, record *TrustRecord) error {
+	if record == nil {
+		return nil
+	}
+	records, err := m.readTrust()
+	if err != nil {
+		return err
+	}
+	records[id] = *record
+	return m.writeTrust(records)
+}
+
+func (m *Manager) forgetTrust(id string) {
+	records, err := m.readTrust()
+	if err != nil {
+		return
+	}
+	if _, ok := records[id]; !ok {
+		return
+	}
+	delete(records, id)
+	m.writeTrust(records) //nolint:errcheck
+}
@@ -0,0 +1,275 @@
+package plugins
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+func minisignKey(t *testing.T) (string, func(message []byte, prehash bool) string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte("12345678")
+
+	publicKey := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	sign := func(message []byte, prehash bool) string {
+		alg := "Ed"
+		if prehash {
+			alg = "ED"
+			hash := blake2b.Sum512(message)
+			message = hash[:]
+		}
+		sig := ed25519.Sign(priv, message)
+		comment := "timestamp:1700000000"
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+		return "untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n"
+	}
+	return publicKey, sign
+}
+
+func sshKey(t *testing.T) (string, func(message []byte) string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	sign := func(message []byte) string {
+		hash := sha512.Sum512(message)
+		signed := append([]byte("SSHSIG"), ssh.Marshal(sshSignedData{
+			Namespace:     SSHNamespace,
+			HashAlgorithm: "sha512",
+			Hash:          hash[:],
+		})...)
+		sig, err := signer.Sign(rand.Reader, signed)
+		require.NoError(t, err)
+		blob := append([]byte("SSHSIG"), ssh.Marshal(sshSignature{
+			Version:       1,
+			PublicKey:     signer.PublicKey().Marshal(),
+			Namespace:     SSHNamespace,
+			HashAlgorithm: "sha512",
+			Signature:     ssh.Marshal(sig),
+		})...)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}))
+	}
+	return string(ssh.MarshalAuthorizedKey(signer.PublicKey())), sign
+}
+
+func TestVerifySignature(t *testing.T) {
+	message := []byte("sha256:abc\n")
+
+	minisignPub, minisignSign := minisignKey(t)
+	assert.NoError(t, VerifySignature(minisignPub, minisignSign(message, false), message))
+	assert.NoError(t, VerifySignature(minisignPub, minisignSign(message, true), message))
+	assert.ErrorContains(t, VerifySignature(minisignPub, minisignSign([]byte("other"), true), message), "does not match")
+
+	otherPub, _ := minisignKey(t)
+	assert.Error(t, VerifySignature(otherPub, minisignSign(message, false), message))
+
+	sshPub, sshSign := sshKey(t)
+	assert.NoError(t, VerifySignature(sshPub, sshSign(message), message))
+	assert.ErrorContains(t, VerifySignature(sshPub, sshSign([]byte("other")), message), "does not match")
+	otherSSH, _ := sshKey(t)
+	assert.ErrorContains(t, VerifySignature(otherSSH, sshSign(message), message), "different key")
+
+	assert.Error(t, VerifySignature("", minisignSign(message, false), message))
+}
+
+func TestInstallVerifiesCommit(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	head := "1111111111111111111111111111111111111111"
+	checkoutErr := errors.New("reference not found")
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(`{"id": "clock"}`), 0o644)
+		},
+		headFunc: func(path string) (string, error) { return head, nil },
+		checkoutFunc: func(path string, commit string) error {
+			if checkoutErr != nil {
+				return checkoutErr
+			}
+			head = commit
+			return nil
+		},
+	}
+
+	// A short commit is never enough, even when HEAD starts with it.
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "https://example.com/clock", Commit: "1111111"}
+	var verr *VerificationError
+	require.ErrorAs(t, manager.Install(plugin), &verr)
+	assert.Contains(t, verr.Reason, "not a full commit hash")
+
+	plugin.Commit = "2222222222222222222222222222222222222222"
+	require.ErrorAs(t, manager.Install(plugin), &verr)
+	assert.Contains(t, verr.Reason, "2222222")
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, "clock"))
+	assert.False(t, exists)
+
+	// The registry's commit is checked out when it is available.
+	checkoutErr = nil
+	require.NoError(t, manager.Install(plugin))
+	assert.Equal(t, plugin.Commit, head)
+	exists, _ = afero.DirExists(fs, filepath.Join(pluginsDir, "clock"))
+	assert.True(t, exists)
+}
+
+func TestInstallVerifiesSignature(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	content := `{"id": "clock"}`
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(content), 0o644)
+		},
+	}
+
+	src := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(src, "/clock/plugin.json", []byte(content), 0o644))
+	require.NoError(t, afero.WriteFile(src, "/clock/.git/HEAD", []byte("ref: refs/heads/main"), 0o644))
+	digest, err := Digest(src, "/clock")
+	require.NoError(t, err)
+
+	pub, sign := minisignKey(t)
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "https://example.com/clock", PublicKey: pub, Signature: sign([]byte(digest+"\n"), true)}
+
+	content = `{"id": "clock", "evil": true}`
+	var verr *VerificationError
+	require.ErrorAs(t, manager.Install(plugin), &verr)
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, "clock"))
+	assert.False(t, exists)
+
+	manager.AllowUnverified(true)
+	require.NoError(t, manager.Install(plugin))
+	require.NoError(t, manager.Uninstall(plugin))
+
+	manager.AllowUnverified(false)
+	content = `{"id": "clock"}`
+	require.NoError(t, manager.Install(plugin))
+}
+
+func TestUpdateKeepsPluginOnFailedVerification(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	head := "3333333333333333333333333333333333333333"
+	manager.gitClient = &mockGitClient{
+		cloneFunc: func(path string, url string) error {
+			return afero.WriteFile(fs, filepath.Join(path, "plugin.json"), []byte(`{"id": "clock", "new": true}`), 0o644)
+		},
+		headFunc: func(path string) (string, error) { return head, nil },
+		checkoutFunc: func(path string, commit string) error {
+			return errors.New("reference not found")
+		},
+	}
+	manifest := filepath.Join(pluginsDir, "clock", "plugin.json")
+	require.NoError(t, fs.MkdirAll(filepath.Dir(manifest), 0o755))
+	require.NoError(t, afero.WriteFile(fs, manifest, []byte(`{"id": "clock"}`), 0o644))
+
+	// The update is verified before it replaces anything.
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "https://example.com/clock", Commit: "2222222222222222222222222222222222222222"}
+	var verr *VerificationError
+	require.ErrorAs(t, manager.Update(plugin), &verr)
+	data, err := afero.ReadFile(fs, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": "clock"}`, string(data))
+	exists, _ := afero.DirExists(fs, filepath.Join(pluginsDir, ".repos", ".staging", "clock"))
+	assert.False(t, exists)
+}
+
+func TestDigest(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/a/plugin.json", []byte("{}"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/a/ui/Widget.qml", []byte("Item {}"), 0o644))
+	first, err := Digest(fs, "/a")
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, first)
+
+	// Version control metadata doesn't count, file contents and names do.
+	require.NoError(t, afero.WriteFile(fs, "/a/.git/HEAD", []byte("x"), 0o644))
+	same, err := Digest(fs, "/a")
+	require.NoError(t, err)
+	assert.Equal(t, first, same)
+
+	require.NoError(t, fs.Rename("/a/ui/Widget.qml", "/a/ui/Other.qml"))
+	renamed, err := Digest(fs, "/a")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, renamed)
+}
+
+func TestLocalPluginTrust(t *testing.T) {
+	manager, fs, pluginsDir := setupTestManager(t)
+	widget := "/mnt/team/src/clock/Clock.qml"
+	require.NoError(t, afero.WriteFile(fs, "/mnt/team/src/clock/plugin.json", []byte(`{"id": "clock"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, widget, []byte("Item {}"), 0o644))
+	plugin := Plugin{ID: "clock", Name: "Clock", Repo: "/mnt/team/src/clock", Source: "team"}
+	trusted := func() (TrustRecord, bool) {
+		records, err := manager.readTrust()
+		require.NoError(t, err)
+		record, ok := records["clock"]
+		return record, ok
+	}
+
+	require.NoError(t, manager.Install(plugin))
+	first, ok := trusted()
+	require.True(t, ok)
+	digest, err := Digest(fs, filepath.Join(pluginsDir, "clock"))
+	require.NoError(t, err)
+	assert.Equal(t, digest, first.Digest)
+
+	// A changed plugin is only trusted again when the update is confirmed.
+	require.NoError(t, afero.WriteFile(fs, widget, []byte("Item { id: v2 }"), 0o644))
+	var verr *VerificationError
+	require.ErrorAs(t, manager.Update(plugin), &verr)
+	assert.True(t, verr.Confirmable)
+
+	var asked []string
+	manager.ConfirmTrustChange(func(p Plugin, reason string) bool {
+		asked = append(asked, p.ID)
+		return false
+	})
+	require.ErrorAs(t, manager.Update(plugin), &verr)
+	assert.Equal(t, []string{"clock"}, asked)
+	record, _ := trusted()
+	assert.Equal(t, first, record)
+
+	// Allowing unverified plugins updates without trusting the change.
+	manager.AllowUnverified(true)
+	require.NoError(t, manager.Update(plugin))
+	record, _ = trusted()
+	assert.Equal(t, first, record)
+	manager.AllowUnverified(false)
+
+	manager.ConfirmTrustChange(func(Plugin, string) bool { return true })
+	require.NoError(t, manager.Update(plugin))
+	record, _ = trusted()
+	assert.NotEqual(t, first.Digest, record.Digest)
+
+	// The record is forgotten with the plugin, and only written again
+	// once an install succeeds.
+	require.NoError(t, manager.Uninstall(plugin))
+	_, ok = trusted()
+	assert.False(t, ok)
+
+	pub, _ := minisignKey(t)
+	signed := plugin
+	signed.PublicKey, signed.Signature = pub, "untrusted comment: bogus\nAAAA"
+	require.ErrorAs(t, manager.Install(signed), &verr)
+	_, ok = trusted()
+	assert.False(t, ok)
+
+	require.NoError(t, manager.Install(plugin))
+	_, ok = trusted()
+	assert.True(t, ok)
+}
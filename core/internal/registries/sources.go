@@ -0,0 +1,249 @@
+// Package registries manages the sources plugins and themes are browsed
+// and installed from. Besides the official GitHub registry, users can add
+// their own in ~/.config/DankMaterialShell/registries.json:
+//
+//	{
+//	  "sources": [
+//	    {"name": "team", "url": "git@git.example.com:desktop/dms-registry.git", "priority": 10, "trusted": true,
+//	     "public_key": "ssh-ed25519 AAAAC3Nza... desktop-team"},
+//	    {"name": "nfs", "url": "/mnt/shared/dms-registry", "priority": 5}
+//	  ]
+//	}
+//
+// A source is a git URL, a file:// URL or a local directory, laid out like
+// the official registry (plugins/*.json and themes/<id>/theme.json). Plugin
+// signatures published by a source are checked with its public_key.
+package registries
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
+	"github.com/spf13/afero"
+)
+
+const (
+	OfficialName = "official"
+	OfficialURL  = "https://github.com/AvengeMedia/dms-plugin-registry.git"
+)
+
+type Source struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Priority decides which source wins when several offer the same ID;
+	// higher wins.
+	Priority int `json:"priority,omitempty"`
+	// Trusted sources can be installed from without confirmation. An
+	// untrusted source never shadows an ID from a trusted one, whatever
+	// its priority.
+	Trusted  bool `json:"trusted,omitempty"`
+	Disabled bool `json:"disabled,omitempty"`
+	// PublicKey is the minisign or SSH key plugin signatures from this
+	// source are checked with. Keys in its entries only count when it
+	// has none.
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+type config struct {
+	Sources []Source `json:"sources"`
+}
+
+func Official() Source {
+	return Source{Name: OfficialName, URL: OfficialURL, Trusted: true}
+}
+
+func ConfigPath() string {
+	return filepath.Join(utils.XDGConfigHome(), "DankMaterialShell", "registries.json")
+}
+
+// Load reads the configured sources and returns them with the official
+// registry in lookup order: trusted before untrusted, then by priority.
+// A configured source named "official" replaces the built-in one, which
+// is how it is disabled or reprioritized.
+func Load(fs afero.Fs, path string) ([]Source, error) {
+	sources := []Source{Official()}
+
+	data, err := afero.ReadFile(fs, path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return sources, nil
+	case err != nil:
+		return sources, fmt.Errorf("failed to read registries: %w", err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return sources, fmt.Errorf("failed to parse registries: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, s := range cfg.Sources {
+		switch {
+		case s.Name == "":
+			return sources, fmt.Errorf("registry source without a name")
+		case seen[s.Name]:
+			return sources, fmt.Errorf("duplicate registry source %q", s.Name)
+		case s.Name == OfficialName:
+			if s.URL == "" {
+				s.URL = OfficialURL
+			}
+			sources[0] = s
+		case s.URL == "":
+			return sources, fmt.Errorf("registry source %q has no url", s.Name)
+		default:
+			sources = append(sources, s)
+		}
+		seen[s.Name] = true
+	}
+
+	sources = slices.DeleteFunc(sources, func(s Source) bool { return s.Disabled })
+	slices.SortStableFunc(sources, func(a, b Source) int {
+		if a.Trusted != b.Trusted {
+			if a.Trusted {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return sources, nil
+}
+
+// Sources is Load on the user's config. A broken config is logged and the
+// official registry used alone.
+func Sources() []Source {
+	sources, err := Load(afero.NewOsFs(), ConfigPath())
+	if err != nil {
+		log.Warnf("Ignoring %s: %v", ConfigPath(), err)
+		return []Source{Official()}
+	}
+	return sources
+}
+
+// LocalPath returns the directory of a file:// URL or filesystem path.
+func LocalPath(url string) (string, bool) {
+	if path, ok := strings.CutPrefix(url, "file://"); ok {
+		return path, true
+	}
+	if strings.Contains(url, "://") || strings.HasPrefix(url, "git@") {
+		return "", false
+	}
+	if strings.HasPrefix(url, "~") || filepath.IsAbs(url) || strings.HasPrefix(url, ".") {
+		path, err := utils.ExpandPath(url)
+		if err != nil {
+			return "", false
+		}
+		return path, true
+	}
+	return "", false
+}
+
+// ResolveRepo resolves a repo listed in a local registry: plugin and
+// theme directories may be given relative to the registry root.
+func ResolveRepo(root, repo string) string {
+	if repo == "" || (strings.Contains(repo, "://") && !strings.HasPrefix(repo, "file://")) || strings.HasPrefix(repo, "git@") {
+		return repo
+	}
+	if path, ok := LocalPath(repo); ok && filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, repo)
+}
+
+func (s Source) IsLocal() bool {
+	_, ok := LocalPath(s.URL)
+	return ok
+}
+
+// Dir is where the source's content lives: the directory itself for local
+// sources, a clone in the temp dir for git ones. The official clone keeps
+// its historical path, which the shell reads theme previews from.
+func (s Source) Dir() string {
+	if path, ok := LocalPath(s.URL); ok {
+		return path
+	}
+	if s.URL == OfficialURL {
+		return filepath.Join(os.TempDir(), "dankdots-plugin-registry")
+	}
+	hash := sha256.Sum256([]byte(s.URL))
+	return filepath.Join(os.TempDir(), "dankdots-registry-"+hex.EncodeToString(hash[:])[:12])
+}
+
+type GitClient interface {
+	PlainClone(path string, url string) error
+	Pull(path string) error
+}
+
+// Sync clones or pulls a git source into dir. A clone that can't be pulled
+// (say, a corrupted shallow clone) is replaced by a fresh one. Local
+// sources are used in place and need no syncing.
+func Sync(fs afero.Fs, git GitClient, s Source, dir string) error {
+	if s.IsLocal() {
+		if exists, _ := afero.DirExists(fs, dir); !exists {
+			return fmt.Errorf("registry directory not found: %s", dir)
+		}
+		return nil
+	}
+
+	exists, err := afero.DirExists(fs, dir)
+	if err != nil {
+		return fmt.Errorf("failed to check cache directory: %w", err)
+	}
+
+	if exists {
+		if err := git.Pull(dir); err == nil {
+			return nil
+		}
+		if err := fs.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove corrupted registry: %w", err)
+		}
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := git.PlainClone(dir, s.URL); err != nil {
+		if exists {
+			return fmt.Errorf("failed to re-clone registry: %w", err)
+		}
+		return fmt.Errorf("failed to clone registry: %w", err)
+	}
+	return nil
+}
+
+// CopyDir copies a plugin or theme out of a local registry, leaving out
+// version control metadata.
+func CopyDir(fs afero.Fs, src, dst string) error {
+	return afero.Walk(fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return fs.MkdirAll(target, 0o755)
+		}
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(fs, target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,86 @@
+package registries
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	sources, err := Load(fs, "/missing.json")
+	require.NoError(t, err)
+	assert.Equal(t, []Source{Official()}, sources)
+
+	require.NoError(t, afero.WriteFile(fs, "/registries.json", []byte(`{"sources": [
+		{"name": "nfs", "url": "/mnt/registry", "priority": 50},
+		{"name": "team", "url": "git@example.com:team/registry.git", "priority": 10, "trusted": true},
+		{"name": "old", "url": "/srv/old", "disabled": true},
+		{"name": "official", "priority": -1, "trusted": true}
+	]}`), 0o644))
+
+	sources, err = Load(fs, "/registries.json")
+	require.NoError(t, err)
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
+	// Untrusted sources come last whatever their priority.
+	assert.Equal(t, []string{"team", "official", "nfs"}, names)
+	assert.Equal(t, OfficialURL, sources[1].URL)
+
+	require.NoError(t, afero.WriteFile(fs, "/dup.json", []byte(`{"sources": [{"name": "a", "url": "/a"}, {"name": "a", "url": "/b"}]}`), 0o644))
+	_, err = Load(fs, "/dup.json")
+	assert.Error(t, err)
+}
+
+func TestLocalPaths(t *testing.T) {
+	path, ok := LocalPath("file:///mnt/registry")
+	assert.True(t, ok)
+	assert.Equal(t, "/mnt/registry", path)
+
+	for _, url := range []string{OfficialURL, "git@example.com:team/registry.git", "ssh://git@example.com/registry"} {
+		_, ok := LocalPath(url)
+		assert.False(t, ok, url)
+	}
+
+	assert.Equal(t, "/mnt/registry/src/clock", ResolveRepo("/mnt/registry", "src/clock"))
+	assert.Equal(t, "/mnt/registry/src/clock", ResolveRepo("/mnt/registry", "./src/clock"))
+	assert.Equal(t, "/srv/clock", ResolveRepo("/mnt/registry", "file:///srv/clock"))
+	assert.Equal(t, "https://github.com/x/clock", ResolveRepo("/mnt/registry", "https://github.com/x/clock"))
+}
+
+type recordingGit struct {
+	cloned, pulled []string
+}
+
+func (g *recordingGit) PlainClone(path, url string) error {
+	g.cloned = append(g.cloned, url)
+	return nil
+}
+
+func (g *recordingGit) Pull(path string) error {
+	g.pulled = append(g.pulled, path)
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	git := &recordingGit{}
+
+	local := Source{Name: "nfs", URL: "/mnt/registry"}
+	assert.Error(t, Sync(fs, git, local, local.Dir()))
+	require.NoError(t, fs.MkdirAll("/mnt/registry", 0o755))
+	assert.NoError(t, Sync(fs, git, local, local.Dir()))
+	assert.Empty(t, git.cloned)
+
+	remote := Source{Name: "team", URL: "https://example.com/registry.git"}
+	require.NoError(t, Sync(fs, git, remote, "/cache/team"))
+	assert.Equal(t, []string{remote.URL}, git.cloned)
+	require.NoError(t, fs.MkdirAll("/cache/team", 0o755))
+	require.NoError(t, Sync(fs, git, remote, "/cache/team"))
+	assert.Equal(t, []string{"/cache/team"}, git.pulled)
+}
@@ -107,6 +107,8 @@ type WindowGeometry struct {
 	OutputX         int32
 	OutputY         int32
 	OutputTransform int32
+	AppID           string
+	Title           string
 }
 
 func GetActiveWindow() (*WindowGeometry, error) {
@@ -121,8 +123,15 @@ func GetActiveWindow() (*WindowGeometry, error) {
 }
 
 type hyprlandWindow struct {
-	At   [2]int32 `json:"at"`
-	Size [2]int32 `json:"size"`
+	At        [2]int32 `json:"at"`
+	Size      [2]int32 `json:"size"`
+	Class     string   `json:"class"`
+	Title     string   `json:"title"`
+	Mapped    bool     `json:"mapped"`
+	Hidden    bool     `json:"hidden"`
+	Workspace struct {
+		ID int `json:"id"`
+	} `json:"workspace"`
 }
 
 func getHyprlandActiveWindow() (*WindowGeometry, error) {
@@ -145,17 +154,127 @@ func getHyprlandActiveWindow() (*WindowGeometry, error) {
 		Y:      win.At[1],
 		Width:  win.Size[0],
 		Height: win.Size[1],
+		AppID:  win.Class,
+		Title:  win.Title,
 	}, nil
 }
 
+// GetVisibleWindows returns the windows on currently visible workspaces in
+// global logical coordinates. Compositors without a window list return nil.
+func GetVisibleWindows() []WindowGeometry {
+	switch DetectCompositor() {
+	case CompositorHyprland:
+		return getHyprlandVisibleWindows()
+	case CompositorSway:
+		return getSwayVisibleWindows("swaymsg")
+	case CompositorScroll:
+		return getSwayVisibleWindows("scrollmsg")
+	default:
+		return nil
+	}
+}
+
+func getHyprlandVisibleWindows() []WindowGeometry {
+	monOut, err := exec.Command("hyprctl", "-j", "monitors").Output()
+	if err != nil {
+		return nil
+	}
+	var monitors []hyprlandMonitor
+	if err := json.Unmarshal(monOut, &monitors); err != nil {
+		return nil
+	}
+	active := make(map[int]bool, len(monitors))
+	for _, m := range monitors {
+		active[m.ActiveWorkspace.ID] = true
+	}
+
+	clientsOut, err := exec.Command("hyprctl", "-j", "clients").Output()
+	if err != nil {
+		return nil
+	}
+	var clients []hyprlandWindow
+	if err := json.Unmarshal(clientsOut, &clients); err != nil {
+		return nil
+	}
+
+	var windows []WindowGeometry
+	for _, c := range clients {
+		if !c.Mapped || c.Hidden || !active[c.Workspace.ID] || c.Size[0] <= 0 || c.Size[1] <= 0 {
+			continue
+		}
+		windows = append(windows, WindowGeometry{
+			X:      c.At[0],
+			Y:      c.At[1],
+			Width:  c.Size[0],
+			Height: c.Size[1],
+			AppID:  c.Class,
+			Title:  c.Title,
+		})
+	}
+	return windows
+}
+
+type swayNode struct {
+	Name    string `json:"name"`
+	AppID   string `json:"app_id"`
+	Visible bool   `json:"visible"`
+	PID     int    `json:"pid"`
+	Rect    struct {
+		X      int32 `json:"x"`
+		Y      int32 `json:"y"`
+		Width  int32 `json:"width"`
+		Height int32 `json:"height"`
+	} `json:"rect"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+func getSwayVisibleWindows(msgCmd string) []WindowGeometry {
+	output, err := exec.Command(msgCmd, "-t", "get_tree").Output()
+	if err != nil {
+		return nil
+	}
+
+	var root swayNode
+	if err := json.Unmarshal(output, &root); err != nil {
+		return nil
+	}
+
+	var windows []WindowGeometry
+	var walk func(n *swayNode)
+	walk = func(n *swayNode) {
+		if n.PID > 0 && n.Visible && n.Rect.Width > 0 && n.Rect.Height > 0 {
+			windows = append(windows, WindowGeometry{
+				X:      n.Rect.X,
+				Y:      n.Rect.Y,
+				Width:  n.Rect.Width,
+				Height: n.Rect.Height,
+				AppID:  n.AppID,
+				Title:  n.Name,
+			})
+		}
+		for i := range n.Nodes {
+			walk(&n.Nodes[i])
+		}
+		for i := range n.FloatingNodes {
+			walk(&n.FloatingNodes[i])
+		}
+	}
+	walk(&root)
+	return windows
+}
+
 type hyprlandMonitor struct {
-	Name    string  `json:"name"`
-	X       int32   `json:"x"`
-	Y       int32   `json:"y"`
-	Width   int32   `json:"width"`
-	Height  int32   `json:"height"`
-	Scale   float64 `json:"scale"`
-	Focused bool    `json:"focused"`
+	Name            string  `json:"name"`
+	X               int32   `json:"x"`
+	Y               int32   `json:"y"`
+	Width           int32   `json:"width"`
+	Height          int32   `json:"height"`
+	Scale           float64 `json:"scale"`
+	Focused         bool    `json:"focused"`
+	ActiveWorkspace struct {
+		ID int `json:"id"`
+	} `json:"activeWorkspace"`
 }
 
 func GetHyprlandMonitorScale(name string) float64 {
@@ -567,6 +686,8 @@ func getDWLActiveWindow() (*WindowGeometry, error) {
 		x, y        int32
 		w, h        int32
 		scalefactor uint32
+		appID       string
+		title       string
 		gotFrame    bool
 	}
 
@@ -597,6 +718,12 @@ func getDWLActiveWindow() (*WindowGeometry, error) {
 		dwlOut.SetScalefactorHandler(func(e dwl_ipc.ZdwlIpcOutputV2ScalefactorEvent) {
 			state.scalefactor = e.Scalefactor
 		})
+		dwlOut.SetAppidHandler(func(e dwl_ipc.ZdwlIpcOutputV2AppidEvent) {
+			state.appID = e.Appid
+		})
+		dwlOut.SetTitleHandler(func(e dwl_ipc.ZdwlIpcOutputV2TitleEvent) {
+			state.title = e.Title
+		})
 		dwlOut.SetFrameHandler(func(e dwl_ipc.ZdwlIpcOutputV2FrameEvent) {
 			state.gotFrame = true
 		})
@@ -636,6 +763,8 @@ func getDWLActiveWindow() (*WindowGeometry, error) {
 			Height: state.h,
 			Output: state.name,
 			Scale:  scale,
+			AppID:  state.appID,
+			Title:  state.title,
 		}
 
 		if info, ok := getOutputInfo(state.name); ok {
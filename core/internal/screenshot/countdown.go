@@ -0,0 +1,264 @@
+package screenshot
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/proto/wlr_layer_shell"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/go-wayland/wayland/client"
+)
+
+const (
+	countdownSize      = 120 // logical pixels
+	countdownMargin    = 48
+	countdownGlyphSize = 6
+	// countdownSettle gives the compositor time to repaint without the
+	// countdown before the capture starts.
+	countdownSettle = 150 * time.Millisecond
+)
+
+type ProgressPhase string
+
+const (
+	PhaseCountdown ProgressPhase = "countdown"
+	PhaseSelecting ProgressPhase = "selecting"
+	PhaseCapturing ProgressPhase = "capturing"
+)
+
+type Progress struct {
+	Phase     ProgressPhase `json:"phase"`
+	Remaining int           `json:"remaining,omitempty"`
+}
+
+type countdownSurface struct {
+	output     *WaylandOutput
+	surface    *client.Surface
+	layerSurf  *wlr_layer_shell.ZwlrLayerSurfaceV1
+	configured bool
+
+	buf   *ShmBuffer
+	pool  *client.ShmPool
+	wlBuf *client.Buffer
+}
+
+func (s *Screenshoter) progress(p Progress) {
+	if s.config.OnProgress != nil {
+		s.config.OnProgress(p)
+	}
+}
+
+// runDelay waits for the configured delay, showing a countdown on every
+// output when the compositor supports layer shell.
+func (s *Screenshoter) runDelay() {
+	delay := s.config.Delay
+	deadline := time.Now().Add(delay)
+
+	var surfaces []*countdownSurface
+	if s.config.Countdown && s.layerShell != nil && s.compositor != nil && s.shm != nil {
+		surfaces = s.createCountdownSurfaces()
+	}
+
+	for remaining := int(math.Ceil(delay.Seconds())); remaining > 0; remaining-- {
+		s.progress(Progress{Phase: PhaseCountdown, Remaining: remaining})
+		for _, cs := range surfaces {
+			s.drawCountdown(cs, remaining)
+		}
+		if len(surfaces) > 0 {
+			if err := s.roundtrip(); err != nil {
+				log.Debug("countdown roundtrip failed", "err", err)
+			}
+		}
+		time.Sleep(time.Until(deadline.Add(-time.Duration(remaining-1) * time.Second)))
+	}
+
+	if len(surfaces) == 0 {
+		return
+	}
+
+	for _, cs := range surfaces {
+		cs.destroy()
+	}
+	if err := s.roundtrip(); err != nil {
+		log.Debug("countdown roundtrip failed", "err", err)
+	}
+	time.Sleep(countdownSettle)
+}
+
+func (s *Screenshoter) createCountdownSurfaces() []*countdownSurface {
+	var surfaces []*countdownSurface
+	for _, output := range s.GetOutputs() {
+		cs, err := s.createCountdownSurface(output)
+		if err != nil {
+			log.Debug("failed to create countdown surface", "output", output.name, "err", err)
+			continue
+		}
+		surfaces = append(surfaces, cs)
+	}
+
+	if err := s.roundtrip(); err != nil {
+		log.Debug("countdown roundtrip failed", "err", err)
+	}
+	return surfaces
+}
+
+func (s *Screenshoter) createCountdownSurface(output *WaylandOutput) (*countdownSurface, error) {
+	surface, err := s.compositor.CreateSurface()
+	if err != nil {
+		return nil, err
+	}
+
+	layerSurf, err := s.layerShell.GetLayerSurface(
+		surface,
+		output.wlOutput,
+		uint32(wlr_layer_shell.ZwlrLayerShellV1LayerOverlay),
+		"dms-screenshot-countdown",
+	)
+	if err != nil {
+		surface.Destroy()
+		return nil, err
+	}
+
+	cs := &countdownSurface{output: output, surface: surface, layerSurf: layerSurf}
+
+	// An empty input region keeps pointer input going to whatever menu or
+	// window is being set up for the capture.
+	if region, err := s.compositor.CreateRegion(); err == nil {
+		_ = surface.SetInputRegion(region)
+		_ = region.Destroy()
+	}
+
+	_ = layerSurf.SetSize(countdownSize, countdownSize)
+	_ = layerSurf.SetAnchor(uint32(wlr_layer_shell.ZwlrLayerSurfaceV1AnchorTop))
+	_ = layerSurf.SetMargin(countdownMargin, 0, 0, 0)
+	_ = layerSurf.SetKeyboardInteractivity(uint32(wlr_layer_shell.ZwlrLayerSurfaceV1KeyboardInteractivityNone))
+
+	layerSurf.SetConfigureHandler(func(e wlr_layer_shell.ZwlrLayerSurfaceV1ConfigureEvent) {
+		if err := layerSurf.AckConfigure(e.Serial); err != nil {
+			return
+		}
+		cs.configured = true
+	})
+
+	if err := surface.Commit(); err != nil {
+		cs.destroy()
+		return nil, err
+	}
+	return cs, nil
+}
+
+func (s *Screenshoter) drawCountdown(cs *countdownSurface, remaining int) {
+	if !cs.configured {
+		return
+	}
+
+	scale := max(cs.output.scale, 1)
+	size := countdownSize * int(scale)
+
+	buf, err := CreateShmBuffer(size, size, size*4)
+	if err != nil {
+		log.Debug("failed to create countdown buffer", "err", err)
+		return
+	}
+	buf.Format = FormatARGB8888
+
+	style := LoadOverlayStyle()
+	drawCountdownFrame(buf, strconv.Itoa(remaining), style, int(scale))
+
+	pool, err := s.shm.CreatePool(buf.Fd(), int32(buf.Size()))
+	if err != nil {
+		buf.Close()
+		return
+	}
+	wlBuf, err := pool.CreateBuffer(0, int32(size), int32(size), int32(buf.Stride), uint32(FormatARGB8888))
+	if err != nil {
+		pool.Destroy()
+		buf.Close()
+		return
+	}
+
+	_ = cs.surface.SetBufferScale(scale)
+	_ = cs.surface.Attach(wlBuf, 0, 0)
+	_ = cs.surface.DamageBuffer(0, 0, int32(size), int32(size))
+	_ = cs.surface.Commit()
+
+	cs.releaseBuffer()
+	cs.buf, cs.pool, cs.wlBuf = buf, pool, wlBuf
+}
+
+// drawCountdownFrame renders the remaining seconds centered on the overlay
+// background, scaling the selector's bitmap font up.
+func drawCountdownFrame(buf *ShmBuffer, text string, style OverlayStyle, scale int) {
+	// ARGB8888 is premultiplied.
+	premul := func(c uint8) uint8 { return uint8(int(c) * int(style.BackgroundA) / 255) }
+	bgR, bgG, bgB := premul(style.BackgroundR), premul(style.BackgroundG), premul(style.BackgroundB)
+
+	data := buf.Data()
+	for i := 0; i+3 < len(data); i += 4 {
+		data[i+0] = bgB
+		data[i+1] = bgG
+		data[i+2] = bgR
+		data[i+3] = style.BackgroundA
+	}
+
+	px := countdownGlyphSize * scale
+	for px > scale && len(text)*9*px > buf.Width {
+		px -= scale
+	}
+	charW := 9 * px
+	textW := len(text)*charW - px
+	textH := 12 * px
+	ox := (buf.Width - textW) / 2
+	oy := (buf.Height-textH)/2 + px
+
+	for i, ch := range text {
+		glyph, ok := fontGlyphs[ch]
+		if !ok {
+			continue
+		}
+		for row := 0; row < 12; row++ {
+			for col := 0; col < 8; col++ {
+				if glyph[row]&(1<<(7-col)) == 0 {
+					continue
+				}
+				x0 := ox + i*charW + col*px
+				y0 := oy + row*px
+				for y := max(y0, 0); y < min(y0+px, buf.Height); y++ {
+					for x := max(x0, 0); x < min(x0+px, buf.Width); x++ {
+						off := y*buf.Stride + x*4
+						data[off+0] = style.AccentB
+						data[off+1] = style.AccentG
+						data[off+2] = style.AccentR
+						data[off+3] = 255
+					}
+				}
+			}
+		}
+	}
+}
+
+func (cs *countdownSurface) releaseBuffer() {
+	if cs.wlBuf != nil {
+		cs.wlBuf.Destroy()
+		cs.wlBuf = nil
+	}
+	if cs.pool != nil {
+		cs.pool.Destroy()
+		cs.pool = nil
+	}
+	if cs.buf != nil {
+		cs.buf.Close()
+		cs.buf = nil
+	}
+}
+
+func (cs *countdownSurface) destroy() {
+	if cs.layerSurf != nil {
+		cs.layerSurf.Destroy()
+	}
+	if cs.surface != nil {
+		cs.surface.Destroy()
+	}
+	cs.releaseBuffer()
+}
@@ -56,11 +56,129 @@ func BufferToImageWithFormat(buf *ShmBuffer, format uint32) *image.RGBA {
 	return img
 }
 
+type EncodeOptions struct {
+	Format      Format
+	Quality     int
+	Compression PNGCompression
+}
+
+func (c Config) EncodeOptions() EncodeOptions {
+	return EncodeOptions{Format: c.Format, Quality: c.Quality, Compression: c.Compression}
+}
+
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(s) {
+	case "png":
+		return FormatPNG, true
+	case "jpg", "jpeg":
+		return FormatJPEG, true
+	case "ppm":
+		return FormatPPM, true
+	case "qoi":
+		return FormatQOI, true
+	case "webp":
+		return FormatWebP, true
+	default:
+		return FormatPNG, false
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatJPEG:
+		return "jpg"
+	case FormatPPM:
+		return "ppm"
+	case FormatQOI:
+		return "qoi"
+	case FormatWebP:
+		return "webp"
+	default:
+		return "png"
+	}
+}
+
+func (f Format) MimeType() string {
+	switch f {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatPPM:
+		return "image/x-portable-pixmap"
+	case FormatQOI:
+		return "image/qoi"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
+
+func ParsePNGCompression(s string) (PNGCompression, bool) {
+	switch strings.ToLower(s) {
+	case "fast", "speed", "":
+		return PNGCompressionFast, true
+	case "default":
+		return PNGCompressionDefault, true
+	case "best", "size":
+		return PNGCompressionBest, true
+	case "none":
+		return PNGCompressionNone, true
+	default:
+		return PNGCompressionFast, false
+	}
+}
+
+func (c PNGCompression) String() string {
+	switch c {
+	case PNGCompressionDefault:
+		return "default"
+	case PNGCompressionBest:
+		return "best"
+	case PNGCompressionNone:
+		return "none"
+	default:
+		return "fast"
+	}
+}
+
+func (c PNGCompression) level() png.CompressionLevel {
+	switch c {
+	case PNGCompressionDefault:
+		return png.DefaultCompression
+	case PNGCompressionBest:
+		return png.BestCompression
+	case PNGCompressionNone:
+		return png.NoCompression
+	default:
+		return png.BestSpeed
+	}
+}
+
 func EncodePNG(w io.Writer, img image.Image) error {
-	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	return EncodePNGWithCompression(w, img, PNGCompressionFast)
+}
+
+func EncodePNGWithCompression(w io.Writer, img image.Image, compression PNGCompression) error {
+	enc := png.Encoder{CompressionLevel: compression.level()}
 	return enc.Encode(w, img)
 }
 
+// EncodeImage writes img in the format selected by opts.
+func EncodeImage(w io.Writer, img *image.RGBA, opts EncodeOptions) error {
+	switch opts.Format {
+	case FormatJPEG:
+		return EncodeJPEG(w, img, opts.Quality)
+	case FormatPPM:
+		return EncodePPM(w, img)
+	case FormatQOI:
+		return EncodeQOI(w, img)
+	case FormatWebP:
+		return EncodeWebP(w, img)
+	default:
+		return EncodePNGWithCompression(w, img, opts.Compression)
+	}
+}
+
 func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
 	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
 }
@@ -89,15 +207,7 @@ func EncodePPM(w io.Writer, img *image.RGBA) error {
 }
 
 func GenerateFilename(format Format) string {
-	t := time.Now()
-	ext := "png"
-	switch format {
-	case FormatJPEG:
-		ext = "jpg"
-	case FormatPPM:
-		ext = "ppm"
-	}
-	return fmt.Sprintf("screenshot_%s.%s", t.Format("2006-01-02_15-04-05"), ext)
+	return ExpandFilenameTemplate(DefaultFilenameTemplate, FilenameContext{Time: time.Now(), Format: format})
 }
 
 func GetOutputDir() string {
@@ -150,23 +260,20 @@ func getXDGPicturesDir() string {
 }
 
 func WriteToFile(buf *ShmBuffer, path string, format Format, quality int) error {
-	return WriteToFileWithFormat(buf, path, format, quality, uint32(FormatARGB8888))
+	opts := EncodeOptions{Format: format, Quality: quality}
+	return WriteToFileWithFormat(buf, path, opts, uint32(FormatARGB8888))
 }
 
-func WriteToFileWithFormat(buf *ShmBuffer, path string, format Format, quality int, pixelFormat uint32) error {
+func WriteToFileWithFormat(buf *ShmBuffer, path string, opts EncodeOptions, pixelFormat uint32) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
 	img := BufferToImageWithFormat(buf, pixelFormat)
-	switch format {
-	case FormatJPEG:
-		return EncodeJPEG(f, img, quality)
-	case FormatPPM:
-		return EncodePPM(f, img)
-	default:
-		return EncodePNG(f, img)
+	if err := EncodeImage(f, img, opts); err != nil {
+		f.Close()
+		return err
 	}
+	return f.Close()
 }
@@ -0,0 +1,112 @@
+package screenshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+const (
+	qoiOpIndex = 0x00
+	qoiOpDiff  = 0x40
+	qoiOpLuma  = 0x80
+	qoiOpRun   = 0xc0
+	qoiOpRGB   = 0xfe
+	qoiOpRGBA  = 0xff
+)
+
+var qoiEndMarker = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+type qoiPixel struct {
+	r, g, b, a uint8
+}
+
+func (p qoiPixel) hash() int {
+	return (int(p.r)*3 + int(p.g)*5 + int(p.b)*7 + int(p.a)*11) % 64
+}
+
+// EncodeQOI writes img in the Quite OK Image format (https://qoiformat.org).
+func EncodeQOI(w io.Writer, img *image.RGBA) error {
+	bw := bufio.NewWriter(w)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	channels := byte(4)
+	if img.Opaque() {
+		channels = 3
+	}
+
+	header := make([]byte, 14)
+	copy(header, "qoif")
+	binary.BigEndian.PutUint32(header[4:], uint32(width))
+	binary.BigEndian.PutUint32(header[8:], uint32(height))
+	header[12] = channels
+	header[13] = 0
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	var index [64]qoiPixel
+	prev := qoiPixel{a: 255}
+	run := 0
+	total := width * height
+	n := 0
+
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+width*4]
+		for x := 0; x < width; x++ {
+			px := qoiPixel{row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]}
+			n++
+
+			if px == prev {
+				run++
+				if run == 62 || n == total {
+					bw.WriteByte(qoiOpRun | byte(run-1))
+					run = 0
+				}
+				continue
+			}
+
+			if run > 0 {
+				bw.WriteByte(qoiOpRun | byte(run-1))
+				run = 0
+			}
+
+			h := px.hash()
+			if index[h] == px {
+				bw.WriteByte(qoiOpIndex | byte(h))
+				prev = px
+				continue
+			}
+			index[h] = px
+
+			if px.a != prev.a {
+				bw.Write([]byte{qoiOpRGBA, px.r, px.g, px.b, px.a})
+				prev = px
+				continue
+			}
+
+			vr := int8(px.r - prev.r)
+			vg := int8(px.g - prev.g)
+			vb := int8(px.b - prev.b)
+			vgR := vr - vg
+			vgB := vb - vg
+
+			switch {
+			case vr > -3 && vr < 2 && vg > -3 && vg < 2 && vb > -3 && vb < 2:
+				bw.WriteByte(qoiOpDiff | byte(vr+2)<<4 | byte(vg+2)<<2 | byte(vb+2))
+			case vgR > -9 && vgR < 8 && vg > -33 && vg < 32 && vgB > -9 && vgB < 8:
+				bw.Write([]byte{qoiOpLuma | byte(vg+32), byte(vgR+8)<<4 | byte(vgB+8)})
+			default:
+				bw.Write([]byte{qoiOpRGB, px.r, px.g, px.b})
+			}
+			prev = px
+		}
+	}
+
+	if _, err := bw.Write(qoiEndMarker); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,168 @@
+package screenshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func testImage(w, h int, opaque bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(42))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			off := y*img.Stride + x*4
+			switch {
+			case x < w/3:
+				copy(img.Pix[off:], []byte{30, 30, 46, 255})
+			case y%2 == 0:
+				copy(img.Pix[off:], []byte{byte(x), byte(y), byte(x + y), 255})
+			default:
+				copy(img.Pix[off:], []byte{byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), 255})
+			}
+			if !opaque && x%5 == 0 {
+				img.Pix[off+3] = byte(r.Intn(256))
+			}
+		}
+	}
+	return img
+}
+
+// assertSamePixels compares raw channel bytes. Screenshot buffers carry
+// straight (non-premultiplied) alpha, so color.Color conversion is avoided.
+func assertSamePixels(t *testing.T, want *image.RGBA, got image.Image) {
+	t.Helper()
+	require.Equal(t, want.Bounds(), got.Bounds())
+
+	var pix []byte
+	switch img := got.(type) {
+	case *image.NRGBA:
+		pix = img.Pix
+	case *image.RGBA:
+		pix = img.Pix
+	default:
+		t.Fatalf("unexpected image type %T", got)
+	}
+	require.Equal(t, want.Pix, pix)
+}
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		w, h   int
+		opaque bool
+	}{
+		{1, 1, true},
+		{2, 3, true},
+		{97, 61, true},
+		{64, 64, false},
+	} {
+		img := testImage(tc.w, tc.h, tc.opaque)
+
+		var buf bytes.Buffer
+		require.NoError(t, EncodeWebP(&buf, img))
+
+		decoded, err := webp.Decode(&buf)
+		require.NoError(t, err)
+		assertSamePixels(t, img, decoded)
+	}
+}
+
+func TestEncodeWebP_TooLarge(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1))
+	assert.Error(t, EncodeWebP(&bytes.Buffer{}, img))
+}
+
+func TestVP8LPrefixEncode(t *testing.T) {
+	for v := 1; v <= 5000; v++ {
+		prefix, bits, extra := vp8lPrefixEncode(v)
+		got := prefix + 1
+		if prefix >= 4 {
+			eb := (prefix - 2) >> 1
+			assert.Equal(t, eb, bits)
+			got = (2+prefix&1)<<eb + extra + 1
+		}
+		require.Equal(t, v, got, "value %d", v)
+	}
+}
+
+// decodeQOI is a minimal reference decoder used to check the encoder.
+func decodeQOI(t *testing.T, data []byte) *image.RGBA {
+	t.Helper()
+	require.Equal(t, "qoif", string(data[:4]))
+	w := int(binary.BigEndian.Uint32(data[4:]))
+	h := int(binary.BigEndian.Uint32(data[8:]))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	var index [64]qoiPixel
+	px := qoiPixel{a: 255}
+	p := 14
+	run := 0
+	for i := 0; i < w*h; i++ {
+		if run > 0 {
+			run--
+		} else {
+			b := data[p]
+			p++
+			switch {
+			case b == qoiOpRGB:
+				px.r, px.g, px.b = data[p], data[p+1], data[p+2]
+				p += 3
+			case b == qoiOpRGBA:
+				px = qoiPixel{data[p], data[p+1], data[p+2], data[p+3]}
+				p += 4
+			case b&0xc0 == qoiOpIndex:
+				px = index[b]
+			case b&0xc0 == qoiOpDiff:
+				px.r += (b>>4)&3 - 2
+				px.g += (b>>2)&3 - 2
+				px.b += b&3 - 2
+			case b&0xc0 == qoiOpLuma:
+				b2 := data[p]
+				p++
+				vg := b&0x3f - 32
+				px.r += vg - 8 + (b2>>4)&0xf
+				px.g += vg
+				px.b += vg - 8 + b2&0xf
+			case b&0xc0 == qoiOpRun:
+				run = int(b & 0x3f)
+			}
+			index[px.hash()] = px
+		}
+		copy(img.Pix[i*4:], []byte{px.r, px.g, px.b, px.a})
+	}
+	assert.Equal(t, qoiEndMarker, data[p:])
+	return img
+}
+
+func TestEncodeQOI_RoundTrip(t *testing.T) {
+	for _, opaque := range []bool{true, false} {
+		img := testImage(120, 40, opaque)
+
+		var buf bytes.Buffer
+		require.NoError(t, EncodeQOI(&buf, img))
+
+		channels := buf.Bytes()[12]
+		if opaque {
+			assert.Equal(t, byte(3), channels)
+		} else {
+			assert.Equal(t, byte(4), channels)
+		}
+		assertSamePixels(t, img, decodeQOI(t, buf.Bytes()))
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"PNG": FormatPNG, "jpeg": FormatJPEG, "webp": FormatWebP, "qoi": FormatQOI, "ppm": FormatPPM} {
+		got, ok := ParseFormat(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, ok := ParseFormat("gif")
+	assert.False(t, ok)
+}
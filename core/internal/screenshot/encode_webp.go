@@ -0,0 +1,464 @@
+package screenshot
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Lossless WebP (VP8L) encoder. It applies the subtract-green transform and
+// codes pixels with a single set of prefix codes, using backward references
+// to the left and upper neighbours, which covers the flat areas and repeated
+// rows that dominate screenshots.
+
+const (
+	vp8lSignature       = 0x2f
+	vp8lMaxDimension    = 1 << 14
+	vp8lTransformSubGrn = 2
+	vp8lNumLiterals     = 256
+	vp8lNumLengthCodes  = 24
+	vp8lNumDistCodes    = 40
+	vp8lMaxLength       = 4096
+	vp8lMinMatch        = 3
+	vp8lMaxCodeLength   = 15
+	vp8lMaxCLCodeLength = 7
+	vp8lNumCLCodes      = 19
+	vp8lDistCodeUp      = 1
+	vp8lDistCodeLeft    = 2
+)
+
+var vp8lCodeLengthOrder = [vp8lNumCLCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+type vp8lBitWriter struct {
+	buf   bytes.Buffer
+	acc   uint64
+	nbits uint
+}
+
+func (w *vp8lBitWriter) writeBits(value uint32, n uint) {
+	if n == 0 {
+		return
+	}
+	w.acc |= uint64(value&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf.WriteByte(byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *vp8lBitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf.WriteByte(byte(w.acc))
+		w.acc = 0
+		w.nbits = 0
+	}
+	return w.buf.Bytes()
+}
+
+type vp8lToken struct {
+	argb     uint32
+	length   int
+	distCode int
+}
+
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	single  bool
+}
+
+func (c *prefixCode) write(w *vp8lBitWriter, symbol int) {
+	if c.single {
+		return
+	}
+	w.writeBits(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+}
+
+// EncodeWebP writes img as a lossless WebP file.
+func EncodeWebP(w io.Writer, img *image.RGBA) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return fmt.Errorf("webp: image size %dx%d outside 1..%d", width, height, vp8lMaxDimension)
+	}
+
+	argb := make([]uint32, width*height)
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			r, g, b, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+			r -= g
+			b -= g
+			argb[y*width+x] = uint32(a)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+		}
+	}
+
+	tokens := vp8lBackwardRefs(argb, width)
+
+	green := make([]int, vp8lNumLiterals+vp8lNumLengthCodes)
+	red := make([]int, vp8lNumLiterals)
+	blue := make([]int, vp8lNumLiterals)
+	alpha := make([]int, vp8lNumLiterals)
+	dist := make([]int, vp8lNumDistCodes)
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[(t.argb>>8)&0xff]++
+			red[(t.argb>>16)&0xff]++
+			blue[t.argb&0xff]++
+			alpha[t.argb>>24]++
+			continue
+		}
+		lp, _, _ := vp8lPrefixEncode(t.length)
+		dp, _, _ := vp8lPrefixEncode(t.distCode)
+		green[vp8lNumLiterals+lp]++
+		dist[dp]++
+	}
+
+	bw := &vp8lBitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if img.Opaque() {
+		bw.writeBits(0, 1)
+	} else {
+		bw.writeBits(1, 1)
+	}
+	bw.writeBits(0, 3)
+
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lTransformSubGrn, 2)
+	bw.writeBits(0, 1)
+
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // no meta prefix codes
+
+	codes := make([]*prefixCode, 5)
+	for i, hist := range [][]int{green, red, blue, alpha, dist} {
+		codes[i] = writePrefixCode(bw, hist)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int((t.argb>>8)&0xff))
+			codes[1].write(bw, int((t.argb>>16)&0xff))
+			codes[2].write(bw, int(t.argb&0xff))
+			codes[3].write(bw, int(t.argb>>24))
+			continue
+		}
+		lp, lbits, lextra := vp8lPrefixEncode(t.length)
+		codes[0].write(bw, vp8lNumLiterals+lp)
+		bw.writeBits(uint32(lextra), uint(lbits))
+		dp, dbits, dextra := vp8lPrefixEncode(t.distCode)
+		codes[4].write(bw, dp)
+		bw.writeBits(uint32(dextra), uint(dbits))
+	}
+
+	data := bw.bytes()
+	chunkSize := len(data)
+	padded := chunkSize + chunkSize&1
+
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+padded))
+	copy(header[8:], "WEBP")
+	copy(header[12:], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(chunkSize))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if chunkSize&1 == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func vp8lBackwardRefs(argb []uint32, width int) []vp8lToken {
+	n := len(argb)
+	tokens := make([]vp8lToken, 0, n/4)
+
+	matchLen := func(pos, dist int) int {
+		if pos < dist {
+			return 0
+		}
+		limit := min(n-pos, vp8lMaxLength)
+		l := 0
+		for l < limit && argb[pos+l] == argb[pos+l-dist] {
+			l++
+		}
+		return l
+	}
+
+	for i := 0; i < n; {
+		left := matchLen(i, 1)
+		up := 0
+		if width > 1 {
+			up = matchLen(i, width)
+		}
+
+		switch {
+		case up >= vp8lMinMatch && up >= left:
+			tokens = append(tokens, vp8lToken{length: up, distCode: vp8lDistCodeUp})
+			i += up
+		case left >= vp8lMinMatch:
+			tokens = append(tokens, vp8lToken{length: left, distCode: vp8lDistCodeLeft})
+			i += left
+		default:
+			tokens = append(tokens, vp8lToken{argb: argb[i]})
+			i++
+		}
+	}
+	return tokens
+}
+
+// vp8lPrefixEncode maps a length or distance code to its prefix symbol and
+// the extra bits that follow it.
+func vp8lPrefixEncode(value int) (prefix, extraBits, extraValue int) {
+	if value <= 4 {
+		return value - 1, 0, 0
+	}
+	value--
+	highest := 0
+	for v := value; v > 1; v >>= 1 {
+		highest++
+	}
+	second := (value >> (highest - 1)) & 1
+	extraBits = highest - 1
+	extraValue = value & (1<<extraBits - 1)
+	return 2*highest + second, extraBits, extraValue
+}
+
+func writePrefixCode(bw *vp8lBitWriter, hist []int) *prefixCode {
+	var used []int
+	for sym, c := range hist {
+		if c > 0 {
+			used = append(used, sym)
+		}
+	}
+
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < vp8lNumLiterals) {
+		return writeSimplePrefixCode(bw, used, len(hist))
+	}
+
+	code := buildPrefixCode(hist, vp8lMaxCodeLength)
+
+	clHist := make([]int, vp8lNumCLCodes)
+	clTokens := encodeCodeLengths(code.lengths)
+	for _, t := range clTokens {
+		clHist[t[0]]++
+	}
+	clCode := buildPrefixCode(clHist, vp8lMaxCLCodeLength)
+
+	numCL := 4
+	for i := vp8lNumCLCodes - 1; i >= 4; i-- {
+		if clCode.lengths[vp8lCodeLengthOrder[i]] != 0 {
+			numCL = i + 1
+			break
+		}
+	}
+
+	bw.writeBits(0, 1) // normal code
+	bw.writeBits(uint32(numCL-4), 4)
+	for i := 0; i < numCL; i++ {
+		bw.writeBits(uint32(clCode.lengths[vp8lCodeLengthOrder[i]]), 3)
+	}
+	bw.writeBits(0, 1) // max_symbol = alphabet size
+
+	for _, t := range clTokens {
+		clCode.write(bw, t[0])
+		switch t[0] {
+		case 17:
+			bw.writeBits(uint32(t[1]-3), 3)
+		case 18:
+			bw.writeBits(uint32(t[1]-11), 7)
+		}
+	}
+	return code
+}
+
+func writeSimplePrefixCode(bw *vp8lBitWriter, used []int, size int) *prefixCode {
+	code := &prefixCode{lengths: make([]uint8, size), codes: make([]uint16, size)}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	bw.writeBits(1, 1) // simple code
+	bw.writeBits(uint32(len(used)-1), 1)
+	if used[0] < 2 {
+		bw.writeBits(0, 1)
+		bw.writeBits(uint32(used[0]), 1)
+	} else {
+		bw.writeBits(1, 1)
+		bw.writeBits(uint32(used[0]), 8)
+	}
+
+	if len(used) == 1 {
+		code.single = true
+		return code
+	}
+
+	bw.writeBits(uint32(used[1]), 8)
+	code.lengths[used[0]], code.codes[used[0]] = 1, 0
+	code.lengths[used[1]], code.codes[used[1]] = 1, 1
+	return code
+}
+
+// encodeCodeLengths run-length codes zero runs with the 17/18 repeat codes.
+func encodeCodeLengths(lengths []uint8) [][2]int {
+	var out [][2]int
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			out = append(out, [2]int{int(lengths[i]), 0})
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(lengths) && lengths[i+run] == 0 {
+			run++
+		}
+		i += run
+		for run > 0 {
+			switch {
+			case run >= 11:
+				r := min(run, 138)
+				out = append(out, [2]int{18, r})
+				run -= r
+			case run >= 3:
+				out = append(out, [2]int{17, run})
+				run = 0
+			default:
+				out = append(out, [2]int{0, 0})
+				run--
+			}
+		}
+	}
+	return out
+}
+
+type huffNode struct {
+	count       int
+	symbol      int
+	left, right *huffNode
+}
+
+type huffHeap []*huffNode
+
+func (h huffHeap) Len() int { return len(h) }
+func (h huffHeap) Less(i, j int) bool {
+	if h[i].count == h[j].count {
+		return h[i].symbol < h[j].symbol
+	}
+	return h[i].count < h[j].count
+}
+func (h huffHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *huffHeap) Push(x any)   { *h = append(*h, x.(*huffNode)) }
+func (h *huffHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// buildPrefixCode builds length-limited canonical codes. Depth is limited
+// the way libwebp does it: rare symbols are bumped up to a rising minimum
+// count until the tree fits.
+func buildPrefixCode(hist []int, maxLength int) *prefixCode {
+	code := &prefixCode{lengths: make([]uint8, len(hist)), codes: make([]uint16, len(hist))}
+
+	used := 0
+	last := 0
+	for sym, c := range hist {
+		if c > 0 {
+			used++
+			last = sym
+		}
+	}
+	if used == 0 {
+		code.single = true
+		return code
+	}
+	if used == 1 {
+		code.lengths[last] = 1
+		code.single = true
+		return code
+	}
+
+	for minCount := 1; ; minCount *= 2 {
+		h := make(huffHeap, 0, used)
+		for sym, c := range hist {
+			if c > 0 {
+				h = append(h, &huffNode{count: max(c, minCount), symbol: sym})
+			}
+		}
+		heap.Init(&h)
+		next := len(hist)
+		for h.Len() > 1 {
+			a := heap.Pop(&h).(*huffNode)
+			b := heap.Pop(&h).(*huffNode)
+			heap.Push(&h, &huffNode{count: a.count + b.count, symbol: next, left: a, right: b})
+			next++
+		}
+
+		for i := range code.lengths {
+			code.lengths[i] = 0
+		}
+		tooDeep := false
+		var walk func(n *huffNode, depth int)
+		walk = func(n *huffNode, depth int) {
+			if n.left == nil {
+				if depth > maxLength {
+					tooDeep = true
+				}
+				code.lengths[n.symbol] = uint8(depth)
+				return
+			}
+			walk(n.left, depth+1)
+			walk(n.right, depth+1)
+		}
+		walk(h[0], 0)
+		if !tooDeep {
+			break
+		}
+	}
+
+	var blCount [vp8lMaxCodeLength + 1]int
+	for _, l := range code.lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+	var nextCode [vp8lMaxCodeLength + 2]int
+	c := 0
+	for bits := 1; bits <= vp8lMaxCodeLength; bits++ {
+		c = (c + blCount[bits-1]) << 1
+		nextCode[bits] = c
+	}
+	for sym, l := range code.lengths {
+		if l == 0 {
+			continue
+		}
+		code.codes[sym] = reverseBits(uint16(nextCode[l]), l)
+		nextCode[l]++
+	}
+	return code
+}
+
+func reverseBits(v uint16, n uint8) uint16 {
+	var r uint16
+	for i := uint8(0); i < n; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
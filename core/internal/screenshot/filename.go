@@ -0,0 +1,222 @@
+package screenshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFilenameTemplate reproduces the historical screenshot_<date>_<time> names.
+const DefaultFilenameTemplate = "screenshot_%Y-%m-%d_%H-%M-%S"
+
+const maxTokenLength = 64
+
+// FilenameContext carries the values substituted into a filename template.
+type FilenameContext struct {
+	Time    time.Time
+	Mode    Mode
+	Format  Format
+	Output  string
+	AppID   string
+	Title   string
+	Counter int
+}
+
+// ExpandFilenameTemplate expands strftime-style conversions (%Y, %m, %d, %H,
+// %M, %S, ...) and the {output}, {app}, {title}, {mode}, {count} and
+// {count:N} tokens. The format extension is appended when missing. Slashes
+// in the template create subdirectories; slashes in token values do not.
+func ExpandFilenameTemplate(tmpl string, ctx FilenameContext) string {
+	if tmpl == "" {
+		tmpl = DefaultFilenameTemplate
+	}
+	if ctx.Time.IsZero() {
+		ctx.Time = time.Now()
+	}
+
+	name := expandStrftime(expandTokens(tmpl, ctx), ctx.Time)
+
+	ext := "." + ctx.Format.Extension()
+	if !strings.EqualFold(filepath.Ext(name), ext) {
+		name += ext
+	}
+	return name
+}
+
+// TemplateUsesCounter reports whether tmpl contains a {count} token, so the
+// persistent counter is only advanced when it is actually used.
+func TemplateUsesCounter(tmpl string) bool {
+	return strings.Contains(tmpl, "{count}") || strings.Contains(tmpl, "{count:")
+}
+
+// ResolveFilePath expands tmpl below dir, creates any subdirectories and
+// appends a numeric suffix rather than overwrite an existing file.
+func ResolveFilePath(dir, tmpl string, ctx FilenameContext) (string, error) {
+	rel := filepath.Clean(ExpandFilenameTemplate(tmpl, ctx))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("filename template escapes output directory: %s", tmpl)
+	}
+
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path, nil
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; i < 10000; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free filename for %s", path)
+}
+
+func expandTokens(tmpl string, ctx FilenameContext) string {
+	var sb strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '{' {
+			sb.WriteByte(tmpl[i])
+			continue
+		}
+		end := strings.IndexByte(tmpl[i:], '}')
+		if end < 0 {
+			sb.WriteString(tmpl[i:])
+			break
+		}
+
+		token := tmpl[i+1 : i+end]
+		value, ok := tokenValue(token, ctx)
+		if !ok {
+			sb.WriteString(tmpl[i : i+end+1])
+		} else {
+			sb.WriteString(value)
+		}
+		i += end
+	}
+	return sb.String()
+}
+
+func tokenValue(token string, ctx FilenameContext) (string, bool) {
+	name, arg, _ := strings.Cut(token, ":")
+	switch name {
+	case "output":
+		return sanitizeToken(ctx.Output, "unknown"), true
+	case "app", "app_id":
+		return sanitizeToken(ctx.AppID, "unknown"), true
+	case "title":
+		return sanitizeToken(ctx.Title, "untitled"), true
+	case "mode":
+		return ctx.Mode.String(), true
+	case "count":
+		width, err := strconv.Atoi(arg)
+		if err != nil || width < 0 {
+			width = 0
+		}
+		return fmt.Sprintf("%0*d", width, ctx.Counter), true
+	default:
+		return "", false
+	}
+}
+
+func sanitizeToken(value, fallback string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+
+	value = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == 0:
+			return '_'
+		case r < 0x20:
+			return -1
+		default:
+			return r
+		}
+	}, value)
+
+	if runes := []rune(value); len(runes) > maxTokenLength {
+		value = strings.TrimSpace(string(runes[:maxTokenLength]))
+	}
+	if value == "." || value == ".." {
+		return fallback
+	}
+	return value
+}
+
+func expandStrftime(s string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'Y':
+			sb.WriteString(t.Format("2006"))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'e':
+			sb.WriteString(t.Format("_2"))
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'I':
+			sb.WriteString(t.Format("03"))
+		case 'M':
+			sb.WriteString(t.Format("04"))
+		case 'S':
+			sb.WriteString(t.Format("05"))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'b', 'h':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			sb.WriteString(strconv.Itoa(wd))
+		case 'V':
+			_, week := t.ISOWeek()
+			fmt.Fprintf(&sb, "%02d", week)
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
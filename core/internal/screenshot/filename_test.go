@@ -0,0 +1,76 @@
+package screenshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandFilenameTemplate(t *testing.T) {
+	ts := time.Date(2026, 3, 7, 9, 5, 2, 0, time.Local)
+	ctx := FilenameContext{
+		Time:    ts,
+		Mode:    ModeWindow,
+		Format:  FormatPNG,
+		Output:  "DP-1",
+		AppID:   "org.mozilla.firefox",
+		Title:   "Docs / Sheet",
+		Counter: 7,
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"default", "", "screenshot_2026-03-07_09-05-02.png"},
+		{"tokens", "{app}_{mode}_{output}", "org.mozilla.firefox_window_DP-1.png"},
+		{"title sanitized", "{title}", "Docs _ Sheet.png"},
+		{"counter padded", "shot-{count:4}", "shot-0007.png"},
+		{"counter", "shot-{count}", "shot-7.png"},
+		{"subdirectory", "%Y/%m/%d-%H%M%S", "2026/03/07-090502.png"},
+		{"percent escape", "100%%", "100%.png"},
+		{"unknown token kept", "{nope}", "{nope}.png"},
+		{"extension kept", "shot.png", "shot.png"},
+		{"day of year", "%j", "066.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExpandFilenameTemplate(tt.tmpl, ctx))
+		})
+	}
+
+	ctx.Format = FormatWebP
+	assert.Equal(t, "x.webp", ExpandFilenameTemplate("x", ctx))
+	ctx.AppID = ""
+	assert.Equal(t, "unknown.webp", ExpandFilenameTemplate("{app}", ctx))
+}
+
+func TestResolveFilePath(t *testing.T) {
+	dir := t.TempDir()
+	ctx := FilenameContext{Time: time.Now(), Format: FormatPNG, Output: "HDMI-A-1"}
+
+	path, err := ResolveFilePath(dir, "{output}/shot", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "HDMI-A-1", "shot.png"), path)
+	assert.DirExists(t, filepath.Join(dir, "HDMI-A-1"))
+
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+	next, err := ResolveFilePath(dir, "{output}/shot", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "HDMI-A-1", "shot_1.png"), next)
+
+	_, err = ResolveFilePath(dir, "../escape", ctx)
+	assert.Error(t, err)
+}
+
+func TestTemplateUsesCounter(t *testing.T) {
+	assert.True(t, TemplateUsesCounter("a{count}"))
+	assert.True(t, TemplateUsesCounter("a{count:3}"))
+	assert.False(t, TemplateUsesCounter(DefaultFilenameTemplate))
+}
@@ -0,0 +1,380 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/clipboard"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	maxHistoryEntries  = 200
+	historyThumbSize   = 320
+	fileManagerDest    = "org.freedesktop.FileManager1"
+	fileManagerPath    = "/org/freedesktop/FileManager1"
+	fileManagerShowCmd = "org.freedesktop.FileManager1.ShowItems"
+)
+
+type HistoryEntry struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Time      time.Time `json:"time"`
+	Mode      string    `json:"mode"`
+	Output    string    `json:"output,omitempty"`
+	Region    Region    `json:"region"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Format    string    `json:"format"`
+	Thumbnail string    `json:"thumbnail,omitempty"`
+}
+
+type History struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRegion:
+		return "region"
+	case ModeWindow:
+		return "window"
+	case ModeFullScreen:
+		return "full"
+	case ModeAllScreens:
+		return "all"
+	case ModeOutput:
+		return "output"
+	case ModeLastRegion:
+		return "last"
+	case ModeScroll:
+		return "scroll"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMode is the inverse of Mode.String.
+func ParseMode(s string) (Mode, bool) {
+	for _, m := range []Mode{ModeRegion, ModeWindow, ModeFullScreen, ModeAllScreens, ModeOutput, ModeLastRegion, ModeScroll} {
+		if m.String() == s {
+			return m, true
+		}
+	}
+	return ModeRegion, false
+}
+
+func getHistoryDir() string {
+	return filepath.Dir(getStateFilePath())
+}
+
+func getHistoryFilePath() string {
+	return filepath.Join(getHistoryDir(), "screenshot-history.json")
+}
+
+func getThumbnailDir() string {
+	return filepath.Join(getHistoryDir(), "screenshot-thumbnails")
+}
+
+// lockHistory takes an exclusive lock on the history, which both the CLI
+// and the server change. The returned function releases it.
+func lockHistory() (func(), error) {
+	path := getHistoryFilePath() + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock screenshot history: %w", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck
+		f.Close()
+	}, nil
+}
+
+func LoadHistory() (*History, error) {
+	data, err := os.ReadFile(getHistoryFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &History{}, nil
+		}
+		return nil, err
+	}
+
+	var history History
+	if err := json.Unmarshal(data, &history); err != nil {
+		log.Warn("screenshot history corrupted, starting fresh", "err", err)
+		return &History{}, nil
+	}
+	return &history, nil
+}
+
+func SaveHistory(history *History) error {
+	path := getHistoryFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RecordHistory appends a capture to the history index and writes its
+// thumbnail. Entries beyond maxHistoryEntries are dropped oldest first.
+func RecordHistory(entry HistoryEntry, img *image.RGBA) (*HistoryEntry, error) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == "" {
+		entry.ID = strconv.FormatInt(entry.Time.UnixNano(), 10)
+	}
+
+	if img != nil {
+		thumbPath := filepath.Join(getThumbnailDir(), entry.ID+".png")
+		if err := writeThumbnail(thumbPath, img, historyThumbSize); err != nil {
+			log.Debug("failed to write screenshot thumbnail", "err", err)
+		} else {
+			entry.Thumbnail = thumbPath
+		}
+	}
+
+	unlock, err := lockHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	history.Entries = append([]HistoryEntry{entry}, history.Entries...)
+	if len(history.Entries) > maxHistoryEntries {
+		for _, dropped := range history.Entries[maxHistoryEntries:] {
+			removeThumbnail(dropped)
+		}
+		history.Entries = history.Entries[:maxHistoryEntries]
+	}
+
+	if err := SaveHistory(history); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListHistory returns entries newest first, skipping captures whose file no
+// longer exists on disk. A limit of zero or less returns everything.
+func ListHistory(limit int) ([]HistoryEntry, error) {
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(history.Entries))
+	for _, e := range history.Entries {
+		if _, err := os.Stat(e.Path); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func GetHistoryEntry(id string) (*HistoryEntry, error) {
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range history.Entries {
+		if history.Entries[i].ID == id {
+			return &history.Entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("screenshot %q not found in history", id)
+}
+
+// DeleteHistoryEntry removes an entry from the index along with its
+// thumbnail. The screenshot file is only removed when deleteFile is set.
+func DeleteHistoryEntry(id string, deleteFile bool) error {
+	unlock, err := lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	history, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	idx := -1
+	for i, e := range history.Entries {
+		if e.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("screenshot %q not found in history", id)
+	}
+
+	entry := history.Entries[idx]
+	if deleteFile {
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete file: %w", err)
+		}
+	}
+	removeThumbnail(entry)
+
+	history.Entries = append(history.Entries[:idx], history.Entries[idx+1:]...)
+	return SaveHistory(history)
+}
+
+func ClearHistory() error {
+	unlock, err := lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	history, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+	for _, e := range history.Entries {
+		removeThumbnail(e)
+	}
+	return SaveHistory(&History{})
+}
+
+func CopyHistoryEntry(id string) error {
+	entry, err := GetHistoryEntry(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return fmt.Errorf("read screenshot: %w", err)
+	}
+	return clipboard.Copy(data, mimeTypeForPath(entry.Path))
+}
+
+func OpenHistoryEntry(id string) error {
+	entry, err := GetHistoryEntry(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(entry.Path); err != nil {
+		return fmt.Errorf("screenshot file missing: %w", err)
+	}
+	openFile(entry.Path)
+	return nil
+}
+
+// RevealHistoryEntry highlights the file in the user's file manager via
+// org.freedesktop.FileManager1, falling back to opening its directory.
+func RevealHistoryEntry(id string) error {
+	entry, err := GetHistoryEntry(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(entry.Path); err != nil {
+		return fmt.Errorf("screenshot file missing: %w", err)
+	}
+
+	if conn, err := dbus.SessionBus(); err == nil {
+		uri := (&url.URL{Scheme: "file", Path: entry.Path}).String()
+		call := conn.Object(fileManagerDest, fileManagerPath).Call(fileManagerShowCmd, 0, []string{uri}, "")
+		if call.Err == nil {
+			return nil
+		}
+		log.Debug("FileManager1.ShowItems failed", "err", call.Err)
+	}
+
+	cmd := exec.Command("xdg-open", filepath.Dir(entry.Path))
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	return cmd.Start()
+}
+
+func removeThumbnail(entry HistoryEntry) {
+	if entry.Thumbnail == "" {
+		return
+	}
+	if err := os.Remove(entry.Thumbnail); err != nil && !os.IsNotExist(err) {
+		log.Debug("failed to remove thumbnail", "path", entry.Thumbnail, "err", err)
+	}
+}
+
+func writeThumbnail(path string, img *image.RGBA, maxSize int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return EncodePNG(f, scaleImage(img, maxSize))
+}
+
+func scaleImage(src *image.RGBA, maxSize int) *image.RGBA {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcW <= maxSize && srcH <= maxSize {
+		return src
+	}
+
+	scale := float64(maxSize) / float64(max(srcW, srcH))
+	dstW := max(int(float64(srcW)*scale), 1)
+	dstH := max(int(float64(srcH)*scale), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		srcY := min(int(float64(y)/scale), srcH-1)
+		for x := 0; x < dstW; x++ {
+			srcX := min(int(float64(x)/scale), srcW-1)
+			si := srcY*src.Stride + srcX*4
+			di := y*dst.Stride + x*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+func mimeTypeForPath(path string) string {
+	format, ok := ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if !ok {
+		return FormatPNG.MimeType()
+	}
+	return format.MimeType()
+}
@@ -0,0 +1,90 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory_RecordListDelete(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	first := filepath.Join(dir, "first.png")
+	second := filepath.Join(dir, "second.png")
+	require.NoError(t, os.WriteFile(first, []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(second, []byte("b"), 0o644))
+
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+
+	e1, err := RecordHistory(HistoryEntry{Path: first, Mode: ModeRegion.String(), Width: 800, Height: 400}, img)
+	require.NoError(t, err)
+	assert.NotEmpty(t, e1.ID)
+	assert.FileExists(t, e1.Thumbnail)
+
+	e2, err := RecordHistory(HistoryEntry{ID: "second", Path: second, Mode: ModeFullScreen.String()}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, e2.Thumbnail)
+
+	entries, err := ListHistory(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "second", entries[0].ID)
+
+	limited, err := ListHistory(1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	require.NoError(t, os.Remove(second))
+	entries, err = ListHistory(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, e1.ID, entries[0].ID)
+
+	require.NoError(t, DeleteHistoryEntry(e1.ID, true))
+	assert.NoFileExists(t, first)
+	assert.NoFileExists(t, e1.Thumbnail)
+
+	_, err = GetHistoryEntry(e1.ID)
+	assert.Error(t, err)
+	assert.Error(t, DeleteHistoryEntry("missing", false))
+}
+
+func TestHistory_ConcurrentRecord(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.png", i))
+		require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := RecordHistory(HistoryEntry{ID: strconv.Itoa(i), Path: path}, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	entries, err := ListHistory(0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestScaleImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1000, 250))
+	dst := scaleImage(src, 100)
+	assert.Equal(t, 100, dst.Bounds().Dx())
+	assert.Equal(t, 25, dst.Bounds().Dy())
+
+	small := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	assert.Same(t, small, scaleImage(small, 100))
+}
@@ -47,6 +47,12 @@ type OutputSurface struct {
 	// Triple-buffered render slots
 	slots      [3]*RenderSlot
 	slotsReady bool
+
+	// Snapping targets, built on first use
+	edges        *edgeMap
+	windowXs     []float64
+	windowYs     []float64
+	windowsReady bool
 }
 
 type PreCapture struct {
@@ -94,6 +100,15 @@ type RegionSelector struct {
 	preSelect          Region
 	showCapturedCursor bool
 	shiftHeld          bool
+	ctrlHeld           bool
+
+	measuring   bool
+	measure     SelectionState
+	snapEnabled bool
+	showGuides  bool
+	aspectIdx   int
+	windows     []WindowGeometry
+	windowsRead bool
 
 	running   bool
 	cancelled bool
@@ -109,6 +124,7 @@ func NewRegionSelector(s *Screenshoter) *RegionSelector {
 		outputs:            make(map[uint32]*WaylandOutput),
 		preCapture:         make(map[*WaylandOutput]*PreCapture),
 		showCapturedCursor: true,
+		snapEnabled:        true,
 	}
 }
 
@@ -1,6 +1,8 @@
 package screenshot
 
 import (
+	"math"
+
 	"github.com/AvengeMedia/DankMaterialShell/core/pkg/go-wayland/wayland/client"
 )
 
@@ -51,42 +53,28 @@ func (r *RegionSelector) setupPointerHandlers() {
 		r.pointerX = e.SurfaceX
 		r.pointerY = e.SurfaceY
 
-		if !r.selection.dragging {
-			return
-		}
-
-		curX, curY := e.SurfaceX, e.SurfaceY
-		if r.shiftHeld {
-			dx := curX - r.selection.anchorX
-			dy := curY - r.selection.anchorY
-			adx, ady := dx, dy
-			if adx < 0 {
-				adx = -adx
-			}
-			if ady < 0 {
-				ady = -ady
-			}
-			size := adx
-			if ady > adx {
-				size = ady
-			}
-			if dx < 0 {
-				curX = r.selection.anchorX - size
-			} else {
-				curX = r.selection.anchorX + size
-			}
-			if dy < 0 {
-				curY = r.selection.anchorY - size
-			} else {
-				curY = r.selection.anchorY + size
+		switch {
+		case r.measure.dragging:
+			curX, curY := r.snapPoint(r.activeSurface, e.SurfaceX, e.SurfaceY)
+			if r.shiftHeld {
+				if math.Abs(curX-r.measure.anchorX) >= math.Abs(curY-r.measure.anchorY) {
+					curY = r.measure.anchorY
+				} else {
+					curX = r.measure.anchorX
+				}
 			}
+			r.measure.currentX = curX
+			r.measure.currentY = curY
+		case r.selection.dragging:
+			curX, curY := r.snapPoint(r.activeSurface, e.SurfaceX, e.SurfaceY)
+			curX, curY = constrainAspect(r.selection.anchorX, r.selection.anchorY, curX, curY, r.dragRatio())
+			r.selection.currentX = curX
+			r.selection.currentY = curY
+		case !r.showGuides:
+			return
 		}
 
-		r.selection.currentX = curX
-		r.selection.currentY = curY
-		for _, os := range r.surfaces {
-			r.redrawSurface(os)
-		}
+		r.redrawAll()
 	})
 
 	r.pointer.SetButtonHandler(func(e client.PointerButtonEvent) {
@@ -98,22 +86,33 @@ func (r *RegionSelector) setupPointerHandlers() {
 		case 0x110: // BTN_LEFT
 			switch e.State {
 			case 1: // pressed
+				anchorX, anchorY := r.snapPoint(r.activeSurface, r.pointerX, r.pointerY)
+				if r.measuring {
+					r.measure = SelectionState{
+						hasSelection: true,
+						dragging:     true,
+						surface:      r.activeSurface,
+						anchorX:      anchorX,
+						anchorY:      anchorY,
+						currentX:     anchorX,
+						currentY:     anchorY,
+					}
+					r.redrawAll()
+					return
+				}
 				r.preSelect = Region{}
 				r.selection.hasSelection = true
 				r.selection.dragging = true
 				r.selection.surface = r.activeSurface
-				r.selection.anchorX = r.pointerX
-				r.selection.anchorY = r.pointerY
-				r.selection.currentX = r.pointerX
-				r.selection.currentY = r.pointerY
-				for _, os := range r.surfaces {
-					r.redrawSurface(os)
-				}
+				r.selection.anchorX = anchorX
+				r.selection.anchorY = anchorY
+				r.selection.currentX = anchorX
+				r.selection.currentY = anchorY
+				r.redrawAll()
 			case 0: // released
 				r.selection.dragging = false
-				for _, os := range r.surfaces {
-					r.redrawSurface(os)
-				}
+				r.measure.dragging = false
+				r.redrawAll()
 			}
 		default:
 			r.cancelled = true
@@ -125,6 +124,7 @@ func (r *RegionSelector) setupPointerHandlers() {
 func (r *RegionSelector) setupKeyboardHandlers() {
 	r.keyboard.SetModifiersHandler(func(e client.KeyboardModifiersEvent) {
 		r.shiftHeld = e.ModsDepressed&1 != 0
+		r.ctrlHeld = e.ModsDepressed&4 != 0
 	})
 
 	r.keyboard.SetKeyHandler(func(e client.KeyboardKeyEvent) {
@@ -133,14 +133,32 @@ func (r *RegionSelector) setupKeyboardHandlers() {
 		}
 
 		switch e.Key {
-		case 1:
+		case 1: // Esc
 			r.cancelled = true
 			r.running = false
-		case 25:
+		case 25: // P
 			r.showCapturedCursor = !r.showCapturedCursor
-			for _, os := range r.surfaces {
-				r.redrawSurface(os)
-			}
+			r.redrawAll()
+		case 50: // M
+			r.measuring = !r.measuring
+			r.measure = SelectionState{}
+			r.redrawAll()
+		case 31: // S
+			r.snapEnabled = !r.snapEnabled
+			r.redrawAll()
+		case 34: // G
+			r.showGuides = !r.showGuides
+			r.redrawAll()
+		case 30: // A
+			r.cycleAspectRatio()
+		case 103: // Up
+			r.nudgeSelection(0, -1)
+		case 108: // Down
+			r.nudgeSelection(0, 1)
+		case 105: // Left
+			r.nudgeSelection(-1, 0)
+		case 106: // Right
+			r.nudgeSelection(1, 0)
 		case 28, 57, 96:
 			if r.selection.hasSelection {
 				r.finishSelection()
@@ -149,6 +167,62 @@ func (r *RegionSelector) setupKeyboardHandlers() {
 	})
 }
 
+func (r *RegionSelector) redrawAll() {
+	for _, os := range r.surfaces {
+		r.redrawSurface(os)
+	}
+}
+
+// cycleAspectRatio switches to the next ratio lock and reshapes an existing
+// selection to match it.
+func (r *RegionSelector) cycleAspectRatio() {
+	r.aspectIdx = (r.aspectIdx + 1) % len(aspectRatios)
+
+	ratio := r.aspectRatio().Value
+	if ratio > 0 && r.selection.hasSelection && !r.selection.dragging {
+		r.preSelect = Region{}
+		r.selection.currentX, r.selection.currentY = constrainAspect(
+			r.selection.anchorX, r.selection.anchorY,
+			r.selection.currentX, r.selection.currentY, ratio)
+	}
+	r.redrawAll()
+}
+
+// nudgeSelection moves the selection by one logical pixel, ten with Shift.
+// With Ctrl held the bottom-right corner moves instead, resizing it. In
+// measure mode the end point of the measurement moves.
+func (r *RegionSelector) nudgeSelection(dx, dy float64) {
+	step := 1.0
+	if r.shiftHeld {
+		step = 10
+	}
+	dx, dy = dx*step, dy*step
+
+	if r.measuring {
+		if !r.measure.hasSelection || r.measure.dragging {
+			return
+		}
+		r.measure.currentX += dx
+		r.measure.currentY += dy
+		r.redrawAll()
+		return
+	}
+
+	sel := &r.selection
+	if !sel.hasSelection || sel.dragging || sel.surface == nil {
+		return
+	}
+
+	x, y, w, h := normalizedRect(sel.anchorX, sel.anchorY, sel.currentX, sel.currentY)
+	x, y, w, h = nudgeRect(x, y, w, h, dx, dy, r.ctrlHeld, r.aspectRatio().Value,
+		float64(sel.surface.logicalW), float64(sel.surface.logicalH))
+
+	r.preSelect = Region{}
+	sel.anchorX, sel.anchorY = x, y
+	sel.currentX, sel.currentY = x+w, y+h
+	r.redrawAll()
+}
+
 func (r *RegionSelector) finishSelection() {
 	if r.selection.surface == nil {
 		r.running = false
@@ -198,7 +272,7 @@ func (r *RegionSelector) finishSelection() {
 	}
 
 	w, h := bx2-bx1+1, by2-by1+1
-	if r.shiftHeld && w != h {
+	if r.shiftHeld && r.aspectRatio().Value == 0 && w != h {
 		if w < h {
 			h = w
 		} else {
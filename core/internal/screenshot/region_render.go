@@ -1,6 +1,9 @@
 package screenshot
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 var fontGlyphs = map[rune][12]uint8{
 	'0': {0x3C, 0x66, 0x66, 0x6E, 0x76, 0x66, 0x66, 0x66, 0x66, 0x3C, 0x00, 0x00},
@@ -37,6 +40,16 @@ var fontGlyphs = map[rune][12]uint8{
 	'/': {0x00, 0x02, 0x06, 0x0C, 0x18, 0x18, 0x30, 0x60, 0x40, 0x00, 0x00, 0x00},
 	'[': {0x3C, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x3C, 0x00, 0x00},
 	']': {0x3C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x3C, 0x00, 0x00},
+	'(': {0x0C, 0x18, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x18, 0x0C, 0x00, 0x00},
+	')': {0x30, 0x18, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x0C, 0x18, 0x30, 0x00, 0x00},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x18, 0x18, 0x00, 0x00},
+	'A': {0x18, 0x3C, 0x66, 0x66, 0x66, 0x7E, 0x66, 0x66, 0x66, 0x66, 0x00, 0x00},
+	'G': {0x3C, 0x66, 0x60, 0x60, 0x6E, 0x66, 0x66, 0x66, 0x66, 0x3C, 0x00, 0x00},
+	'M': {0x63, 0x77, 0x7F, 0x6B, 0x6B, 0x63, 0x63, 0x63, 0x63, 0x63, 0x00, 0x00},
+	'f': {0x1C, 0x30, 0x30, 0x7C, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x00, 0x00},
+	'g': {0x00, 0x00, 0x00, 0x3E, 0x66, 0x66, 0x66, 0x66, 0x3E, 0x06, 0x06, 0x3C},
+	'm': {0x00, 0x00, 0x00, 0x76, 0x7F, 0x6B, 0x6B, 0x6B, 0x6B, 0x63, 0x00, 0x00},
+	'y': {0x00, 0x00, 0x00, 0x66, 0x66, 0x66, 0x66, 0x66, 0x3E, 0x06, 0x06, 0x3C},
 }
 
 type OverlayStyle struct {
@@ -72,6 +85,16 @@ func (r *RegionSelector) drawOverlay(os *OutputSurface, renderBuf *ShmBuffer) {
 	}
 
 	r.drawHUD(data, stride, w, h, format)
+	r.drawSelection(os, renderBuf)
+	r.drawGuides(os, renderBuf)
+	r.drawMeasurement(os, renderBuf)
+}
+
+func (r *RegionSelector) drawSelection(os *OutputSurface, renderBuf *ShmBuffer) {
+	data := renderBuf.Data()
+	stride := renderBuf.Stride
+	w, h := renderBuf.Width, renderBuf.Height
+	format := os.screenFormat
 
 	if !r.selection.hasSelection || r.selection.surface != os {
 		return
@@ -115,7 +138,7 @@ func (r *RegionSelector) drawOverlay(os *OutputSurface, renderBuf *ShmBuffer) {
 	}
 
 	selW, selH := bx2-bx1+1, by2-by1+1
-	if r.shiftHeld && selW != selH {
+	if r.shiftHeld && r.aspectRatio().Value == 0 && selW != selH {
 		if selW < selH {
 			selH = selW
 		} else {
@@ -123,7 +146,98 @@ func (r *RegionSelector) drawOverlay(os *OutputSurface, renderBuf *ShmBuffer) {
 		}
 	}
 	r.drawBorder(data, stride, w, h, bx1, by1, selW, selH, format)
-	r.drawDimensions(data, stride, w, h, bx1, by1, selW, selH, format)
+	r.drawDimensions(data, stride, w, h, bx1, by1, selW, selH, os.output.fractionalScale, format)
+}
+
+// drawGuides extends the selection edges across the whole output, or draws a
+// crosshair at the pointer when nothing is selected yet.
+func (r *RegionSelector) drawGuides(os *OutputSurface, renderBuf *ShmBuffer) {
+	if !r.showGuides {
+		return
+	}
+
+	data := renderBuf.Data()
+	stride := renderBuf.Stride
+	w, h := renderBuf.Width, renderBuf.Height
+	style := LoadOverlayStyle()
+	scaleX := float64(w) / float64(os.logicalW)
+	scaleY := float64(h) / float64(os.logicalH)
+
+	var xs, ys []int
+	switch {
+	case r.selection.hasSelection && r.selection.surface == os:
+		x, y, sw, sh := normalizedRect(r.selection.anchorX, r.selection.anchorY, r.selection.currentX, r.selection.currentY)
+		xs = []int{int(x * scaleX), int((x + sw) * scaleX)}
+		ys = []int{int(y * scaleY), int((y + sh) * scaleY)}
+	case r.activeSurface == os:
+		xs = []int{int(r.pointerX * scaleX)}
+		ys = []int{int(r.pointerY * scaleY)}
+	}
+
+	for _, x := range xs {
+		r.fillRect(data, stride, w, h, x, 0, 1, h, style.AccentR, style.AccentG, style.AccentB, 160, os.screenFormat)
+	}
+	for _, y := range ys {
+		r.fillRect(data, stride, w, h, 0, y, w, 1, style.AccentR, style.AccentG, style.AccentB, 160, os.screenFormat)
+	}
+}
+
+func (r *RegionSelector) drawMeasurement(os *OutputSurface, renderBuf *ShmBuffer) {
+	if !r.measuring || !r.measure.hasSelection || r.measure.surface != os {
+		return
+	}
+
+	data := renderBuf.Data()
+	stride := renderBuf.Stride
+	w, h := renderBuf.Width, renderBuf.Height
+	format := os.screenFormat
+	style := LoadOverlayStyle()
+	scaleX := float64(w) / float64(os.logicalW)
+	scaleY := float64(h) / float64(os.logicalH)
+
+	x1, y1 := int(r.measure.anchorX*scaleX), int(r.measure.anchorY*scaleY)
+	x2, y2 := int(r.measure.currentX*scaleX), int(r.measure.currentY*scaleY)
+
+	r.drawLine(data, stride, w, h, x1, y1, x2, y2, style.AccentR, style.AccentG, style.AccentB, format)
+	r.fillRect(data, stride, w, h, x1-3, y1-3, 7, 7, 255, 255, 255, 255, format)
+	r.fillRect(data, stride, w, h, x2-3, y2-3, 7, 7, 255, 255, 255, 255, format)
+
+	text := measurementLabel(r.measure.anchorX, r.measure.anchorY, r.measure.currentX, r.measure.currentY, os.output.fractionalScale)
+	const charW, charH = 8, 12
+	textW := len(text) * (charW + 1)
+	tx := clamp((x1+x2)/2-textW/2, 0, max(w-textW, 0))
+	ty := clamp((y1+y2)/2+12, 0, max(h-charH, 0))
+
+	r.fillRect(data, stride, w, h, tx-4, ty-2, textW+8, charH+4, 0, 0, 0, 200, format)
+	r.drawText(data, stride, w, h, tx, ty, text, 255, 255, 255, format)
+}
+
+func (r *RegionSelector) drawLine(data []byte, stride, bufW, bufH, x1, y1, x2, y2 int, cr, cg, cb uint8, format uint32) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		r.fillRect(data, stride, bufW, bufH, x1, y1, 2, 2, cr, cg, cb, 255, format)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y1 += sy
+		}
+	}
 }
 
 func (r *RegionSelector) drawHUD(data []byte, stride, bufW, bufH int, format uint32) {
@@ -139,9 +253,22 @@ func (r *RegionSelector) drawHUD(data []byte, stride, bufW, bufH int, format uin
 		cursorLabel = "show"
 	}
 
+	modeLabel := "measure"
+	if r.measuring {
+		modeLabel = "select"
+	}
+	snapLabel := "snap off"
+	if !r.snapEnabled {
+		snapLabel = "snap on"
+	}
+
 	items := []struct{ key, desc string }{
 		{"Space/Enter", "capture"},
 		{"P", cursorLabel + " cursor"},
+		{"M", modeLabel},
+		{"S", snapLabel},
+		{"A", "ratio " + r.aspectRatio().Label},
+		{"G", "guides"},
 		{"Esc", "cancel"},
 	}
 
@@ -222,8 +349,14 @@ func (r *RegionSelector) drawVLine(data []byte, stride, bufW, bufH, x, y, length
 	}
 }
 
-func (r *RegionSelector) drawDimensions(data []byte, stride, bufW, bufH, x, y, w, h int, format uint32) {
+func (r *RegionSelector) drawDimensions(data []byte, stride, bufW, bufH, x, y, w, h int, scale float64, format uint32) {
 	text := fmt.Sprintf("%dx%d", w, h)
+	if scale > 0 && scale != 1 {
+		text += fmt.Sprintf(" (%dx%d)", int(math.Round(float64(w)/scale)), int(math.Round(float64(h)/scale)))
+	}
+	if ratio := r.aspectRatio(); ratio.Value > 0 {
+		text += " " + ratio.Label
+	}
 
 	const charW, charH = 8, 12
 	textW := len(text) * (charW + 1)
@@ -0,0 +1,273 @@
+package screenshot
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	snapThreshold   = 8.0 // logical pixels
+	edgeSearchSpan  = 24  // buffer pixels along the edge
+	edgeMinContrast = 40  // mean luminance step across an edge
+)
+
+type AspectRatio struct {
+	Label string
+	Value float64
+}
+
+// aspectRatios is cycled with the A key. A zero value means free-form.
+var aspectRatios = []AspectRatio{
+	{"free", 0},
+	{"1:1", 1},
+	{"4:3", 4.0 / 3.0},
+	{"3:2", 3.0 / 2.0},
+	{"16:9", 16.0 / 9.0},
+	{"21:9", 21.0 / 9.0},
+}
+
+// edgeMap holds the luminance of a frozen pre-capture in logical
+// orientation, used to find strong edges near the pointer.
+type edgeMap struct {
+	width, height int
+	lum           []uint8
+}
+
+func newEdgeMap(buf *ShmBuffer, format uint32, yInverted bool) *edgeMap {
+	m := &edgeMap{
+		width:  buf.Width,
+		height: buf.Height,
+		lum:    make([]uint8, buf.Width*buf.Height),
+	}
+
+	ri, bi := 2, 0
+	if format == uint32(FormatABGR8888) || format == uint32(FormatXBGR8888) {
+		ri, bi = 0, 2
+	}
+
+	data := buf.Data()
+	for y := 0; y < buf.Height; y++ {
+		srcY := y
+		if yInverted {
+			srcY = buf.Height - 1 - y
+		}
+		row := data[srcY*buf.Stride:]
+		for x := 0; x < buf.Width; x++ {
+			r, g, b := int(row[x*4+ri]), int(row[x*4+1]), int(row[x*4+bi])
+			m.lum[y*buf.Width+x] = uint8((r*299 + g*587 + b*114) / 1000)
+		}
+	}
+	return m
+}
+
+func (m *edgeMap) at(x, y int) int {
+	return int(m.lum[y*m.width+x])
+}
+
+// nearestVerticalEdge looks for the column closest to x, within radius, where
+// the image steps sharply from left to right across a span of rows around
+// y. It returns the column on the right side of the step.
+func (m *edgeMap) nearestVerticalEdge(x, y, radius int) (int, bool) {
+	y0, y1 := max(y-edgeSearchSpan, 0), min(y+edgeSearchSpan, m.height-1)
+	best, bestDist := 0, radius+1
+	for cx := max(x-radius, 1); cx <= min(x+radius, m.width-1); cx++ {
+		sum := 0
+		for cy := y0; cy <= y1; cy++ {
+			d := m.at(cx, cy) - m.at(cx-1, cy)
+			if d < 0 {
+				d = -d
+			}
+			sum += d
+		}
+		if sum/(y1-y0+1) < edgeMinContrast {
+			continue
+		}
+		if dist := abs(cx - x); dist < bestDist {
+			best, bestDist = cx, dist
+		}
+	}
+	return best, bestDist <= radius
+}
+
+// nearestHorizontalEdge is nearestVerticalEdge for rows.
+func (m *edgeMap) nearestHorizontalEdge(x, y, radius int) (int, bool) {
+	x0, x1 := max(x-edgeSearchSpan, 0), min(x+edgeSearchSpan, m.width-1)
+	best, bestDist := 0, radius+1
+	for cy := max(y-radius, 1); cy <= min(y+radius, m.height-1); cy++ {
+		sum := 0
+		for cx := x0; cx <= x1; cx++ {
+			d := m.at(cx, cy) - m.at(cx, cy-1)
+			if d < 0 {
+				d = -d
+			}
+			sum += d
+		}
+		if sum/(x1-x0+1) < edgeMinContrast {
+			continue
+		}
+		if dist := abs(cy - y); dist < bestDist {
+			best, bestDist = cy, dist
+		}
+	}
+	return best, bestDist <= radius
+}
+
+// snapToLines returns the line closest to v when it is within threshold.
+func snapToLines(v float64, lines []float64, threshold float64) (float64, bool) {
+	best, bestDist := v, threshold
+	found := false
+	for _, l := range lines {
+		if d := math.Abs(l - v); d <= bestDist {
+			best, bestDist, found = l, d, true
+		}
+	}
+	return best, found
+}
+
+// constrainAspect moves (cx, cy) so the rectangle spanned from the anchor has
+// the given width/height ratio, keeping the larger of the two extents.
+func constrainAspect(ax, ay, cx, cy, ratio float64) (float64, float64) {
+	if ratio <= 0 {
+		return cx, cy
+	}
+
+	dx, dy := cx-ax, cy-ay
+	w, h := math.Abs(dx), math.Abs(dy)
+	if w/ratio >= h {
+		h = w / ratio
+	} else {
+		w = h * ratio
+	}
+
+	return ax + math.Copysign(w, dx), ay + math.Copysign(h, dy)
+}
+
+// normalizedRect returns the selection as top-left corner plus size.
+func normalizedRect(ax, ay, cx, cy float64) (x, y, w, h float64) {
+	return math.Min(ax, cx), math.Min(ay, cy), math.Abs(cx - ax), math.Abs(cy - ay)
+}
+
+// nudgeRect moves or resizes a rectangle by (dx, dy) and keeps it inside
+// bounds. When resizing with a locked ratio, the other extent follows.
+func nudgeRect(x, y, w, h, dx, dy float64, resize bool, ratio, boundW, boundH float64) (float64, float64, float64, float64) {
+	if resize {
+		w = math.Max(w+dx, 1)
+		h = math.Max(h+dy, 1)
+		if ratio > 0 {
+			if dx != 0 {
+				h = math.Max(w/ratio, 1)
+			} else {
+				w = math.Max(h*ratio, 1)
+			}
+		}
+		w = math.Min(w, boundW-x)
+		h = math.Min(h, boundH-y)
+		return x, y, w, h
+	}
+
+	x = math.Max(math.Min(x+dx, boundW-w), 0)
+	y = math.Max(math.Min(y+dy, boundH-h), 0)
+	return x, y, w, h
+}
+
+// measurementLabel formats the distance between two points in logical and
+// physical pixels.
+func measurementLabel(x1, y1, x2, y2, scale float64) string {
+	if scale <= 0 {
+		scale = 1
+	}
+	dx, dy := math.Abs(x2-x1), math.Abs(y2-y1)
+	dist := math.Hypot(dx, dy)
+	label := fmt.Sprintf("%.0f px  %.0fx%.0f", dist, dx, dy)
+	if scale != 1 {
+		label += fmt.Sprintf("  (%.0f phys)", dist*scale)
+	}
+	return label
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func (r *RegionSelector) aspectRatio() AspectRatio {
+	return aspectRatios[r.aspectIdx%len(aspectRatios)]
+}
+
+// dragRatio is the ratio applied while dragging: the locked one, or square
+// while Shift is held.
+func (r *RegionSelector) dragRatio() float64 {
+	if ratio := r.aspectRatio().Value; ratio > 0 {
+		return ratio
+	}
+	if r.shiftHeld {
+		return 1
+	}
+	return 0
+}
+
+// snapPoint pulls a surface-local point onto nearby window edges, falling
+// back to strong edges in the frozen capture.
+func (r *RegionSelector) snapPoint(os *OutputSurface, x, y float64) (float64, float64) {
+	if !r.snapEnabled || os == nil || os.logicalW <= 0 {
+		return x, y
+	}
+
+	xs, ys := r.windowEdges(os)
+	sx, okX := snapToLines(x, xs, snapThreshold)
+	sy, okY := snapToLines(y, ys, snapThreshold)
+	if okX && okY {
+		return sx, sy
+	}
+
+	m := r.edgesFor(os)
+	if m == nil {
+		return sx, sy
+	}
+
+	scale := float64(m.width) / float64(os.logicalW)
+	bx, by := int(x*scale), int(y*scale)
+	radius := int(snapThreshold * scale)
+	if !okX {
+		if ex, ok := m.nearestVerticalEdge(bx, by, radius); ok {
+			sx = float64(ex) / scale
+		}
+	}
+	if !okY {
+		if ey, ok := m.nearestHorizontalEdge(bx, by, radius); ok {
+			sy = float64(ey) / scale
+		}
+	}
+	return sx, sy
+}
+
+func (r *RegionSelector) windowEdges(os *OutputSurface) ([]float64, []float64) {
+	if os.windowsReady {
+		return os.windowXs, os.windowYs
+	}
+	if !r.windowsRead {
+		r.windows = GetVisibleWindows()
+		r.windowsRead = true
+	}
+
+	for _, win := range r.windows {
+		x := float64(win.X - os.output.x)
+		y := float64(win.Y - os.output.y)
+		os.windowXs = append(os.windowXs, x, x+float64(win.Width))
+		os.windowYs = append(os.windowYs, y, y+float64(win.Height))
+	}
+	os.windowsReady = true
+	return os.windowXs, os.windowYs
+}
+
+func (r *RegionSelector) edgesFor(os *OutputSurface) *edgeMap {
+	if os.edges == nil && os.screenBufNoCursor != nil {
+		os.edges = newEdgeMap(os.screenBufNoCursor, os.screenFormat, os.yInverted)
+	}
+	if os.edges == nil && os.screenBuf != nil {
+		os.edges = newEdgeMap(os.screenBuf, os.screenFormat, os.yInverted)
+	}
+	return os.edges
+}
@@ -0,0 +1,75 @@
+package screenshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstrainAspect(t *testing.T) {
+	x, y := constrainAspect(10, 10, 170, 20, 16.0/9.0)
+	assert.InDelta(t, 170, x, 0.001)
+	assert.InDelta(t, 100, y, 0.001)
+
+	x, y = constrainAspect(100, 100, 90, 20, 1)
+	assert.InDelta(t, 20, x, 0.001)
+	assert.InDelta(t, 20, y, 0.001)
+
+	x, y = constrainAspect(0, 0, 30, 40, 0)
+	assert.Equal(t, 30.0, x)
+	assert.Equal(t, 40.0, y)
+}
+
+func TestNudgeRect(t *testing.T) {
+	x, y, w, h := nudgeRect(10, 10, 100, 50, -20, 0, false, 0, 1920, 1080)
+	assert.Equal(t, []float64{0, 10, 100, 50}, []float64{x, y, w, h})
+
+	x, y, w, h = nudgeRect(1800, 10, 100, 50, 50, 0, false, 0, 1920, 1080)
+	assert.Equal(t, []float64{1820, 10, 100, 50}, []float64{x, y, w, h})
+
+	x, y, w, h = nudgeRect(10, 10, 160, 90, 16, 0, true, 16.0/9.0, 1920, 1080)
+	assert.Equal(t, 176.0, w)
+	assert.InDelta(t, 99, h, 0.001)
+	assert.Equal(t, []float64{10, 10}, []float64{x, y})
+
+	_, _, w, h = nudgeRect(10, 10, 1, 1, -5, -5, true, 0, 1920, 1080)
+	assert.Equal(t, []float64{1, 1}, []float64{w, h})
+}
+
+func TestSnapToLines(t *testing.T) {
+	v, ok := snapToLines(103, []float64{0, 100, 200}, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, v)
+
+	v, ok = snapToLines(150, []float64{0, 100, 200}, 8)
+	assert.False(t, ok)
+	assert.Equal(t, 150.0, v)
+}
+
+func TestEdgeMapNearestEdges(t *testing.T) {
+	const w, h = 64, 64
+	m := &edgeMap{width: w, height: h, lum: make([]uint8, w*h)}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x >= 30 && y >= 20 {
+				m.lum[y*w+x] = 220
+			}
+		}
+	}
+
+	x, ok := m.nearestVerticalEdge(26, 40, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 30, x)
+
+	y, ok := m.nearestHorizontalEdge(45, 24, 8)
+	assert.True(t, ok)
+	assert.Equal(t, 20, y)
+
+	_, ok = m.nearestVerticalEdge(10, 40, 8)
+	assert.False(t, ok)
+}
+
+func TestMeasurementLabel(t *testing.T) {
+	assert.Equal(t, "50 px  30x40", measurementLabel(0, 0, 30, 40, 1))
+	assert.Equal(t, "50 px  30x40  (75 phys)", measurementLabel(0, 0, 30, 40, 1.5))
+}
@@ -0,0 +1,223 @@
+package screenshot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/clipboard"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+)
+
+type SaveResult struct {
+	Path      string `json:"path,omitempty"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Format    string `json:"format"`
+	Mode      string `json:"mode"`
+	Output    string `json:"output,omitempty"`
+	Region    Region `json:"region"`
+	Clipboard bool   `json:"clipboard"`
+	HistoryID string `json:"historyId,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// Save writes a capture to disk, the history index and the clipboard and
+// sends the notification, as configured. It does not handle Stdout.
+func Save(config Config, result *CaptureResult) (*SaveResult, error) {
+	if result.YInverted {
+		result.Buffer.FlipVertical()
+		result.YInverted = false
+	}
+
+	saved := &SaveResult{
+		Width:  result.Buffer.Width,
+		Height: result.Buffer.Height,
+		Format: config.Format.Extension(),
+		Mode:   config.Mode.String(),
+		Output: result.Region.Output,
+		Region: result.Region,
+	}
+	if saved.Output == "" {
+		saved.Output = config.OutputName
+	}
+
+	opts := config.EncodeOptions()
+
+	if config.SaveFile {
+		outputDir := config.OutputDir
+		if outputDir == "" {
+			outputDir = GetOutputDir()
+		}
+
+		var filePath string
+		if config.Filename != "" {
+			filePath = filepath.Join(outputDir, config.Filename)
+		} else {
+			var err error
+			filePath, err = ResolveFilePath(outputDir, config.Template, newFilenameContext(config, result))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := WriteToFileWithFormat(result.Buffer, filePath, opts, result.Format); err != nil {
+			return nil, fmt.Errorf("write file: %w", err)
+		}
+		saved.Path = filePath
+
+		if config.History {
+			saved.HistoryID = recordCapture(config, result, filePath)
+		}
+	}
+
+	if config.Clipboard {
+		if err := CopyBufferToClipboard(result.Buffer, opts, result.Format); err != nil {
+			return nil, fmt.Errorf("copy to clipboard: %w", err)
+		}
+		saved.Clipboard = true
+	}
+
+	if config.IncludeData {
+		var data bytes.Buffer
+		if err := EncodeImage(&data, BufferToImageWithFormat(result.Buffer, result.Format), opts); err != nil {
+			return nil, fmt.Errorf("encode image: %w", err)
+		}
+		saved.Data = base64.StdEncoding.EncodeToString(data.Bytes())
+	}
+
+	if config.Notify {
+		thumbData, thumbW, thumbH := bufferToRGBThumbnail(result.Buffer, 256, result.Format)
+		SendNotification(NotifyResult{
+			FilePath:  saved.Path,
+			Clipboard: config.Clipboard,
+			ImageData: thumbData,
+			Width:     thumbW,
+			Height:    thumbH,
+		})
+	}
+
+	return saved, nil
+}
+
+func newFilenameContext(config Config, result *CaptureResult) FilenameContext {
+	ctx := FilenameContext{
+		Time:   time.Now(),
+		Mode:   config.Mode,
+		Format: config.Format,
+		Output: result.Region.Output,
+		AppID:  result.AppID,
+		Title:  result.Title,
+	}
+	if ctx.Output == "" {
+		ctx.Output = config.OutputName
+	}
+	if TemplateUsesCounter(config.Template) {
+		ctx.Counter = NextCounter()
+	}
+	return ctx
+}
+
+func recordCapture(config Config, result *CaptureResult, filePath string) string {
+	entry := HistoryEntry{
+		Path:   filePath,
+		Mode:   config.Mode.String(),
+		Output: result.Region.Output,
+		Region: result.Region,
+		Width:  result.Buffer.Width,
+		Height: result.Buffer.Height,
+		Format: config.Format.Extension(),
+	}
+	if entry.Output == "" {
+		entry.Output = config.OutputName
+	}
+
+	img := BufferToImageWithFormat(result.Buffer, result.Format)
+	recorded, err := RecordHistory(entry, img)
+	if err != nil {
+		log.Warnf("Failed to record screenshot history: %v", err)
+		return ""
+	}
+	return recorded.ID
+}
+
+func CopyBufferToClipboard(buf *ShmBuffer, opts EncodeOptions, pixelFormat uint32) error {
+	var data bytes.Buffer
+
+	// Clipboard consumers rarely understand QOI/PPM, so anything but JPEG
+	// and WebP is offered as PNG.
+	switch opts.Format {
+	case FormatJPEG, FormatWebP:
+	default:
+		opts.Format = FormatPNG
+	}
+
+	img := BufferToImageWithFormat(buf, pixelFormat)
+	if err := EncodeImage(&data, img, opts); err != nil {
+		return err
+	}
+
+	return clipboard.Copy(data.Bytes(), opts.Format.MimeType())
+}
+
+func bufferToRGBThumbnail(buf *ShmBuffer, maxSize int, pixelFormat uint32) ([]byte, int, int) {
+	srcW, srcH := buf.Width, buf.Height
+	scale := 1.0
+	if srcW > maxSize || srcH > maxSize {
+		if srcW > srcH {
+			scale = float64(maxSize) / float64(srcW)
+		} else {
+			scale = float64(maxSize) / float64(srcH)
+		}
+	}
+
+	dstW := int(float64(srcW) * scale)
+	dstH := int(float64(srcH) * scale)
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	data := buf.Data()
+	rgb := make([]byte, dstW*dstH*3)
+
+	var swapRB bool
+	switch pixelFormat {
+	case uint32(FormatABGR8888), uint32(FormatXBGR8888):
+		swapRB = false
+	default:
+		swapRB = true
+	}
+
+	for y := 0; y < dstH; y++ {
+		srcY := int(float64(y) / scale)
+		if srcY >= srcH {
+			srcY = srcH - 1
+		}
+		for x := 0; x < dstW; x++ {
+			srcX := int(float64(x) / scale)
+			if srcX >= srcW {
+				srcX = srcW - 1
+			}
+			si := srcY*buf.Stride + srcX*4
+			di := (y*dstW + x) * 3
+			if si+3 >= len(data) {
+				continue
+			}
+			if swapRB {
+				rgb[di+0] = data[si+2]
+				rgb[di+1] = data[si+1]
+				rgb[di+2] = data[si+0]
+			} else {
+				rgb[di+0] = data[si+0]
+				rgb[di+1] = data[si+1]
+				rgb[di+2] = data[si+2]
+			}
+		}
+	}
+	return rgb, dstW, dstH
+}
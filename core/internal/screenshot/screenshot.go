@@ -5,7 +5,9 @@ import (
 	"sync"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/proto/wlr_layer_shell"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/proto/wlr_screencopy"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/proto/wlr_virtual_pointer"
 	wlhelpers "github.com/AvengeMedia/DankMaterialShell/core/internal/wayland/client"
 	"github.com/AvengeMedia/DankMaterialShell/core/pkg/go-wayland/wayland/client"
 )
@@ -27,6 +29,8 @@ type CaptureResult struct {
 	Region    Region
 	YInverted bool
 	Format    uint32
+	AppID     string
+	Title     string
 }
 
 type Screenshoter struct {
@@ -40,6 +44,9 @@ type Screenshoter struct {
 	shm        *client.Shm
 	screencopy *wlr_screencopy.ZwlrScreencopyManagerV1
 
+	virtualPointer *wlr_virtual_pointer.ZwlrVirtualPointerManagerV1
+	layerShell     *wlr_layer_shell.ZwlrLayerShellV1
+
 	outputs   map[uint32]*WaylandOutput
 	outputsMu sync.Mutex
 }
@@ -73,6 +80,17 @@ func (s *Screenshoter) Run() (*CaptureResult, error) {
 		return nil, fmt.Errorf("roundtrip: %w", err)
 	}
 
+	if s.config.Delay > 0 {
+		s.runDelay()
+	}
+
+	switch s.config.Mode {
+	case ModeRegion, ModeScroll:
+		s.progress(Progress{Phase: PhaseSelecting})
+	default:
+		s.progress(Progress{Phase: PhaseCapturing})
+	}
+
 	switch s.config.Mode {
 	case ModeLastRegion:
 		return s.captureLastRegion()
@@ -86,6 +104,8 @@ func (s *Screenshoter) Run() (*CaptureResult, error) {
 		return s.captureFullScreen()
 	case ModeAllScreens:
 		return s.captureAllScreens()
+	case ModeScroll:
+		return s.captureScroll()
 	default:
 		return s.captureRegion()
 	}
@@ -146,14 +166,22 @@ func (s *Screenshoter) captureWindow() (*CaptureResult, error) {
 		return nil, fmt.Errorf("could not find output for window")
 	}
 
+	var result *CaptureResult
 	switch DetectCompositor() {
 	case CompositorHyprland:
-		return s.captureAndCrop(output, region)
+		result, err = s.captureAndCrop(output, region)
 	case CompositorDWL:
-		return s.captureDWLWindow(output, region, geom)
+		result, err = s.captureDWLWindow(output, region, geom)
 	default:
-		return s.captureRegionOnOutput(output, region)
+		result, err = s.captureRegionOnOutput(output, region)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.AppID = geom.AppID
+	result.Title = geom.Title
+	return result, nil
 }
 
 func (s *Screenshoter) captureDWLWindow(output *WaylandOutput, region Region, geom *WindowGeometry) (*CaptureResult, error) {
@@ -951,6 +979,24 @@ func (s *Screenshoter) handleGlobal(e client.RegistryGlobalEvent) {
 		if err := s.registry.Bind(e.Name, e.Interface, version, sc); err == nil {
 			s.screencopy = sc
 		}
+
+	case wlr_layer_shell.ZwlrLayerShellV1InterfaceName:
+		if s.config.Delay <= 0 || !s.config.Countdown {
+			return
+		}
+		ls := wlr_layer_shell.NewZwlrLayerShellV1(s.ctx)
+		if err := s.registry.Bind(e.Name, e.Interface, min(e.Version, 4), ls); err == nil {
+			s.layerShell = ls
+		}
+
+	case wlr_virtual_pointer.ZwlrVirtualPointerManagerV1InterfaceName:
+		if s.config.Mode != ModeScroll || !s.config.Scroll.Auto {
+			return
+		}
+		vp := wlr_virtual_pointer.NewZwlrVirtualPointerManagerV1(s.ctx)
+		if err := s.registry.Bind(e.Name, e.Interface, min(e.Version, 2), vp); err == nil {
+			s.virtualPointer = vp
+		}
 	}
 }
 
@@ -997,6 +1043,12 @@ func (s *Screenshoter) cleanup() {
 	if s.screencopy != nil {
 		s.screencopy.Destroy()
 	}
+	if s.virtualPointer != nil {
+		s.virtualPointer.Destroy()
+	}
+	if s.layerShell != nil {
+		s.layerShell.Destroy()
+	}
 	if s.display != nil {
 		s.ctx.Close()
 	}
@@ -0,0 +1,310 @@
+package screenshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/proto/wlr_virtual_pointer"
+)
+
+const (
+	scrollMaxHeight = 32768
+	wheelStepValue  = 15.0
+	axisVertical    = 0
+	axisSourceWheel = 0
+	scrollPIDFile   = "dms-screenshot-scroll.pid"
+	defaultAutoIdle = 2
+)
+
+type ScrollOptions struct {
+	// Auto injects wheel events through a virtual pointer instead of waiting
+	// for the user to scroll.
+	Auto bool
+	// Steps is the number of wheel clicks sent between frames in auto mode.
+	Steps int
+	// Interval is the delay between captures.
+	Interval time.Duration
+	// IdleFrames stops the capture after this many frames without new rows.
+	// Zero only stops on request in manual mode.
+	IdleFrames int
+	MaxFrames  int
+	// Stop ends the capture and keeps what was stitched so far.
+	Stop <-chan struct{}
+}
+
+func DefaultScrollOptions() ScrollOptions {
+	return ScrollOptions{
+		Steps:     3,
+		Interval:  300 * time.Millisecond,
+		MaxFrames: 200,
+	}
+}
+
+func (s *Screenshoter) captureScroll() (*CaptureResult, error) {
+	selector := NewRegionSelector(s)
+	selected, cancelled, err := selector.Run()
+	if err != nil {
+		return nil, fmt.Errorf("region selection: %w", err)
+	}
+	if cancelled || selected == nil {
+		return nil, nil
+	}
+	region := selected.Region
+	if selected.Buffer != nil {
+		selected.Buffer.Close()
+	}
+
+	if err := SaveLastRegion(region); err != nil {
+		log.Debug("failed to save last region", "err", err)
+	}
+
+	output := s.findOutputForRegion(region)
+	if output == nil {
+		return nil, fmt.Errorf("could not find output for region")
+	}
+
+	opts := s.config.Scroll
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultScrollOptions().Interval
+	}
+	if opts.Steps <= 0 {
+		opts.Steps = DefaultScrollOptions().Steps
+	}
+	if opts.MaxFrames <= 0 {
+		opts.MaxFrames = DefaultScrollOptions().MaxFrames
+	}
+	if opts.Auto && opts.IdleFrames <= 0 {
+		opts.IdleFrames = defaultAutoIdle
+	}
+
+	var pointer *wlr_virtual_pointer.ZwlrVirtualPointerV1
+	if opts.Auto {
+		pointer, err = s.createScrollPointer(output, region)
+		if err != nil {
+			return nil, err
+		}
+		defer pointer.Destroy()
+	}
+
+	first, err := s.captureScrollFrame(output, region)
+	if err != nil {
+		return nil, err
+	}
+	format := PixelFormat(first.Format)
+	stitcher := NewStitcher(first.Buffer.Width)
+	stitcher.Add(first.Buffer.Data(), first.Buffer.Stride, first.Buffer.Height)
+	frameHeight := first.Buffer.Height
+	first.Buffer.Close()
+
+	idle := 0
+	for frame := 1; frame < opts.MaxFrames; frame++ {
+		if pointer != nil {
+			if err := s.sendScroll(pointer, opts.Steps); err != nil {
+				return nil, fmt.Errorf("virtual pointer scroll: %w", err)
+			}
+		}
+
+		select {
+		case <-opts.Stop:
+			return s.finishScroll(stitcher, format, region, frameHeight)
+		case <-time.After(opts.Interval):
+		}
+
+		result, err := s.captureScrollFrame(output, region)
+		if err != nil {
+			return nil, err
+		}
+		if result.Buffer.Width != stitcher.Width() {
+			result.Buffer.Close()
+			return nil, fmt.Errorf("region size changed during capture")
+		}
+		added := stitcher.Add(result.Buffer.Data(), result.Buffer.Stride, result.Buffer.Height)
+		result.Buffer.Close()
+
+		log.Debug("scroll capture frame", "frame", frame, "added", added, "height", stitcher.Height())
+
+		switch {
+		case added > 0:
+			idle = 0
+		case opts.IdleFrames > 0:
+			idle++
+			if idle >= opts.IdleFrames {
+				return s.finishScroll(stitcher, format, region, frameHeight)
+			}
+		}
+
+		if stitcher.Height() >= scrollMaxHeight {
+			log.Warn("scroll capture reached maximum height", "height", stitcher.Height())
+			break
+		}
+	}
+
+	return s.finishScroll(stitcher, format, region, frameHeight)
+}
+
+func (s *Screenshoter) captureScrollFrame(output *WaylandOutput, region Region) (*CaptureResult, error) {
+	result, err := s.captureRegionOnOutput(output, region)
+	if err != nil {
+		return nil, err
+	}
+	if result.YInverted {
+		result.Buffer.FlipVertical()
+		result.YInverted = false
+	}
+	return result, nil
+}
+
+func (s *Screenshoter) finishScroll(stitcher *Stitcher, format PixelFormat, region Region, frameHeight int) (*CaptureResult, error) {
+	buf, err := stitcher.Buffer(format)
+	if err != nil {
+		return nil, fmt.Errorf("create stitched buffer: %w", err)
+	}
+
+	region.Height = int32(int64(region.Height) * int64(stitcher.Height()) / int64(max(frameHeight, 1)))
+	return &CaptureResult{
+		Buffer: buf,
+		Region: region,
+		Format: uint32(format),
+	}, nil
+}
+
+// createScrollPointer parks a virtual pointer in the middle of the region so
+// injected wheel events reach the window underneath it.
+func (s *Screenshoter) createScrollPointer(output *WaylandOutput, region Region) (*wlr_virtual_pointer.ZwlrVirtualPointerV1, error) {
+	if s.virtualPointer == nil {
+		return nil, fmt.Errorf("compositor does not support wlr-virtual-pointer-unstable-v1")
+	}
+
+	pointer, err := s.virtualPointer.CreateVirtualPointerWithOutput(nil, output.wlOutput)
+	if err != nil {
+		return nil, fmt.Errorf("create virtual pointer: %w", err)
+	}
+
+	scale := output.fractionalScale
+	if scale <= 0 {
+		scale = 1.0
+	}
+	extentW := uint32(float64(output.width) / scale)
+	extentH := uint32(float64(output.height) / scale)
+	x := uint32(max(region.X-output.x+region.Width/2, 0))
+	y := uint32(max(region.Y-output.y+region.Height/2, 0))
+
+	now := pointerTime()
+	if err := pointer.MotionAbsolute(now, min(x, extentW), min(y, extentH), extentW, extentH); err != nil {
+		pointer.Destroy()
+		return nil, err
+	}
+	if err := pointer.Frame(); err != nil {
+		pointer.Destroy()
+		return nil, err
+	}
+	return pointer, s.roundtrip()
+}
+
+func (s *Screenshoter) sendScroll(pointer *wlr_virtual_pointer.ZwlrVirtualPointerV1, steps int) error {
+	now := pointerTime()
+	if err := pointer.AxisSource(axisSourceWheel); err != nil {
+		return err
+	}
+	if err := pointer.AxisDiscrete(now, axisVertical, wheelStepValue*float64(steps), int32(steps)); err != nil {
+		return err
+	}
+	if err := pointer.Frame(); err != nil {
+		return err
+	}
+	return s.roundtrip()
+}
+
+func pointerTime() uint32 {
+	return uint32(time.Now().UnixMilli())
+}
+
+func scrollPIDPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, scrollPIDFile)
+}
+
+// WriteScrollPID records the running scroll capture so StopScrollCapture can
+// reach it from a compositor keybind.
+func WriteScrollPID() error {
+	return os.WriteFile(scrollPIDPath(), []byte(strconv.Itoa(os.Getpid())), 0o644)
+}
+
+func RemoveScrollPID() {
+	if err := os.Remove(scrollPIDPath()); err != nil && !os.IsNotExist(err) {
+		log.Debug("failed to remove scroll pid file", "err", err)
+	}
+}
+
+// StopScrollCapture asks a running scroll capture to finish with SIGUSR1.
+func StopScrollCapture() error {
+	data, err := os.ReadFile(scrollPIDPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no scroll capture running")
+		}
+		return err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("invalid scroll pid file: %w", err)
+	}
+
+	if !isScrollCapture(pid) {
+		RemoveScrollPID()
+		return fmt.Errorf("no scroll capture running")
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGUSR1); err != nil {
+		if err == syscall.ESRCH {
+			RemoveScrollPID()
+			return fmt.Errorf("no scroll capture running")
+		}
+		return err
+	}
+	return nil
+}
+
+// isScrollCapture reports whether pid is a `dms screenshot scroll` run from
+// this binary. The pid file can outlive a capture that exited early and its
+// pid be reused, even by the scroll compositor, which must not be signalled.
+func isScrollCapture(pid int) bool {
+	proc := filepath.Join("/proc", strconv.Itoa(pid))
+	exe, err := os.Readlink(filepath.Join(proc, "exe"))
+	if err != nil {
+		return false
+	}
+	self, err := os.Executable()
+	if err != nil || strings.TrimSuffix(exe, " (deleted)") != strings.TrimSuffix(self, " (deleted)") {
+		return false
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(proc, "cmdline"))
+	if err != nil {
+		return false
+	}
+	return isScrollCommand(strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00"))
+}
+
+// isScrollCommand reports whether args run `screenshot scroll` and not its
+// stop subcommand.
+func isScrollCommand(args []string) bool {
+	var commands []string
+	for _, arg := range args[min(1, len(args)):] {
+		if !strings.HasPrefix(arg, "-") {
+			commands = append(commands, arg)
+		}
+	}
+	return len(commands) >= 2 && commands[0] == "screenshot" && commands[1] == "scroll" &&
+		(len(commands) == 2 || commands[2] != "stop")
+}
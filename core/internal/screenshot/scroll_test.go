@@ -0,0 +1,47 @@
+package screenshot
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsScrollCommand(t *testing.T) {
+	assert.True(t, isScrollCommand([]string{"dms", "screenshot", "scroll"}))
+	assert.True(t, isScrollCommand([]string{"/usr/bin/dms", "screenshot", "scroll", "--auto", "--idle", "3"}))
+	assert.False(t, isScrollCommand([]string{"dms", "screenshot", "scroll", "stop"}))
+	assert.False(t, isScrollCommand([]string{"scroll"}))
+	assert.False(t, isScrollCommand([]string{"scroll", "--config", "/etc/scroll/config"}))
+	assert.False(t, isScrollCommand([]string{"dms", "screenshot", "region"}))
+	assert.False(t, isScrollCommand(nil))
+}
+
+func TestIsScrollCapture(t *testing.T) {
+	// Another binary, however it was started, is never a capture.
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer cmd.Process.Kill() //nolint:errcheck
+	assert.False(t, isScrollCapture(cmd.Process.Pid))
+
+	// This binary, but not running a scroll capture.
+	assert.False(t, isScrollCapture(os.Getpid()))
+
+	// This binary running `screenshot scroll`.
+	capture := exec.Command(os.Args[0], "-test.run=^TestScrollCaptureHelper$", "screenshot", "scroll")
+	capture.Env = append(os.Environ(), "DMS_SCROLL_CAPTURE_HELPER=1")
+	require.NoError(t, capture.Start())
+	defer capture.Process.Kill() //nolint:errcheck
+	assert.Eventually(t, func() bool { return isScrollCapture(capture.Process.Pid) }, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestScrollCaptureHelper stands in for a running capture.
+func TestScrollCaptureHelper(t *testing.T) {
+	if os.Getenv("DMS_SCROLL_CAPTURE_HELPER") == "" {
+		t.Skip("helper process")
+	}
+	time.Sleep(10 * time.Second)
+}
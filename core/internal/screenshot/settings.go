@@ -0,0 +1,91 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Settings are the user defaults for captures, kept in screenshot.json in
+// the DMS config directory. The CLI applies them under its flags, and the
+// server reads and changes them with screenshot.getConfig and setConfig.
+type Settings struct {
+	FilenameTemplate string `json:"filenameTemplate"`
+	OutputDir        string `json:"outputDir,omitempty"`
+	Format           string `json:"format"`
+	Quality          int    `json:"quality"`
+	PNGCompression   string `json:"pngCompression"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		FilenameTemplate: DefaultFilenameTemplate,
+		Format:           FormatPNG.Extension(),
+		Quality:          90,
+		PNGCompression:   PNGCompressionFast.String(),
+	}
+}
+
+func getSettingsPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "DankMaterialShell", "screenshot.json"), nil
+}
+
+func LoadSettings() Settings {
+	settings := DefaultSettings()
+
+	path, err := getSettingsPath()
+	if err != nil {
+		return settings
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return settings
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return DefaultSettings()
+	}
+	return settings
+}
+
+func SaveSettings(settings Settings) error {
+	path, err := getSettingsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Apply copies the persisted defaults onto cfg. Invalid values are ignored.
+func (s Settings) Apply(cfg *Config) {
+	if s.FilenameTemplate != "" {
+		cfg.Template = s.FilenameTemplate
+	}
+	if s.OutputDir != "" {
+		cfg.OutputDir = s.OutputDir
+	}
+	if format, ok := ParseFormat(s.Format); ok {
+		cfg.Format = format
+	}
+	if s.Quality >= 1 && s.Quality <= 100 {
+		cfg.Quality = s.Quality
+	}
+	if compression, ok := ParsePNGCompression(s.PNGCompression); ok {
+		cfg.Compression = compression
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
 )
 
 type PersistentState struct {
 	LastRegion Region `json:"last_region"`
+	Counter    int    `json:"counter,omitempty"`
 }
 
 func getStateFilePath() string {
@@ -63,3 +66,16 @@ func SaveLastRegion(r Region) error {
 	state.LastRegion = r
 	return SaveState(state)
 }
+
+// NextCounter returns the next value of the persistent {count} template token.
+func NextCounter() int {
+	state, err := LoadState()
+	if err != nil {
+		state = &PersistentState{}
+	}
+	state.Counter++
+	if err := SaveState(state); err != nil {
+		log.Debug("failed to save screenshot counter", "err", err)
+	}
+	return state.Counter
+}
@@ -0,0 +1,157 @@
+package screenshot
+
+import (
+	"hash/fnv"
+)
+
+const (
+	stitchMatchRatio  = 0.97
+	stitchMinOverlap  = 8
+	stitchMaxSideTrim = 32
+)
+
+// Stitcher assembles consecutive captures of a scrolling region into one tall
+// image. Each frame is matched against the previous one by hashing its rows
+// and finding the vertical offset where the two overlap. Rows that stay put
+// at the same position in both frames (sticky headers and footers) are kept
+// once and excluded from the overlap search.
+type Stitcher struct {
+	width    int
+	rowBytes int
+	sideTrim int
+
+	rows     [][]byte
+	prev     []uint64
+	prevRows [][]byte
+}
+
+func NewStitcher(width int) *Stitcher {
+	return &Stitcher{
+		width:    width,
+		rowBytes: width * 4,
+		sideTrim: min(width/16, stitchMaxSideTrim),
+	}
+}
+
+func (s *Stitcher) Width() int  { return s.width }
+func (s *Stitcher) Height() int { return len(s.rows) }
+
+// Add appends the rows of a frame that were not visible in the previous one
+// and returns how many were added. The first frame is always taken whole.
+// Frames must share the stitcher's width and pixel format.
+func (s *Stitcher) Add(pix []byte, stride, height int) int {
+	frame := make([][]byte, height)
+	hashes := make([]uint64, height)
+	for y := 0; y < height; y++ {
+		row := make([]byte, s.rowBytes)
+		copy(row, pix[y*stride:y*stride+s.rowBytes])
+		frame[y] = row
+		hashes[y] = s.hashRow(row)
+	}
+
+	if s.prev == nil {
+		s.rows = append(s.rows, frame...)
+		s.prev, s.prevRows = hashes, frame
+		return height
+	}
+
+	added := s.merge(frame, hashes)
+	s.prev, s.prevRows = hashes, frame
+	return added
+}
+
+func (s *Stitcher) merge(frame [][]byte, hashes []uint64) int {
+	height := min(len(hashes), len(s.prev))
+
+	top := 0
+	for top < height && hashes[top] == s.prev[top] {
+		top++
+	}
+	if top == height {
+		return 0
+	}
+
+	bottom := 0
+	for bottom < height-top && hashes[len(hashes)-1-bottom] == s.prev[len(s.prev)-1-bottom] {
+		bottom++
+	}
+
+	prevBand := s.prev[top : len(s.prev)-bottom]
+	curBand := hashes[top : len(hashes)-bottom]
+
+	offset, ok := findOverlap(prevBand, curBand)
+	if !ok {
+		offset = len(curBand)
+	}
+	if offset == 0 {
+		return 0
+	}
+
+	newRows := frame[top+len(curBand)-offset : len(frame)-bottom]
+	insertAt := len(s.rows) - bottom
+
+	merged := make([][]byte, 0, len(s.rows)+len(newRows))
+	merged = append(merged, s.rows[:insertAt]...)
+	merged = append(merged, newRows...)
+	merged = append(merged, s.rows[insertAt:]...)
+	s.rows = merged
+
+	return len(newRows)
+}
+
+// findOverlap returns how far cur is scrolled relative to prev: the smallest
+// offset d where cur[0:n-d] lines up with prev[d:n]. Offsets whose overlap
+// is too short to be meaningful are not considered.
+func findOverlap(prev, cur []uint64) (int, bool) {
+	n := min(len(prev), len(cur))
+	minOverlap := max(stitchMinOverlap, n/10)
+	if n < minOverlap {
+		return 0, false
+	}
+
+	bestOffset, bestRatio := 0, 0.0
+	for d := 0; d <= n-minOverlap; d++ {
+		overlap := n - d
+		matches := 0
+		for i := 0; i < overlap; i++ {
+			if prev[d+i] == cur[i] {
+				matches++
+			}
+		}
+		ratio := float64(matches) / float64(overlap)
+		if ratio > bestRatio {
+			bestOffset, bestRatio = d, ratio
+		}
+		if ratio == 1 {
+			break
+		}
+	}
+
+	if bestRatio < stitchMatchRatio {
+		return 0, false
+	}
+	return bestOffset, true
+}
+
+// hashRow ignores a few columns on each side so overlay scrollbars moving
+// along the edge don't break row matching.
+func (s *Stitcher) hashRow(row []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(row[s.sideTrim*4 : s.rowBytes-s.sideTrim*4])
+	return h.Sum64()
+}
+
+// Buffer copies the stitched image into a new shm buffer.
+func (s *Stitcher) Buffer(format PixelFormat) (*ShmBuffer, error) {
+	buf, err := CreateShmBuffer(s.width, len(s.rows), s.rowBytes)
+	if err != nil {
+		return nil, err
+	}
+	buf.Format = format
+
+	data := buf.Data()
+	for y, row := range s.rows {
+		copy(data[y*buf.Stride:], row)
+	}
+	return buf, nil
+}
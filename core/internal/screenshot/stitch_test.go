@@ -0,0 +1,121 @@
+package screenshot
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makePage builds a tall BGRA page where every row is distinct.
+func makePage(width, height int) [][]byte {
+	rng := rand.New(rand.NewSource(1))
+	rows := make([][]byte, height)
+	for y := range rows {
+		row := make([]byte, width*4)
+		rng.Read(row)
+		rows[y] = row
+	}
+	return rows
+}
+
+// viewport renders a window of the page with optional sticky header and
+// footer rows on top of it.
+func viewport(page, header, footer [][]byte, scroll, height int) ([]byte, int) {
+	stride := len(page[0])
+	pix := make([]byte, 0, stride*height)
+	for y := 0; y < height; y++ {
+		switch {
+		case y < len(header):
+			pix = append(pix, header[y]...)
+		case y >= height-len(footer):
+			pix = append(pix, footer[y-(height-len(footer))]...)
+		default:
+			pix = append(pix, page[scroll+y]...)
+		}
+	}
+	return pix, stride
+}
+
+func TestStitcherAppendsScrolledRows(t *testing.T) {
+	const width, height = 40, 100
+	page := makePage(width, 400)
+	s := NewStitcher(width)
+
+	pix, stride := viewport(page, nil, nil, 0, height)
+	assert.Equal(t, height, s.Add(pix, stride, height))
+
+	for _, scroll := range []int{30, 75, 160, 200} {
+		pix, stride = viewport(page, nil, nil, scroll, height)
+		s.Add(pix, stride, height)
+	}
+
+	require.Equal(t, 300, s.Height())
+	assert.Equal(t, page[:300], s.rows)
+}
+
+func TestStitcherNoNewRows(t *testing.T) {
+	const width, height = 40, 80
+	page := makePage(width, 200)
+	s := NewStitcher(width)
+
+	pix, stride := viewport(page, nil, nil, 10, height)
+	s.Add(pix, stride, height)
+	assert.Equal(t, 0, s.Add(pix, stride, height))
+	assert.Equal(t, height, s.Height())
+}
+
+func TestStitcherStickyHeaderFooter(t *testing.T) {
+	const width, height = 40, 120
+	page := makePage(width, 500)
+	chrome := makePage(width+1, 30)
+	header := make([][]byte, 12)
+	footer := make([][]byte, 10)
+	for i := range header {
+		header[i] = chrome[i][:width*4]
+	}
+	for i := range footer {
+		footer[i] = chrome[12+i][:width*4]
+	}
+
+	s := NewStitcher(width)
+	for _, scroll := range []int{0, 40, 90} {
+		pix, stride := viewport(page, header, footer, scroll, height)
+		s.Add(pix, stride, height)
+	}
+
+	want := append([][]byte{}, header...)
+	want = append(want, page[12:90+height-10]...)
+	want = append(want, footer...)
+	assert.Equal(t, want, s.rows)
+}
+
+func TestStitcherIgnoresScrollbarColumns(t *testing.T) {
+	const width, height = 64, 100
+	page := makePage(width, 300)
+	s := NewStitcher(width)
+
+	for i, scroll := range []int{0, 50} {
+		pix, stride := viewport(page, nil, nil, scroll, height)
+		pix = append([]byte{}, pix...)
+		for y := 0; y < height; y++ {
+			pix[y*stride+(width-1)*4] = byte(i * 100)
+		}
+		s.Add(pix, stride, height)
+	}
+
+	assert.Equal(t, 150, s.Height())
+}
+
+func TestFindOverlap(t *testing.T) {
+	prev := []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	cur := []uint64{5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+
+	offset, ok := findOverlap(prev, cur)
+	require.True(t, ok)
+	assert.Equal(t, 4, offset)
+
+	_, ok = findOverlap(prev, []uint64{90, 91, 92, 93, 94, 95, 96, 97, 98, 99, 100, 101, 102, 103, 104, 105})
+	assert.False(t, ok)
+}
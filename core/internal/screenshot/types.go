@@ -1,5 +1,7 @@
 package screenshot
 
+import "time"
+
 type Mode int
 
 const (
@@ -9,6 +11,7 @@ const (
 	ModeAllScreens
 	ModeOutput
 	ModeLastRegion
+	ModeScroll
 )
 
 type Format int
@@ -17,6 +20,17 @@ const (
 	FormatPNG Format = iota
 	FormatJPEG
 	FormatPPM
+	FormatQOI
+	FormatWebP
+)
+
+type PNGCompression int
+
+const (
+	PNGCompressionFast PNGCompression = iota
+	PNGCompressionDefault
+	PNGCompressionBest
+	PNGCompressionNone
 )
 
 type Region struct {
@@ -47,12 +61,20 @@ type Config struct {
 	IncludeCursor bool
 	Format        Format
 	Quality       int
+	Compression   PNGCompression
 	OutputDir     string
 	Filename      string
+	Template      string
 	Clipboard     bool
 	SaveFile      bool
 	Notify        bool
 	Stdout        bool
+	History       bool
+	Scroll        ScrollOptions
+	IncludeData   bool
+	Delay         time.Duration
+	Countdown     bool
+	OnProgress    func(Progress)
 }
 
 func DefaultConfig() Config {
@@ -61,10 +83,15 @@ func DefaultConfig() Config {
 		IncludeCursor: false,
 		Format:        FormatPNG,
 		Quality:       90,
+		Compression:   PNGCompressionFast,
 		OutputDir:     "",
 		Filename:      "",
+		Template:      DefaultFilenameTemplate,
 		Clipboard:     true,
 		SaveFile:      true,
 		Notify:        true,
+		History:       true,
+		Scroll:        DefaultScrollOptions(),
+		Countdown:     true,
 	}
 }
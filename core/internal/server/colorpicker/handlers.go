@@ -0,0 +1,201 @@
+package colorpicker
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/colorpicker"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
+)
+
+type ExportResult struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+func HandleRequest(conn net.Conn, req models.Request, manager *Manager) {
+	switch req.Method {
+	case "colorpicker.getState":
+		models.Respond(conn, req.ID, manager.GetState())
+	case "colorpicker.history.list":
+		handleHistoryList(conn, req)
+	case "colorpicker.history.remove":
+		handleColorAction(conn, req, colorpicker.RemoveHistoryEntry, "removed")
+	case "colorpicker.history.clear":
+		respondResult(conn, req, colorpicker.ClearHistory(), "history cleared")
+	case "colorpicker.history.export":
+		handleHistoryExport(conn, req)
+	case "colorpicker.palettes.list":
+		handlePalettesList(conn, req)
+	case "colorpicker.palettes.get":
+		handlePaletteGet(conn, req)
+	case "colorpicker.palettes.create":
+		handleNameAction(conn, req, colorpicker.CreatePalette, "created")
+	case "colorpicker.palettes.delete":
+		handleNameAction(conn, req, colorpicker.DeletePalette, "deleted")
+	case "colorpicker.palettes.rename":
+		handlePaletteRename(conn, req)
+	case "colorpicker.palettes.addColor":
+		handlePaletteColor(conn, req, colorpicker.AddPaletteColor, "color added")
+	case "colorpicker.palettes.removeColor":
+		handlePaletteColor(conn, req, colorpicker.RemovePaletteColor, "color removed")
+	case "colorpicker.palettes.export":
+		handlePaletteExport(conn, req)
+	default:
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+	}
+}
+
+func respondResult(conn net.Conn, req models.Request, err error, message string) {
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: message})
+}
+
+func handleHistoryList(conn net.Conn, req models.Request) {
+	entries, err := colorpicker.ListHistory(params.IntOpt(req.Params, "limit", 0))
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []colorpicker.HistoryEntry{}
+	}
+	models.Respond(conn, req.ID, entries)
+}
+
+func handleColorAction(conn net.Conn, req models.Request, action func(string) error, message string) {
+	color, err := params.StringNonEmpty(req.Params, "color")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	respondResult(conn, req, action(color), message)
+}
+
+func handleNameAction(conn net.Conn, req models.Request, action func(string) error, message string) {
+	name, err := params.StringNonEmpty(req.Params, "name")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	respondResult(conn, req, action(name), message)
+}
+
+func handlePalettesList(conn net.Conn, req models.Request) {
+	palettes, err := colorpicker.LoadPalettes()
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	if palettes.Palettes == nil {
+		palettes.Palettes = []colorpicker.Palette{}
+	}
+	models.Respond(conn, req.ID, palettes.Palettes)
+}
+
+func handlePaletteGet(conn net.Conn, req models.Request) {
+	name, err := params.StringNonEmpty(req.Params, "name")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	palette, err := colorpicker.GetPalette(name)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, palette)
+}
+
+func handlePaletteRename(conn net.Conn, req models.Request) {
+	name, err := params.StringNonEmpty(req.Params, "name")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	newName, err := params.StringNonEmpty(req.Params, "newName")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	respondResult(conn, req, colorpicker.RenamePalette(name, newName), "renamed")
+}
+
+func handlePaletteColor(conn net.Conn, req models.Request, action func(name, color string) error, message string) {
+	name, err := params.StringNonEmpty(req.Params, "name")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	color, err := params.StringNonEmpty(req.Params, "color")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	respondResult(conn, req, action(name, color), message)
+}
+
+func exportFormat(req models.Request) (colorpicker.ExportFormat, error) {
+	raw := params.StringOpt(req.Params, "format", string(colorpicker.ExportJSON))
+	format, ok := colorpicker.ParseExportFormat(raw)
+	if !ok {
+		return "", fmt.Errorf("invalid format: %s", raw)
+	}
+	return format, nil
+}
+
+func handleHistoryExport(conn net.Conn, req models.Request) {
+	format, err := exportFormat(req)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	entries, err := colorpicker.ListHistory(params.IntOpt(req.Params, "limit", 0))
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	colors := make([]colorpicker.Color, 0, len(entries))
+	for _, e := range entries {
+		if c, err := e.Color(); err == nil {
+			colors = append(colors, c)
+		}
+	}
+	respondExport(conn, req, "history", colors, format)
+}
+
+func handlePaletteExport(conn net.Conn, req models.Request) {
+	name, err := params.StringNonEmpty(req.Params, "name")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	format, err := exportFormat(req)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	palette, err := colorpicker.GetPalette(name)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	respondExport(conn, req, palette.Name, palette.ParsedColors(), format)
+}
+
+func respondExport(conn net.Conn, req models.Request, name string, colors []colorpicker.Color, format colorpicker.ExportFormat) {
+	content, err := colorpicker.Export(name, colors, format)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, ExportResult{Format: string(format), Content: content})
+}
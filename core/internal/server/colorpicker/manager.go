@@ -0,0 +1,139 @@
+package colorpicker
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/colorpicker"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+	"github.com/fsnotify/fsnotify"
+)
+
+type State struct {
+	History  []colorpicker.HistoryEntry `json:"history"`
+	Palettes []colorpicker.Palette      `json:"palettes"`
+}
+
+// Manager serves the color history and palettes. Colors are picked by the
+// `dms color pick` process, so the files are watched to tell subscribers
+// when something changed.
+type Manager struct {
+	stateMu     sync.RWMutex
+	state       State
+	subscribers syncmap.Map[string, chan State]
+	stopChan    chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewManager() *Manager {
+	m := &Manager{stopChan: make(chan struct{})}
+	m.reload()
+	go m.watch()
+	return m
+}
+
+func (m *Manager) Subscribe(id string) chan State {
+	ch := make(chan State, 16)
+	m.subscribers.Store(id, ch)
+	return ch
+}
+
+func (m *Manager) Unsubscribe(id string) {
+	if val, ok := m.subscribers.LoadAndDelete(id); ok {
+		close(val)
+	}
+}
+
+func (m *Manager) GetState() State {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.state
+}
+
+func (m *Manager) reload() {
+	state := State{
+		History:  []colorpicker.HistoryEntry{},
+		Palettes: []colorpicker.Palette{},
+	}
+	if history, err := colorpicker.LoadHistory(); err == nil && history.Entries != nil {
+		state.History = history.Entries
+	}
+	if palettes, err := colorpicker.LoadPalettes(); err == nil && palettes.Palettes != nil {
+		state.Palettes = palettes.Palettes
+	}
+
+	m.stateMu.Lock()
+	m.state = state
+	m.stateMu.Unlock()
+
+	m.subscribers.Range(func(key string, ch chan State) bool {
+		select {
+		case ch <- state:
+		default:
+		}
+		return true
+	})
+}
+
+func (m *Manager) watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warnf("Failed to create color picker watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	files := map[string]bool{colorpicker.HistoryPath(): true}
+	if path, err := colorpicker.PalettesPath(); err == nil {
+		files[path] = true
+	}
+
+	// Watch the parent directories: the files are replaced by rename on every
+	// write and may not exist before the first pick.
+	for path := range files {
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Debugf("Failed to create %s: %v", dir, err)
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			log.Debugf("Failed to watch %s: %v", dir, err)
+		}
+	}
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !files[event.Name] {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			m.reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Color picker watcher error: %v", err)
+		}
+	}
+}
+
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stopChan)
+		m.subscribers.Range(func(key string, ch chan State) bool {
+			close(ch)
+			m.subscribers.Delete(key)
+			return true
+		})
+	})
+}
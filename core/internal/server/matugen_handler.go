@@ -2,11 +2,18 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/matugen"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/thememode"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/utils"
 )
 
 type MatugenQueueResult struct {
@@ -29,10 +36,16 @@ func handleMatugenQueue(conn net.Conn, req models.Request) {
 		SyncModeWithPortal:  models.GetOr(req, "syncModeWithPortal", false),
 		TerminalsAlwaysDark: models.GetOr(req, "terminalsAlwaysDark", false),
 		SkipTemplates:       models.GetOr(req, "skipTemplates", ""),
+		Outputs:             params.StringMapOpt(req.Params, "outputs"),
 	}
 
 	wait := models.GetOr(req, "wait", true)
 
+	lastMatugen.set(opts)
+	if !models.GetOr(req, "ignoreSchedule", false) {
+		applyScheduledPalette(&opts, themeModeManager)
+	}
+
 	queue := matugen.GetQueue()
 	resultCh := queue.Submit(opts)
 
@@ -69,6 +82,93 @@ func handleMatugenQueue(conn net.Conn, req models.Request) {
 	}
 }
 
+// lastMatugen is the last theme the shell asked for, kept so it can be
+// regenerated when theme.auto switches palettes on its own.
+var lastMatugen matugenRequest
+
+type matugenRequest struct {
+	mu   sync.Mutex
+	opts *matugen.Options
+}
+
+func (r *matugenRequest) set(opts matugen.Options) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opts = &opts
+}
+
+func (r *matugenRequest) get() (matugen.Options, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.opts == nil {
+		return matugen.Options{}, false
+	}
+	return *r.opts, true
+}
+
+// applyScheduledPalette swaps the wallpaper for the palette theme.auto has
+// scheduled for the current period. Stock themes aren't generated from a
+// wallpaper and are left alone.
+func applyScheduledPalette(opts *matugen.Options, manager *thememode.Manager) {
+	if manager == nil || opts.StockColors != "" {
+		return
+	}
+	palette, ok := manager.ActivePalette()
+	if !ok {
+		return
+	}
+	opts.Kind = palette.Kind
+	opts.Value = palette.Value
+}
+
+// watchScheduledPalettes regenerates the last requested theme when the
+// scheduled palette changes. Transitions that also flip light/dark are
+// left to the shell, which regenerates on mode changes anyway.
+func watchScheduledPalettes(manager *thememode.Manager) {
+	states := manager.Subscribe("matugen-palettes")
+	last := manager.GetState()
+	for state := range states {
+		changed := !palettesEqual(last.Palette, state.Palette) && state.IsLight == last.IsLight
+		last = state
+		if !changed {
+			continue
+		}
+
+		opts, ok := lastMatugen.get()
+		if !ok || opts.StockColors != "" {
+			continue
+		}
+		if opts.Mode = matugen.ColorModeDark; state.IsLight {
+			opts.Mode = matugen.ColorModeLight
+		}
+		applyScheduledPalette(&opts, manager)
+		log.Infof("Scheduled palette changed, regenerating theme from %s", opts.Value)
+		matugen.GetQueue().Submit(opts)
+	}
+}
+
+func palettesEqual(a, b *thememode.PaletteSource) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func handleMatugenPalettes(conn net.Conn, req models.Request) {
+	stateDir := models.GetOr(req, "stateDir", filepath.Join(utils.XDGCacheHome(), "DankMaterialShell"))
+	colors, err := matugen.LoadColors(stateDir)
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("failed to load colors: %v", err))
+		return
+	}
+
+	if output, ok := models.Get[string](req, "output"); ok {
+		models.Respond(conn, req.ID, colors.Palette(output))
+		return
+	}
+	models.Respond(conn, req.ID, colors.Outputs)
+}
+
 func handleMatugenStatus(conn net.Conn, req models.Request) {
 	queue := matugen.GetQueue()
 	models.Respond(conn, req.ID, map[string]bool{
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxPeerAncestors bounds how far up the process tree a peer is followed.
+const maxPeerAncestors = 32
+
+// peerProcesses returns the PID of the process on the other end of conn,
+// read with SO_PEERCRED, followed by its ancestors, so a connection from
+// a process a plugin backend started is traced back to the backend.
+func peerProcesses(conn net.Conn) []int {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return nil
+	}
+
+	var pids []int
+	for pid := int(cred.Pid); pid > 1 && len(pids) < maxPeerAncestors; pid = parentPID(pid) {
+		pids = append(pids, pid)
+	}
+	return pids
+}
+
+// parentPID reads the parent of pid from /proc, or returns 0.
+func parentPID(pid int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0
+	}
+	// The command name is in parentheses and may hold spaces; the state
+	// and the parent PID follow it.
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0
+	}
+	fields := bytes.Fields(data[end+1:])
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(string(fields[1]))
+	return ppid
+}
@@ -0,0 +1,79 @@
+package pluginbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
+)
+
+// callTimeout bounds how long a request waits for a backend's answer.
+var callTimeout = 30 * time.Second
+
+func HandleRequest(conn net.Conn, req models.Request, manager *Manager) {
+	switch req.Method {
+	case "plugins.backend.status":
+		models.Respond(conn, req.ID, manager.Status())
+	case "plugins.backend.start":
+		handleLifecycle(conn, req, manager.Start, "started")
+	case "plugins.backend.stop":
+		handleLifecycle(conn, req, manager.Stop, "stopped")
+	case "plugins.backend.restart":
+		handleLifecycle(conn, req, func(id string) error {
+			manager.Stop(id) //nolint:errcheck
+			return manager.Start(id)
+		}, "restarted")
+	default:
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+	}
+}
+
+func handleLifecycle(conn net.Conn, req models.Request, action func(string) error, done string) {
+	id, err := params.StringNonEmpty(req.Params, "plugin")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	if err := action(id); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: fmt.Sprintf("backend %s %s", id, done)})
+}
+
+// SplitMethod splits plugin.<id>.<method> into the plugin ID and the
+// method of its backend.
+func SplitMethod(method string) (id, backendMethod string, ok bool) {
+	rest, ok := strings.CutPrefix(method, "plugin.")
+	if !ok {
+		return "", "", false
+	}
+	id, backendMethod, ok = strings.Cut(rest, ".")
+	return id, backendMethod, ok && id != "" && backendMethod != ""
+}
+
+// HandleCall forwards plugin.<id>.<method> to the backend of plugin id.
+func HandleCall(conn net.Conn, req models.Request, manager *Manager) {
+	id, method, ok := SplitMethod(req.Method)
+	if !ok {
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	result, err := manager.Call(ctx, id, method, req.Params)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	models.Respond(conn, req.ID, result)
+}
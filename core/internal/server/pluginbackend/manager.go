@@ -0,0 +1,411 @@
+package pluginbackend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+)
+
+// Restarts back off from restartDelay, doubling up to maxRestartDelay. A
+// backend that stayed up for stableAfter starts over from restartDelay.
+var (
+	restartDelay    = time.Second
+	maxRestartDelay = time.Minute
+	stableAfter     = 30 * time.Second
+	stopTimeout     = 5 * time.Second
+)
+
+// Manager supervises plugin backends: it starts them, restarts them when
+// they exit and bridges their requests and events.
+type Manager struct {
+	resolve     Resolver
+	mu          sync.Mutex
+	backends    map[string]*backend
+	subscribers syncmap.Map[string, chan Event]
+}
+
+func NewManager(resolve Resolver) *Manager {
+	return &Manager{
+		resolve:  resolve,
+		backends: map[string]*backend{},
+	}
+}
+
+type backend struct {
+	id      string
+	manager *Manager
+	stop    chan struct{}
+	done    chan struct{}
+	// first is the spec Start resolved, used for the first spawn so a
+	// backend is resolved once per spawn.
+	first *Spec
+
+	mu      sync.Mutex
+	status  Status
+	stdin   io.Writer
+	nextID  int
+	pending map[int]chan message
+
+	// writeMu keeps requests from interleaving on stdin. It is never held
+	// together with mu, so a backend that stops reading can't block its
+	// status or its responses.
+	writeMu sync.Mutex
+}
+
+// Start starts a plugin's backend, if it isn't running already.
+func (m *Manager) Start(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.backends[id]; ok {
+		return nil
+	}
+	// Resolving first checks the plugin has a backend before supervising
+	// it; the first spawn runs what was resolved here.
+	spec, err := m.resolve(id)
+	if err != nil {
+		return err
+	}
+
+	b := &backend{
+		id:      id,
+		manager: m,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		first:   &spec,
+		status:  Status{Plugin: id},
+		pending: map[int]chan message{},
+	}
+	m.backends[id] = b
+	go b.supervise()
+	return nil
+}
+
+// Stop stops a plugin's backend and waits for it to exit.
+func (m *Manager) Stop(id string) error {
+	m.mu.Lock()
+	b, ok := m.backends[id]
+	delete(m.backends, id)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("backend for %s is not running", id)
+	}
+	close(b.stop)
+	<-b.done
+	return nil
+}
+
+func (m *Manager) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, 0, len(m.backends))
+	for _, b := range m.backends {
+		statuses = append(statuses, b.getStatus())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Plugin < statuses[j].Plugin })
+	return statuses
+}
+
+// PluginForPID returns the plugin whose running backend has one of pids.
+func (m *Manager) PluginForPID(pids ...int) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, b := range m.backends {
+		if pid := b.getStatus().PID; pid != 0 && slices.Contains(pids, pid) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Call sends a request to a plugin's backend and waits for its result.
+func (m *Manager) Call(ctx context.Context, id, method string, params map[string]any) (json.RawMessage, error) {
+	m.mu.Lock()
+	b, ok := m.backends[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("backend for %s is not running", id)
+	}
+	return b.call(ctx, method, params)
+}
+
+// Subscribe returns a channel of the events of every backend.
+func (m *Manager) Subscribe(id string) chan Event {
+	ch := make(chan Event, 64)
+	m.subscribers.Store(id, ch)
+	return ch
+}
+
+func (m *Manager) Unsubscribe(id string) {
+	if val, ok := m.subscribers.LoadAndDelete(id); ok {
+		close(val)
+	}
+}
+
+func (m *Manager) publish(event Event) {
+	m.subscribers.Range(func(key string, ch chan Event) bool {
+		select {
+		case ch <- event:
+		default:
+		}
+		return true
+	})
+}
+
+func (m *Manager) Close() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.backends))
+	for id := range m.backends {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		m.Stop(id) //nolint:errcheck
+	}
+	m.subscribers.Range(func(key string, ch chan Event) bool {
+		m.subscribers.Delete(key)
+		close(ch)
+		return true
+	})
+}
+
+func (b *backend) getStatus() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
+
+func (b *backend) updateStatus(update func(*Status)) {
+	b.mu.Lock()
+	update(&b.status)
+	status := b.status
+	b.mu.Unlock()
+	b.manager.publish(Event{Plugin: b.id, Event: StatusEvent, Data: status})
+}
+
+// supervise runs the backend until it is stopped, restarting it with
+// backoff whenever it exits.
+func (b *backend) supervise() {
+	defer close(b.done)
+	delay := restartDelay
+	for {
+		started := time.Now()
+		err := b.run()
+		if err != nil {
+			log.Warnf("Plugin backend %s exited: %v", b.id, err)
+		}
+		b.updateStatus(func(s *Status) {
+			s.Running = false
+			s.PID = 0
+			if err != nil {
+				s.LastError = err.Error()
+			}
+		})
+
+		select {
+		case <-b.stop:
+			return
+		default:
+		}
+
+		if time.Since(started) >= stableAfter {
+			delay = restartDelay
+		}
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRestartDelay)
+		b.updateStatus(func(s *Status) { s.Restarts++ })
+	}
+}
+
+func (b *backend) run() error {
+	spec, err := b.spec()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, spec.Exec, spec.Args...)
+	cmd.Dir = spec.Dir
+	cmd.Env = append(os.Environ(), spec.Env...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = stopTimeout
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.stdin = stdin
+	b.mu.Unlock()
+	b.updateStatus(func(s *Status) {
+		s.Running = true
+		s.PID = cmd.Process.Pid
+		s.StartedAt = time.Now()
+	})
+	log.Infof("Plugin backend %s started (pid %d)", b.id, cmd.Process.Pid)
+
+	go b.logStderr(stderr)
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-b.stop:
+			cancel()
+		case <-exited:
+		}
+	}()
+
+	b.read(stdout)
+	err = cmd.Wait()
+	close(exited)
+
+	b.mu.Lock()
+	b.stdin = nil
+	pending := b.pending
+	b.pending = map[int]chan message{}
+	b.mu.Unlock()
+	for _, ch := range pending {
+		ch <- message{Error: "backend exited"}
+	}
+
+	select {
+	case <-b.stop:
+		return nil
+	default:
+	}
+	if err == nil {
+		err = errors.New("exited")
+	}
+	return err
+}
+
+// spec returns what to run: the spec Start resolved on the first spawn,
+// a freshly resolved one on every restart.
+func (b *backend) spec() (Spec, error) {
+	if spec := b.first; spec != nil {
+		b.first = nil
+		return *spec, nil
+	}
+	return b.manager.resolve(b.id)
+}
+
+func (b *backend) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Warnf("Plugin backend %s sent invalid JSON: %v", b.id, err)
+			continue
+		}
+
+		if msg.Event != "" {
+			var data any
+			if len(msg.Data) > 0 {
+				data = msg.Data
+			}
+			b.manager.publish(Event{Plugin: b.id, Event: msg.Event, Data: data})
+			continue
+		}
+
+		b.mu.Lock()
+		ch, ok := b.pending[msg.ID]
+		delete(b.pending, msg.ID)
+		b.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+func (b *backend) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Debugf("Plugin backend %s: %s", b.id, scanner.Text())
+	}
+}
+
+func (b *backend) call(ctx context.Context, method string, params map[string]any) (json.RawMessage, error) {
+	b.mu.Lock()
+	stdin := b.stdin
+	if stdin == nil {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("backend for %s is not running", b.id)
+	}
+	b.nextID++
+	id := b.nextID
+	ch := make(chan message, 1)
+	b.pending[id] = ch
+	b.mu.Unlock()
+
+	forget := func() {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+	}
+
+	data, err := json.Marshal(message{ID: id, Method: method, Params: params})
+	if err != nil {
+		forget()
+		return nil, fmt.Errorf("failed to send to backend: %w", err)
+	}
+	// The write can block on a backend that isn't reading; the caller
+	// gives up with its context, and the write fails once it exits.
+	written := make(chan error, 1)
+	go func() {
+		b.writeMu.Lock()
+		defer b.writeMu.Unlock()
+		_, err := stdin.Write(append(data, '\n'))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			forget()
+			return nil, fmt.Errorf("failed to send to backend: %w", err)
+		}
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != "" {
+			return nil, errors.New(msg.Error)
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,161 @@
+package pluginbackend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoBackend answers requests, fails "fail" and exits on "exit". It emits a
+// ready event on start.
+const echoBackend = `#!/bin/sh
+echo '{"event": "ready", "data": {"plugin": "'"$DMS_PLUGIN_ID"'"}}'
+while read -r line; do
+	id=$(echo "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+	case "$line" in
+	*'"method":"fail"'*) echo '{"id": '"$id"', "error": "failed"}' ;;
+	*'"method":"exit"'*) exit 1 ;;
+	*) echo '{"id": '"$id"', "result": {"ok": true}}' ;;
+	esac
+done
+`
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backend.sh"), []byte(echoBackend), 0o755))
+
+	restartDelay = 10 * time.Millisecond
+	manager := NewManager(func(id string) (Spec, error) {
+		return Spec{Dir: dir, Exec: "./backend.sh", Env: []string{"DMS_PLUGIN_ID=" + id}}, nil
+	})
+	t.Cleanup(manager.Close)
+	return manager
+}
+
+func waitForEvent(t *testing.T, events chan Event, match func(Event) bool) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if match(event) {
+				return event
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+func TestManagerCall(t *testing.T) {
+	manager := newTestManager(t)
+	events := manager.Subscribe("test")
+
+	require.NoError(t, manager.Start("weather"))
+	ready := waitForEvent(t, events, func(e Event) bool { return e.Event == "ready" })
+	assert.Equal(t, "weather", ready.Plugin)
+	assert.JSONEq(t, `{"plugin": "weather"}`, string(ready.Data.(json.RawMessage)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := manager.Call(ctx, "weather", "refresh", map[string]any{"city": "Oslo"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true}`, string(result))
+
+	_, err = manager.Call(ctx, "weather", "fail", nil)
+	assert.EqualError(t, err, "failed")
+
+	_, err = manager.Call(ctx, "clock", "refresh", nil)
+	assert.ErrorContains(t, err, "not running")
+
+	statuses := manager.Status()
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Running)
+	assert.NotZero(t, statuses[0].PID)
+	id, ok := manager.PluginForPID(os.Getpid(), statuses[0].PID)
+	assert.True(t, ok)
+	assert.Equal(t, "weather", id)
+	_, ok = manager.PluginForPID(os.Getpid())
+	assert.False(t, ok)
+
+	require.NoError(t, manager.Stop("weather"))
+	assert.Empty(t, manager.Status())
+	assert.Error(t, manager.Stop("weather"))
+}
+
+func TestManagerRestart(t *testing.T) {
+	manager := newTestManager(t)
+	events := manager.Subscribe("test")
+	var resolves atomic.Int32
+	resolve := manager.resolve
+	manager.resolve = func(id string) (Spec, error) {
+		resolves.Add(1)
+		return resolve(id)
+	}
+
+	require.NoError(t, manager.Start("weather"))
+	waitForEvent(t, events, func(e Event) bool { return e.Event == "ready" })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := manager.Call(ctx, "weather", "exit", nil)
+	assert.ErrorContains(t, err, "backend exited")
+
+	waitForEvent(t, events, func(e Event) bool { return e.Event == "ready" })
+	statuses := manager.Status()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, 1, statuses[0].Restarts)
+	assert.NotEmpty(t, statuses[0].LastError)
+	// Each spawn resolves the backend once, so it gets one token.
+	assert.Equal(t, int32(2), resolves.Load())
+
+	result, err := manager.Call(ctx, "weather", "refresh", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true}`, string(result))
+}
+
+func TestCallDoesNotBlockOnStuckBackend(t *testing.T) {
+	// Nothing reads the backend's stdin, so writes to it block.
+	r, w := io.Pipe()
+	t.Cleanup(func() { r.Close() })
+	b := &backend{id: "weather", stdin: w, pending: map[int]chan message{}}
+
+	for range 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := b.call(ctx, "refresh", nil)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	status := make(chan Status, 1)
+	go func() { status <- b.getStatus() }()
+	select {
+	case <-status:
+	case <-time.After(time.Second):
+		t.Fatal("status blocked behind a stuck write")
+	}
+	b.mu.Lock()
+	assert.Empty(t, b.pending)
+	b.mu.Unlock()
+}
+
+func TestSplitMethod(t *testing.T) {
+	id, method, ok := SplitMethod("plugin.weather.refresh")
+	assert.True(t, ok)
+	assert.Equal(t, "weather", id)
+	assert.Equal(t, "refresh", method)
+
+	_, _, ok = SplitMethod("plugin.weather")
+	assert.False(t, ok)
+	_, _, ok = SplitMethod("plugins.list")
+	assert.False(t, ok)
+}
@@ -0,0 +1,49 @@
+package pluginbackend
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Spec is how to run a plugin's backend.
+type Spec struct {
+	Dir  string
+	Exec string
+	Args []string
+	Env  []string
+}
+
+// Resolver looks up the backend of a plugin when it is (re)started.
+type Resolver func(id string) (Spec, error)
+
+type Status struct {
+	Plugin    string    `json:"plugin"`
+	Running   bool      `json:"running"`
+	PID       int       `json:"pid,omitempty"`
+	Restarts  int       `json:"restarts"`
+	StartedAt time.Time `json:"startedAt,omitzero"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// Event is something a backend emitted, or a change of its status, which
+// is sent as StatusEvent with the Status as data.
+type Event struct {
+	Plugin string `json:"plugin"`
+	Event  string `json:"event"`
+	Data   any    `json:"data,omitempty"`
+}
+
+const StatusEvent = "dms.status"
+
+// message is a line of the backend protocol. The server sends requests
+// with an ID, method and params; backends answer with the same ID and a
+// result or error, and send events with an event name and data.
+type message struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params map[string]any  `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
@@ -3,11 +3,17 @@ package plugins
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 )
 
 func HandleRequest(conn net.Conn, req models.Request) {
+	if strings.HasPrefix(req.Method, "plugins.permissions.") {
+		HandlePermissions(conn, req)
+		return
+	}
+
 	switch req.Method {
 	case "plugins.list":
 		HandleList(conn, req)
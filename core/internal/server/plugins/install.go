@@ -1,8 +1,10 @@
 package plugins
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"slices"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
@@ -57,13 +59,107 @@ func HandleInstall(conn net.Conn, req models.Request) {
 		return
 	}
 
-	if err := manager.Install(*plugin); err != nil {
+	installed, err := manager.ListInstalled()
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("failed to list installed plugins: %v", err))
+		return
+	}
+	plan, err := plugins.PlanInstall(*plugin, pluginList, installed)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	allowUntrusted, _ := models.Get[bool](req, "allowUntrusted")
+	for _, p := range plan.Install {
+		if err := plugins.CheckTrust(p, allowUntrusted); err != nil {
+			models.RespondError(conn, req.ID, err.Error())
+			return
+		}
+	}
+
+	if !models.GetOr(req, "force", false) {
+		env := plugins.DetectEnvironment()
+		var incompatible []string
+		for _, p := range plan.Install {
+			for _, reason := range plugins.CheckCompatibility(p, env) {
+				incompatible = append(incompatible, fmt.Sprintf("%s: %s", p.ID, reason))
+			}
+		}
+		if len(incompatible) > 0 {
+			models.Respond(conn, req.ID, InstallResult{
+				Success:      false,
+				Message:      fmt.Sprintf("%s is not compatible with this system", plugin.Name),
+				Incompatible: incompatible,
+			})
+			return
+		}
+	}
+
+	if deps := plan.Dependencies(); len(deps) > 0 && !models.GetOr(req, "withDependencies", false) {
+		requires := make([]string, len(deps))
+		for i, dep := range deps {
+			requires[i] = dep.ID
+		}
+		models.Respond(conn, req.ID, InstallResult{
+			Success:  false,
+			Message:  fmt.Sprintf("%s requires plugins that are not installed", plugin.Name),
+			Requires: requires,
+		})
+		return
+	}
+
+	manager.AllowUnverified(models.GetOr(req, "allowUnverified", false))
+	if err := manager.InstallPlan(plan); err != nil {
+		var verr *plugins.VerificationError
+		if errors.As(err, &verr) {
+			models.Respond(conn, req.ID, InstallResult{
+				Success:    false,
+				Message:    err.Error(),
+				Unverified: verr.Reason,
+			})
+			return
+		}
 		models.RespondError(conn, req.ID, fmt.Sprintf("failed to install plugin: %v", err))
 		return
 	}
 
-	models.Respond(conn, req.ID, SuccessResult{
-		Success: true,
-		Message: fmt.Sprintf("plugin installed: %s", plugin.Name),
+	pending, err := grantApproved(manager, plan.IDs(), stringList(req.Params["permissions"]))
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("plugin installed but permissions were not granted: %v", err))
+		return
+	}
+
+	models.Respond(conn, req.ID, InstallResult{
+		Success:   true,
+		Message:   fmt.Sprintf("plugin installed: %s", plugin.Name),
+		Installed: plan.IDs(),
+		Pending:   pending,
 	})
 }
+
+// grantApproved grants newly installed plugins the permissions they
+// request that were approved, and returns the ones still to be granted.
+func grantApproved(manager *plugins.Manager, ids []string, approved []string) (map[string][]string, error) {
+	pending := map[string][]string{}
+	for _, id := range ids {
+		requested, err := manager.Requested(id)
+		if err != nil {
+			return nil, err
+		}
+		var grant []string
+		for _, permission := range requested {
+			if slices.Contains(approved, permission) {
+				grant = append(grant, permission)
+			} else {
+				pending[id] = append(pending[id], permission)
+			}
+		}
+		if len(grant) > 0 {
+			if _, err := manager.Grant(id, grant...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pending, nil
+}
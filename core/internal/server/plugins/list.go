@@ -46,6 +46,9 @@ func HandleList(conn net.Conn, req models.Request) {
 			FirstParty:   strings.HasPrefix(p.Repo, "https://github.com/AvengeMedia"),
 			Featured:     p.Featured,
 			RequiresDMS:  p.RequiresDMS,
+			Source:       p.Source,
+			Trusted:      p.Trusted,
+			Permissions:  plugins.RequestedPermissions(p.Permissions),
 		}
 	}
 
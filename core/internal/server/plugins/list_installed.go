@@ -39,9 +39,19 @@ func HandleListInstalled(conn net.Conn, req models.Request) {
 		pluginMap[p.ID] = p
 	}
 
+	grants, err := manager.Grants()
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	env := plugins.DetectEnvironment()
 	result := make([]PluginInfo, 0, len(installedNames))
 	for _, id := range installedNames {
+		requested, _ := manager.Requested(id)
 		if plugin, ok := pluginMap[id]; ok {
+			reasons := manager.CheckInstalled(id, &plugin, env)
+
 			hasUpdate := false
 			if hasUpdates, err := manager.HasUpdates(id, plugin); err == nil {
 				hasUpdate = hasUpdates
@@ -61,12 +71,21 @@ func HandleListInstalled(conn net.Conn, req models.Request) {
 				FirstParty:   strings.HasPrefix(plugin.Repo, "https://github.com/AvengeMedia"),
 				HasUpdate:    hasUpdate,
 				RequiresDMS:  plugin.RequiresDMS,
+				Incompatible: len(reasons) > 0,
+				Reasons:      reasons,
+				Permissions:  requested,
+				Granted:      grants[id],
 			})
 		} else {
+			reasons := manager.CheckInstalled(id, nil, env)
 			result = append(result, PluginInfo{
-				ID:   id,
-				Name: id,
-				Note: "not in registry",
+				ID:           id,
+				Name:         id,
+				Note:         "not in registry",
+				Incompatible: len(reasons) > 0,
+				Reasons:      reasons,
+				Permissions:  requested,
+				Granted:      grants[id],
 			})
 		}
 	}
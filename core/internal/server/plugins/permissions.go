@@ -0,0 +1,201 @@
+package plugins
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+)
+
+// tokens maps the tokens the backend supervisor hands out to the plugin
+// they were issued for. A connection that authenticates with one is tagged
+// as that plugin for the rest of its life, and the token can't be used
+// again. Tokens expire after tokenLifetime, and issuing a new one for a
+// plugin revokes the last, so a restarted backend's old token is dead.
+var (
+	tokens        syncmap.Map[string, issuedToken]
+	latestTokens  syncmap.Map[string, string]
+	tokenLifetime = time.Minute
+)
+
+type issuedToken struct {
+	plugin  string
+	expires time.Time
+}
+
+// IssueToken returns a new token a connection can authenticate as plugin
+// id with. Only the backend supervisor issues tokens, to the backends it
+// spawns.
+func IssueToken(id string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	tokens.Store(token, issuedToken{plugin: id, expires: time.Now().Add(tokenLifetime)})
+	if previous, ok := latestTokens.Swap(id, token); ok {
+		tokens.Delete(previous)
+	}
+	return token, nil
+}
+
+// PluginForToken returns the plugin a token was issued for and uses the
+// token up.
+func PluginForToken(token string) (string, bool) {
+	issued, ok := tokens.LoadAndDelete(token)
+	if !ok || time.Now().After(issued.expires) {
+		return "", false
+	}
+	return issued.plugin, true
+}
+
+// Authorize checks a request from a connection tagged as plugin id against
+// the permissions the user granted it. A plugin needs no permission to
+// call or subscribe to its own backend or to use its own settings. Grants
+// are read on every request, so revoking takes effect immediately.
+func Authorize(manager *plugins.Manager, id string, req models.Request) error {
+	var needed []string
+	if req.Method == "subscribe" {
+		services := stringList(req.Params["services"])
+		if len(services) == 0 || slices.Contains(services, "all") {
+			return fmt.Errorf("plugin %s must name the services it subscribes to", id)
+		}
+		for _, service := range services {
+			if service == "plugin."+id {
+				continue
+			}
+			permission, ok := plugins.SubscriptionPermission(service)
+			if !ok {
+				return fmt.Errorf("plugin %s is not allowed to subscribe to %s", id, service)
+			}
+			needed = append(needed, permission)
+		}
+	} else if strings.HasPrefix(req.Method, "plugin."+id+".") {
+		// A plugin may always talk to its own backend.
+		return nil
+	} else if strings.HasPrefix(req.Method, "plugins.settings.") {
+		// A plugin may use its own settings, but no other plugin's.
+		if plugin, _ := req.Params["plugin"].(string); plugin != id {
+			return fmt.Errorf("plugin %s is not allowed to access the settings of other plugins", id)
+		}
+		return nil
+	} else {
+		permission, ok := plugins.RequiredPermission(req.Method, req.Params)
+		if !ok {
+			return fmt.Errorf("plugin %s is not allowed to call %s", id, req.Method)
+		}
+		needed = append(needed, permission)
+	}
+
+	granted, err := manager.Granted(id)
+	if err != nil {
+		return err
+	}
+	for _, permission := range needed {
+		if permission != "" && !slices.Contains(granted, permission) {
+			return fmt.Errorf("plugin %s has not been granted %s", id, permission)
+		}
+	}
+	return nil
+}
+
+func HandlePermissions(conn net.Conn, req models.Request) {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("failed to create manager: %v", err))
+		return
+	}
+
+	switch req.Method {
+	case "plugins.permissions.available":
+		models.Respond(conn, req.ID, plugins.Permissions)
+	case "plugins.permissions.list":
+		handlePermissionsList(conn, req, manager)
+	case "plugins.permissions.grant", "plugins.permissions.revoke":
+		handlePermissionsChange(conn, req, manager)
+	default:
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+	}
+}
+
+func handlePermissionsList(conn net.Conn, req models.Request, manager *plugins.Manager) {
+	grants, err := manager.Grants()
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	ids := []string{}
+	if id, ok := models.Get[string](req, "plugin"); ok {
+		ids = append(ids, id)
+	} else {
+		installed, err := manager.ListInstalled()
+		if err != nil {
+			models.RespondError(conn, req.ID, fmt.Sprintf("failed to list installed plugins: %v", err))
+			return
+		}
+		ids = append(ids, installed...)
+	}
+
+	result := make([]PermissionsInfo, 0, len(ids))
+	for _, id := range ids {
+		requested, err := manager.Requested(id)
+		if err != nil {
+			models.RespondError(conn, req.ID, err.Error())
+			return
+		}
+		result = append(result, PermissionsInfo{Plugin: id, Requested: requested, Granted: grants[id]})
+	}
+	models.Respond(conn, req.ID, result)
+}
+
+func handlePermissionsChange(conn net.Conn, req models.Request, manager *plugins.Manager) {
+	id, ok := models.Get[string](req, "plugin")
+	if !ok {
+		models.RespondError(conn, req.ID, "missing or invalid 'plugin' parameter")
+		return
+	}
+	requested, err := manager.Requested(id)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	permissions := stringList(req.Params["permissions"])
+	var granted []string
+	if req.Method == "plugins.permissions.grant" {
+		// Granting nothing in particular grants what the plugin requested.
+		if len(permissions) == 0 {
+			permissions = requested
+		}
+		granted, err = manager.Grant(id, permissions...)
+	} else {
+		granted, err = manager.Revoke(id, permissions...)
+	}
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, PermissionsInfo{Plugin: id, Requested: requested, Granted: granted})
+}
+
+func stringList(value any) []string {
+	if list, ok := value.([]string); ok {
+		return list
+	}
+	items, _ := value.([]any)
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
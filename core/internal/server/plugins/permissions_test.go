@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	require.NoError(t, os.MkdirAll(filepath.Join(config, "DankMaterialShell"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(config, "DankMaterialShell", "plugins.permissions.json"),
+		[]byte(`{"clock": ["clipboard.read", "dbus.session"]}`), 0o644))
+
+	manager, err := plugins.NewManager()
+	require.NoError(t, err)
+	authorize := func(method string, params map[string]any) error {
+		return Authorize(manager, "clock", models.Request{Method: method, Params: params})
+	}
+
+	assert.NoError(t, authorize("ping", nil))
+	assert.NoError(t, authorize("clipboard.getHistory", nil))
+	assert.NoError(t, authorize("dbus.call", map[string]any{"bus": "session"}))
+	assert.NoError(t, authorize("subscribe", map[string]any{"services": []any{"clipboard", "server"}}))
+	assert.NoError(t, authorize("plugin.clock.refresh", nil))
+	assert.NoError(t, authorize("subscribe", map[string]any{"services": []any{"plugin.clock"}}))
+	assert.NoError(t, authorize("plugins.settings.set", map[string]any{"plugin": "clock", "key": "format", "value": "HH:mm"}))
+
+	assert.ErrorContains(t, authorize("clipboard.paste", nil), "not been granted clipboard.write")
+	assert.ErrorContains(t, authorize("dbus.call", map[string]any{"bus": "system"}), "not been granted dbus.system")
+	assert.ErrorContains(t, authorize("network.credentials.submit", nil), "not been granted network.credentials")
+	assert.ErrorContains(t, authorize("plugins.permissions.grant", nil), "not allowed to call")
+	assert.ErrorContains(t, authorize("subscribe", nil), "must name the services")
+	assert.ErrorContains(t, authorize("plugin.weather.refresh", nil), "not allowed to call")
+	assert.ErrorContains(t, authorize("plugins.settings.get", map[string]any{"plugin": "weather"}), "settings of other plugins")
+	assert.ErrorContains(t, authorize("subscribe", map[string]any{"services": []any{"plugins.settings"}}), "not allowed to subscribe")
+	assert.ErrorContains(t, authorize("subscribe", map[string]any{"services": []any{"plugin.weather"}}), "not allowed to subscribe")
+	assert.ErrorContains(t, authorize("subscribe", map[string]any{"services": []any{"network"}}), "not been granted network.read")
+}
+
+func TestIssueToken(t *testing.T) {
+	token, err := IssueToken("clock")
+	require.NoError(t, err)
+	id, ok := PluginForToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, "clock", id)
+
+	_, ok = PluginForToken(token)
+	assert.False(t, ok, "tokens can only be used once")
+
+	_, ok = PluginForToken("guess")
+	assert.False(t, ok)
+
+	// A restarted backend gets a new token, which revokes the old one.
+	first, err := IssueToken("clock")
+	require.NoError(t, err)
+	second, err := IssueToken("clock")
+	require.NoError(t, err)
+	_, ok = PluginForToken(first)
+	assert.False(t, ok)
+	_, ok = PluginForToken(second)
+	assert.True(t, ok)
+
+	lifetime := tokenLifetime
+	tokenLifetime = -time.Second
+	defer func() { tokenLifetime = lifetime }()
+	expired, err := IssueToken("clock")
+	require.NoError(t, err)
+	_, ok = PluginForToken(expired)
+	assert.False(t, ok, "tokens expire")
+}
@@ -67,6 +67,8 @@ func HandleSearch(conn net.Conn, req models.Request) {
 			Installed:    installed,
 			FirstParty:   strings.HasPrefix(p.Repo, "https://github.com/AvengeMedia"),
 			RequiresDMS:  p.RequiresDMS,
+			Source:       p.Source,
+			Trusted:      p.Trusted,
 		}
 	}
 
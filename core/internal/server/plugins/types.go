@@ -17,9 +17,64 @@ type PluginInfo struct {
 	Note         string   `json:"note,omitempty"`
 	HasUpdate    bool     `json:"hasUpdate,omitempty"`
 	RequiresDMS  string   `json:"requires_dms,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	Trusted      bool     `json:"trusted,omitempty"`
+	// Incompatible installed plugins can't run with the current DMS
+	// version, compositor or distribution; Reasons says why.
+	Incompatible bool     `json:"incompatible,omitempty"`
+	Reasons      []string `json:"reasons,omitempty"`
+	// Permissions are the server permissions the plugin requests and
+	// Granted the ones the user granted it.
+	Permissions []string `json:"permissions,omitempty"`
+	Granted     []string `json:"granted,omitempty"`
 }
 
 type SuccessResult struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 }
+
+// InstallResult lists what an install did. When the plugin needs plugins
+// that aren't installed and the request didn't allow installing them,
+// nothing is installed and Requires lists them. Likewise Incompatible
+// lists why a plugin can't run here unless the request forces it, and
+// Unverified why it failed verification unless the request allows that.
+// Installed plugins are granted the permissions they request that the
+// request approved; Pending lists the ones left to grant per plugin.
+type InstallResult struct {
+	Success      bool                `json:"success"`
+	Message      string              `json:"message"`
+	Installed    []string            `json:"installed,omitempty"`
+	Requires     []string            `json:"requires,omitempty"`
+	Incompatible []string            `json:"incompatible,omitempty"`
+	Unverified   string              `json:"unverified,omitempty"`
+	Pending      map[string][]string `json:"pending,omitempty"`
+}
+
+// UpdateResult is the result of an update; Unverified is set when the new
+// version failed verification and the plugin was left as it was. Retrust
+// is set when retrying with trustChange accepts the new version.
+type UpdateResult struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	Unverified string `json:"unverified,omitempty"`
+	Retrust    bool   `json:"retrust,omitempty"`
+}
+
+// UninstallResult lists what an uninstall removed. When installed plugins
+// depend on the target and the request didn't cascade, nothing is removed
+// and Dependents lists them.
+type UninstallResult struct {
+	Success    bool     `json:"success"`
+	Message    string   `json:"message"`
+	Removed    []string `json:"removed,omitempty"`
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+// PermissionsInfo lists the server permissions a plugin requests in its
+// plugin.json and the ones it has been granted.
+type PermissionsInfo struct {
+	Plugin    string   `json:"plugin"`
+	Requested []string `json:"requested"`
+	Granted   []string `json:"granted"`
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 )
 
+// StopBackend stops the backend of a plugin about to be removed, if it
+// runs one. The server sets it once it supervises backends.
+var StopBackend = func(id string) {}
+
 func HandleUninstall(conn net.Conn, req models.Request) {
 	name, ok := models.Get[string](req, "name")
 	if !ok {
@@ -31,36 +35,52 @@ func HandleUninstall(conn net.Conn, req models.Request) {
 	pluginList, _ := registry.List()
 	plugin := plugins.FindByIDOrName(name, pluginList)
 
-	// If found in registry, use that
+	// Find the plugin before anything is removed, so a missing target
+	// doesn't cost the plugins depending on it.
+	id := name
 	if plugin != nil {
-		installed, err := manager.IsInstalled(*plugin)
-		if err != nil {
-			models.RespondError(conn, req.ID, fmt.Sprintf("failed to check if plugin is installed: %v", err))
-			return
-		}
-		if !installed {
-			models.RespondError(conn, req.ID, fmt.Sprintf("plugin not installed: %s", name))
-			return
-		}
-		if err := manager.Uninstall(*plugin); err != nil {
-			models.RespondError(conn, req.ID, fmt.Sprintf("failed to uninstall plugin: %v", err))
-			return
-		}
-		models.Respond(conn, req.ID, SuccessResult{
-			Success: true,
-			Message: fmt.Sprintf("plugin uninstalled: %s", plugin.Name),
+		id = plugin.ID
+	}
+	id, err = manager.InstalledID(id)
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("failed to check if plugin is installed: %v", err))
+		return
+	}
+	if id == "" {
+		models.RespondError(conn, req.ID, fmt.Sprintf("plugin not installed: %s", name))
+		return
+	}
+
+	deps, err := manager.InstalledDependencies(pluginList)
+	if err != nil {
+		models.RespondError(conn, req.ID, fmt.Sprintf("failed to read plugin dependencies: %v", err))
+		return
+	}
+	dependents := plugins.PlanUninstall(id, deps)
+	if len(dependents) > 0 && !models.GetOr(req, "cascade", false) {
+		models.Respond(conn, req.ID, UninstallResult{
+			Success:    false,
+			Message:    fmt.Sprintf("%s is required by other installed plugins", name),
+			Dependents: dependents,
 		})
 		return
 	}
 
-	// Not in registry - try to find and uninstall from installed plugins directly
-	if err := manager.UninstallByIDOrName(name); err != nil {
-		models.RespondError(conn, req.ID, fmt.Sprintf("plugin not found: %s", name))
+	removed := append(dependents, id)
+	for _, id := range removed {
+		StopBackend(id)
+	}
+	if err := manager.UninstallAll(removed, pluginList); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
 		return
 	}
 
-	models.Respond(conn, req.ID, SuccessResult{
+	if plugin != nil {
+		name = plugin.Name
+	}
+	models.Respond(conn, req.ID, UninstallResult{
 		Success: true,
 		Message: fmt.Sprintf("plugin uninstalled: %s", name),
+		Removed: removed,
 	})
 }
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"errors"
 	"fmt"
 	"net"
 
@@ -40,11 +41,24 @@ func HandleUpdate(conn net.Conn, req models.Request) {
 			models.RespondError(conn, req.ID, fmt.Sprintf("plugin not installed: %s", name))
 			return
 		}
+		manager.AllowUnverified(models.GetOr(req, "allowUnverified", false))
+		trustChange := models.GetOr(req, "trustChange", false)
+		manager.ConfirmTrustChange(func(plugins.Plugin, string) bool { return trustChange })
 		if err := manager.Update(*plugin); err != nil {
+			var verr *plugins.VerificationError
+			if errors.As(err, &verr) {
+				models.Respond(conn, req.ID, UpdateResult{
+					Success:    false,
+					Message:    err.Error(),
+					Unverified: verr.Reason,
+					Retrust:    verr.Confirmable,
+				})
+				return
+			}
 			models.RespondError(conn, req.ID, fmt.Sprintf("failed to update plugin: %v", err))
 			return
 		}
-		models.Respond(conn, req.ID, SuccessResult{
+		models.Respond(conn, req.ID, UpdateResult{
 			Success: true,
 			Message: fmt.Sprintf("plugin updated: %s", plugin.Name),
 		})
@@ -57,7 +71,7 @@ func HandleUpdate(conn net.Conn, req models.Request) {
 		return
 	}
 
-	models.Respond(conn, req.ID, SuccessResult{
+	models.Respond(conn, req.ID, UpdateResult{
 		Success: true,
 		Message: fmt.Sprintf("plugin updated: %s", name),
 	})
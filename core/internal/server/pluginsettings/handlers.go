@@ -0,0 +1,110 @@
+package pluginsettings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
+)
+
+func HandleRequest(conn net.Conn, req models.Request, manager *Manager) {
+	switch req.Method {
+	case "plugins.settings.get":
+		handleGet(conn, req, manager)
+	case "plugins.settings.set":
+		handleSet(conn, req, manager)
+	case "plugins.settings.subscribe":
+		handleSubscribe(conn, req, manager)
+	default:
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+	}
+}
+
+func handleGet(conn net.Conn, req models.Request, manager *Manager) {
+	id, err := params.StringNonEmpty(req.Params, "plugin")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	settings, err := manager.Get(id)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	if key, ok := models.Get[string](req, "key"); ok {
+		models.Respond(conn, req.ID, settings[key])
+		return
+	}
+	models.Respond(conn, req.ID, settings)
+}
+
+// handleSet changes one key, given as key and value, or several, given as
+// values. A null value resets a key to its default.
+func handleSet(conn net.Conn, req models.Request, manager *Manager) {
+	id, err := params.StringNonEmpty(req.Params, "plugin")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	values, ok := models.Get[map[string]any](req, "values")
+	if !ok {
+		key, err := params.StringNonEmpty(req.Params, "key")
+		if err != nil {
+			models.RespondError(conn, req.ID, "missing 'values' or 'key' parameter")
+			return
+		}
+		value, ok := req.Params["value"]
+		if !ok {
+			models.RespondError(conn, req.ID, "missing 'value' parameter")
+			return
+		}
+		values = map[string]any{key: value}
+	}
+
+	settings, err := manager.Update(id, values)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, settings)
+}
+
+// handleSubscribe streams the changes to a plugin's settings, starting with
+// the current ones, or the changes to every plugin's settings.
+func handleSubscribe(conn net.Conn, req models.Request, manager *Manager) {
+	clientID := fmt.Sprintf("plugins-settings-%p-%d", conn, req.ID)
+	id := params.StringOpt(req.Params, "plugin", "")
+
+	ch := manager.Subscribe(clientID)
+	defer manager.Unsubscribe(clientID)
+
+	if id != "" {
+		settings, err := manager.Get(id)
+		if err != nil {
+			models.RespondError(conn, req.ID, err.Error())
+			return
+		}
+		if err := json.NewEncoder(conn).Encode(models.Response[Change]{
+			ID:     req.ID,
+			Result: &Change{Plugin: id, Settings: settings},
+		}); err != nil {
+			return
+		}
+	}
+
+	for change := range ch {
+		if id != "" && change.Plugin != id {
+			continue
+		}
+		if err := json.NewEncoder(conn).Encode(models.Response[Change]{
+			ID:     req.ID,
+			Result: &change,
+		}); err != nil {
+			return
+		}
+	}
+}
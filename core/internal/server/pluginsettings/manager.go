@@ -0,0 +1,132 @@
+package pluginsettings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Change is the settings of a plugin after they changed.
+type Change struct {
+	Plugin   string         `json:"plugin"`
+	Settings map[string]any `json:"settings"`
+}
+
+// Manager serves plugin settings. They are also changed by `dms plugins
+// config` and by hand, so the settings directory is watched to tell
+// subscribers when something changed.
+type Manager struct {
+	plugins     *plugins.Manager
+	subscribers syncmap.Map[string, chan Change]
+	stopChan    chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewManager() (*Manager, error) {
+	pluginManager, err := plugins.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{plugins: pluginManager, stopChan: make(chan struct{})}
+	go m.watch()
+	return m, nil
+}
+
+func (m *Manager) Subscribe(id string) chan Change {
+	ch := make(chan Change, 16)
+	m.subscribers.Store(id, ch)
+	return ch
+}
+
+func (m *Manager) Unsubscribe(id string) {
+	if val, ok := m.subscribers.LoadAndDelete(id); ok {
+		close(val)
+	}
+}
+
+func (m *Manager) Get(id string) (map[string]any, error) {
+	return m.plugins.Settings(id)
+}
+
+func (m *Manager) Update(id string, values map[string]any) (map[string]any, error) {
+	return m.plugins.UpdateSettings(id, values)
+}
+
+func (m *Manager) reload(id string) {
+	settings, err := m.plugins.Settings(id)
+	if err != nil {
+		log.Debugf("Failed to read settings of %s: %v", id, err)
+		return
+	}
+
+	change := Change{Plugin: id, Settings: settings}
+	m.subscribers.Range(func(key string, ch chan Change) bool {
+		select {
+		case ch <- change:
+		default:
+		}
+		return true
+	})
+}
+
+func (m *Manager) watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warnf("Failed to create plugin settings watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	dir := m.plugins.SettingsDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Warnf("Failed to create %s: %v", dir, err)
+		return
+	}
+	if err := watcher.Add(dir); err != nil {
+		log.Warnf("Failed to watch %s: %v", dir, err)
+		return
+	}
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// Settings are replaced by rename, so the temporary file is
+			// skipped and the rename shows up as a create.
+			id, ok := strings.CutSuffix(filepath.Base(event.Name), ".json")
+			if !ok || filepath.Dir(event.Name) != dir {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove) == 0 {
+				continue
+			}
+			m.reload(id)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Plugin settings watcher error: %v", err)
+		}
+	}
+}
+
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.stopChan)
+		m.subscribers.Range(func(key string, ch chan Change) bool {
+			close(ch)
+			m.subscribers.Delete(key)
+			return true
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package pluginsettings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerNotifiesChanges(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	pluginDir := filepath.Join(config, "DankMaterialShell", "plugins", "weather")
+	require.NoError(t, os.MkdirAll(pluginDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.json"), []byte(`{
+		"id": "weather",
+		"settings_schema": {"type": "object", "properties": {"interval": {"type": "integer", "default": 60}}}
+	}`), 0o644))
+
+	manager, err := NewManager()
+	require.NoError(t, err)
+	defer manager.Close()
+	changes := manager.Subscribe("test")
+
+	settings, err := manager.Get("weather")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"interval": float64(60)}, settings)
+
+	_, err = manager.Update("weather", map[string]any{"interval": "often"})
+	assert.ErrorContains(t, err, "interval must be integer")
+
+	// The watcher may start after the first write, so keep changing the
+	// settings until a change comes through.
+	timeout := time.After(5 * time.Second)
+	for {
+		_, err := manager.Update("weather", map[string]any{"interval": 30})
+		require.NoError(t, err)
+		select {
+		case change := <-changes:
+			assert.Equal(t, "weather", change.Plugin)
+			assert.Equal(t, map[string]any{"interval": float64(30)}, change.Settings)
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timed out waiting for a change")
+		}
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"net"
 	"strings"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/apppicker"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/bluez"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/brightness"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/clipboard"
+	serverColorpicker "github.com/AvengeMedia/DankMaterialShell/core/internal/server/colorpicker"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/cups"
 	serverDbus "github.com/AvengeMedia/DankMaterialShell/core/internal/server/dbus"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/dwl"
@@ -18,14 +20,85 @@ import (
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/loginctl"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/network"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/pluginbackend"
 	serverPlugins "github.com/AvengeMedia/DankMaterialShell/core/internal/server/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/pluginsettings"
+	serverScreenshot "github.com/AvengeMedia/DankMaterialShell/core/internal/server/screenshot"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/thememode"
 	serverThemes "github.com/AvengeMedia/DankMaterialShell/core/internal/server/themes"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/wayland"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/wlroutput"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
 )
 
+// pluginConnections maps connections that authenticated as a plugin to
+// its ID. Requests on them are limited to what the plugin was granted.
+var pluginConnections syncmap.Map[net.Conn, string]
+
+// tagBackendConnection tags conn as the plugin whose backend is one of
+// peers, the process on the other end and its ancestors. Backends are
+// held to their plugin's permissions whether they authenticate or not.
+func tagBackendConnection(conn net.Conn, peers []int) {
+	if len(peers) == 0 || pluginBackendManager == nil {
+		return
+	}
+	if _, ok := pluginConnections.Load(conn); ok {
+		return
+	}
+	if id, ok := pluginBackendManager.PluginForPID(peers...); ok {
+		pluginConnections.Store(conn, id)
+	}
+}
+
+// authenticatePlugin tags conn as a plugin. Backends authenticate with the
+// token the supervisor started them with. The shell names the plugin
+// instead: an untagged connection may do anything, so tagging one only
+// takes permissions away. It runs before any later request on the
+// connection is routed.
+func authenticatePlugin(conn net.Conn, req models.Request) {
+	var id string
+	if token, ok := models.Get[string](req, "token"); ok {
+		if id, ok = serverPlugins.PluginForToken(token); !ok {
+			models.RespondError(conn, req.ID, "invalid plugin token")
+			return
+		}
+	} else {
+		plugin, ok := models.Get[string](req, "plugin")
+		if !ok {
+			models.RespondError(conn, req.ID, "missing 'token' or 'plugin' parameter")
+			return
+		}
+		manager, err := plugins.NewManager()
+		if err == nil {
+			ok, err = manager.IsInstalled(plugins.Plugin{ID: plugin})
+		}
+		if err != nil || !ok {
+			models.RespondError(conn, req.ID, fmt.Sprintf("plugin not installed: %s", plugin))
+			return
+		}
+		id = plugin
+	}
+
+	// A backend's connection is already tagged as its plugin.
+	if tagged, ok := pluginConnections.LoadOrStore(conn, id); ok && tagged != id {
+		models.RespondError(conn, req.ID, fmt.Sprintf("already authenticated as plugin %s", tagged))
+		return
+	}
+	models.Respond(conn, req.ID, map[string]string{"plugin": id})
+}
+
 func RouteRequest(conn net.Conn, req models.Request) {
+	if id, ok := pluginConnections.Load(conn); ok {
+		manager, err := plugins.NewManager()
+		if err == nil {
+			err = serverPlugins.Authorize(manager, id, req)
+		}
+		if err != nil {
+			models.RespondError(conn, req.ID, err.Error())
+			return
+		}
+	}
+
 	if strings.HasPrefix(req.Method, "network.") {
 		if networkManager == nil {
 			models.RespondError(conn, req.ID, "network manager not initialized")
@@ -35,6 +108,28 @@ func RouteRequest(conn net.Conn, req models.Request) {
 		return
 	}
 
+	if strings.HasPrefix(req.Method, "plugins.settings.") {
+		if pluginSettingsManager == nil {
+			models.RespondError(conn, req.ID, "plugin settings manager not initialized")
+			return
+		}
+		pluginsettings.HandleRequest(conn, req, pluginSettingsManager)
+		return
+	}
+
+	if strings.HasPrefix(req.Method, "plugins.backend.") || strings.HasPrefix(req.Method, "plugin.") {
+		if pluginBackendManager == nil {
+			models.RespondError(conn, req.ID, "plugin backend manager not initialized")
+			return
+		}
+		if strings.HasPrefix(req.Method, "plugin.") {
+			pluginbackend.HandleCall(conn, req, pluginBackendManager)
+		} else {
+			pluginbackend.HandleRequest(conn, req, pluginBackendManager)
+		}
+		return
+	}
+
 	if strings.HasPrefix(req.Method, "plugins.") {
 		serverPlugins.HandleRequest(conn, req)
 		return
@@ -45,6 +140,24 @@ func RouteRequest(conn net.Conn, req models.Request) {
 		return
 	}
 
+	if strings.HasPrefix(req.Method, "screenshot.") {
+		if screenshotManager == nil {
+			models.RespondError(conn, req.ID, "screenshot manager not initialized")
+			return
+		}
+		serverScreenshot.HandleRequest(conn, req, screenshotManager)
+		return
+	}
+
+	if strings.HasPrefix(req.Method, "colorpicker.") {
+		if colorPickerManager == nil {
+			models.RespondError(conn, req.ID, "color picker manager not initialized")
+			return
+		}
+		serverColorpicker.HandleRequest(conn, req, colorPickerManager)
+		return
+	}
+
 	if strings.HasPrefix(req.Method, "theme.auto.") {
 		if themeModeManager == nil {
 			models.RespondError(conn, req.ID, "theme mode manager not initialized")
@@ -204,6 +317,8 @@ func RouteRequest(conn net.Conn, req models.Request) {
 		handleMatugenQueue(conn, req)
 	case "matugen.status":
 		handleMatugenStatus(conn, req)
+	case "matugen.palettes":
+		handleMatugenPalettes(conn, req)
 	default:
 		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
 	}
@@ -0,0 +1,245 @@
+package screenshot
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/screenshot"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
+)
+
+// maxDelay bounds the delay before a capture, which holds the capture lock.
+const maxDelay = 60
+
+func HandleRequest(conn net.Conn, req models.Request, manager *Manager) {
+	switch req.Method {
+	case "screenshot.capture":
+		handleCapture(conn, req, manager)
+	case "screenshot.stop":
+		if err := manager.StopScroll(); err != nil {
+			models.RespondError(conn, req.ID, err.Error())
+			return
+		}
+		models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "stopping"})
+	case "screenshot.getState":
+		models.Respond(conn, req.ID, manager.GetState())
+	case "screenshot.list":
+		handleList(conn, req)
+	case "screenshot.get":
+		handleGet(conn, req)
+	case "screenshot.copy":
+		handleEntryAction(conn, req, screenshot.CopyHistoryEntry, "copied to clipboard")
+	case "screenshot.open":
+		handleEntryAction(conn, req, screenshot.OpenHistoryEntry, "opened")
+	case "screenshot.reveal":
+		handleEntryAction(conn, req, screenshot.RevealHistoryEntry, "revealed")
+	case "screenshot.delete":
+		handleDelete(conn, req)
+	case "screenshot.clear":
+		handleClear(conn, req)
+	case "screenshot.getConfig":
+		models.Respond(conn, req.ID, screenshot.LoadSettings())
+	case "screenshot.setConfig":
+		handleSetConfig(conn, req)
+	default:
+		models.RespondError(conn, req.ID, fmt.Sprintf("unknown method: %s", req.Method))
+	}
+}
+
+func handleList(conn net.Conn, req models.Request) {
+	entries, err := screenshot.ListHistory(params.IntOpt(req.Params, "limit", 0))
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, entries)
+}
+
+func handleGet(conn net.Conn, req models.Request) {
+	id, err := params.StringNonEmpty(req.Params, "id")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	entry, err := screenshot.GetHistoryEntry(id)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, entry)
+}
+
+func handleEntryAction(conn net.Conn, req models.Request, action func(string) error, message string) {
+	id, err := params.StringNonEmpty(req.Params, "id")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	if err := action(id); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: message})
+}
+
+func handleDelete(conn net.Conn, req models.Request) {
+	id, err := params.StringNonEmpty(req.Params, "id")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	deleteFile := params.BoolOpt(req.Params, "deleteFile", true)
+	if err := screenshot.DeleteHistoryEntry(id, deleteFile); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "deleted"})
+}
+
+func handleClear(conn net.Conn, req models.Request) {
+	if err := screenshot.ClearHistory(); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "history cleared"})
+}
+
+func handleSetConfig(conn net.Conn, req models.Request) {
+	settings := screenshot.LoadSettings()
+
+	if v, ok := models.Get[string](req, "filenameTemplate"); ok {
+		settings.FilenameTemplate = v
+	}
+	if v, ok := models.Get[string](req, "outputDir"); ok {
+		settings.OutputDir = v
+	}
+	if v, ok := models.Get[string](req, "format"); ok {
+		if _, valid := screenshot.ParseFormat(v); !valid {
+			models.RespondError(conn, req.ID, fmt.Sprintf("invalid format: %s", v))
+			return
+		}
+		settings.Format = v
+	}
+	if v, ok := models.Get[float64](req, "quality"); ok {
+		if v < 1 || v > 100 {
+			models.RespondError(conn, req.ID, "quality must be between 1 and 100")
+			return
+		}
+		settings.Quality = int(v)
+	}
+	if v, ok := models.Get[string](req, "pngCompression"); ok {
+		if _, valid := screenshot.ParsePNGCompression(v); !valid {
+			models.RespondError(conn, req.ID, fmt.Sprintf("invalid png compression: %s", v))
+			return
+		}
+		settings.PNGCompression = v
+	}
+
+	if err := screenshot.SaveSettings(settings); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "config updated"})
+}
+
+func handleCapture(conn net.Conn, req models.Request, manager *Manager) {
+	config, err := captureConfig(req)
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
+	saved, err := manager.Capture(config)
+	switch {
+	case err != nil:
+		models.RespondError(conn, req.ID, err.Error())
+	case saved == nil:
+		models.Respond(conn, req.ID, models.SuccessResult{Success: false, Message: "cancelled"})
+	default:
+		models.Respond(conn, req.ID, saved)
+	}
+}
+
+// captureConfig builds a capture config from the saved defaults, overridden
+// by request params named after the CLI flags.
+func captureConfig(req models.Request) (screenshot.Config, error) {
+	config := screenshot.DefaultConfig()
+	screenshot.LoadSettings().Apply(&config)
+
+	modeName := params.StringOpt(req.Params, "mode", "region")
+	mode, ok := screenshot.ParseMode(modeName)
+	if !ok {
+		return config, fmt.Errorf("invalid mode: %s", modeName)
+	}
+	config.Mode = mode
+
+	// Scroll captures end on their own after idle frames without new rows,
+	// or with screenshot.stop.
+	if mode == screenshot.ModeScroll {
+		config.Scroll.Auto = params.BoolOpt(req.Params, "auto", false)
+		config.Scroll.IdleFrames = params.IntOpt(req.Params, "idle", 0)
+		if config.Scroll.IdleFrames < 0 {
+			return config, fmt.Errorf("idle must not be negative")
+		}
+	}
+
+	config.OutputName = params.StringOpt(req.Params, "output", "")
+	if mode == screenshot.ModeOutput && config.OutputName == "" {
+		return config, fmt.Errorf("output mode requires an output name")
+	}
+
+	if v, ok := models.Get[string](req, "format"); ok {
+		format, valid := screenshot.ParseFormat(v)
+		if !valid {
+			return config, fmt.Errorf("invalid format: %s", v)
+		}
+		config.Format = format
+	}
+	if v, ok := models.Get[float64](req, "quality"); ok {
+		if v < 1 || v > 100 {
+			return config, fmt.Errorf("quality must be between 1 and 100")
+		}
+		config.Quality = int(v)
+	}
+	if v, ok := models.Get[string](req, "pngCompression"); ok {
+		compression, valid := screenshot.ParsePNGCompression(v)
+		if !valid {
+			return config, fmt.Errorf("invalid png compression: %s", v)
+		}
+		config.Compression = compression
+	}
+	if v, ok := models.Get[string](req, "dir"); ok && v != "" {
+		config.OutputDir = v
+	}
+	if v, ok := models.Get[string](req, "filename"); ok {
+		config.Filename = v
+	}
+	if v, ok := models.Get[string](req, "filenameTemplate"); ok && v != "" {
+		config.Template = v
+	}
+
+	delay := params.FloatOpt(req.Params, "delay", 0)
+	if delay < 0 || delay > maxDelay {
+		return config, fmt.Errorf("delay must be between 0 and %d seconds", maxDelay)
+	}
+	config.Delay = time.Duration(delay * float64(time.Second))
+
+	config.IncludeCursor = params.BoolOpt(req.Params, "cursor", config.IncludeCursor)
+	config.Clipboard = params.BoolOpt(req.Params, "clipboard", config.Clipboard)
+	config.SaveFile = params.BoolOpt(req.Params, "saveFile", config.SaveFile)
+	config.Notify = params.BoolOpt(req.Params, "notify", config.Notify)
+	config.History = params.BoolOpt(req.Params, "history", config.History)
+	config.Countdown = params.BoolOpt(req.Params, "countdown", config.Countdown)
+	config.IncludeData = params.BoolOpt(req.Params, "includeData", false)
+
+	if !config.SaveFile && !config.Clipboard && !config.IncludeData {
+		return config, fmt.Errorf("nothing to do: saveFile, clipboard and includeData are all false")
+	}
+
+	return config, nil
+}
@@ -0,0 +1,98 @@
+package screenshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/screenshot"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	config, err := captureConfig(models.Request{Params: map[string]any{
+		"mode":        "full",
+		"format":      "webp",
+		"delay":       2.5,
+		"clipboard":   false,
+		"includeData": true,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, screenshot.ModeFullScreen, config.Mode)
+	assert.Equal(t, screenshot.FormatWebP, config.Format)
+	assert.Equal(t, 2500*time.Millisecond, config.Delay)
+	assert.False(t, config.Clipboard)
+	assert.True(t, config.SaveFile)
+	assert.True(t, config.IncludeData)
+	assert.True(t, config.Countdown)
+}
+
+func TestCaptureConfigScroll(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	config, err := captureConfig(models.Request{Params: map[string]any{
+		"mode": "scroll",
+		"auto": true,
+		"idle": float64(3),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, screenshot.ModeScroll, config.Mode)
+	assert.True(t, config.Scroll.Auto)
+	assert.Equal(t, 3, config.Scroll.IdleFrames)
+}
+
+func TestManagerStopScroll(t *testing.T) {
+	m := NewManager()
+	assert.ErrorIs(t, m.StopScroll(), ErrNoScrollCapture)
+
+	stop := make(chan struct{})
+	m.stopScroll = stop
+	require.NoError(t, m.StopScroll())
+	_, open := <-stop
+	assert.False(t, open)
+	assert.ErrorIs(t, m.StopScroll(), ErrNoScrollCapture)
+}
+
+func TestCaptureConfigErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []map[string]any{
+		{"mode": "bogus"},
+		{"mode": "output"},
+		{"format": "gif"},
+		{"quality": float64(0)},
+		{"delay": float64(-1)},
+		{"delay": float64(3600)},
+		{"mode": "scroll", "idle": float64(-1)},
+		{"saveFile": false, "clipboard": false},
+	}
+	for _, p := range tests {
+		_, err := captureConfig(models.Request{Params: p})
+		assert.Error(t, err, "%v", p)
+	}
+}
+
+func TestManagerRejectsConcurrentCapture(t *testing.T) {
+	m := NewManager()
+	m.captureMu.Lock()
+	defer m.captureMu.Unlock()
+
+	_, err := m.Capture(screenshot.DefaultConfig())
+	assert.ErrorIs(t, err, ErrCaptureInProgress)
+}
+
+func TestManagerBroadcastsState(t *testing.T) {
+	m := NewManager()
+	ch := m.Subscribe("test")
+	defer m.Unsubscribe("test")
+
+	m.setState(State{Capturing: true, Event: EventProgress, Phase: screenshot.PhaseCountdown, Remaining: 3})
+
+	state := <-ch
+	assert.Equal(t, screenshot.PhaseCountdown, state.Phase)
+	assert.Equal(t, 3, state.Remaining)
+	assert.Equal(t, state, m.GetState())
+}
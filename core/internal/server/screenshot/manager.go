@@ -0,0 +1,152 @@
+package screenshot
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/screenshot"
+	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
+)
+
+var (
+	ErrCaptureInProgress = errors.New("a capture is already in progress")
+	ErrNoScrollCapture   = errors.New("no scroll capture running")
+)
+
+const (
+	EventProgress  = "progress"
+	EventCompleted = "completed"
+	EventCancelled = "cancelled"
+	EventFailed    = "failed"
+)
+
+type State struct {
+	Capturing bool                     `json:"capturing"`
+	Event     string                   `json:"event,omitempty"`
+	Phase     screenshot.ProgressPhase `json:"phase,omitempty"`
+	Remaining int                      `json:"remaining,omitempty"`
+	Result    *screenshot.SaveResult   `json:"result,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// Manager runs captures requested over IPC one at a time and streams their
+// progress to subscribers of the screenshot topic.
+type Manager struct {
+	captureMu   sync.Mutex
+	stopMu      sync.Mutex
+	stopScroll  chan struct{}
+	stateMu     sync.RWMutex
+	state       State
+	subscribers syncmap.Map[string, chan State]
+	closeOnce   sync.Once
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+func (m *Manager) Subscribe(id string) chan State {
+	ch := make(chan State, 16)
+	m.subscribers.Store(id, ch)
+	return ch
+}
+
+func (m *Manager) Unsubscribe(id string) {
+	if val, ok := m.subscribers.LoadAndDelete(id); ok {
+		close(val)
+	}
+}
+
+func (m *Manager) GetState() State {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+	return m.state
+}
+
+// Capture runs a capture with config and saves it. A nil result with a nil
+// error means the user cancelled the selection.
+func (m *Manager) Capture(config screenshot.Config) (*screenshot.SaveResult, error) {
+	if !m.captureMu.TryLock() {
+		return nil, ErrCaptureInProgress
+	}
+	defer m.captureMu.Unlock()
+
+	config.Stdout = false
+	if config.Mode == screenshot.ModeScroll {
+		stop := make(chan struct{})
+		config.Scroll.Stop = stop
+		m.stopMu.Lock()
+		m.stopScroll = stop
+		m.stopMu.Unlock()
+		defer func() {
+			m.stopMu.Lock()
+			m.stopScroll = nil
+			m.stopMu.Unlock()
+		}()
+	}
+	config.OnProgress = func(p screenshot.Progress) {
+		m.setState(State{Capturing: true, Event: EventProgress, Phase: p.Phase, Remaining: p.Remaining})
+	}
+	m.setState(State{Capturing: true, Event: EventProgress})
+
+	result, err := screenshot.New(config).Run()
+	if err != nil {
+		m.setState(State{Event: EventFailed, Error: err.Error()})
+		return nil, err
+	}
+	if result == nil {
+		m.setState(State{Event: EventCancelled})
+		return nil, nil
+	}
+	defer result.Buffer.Close()
+
+	saved, err := screenshot.Save(config, result)
+	if err != nil {
+		m.setState(State{Event: EventFailed, Error: err.Error()})
+		return nil, err
+	}
+
+	// Subscribers get the metadata only; the image data is returned to the
+	// caller that asked for it.
+	event := *saved
+	event.Data = ""
+	m.setState(State{Event: EventCompleted, Result: &event})
+	return saved, nil
+}
+
+// StopScroll finishes the running scroll capture, keeping what was stitched
+// so far.
+func (m *Manager) StopScroll() error {
+	m.stopMu.Lock()
+	defer m.stopMu.Unlock()
+	if m.stopScroll == nil {
+		return ErrNoScrollCapture
+	}
+	close(m.stopScroll)
+	m.stopScroll = nil
+	return nil
+}
+
+func (m *Manager) setState(state State) {
+	m.stateMu.Lock()
+	m.state = state
+	m.stateMu.Unlock()
+
+	m.subscribers.Range(func(key string, ch chan State) bool {
+		select {
+		case ch <- state:
+		default:
+		}
+		return true
+	})
+}
+
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		m.subscribers.Range(func(key string, ch chan State) bool {
+			close(ch)
+			m.subscribers.Delete(key)
+			return true
+		})
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,10 +16,12 @@ import (
 	"time"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/plugins"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/apppicker"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/bluez"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/brightness"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/clipboard"
+	serverColorpicker "github.com/AvengeMedia/DankMaterialShell/core/internal/server/colorpicker"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/cups"
 	serverDbus "github.com/AvengeMedia/DankMaterialShell/core/internal/server/dbus"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/dwl"
@@ -28,6 +31,10 @@ import (
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/loginctl"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/network"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/pluginbackend"
+	serverPlugins "github.com/AvengeMedia/DankMaterialShell/core/internal/server/plugins"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/pluginsettings"
+	serverScreenshot "github.com/AvengeMedia/DankMaterialShell/core/internal/server/screenshot"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/thememode"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/wayland"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/wlcontext"
@@ -35,7 +42,7 @@ import (
 	"github.com/AvengeMedia/DankMaterialShell/core/pkg/syncmap"
 )
 
-const APIVersion = 24
+const APIVersion = 27
 
 var CLIVersion = "dev"
 
@@ -60,6 +67,8 @@ var freedesktopManager *freedesktop.Manager
 var waylandManager *wayland.Manager
 var bluezManager *bluez.Manager
 var appPickerManager *apppicker.Manager
+var screenshotManager *serverScreenshot.Manager
+var colorPickerManager *serverColorpicker.Manager
 var cupsManager *cups.Manager
 var dwlManager *dwl.Manager
 var extWorkspaceManager *extworkspace.Manager
@@ -70,6 +79,8 @@ var clipboardManager *clipboard.Manager
 var dbusManager *serverDbus.Manager
 var wlContext *wlcontext.SharedContext
 var themeModeManager *thememode.Manager
+var pluginBackendManager *pluginbackend.Manager
+var pluginSettingsManager *pluginsettings.Manager
 
 const dbusClientID = "dms-dbus-client"
 
@@ -233,6 +244,72 @@ func InitializeAppPickerManager() error {
 	return nil
 }
 
+func InitializeScreenshotManager() error {
+	screenshotManager = serverScreenshot.NewManager()
+	log.Info("Screenshot manager initialized")
+	return nil
+}
+
+func InitializeColorPickerManager() error {
+	colorPickerManager = serverColorpicker.NewManager()
+	log.Info("Color picker manager initialized")
+	return nil
+}
+
+func InitializePluginSettingsManager() error {
+	manager, err := pluginsettings.NewManager()
+	if err != nil {
+		log.Errorf("Failed to initialize plugin settings manager: %v", err)
+		return err
+	}
+	pluginSettingsManager = manager
+	log.Info("Plugin settings manager initialized")
+	return nil
+}
+
+func InitializePluginBackendManager() error {
+	pluginBackendManager = pluginbackend.NewManager(resolvePluginBackend)
+	serverPlugins.StopBackend = func(id string) {
+		pluginBackendManager.Stop(id) //nolint:errcheck
+	}
+	log.Info("Plugin backend manager initialized")
+	return nil
+}
+
+// resolvePluginBackend runs a plugin's backend with a token to connect to
+// the server as the plugin, so it is held to the plugin's permissions.
+func resolvePluginBackend(id string) (pluginbackend.Spec, error) {
+	manager, err := plugins.NewManager()
+	if err != nil {
+		return pluginbackend.Spec{}, err
+	}
+	backend, dir, err := manager.Backend(id)
+	if err != nil {
+		return pluginbackend.Spec{}, err
+	}
+	if backend == nil {
+		return pluginbackend.Spec{}, fmt.Errorf("plugin %s has no backend", id)
+	}
+	command, err := backend.Command(dir)
+	if err != nil {
+		return pluginbackend.Spec{}, err
+	}
+	token, err := serverPlugins.IssueToken(id)
+	if err != nil {
+		return pluginbackend.Spec{}, err
+	}
+
+	env := []string{
+		"DMS_SOCKET=" + GetSocketPath(),
+		"DMS_PLUGIN_ID=" + id,
+		"DMS_PLUGIN_TOKEN=" + token,
+	}
+	for key, value := range backend.Env {
+		env = append(env, key+"="+value)
+	}
+	return pluginbackend.Spec{Dir: dir, Exec: command, Args: backend.Args, Env: env}, nil
+}
+
 func InitializeCupsManager() error {
 	manager, err := cups.NewManager()
 	if err != nil {
@@ -385,6 +462,10 @@ func InitializeDbusManager() error {
 func InitializeThemeModeManager() error {
 	manager := thememode.NewManager()
 	themeModeManager = manager
+	go watchScheduledPalettes(manager)
+	if waylandManager != nil {
+		manager.FollowGamma(waylandManager)
+	}
 
 	log.Info("Theme mode automation manager initialized")
 	return nil
@@ -392,6 +473,11 @@ func InitializeThemeModeManager() error {
 
 func handleConnection(conn net.Conn) {
 	defer conn.Close()
+	defer pluginConnections.Delete(conn)
+
+	// Backends may be started after the connection, so the peer is
+	// matched against them on every request until it is tagged.
+	peers := peerProcesses(conn)
 
 	caps := getCapabilities()
 	capsData, _ := json.Marshal(caps)
@@ -408,6 +494,13 @@ func handleConnection(conn net.Conn) {
 			continue
 		}
 
+		tagBackendConnection(conn, peers)
+
+		if req.Method == "plugins.authenticate" {
+			authenticatePlugin(conn, req)
+			continue
+		}
+
 		go RouteRequest(conn, req)
 	}
 }
@@ -467,6 +560,14 @@ func getCapabilities() Capabilities {
 		caps = append(caps, "clipboard")
 	}
 
+	if screenshotManager != nil {
+		caps = append(caps, "screenshot")
+	}
+
+	if colorPickerManager != nil {
+		caps = append(caps, "colorpicker")
+	}
+
 	if themeModeManager != nil {
 		caps = append(caps, "theme.auto")
 	}
@@ -475,6 +576,14 @@ func getCapabilities() Capabilities {
 		caps = append(caps, "dbus")
 	}
 
+	if pluginBackendManager != nil {
+		caps = append(caps, "plugin.backend")
+	}
+
+	if pluginSettingsManager != nil {
+		caps = append(caps, "plugins.settings")
+	}
+
 	return Capabilities{Capabilities: caps}
 }
 
@@ -533,6 +642,14 @@ func getServerInfo() ServerInfo {
 		caps = append(caps, "clipboard")
 	}
 
+	if screenshotManager != nil {
+		caps = append(caps, "screenshot")
+	}
+
+	if colorPickerManager != nil {
+		caps = append(caps, "colorpicker")
+	}
+
 	if themeModeManager != nil {
 		caps = append(caps, "theme.auto")
 	}
@@ -541,6 +658,14 @@ func getServerInfo() ServerInfo {
 		caps = append(caps, "dbus")
 	}
 
+	if pluginBackendManager != nil {
+		caps = append(caps, "plugin.backend")
+	}
+
+	if pluginSettingsManager != nil {
+		caps = append(caps, "plugins.settings")
+	}
+
 	return ServerInfo{
 		APIVersion:   APIVersion,
 		CLIVersion:   CLIVersion,
@@ -1208,6 +1333,95 @@ func handleSubscribe(conn net.Conn, req models.Request) {
 		}()
 	}
 
+	if shouldSubscribe("screenshot") && screenshotManager != nil {
+		wg.Add(1)
+		screenshotChan := screenshotManager.Subscribe(clientID + "-screenshot")
+		go func() {
+			defer wg.Done()
+			defer screenshotManager.Unsubscribe(clientID + "-screenshot")
+
+			initialState := screenshotManager.GetState()
+			select {
+			case eventChan <- ServiceEvent{Service: "screenshot", Data: initialState}:
+			case <-stopChan:
+				return
+			}
+
+			for {
+				select {
+				case state, ok := <-screenshotChan:
+					if !ok {
+						return
+					}
+					select {
+					case eventChan <- ServiceEvent{Service: "screenshot", Data: state}:
+					case <-stopChan:
+						return
+					}
+				case <-stopChan:
+					return
+				}
+			}
+		}()
+	}
+
+	if shouldSubscribe("colorpicker") && colorPickerManager != nil {
+		wg.Add(1)
+		colorPickerChan := colorPickerManager.Subscribe(clientID + "-colorpicker")
+		go func() {
+			defer wg.Done()
+			defer colorPickerManager.Unsubscribe(clientID + "-colorpicker")
+
+			initialState := colorPickerManager.GetState()
+			select {
+			case eventChan <- ServiceEvent{Service: "colorpicker", Data: initialState}:
+			case <-stopChan:
+				return
+			}
+
+			for {
+				select {
+				case state, ok := <-colorPickerChan:
+					if !ok {
+						return
+					}
+					select {
+					case eventChan <- ServiceEvent{Service: "colorpicker", Data: state}:
+					case <-stopChan:
+						return
+					}
+				case <-stopChan:
+					return
+				}
+			}
+		}()
+	}
+
+	if shouldSubscribe("plugins.settings") && pluginSettingsManager != nil {
+		wg.Add(1)
+		settingsChan := pluginSettingsManager.Subscribe(clientID + "-plugins-settings")
+		go func() {
+			defer wg.Done()
+			defer pluginSettingsManager.Unsubscribe(clientID + "-plugins-settings")
+
+			for {
+				select {
+				case change, ok := <-settingsChan:
+					if !ok {
+						return
+					}
+					select {
+					case eventChan <- ServiceEvent{Service: "plugins.settings", Data: change}:
+					case <-stopChan:
+						return
+					}
+				case <-stopChan:
+					return
+				}
+			}
+		}()
+	}
+
 	if shouldSubscribe("dbus") && dbusManager != nil {
 		wg.Add(1)
 		dbusChan := dbusManager.SubscribeSignals(dbusClientID)
@@ -1233,6 +1447,43 @@ func handleSubscribe(conn net.Conn, req models.Request) {
 		}()
 	}
 
+	// plugin subscribes to the events of every plugin backend, plugin.<id>
+	// to those of one.
+	var backendPlugins []string
+	subscribeBackends := subscribeAll || shouldSubscribe("plugin")
+	for _, s := range services {
+		if id, ok := strings.CutPrefix(s, "plugin."); ok {
+			backendPlugins = append(backendPlugins, id)
+		}
+	}
+	if (subscribeBackends || len(backendPlugins) > 0) && pluginBackendManager != nil {
+		wg.Add(1)
+		backendChan := pluginBackendManager.Subscribe(clientID + "-plugin-backends")
+		go func() {
+			defer wg.Done()
+			defer pluginBackendManager.Unsubscribe(clientID + "-plugin-backends")
+
+			for {
+				select {
+				case event, ok := <-backendChan:
+					if !ok {
+						return
+					}
+					if !subscribeBackends && !slices.Contains(backendPlugins, event.Plugin) {
+						continue
+					}
+					select {
+					case eventChan <- ServiceEvent{Service: "plugin." + event.Plugin, Data: event}:
+					case <-stopChan:
+						return
+					}
+				case <-stopChan:
+					return
+				}
+			}
+		}()
+	}
+
 	go func() {
 		wg.Wait()
 		close(eventChan)
@@ -1298,12 +1549,24 @@ func cleanupManagers() {
 	if clipboardManager != nil {
 		clipboardManager.Close()
 	}
+	if screenshotManager != nil {
+		screenshotManager.Close()
+	}
+	if colorPickerManager != nil {
+		colorPickerManager.Close()
+	}
 	if dbusManager != nil {
 		dbusManager.Close()
 	}
 	if themeModeManager != nil {
 		themeModeManager.Close()
 	}
+	if pluginBackendManager != nil {
+		pluginBackendManager.Close()
+	}
+	if pluginSettingsManager != nil {
+		pluginSettingsManager.Close()
+	}
 	if wlContext != nil {
 		wlContext.Close()
 	}
@@ -1340,6 +1603,46 @@ func Start(printDocs bool) error {
 		log.Info(" plugins.uninstall           - Uninstall plugin (params: name)")
 		log.Info(" plugins.update              - Update plugin (params: name)")
 		log.Info(" plugins.search              - Search plugins (params: query, category?, compositor?, capability?)")
+		log.Info(" plugins.permissions.available - List the permissions plugins can request")
+		log.Info(" plugins.permissions.list    - List requested and granted permissions (params: plugin?)")
+		log.Info(" plugins.permissions.grant   - Grant permissions, default all requested (params: plugin, permissions?)")
+		log.Info(" plugins.permissions.revoke  - Revoke permissions, default all (params: plugin, permissions?)")
+		log.Info(" plugins.authenticate        - Limit this connection to a plugin's granted permissions (params: plugin, or token for backends)")
+		log.Info(" plugins.settings.get        - Get a plugin's settings with defaults filled in (params: plugin, key?)")
+		log.Info(" plugins.settings.set        - Change a plugin's settings, null resets a key (params: plugin, key + value or values)")
+		log.Info(" plugins.settings.subscribe  - Stream settings changes (params: plugin?)")
+		log.Info(" plugins.backend.status      - List running plugin backends")
+		log.Info(" plugins.backend.start       - Start a plugin's backend (params: plugin)")
+		log.Info(" plugins.backend.stop        - Stop a plugin's backend (params: plugin)")
+		log.Info(" plugins.backend.restart     - Restart a plugin's backend (params: plugin)")
+		log.Info(" plugin.<id>.<method>        - Call a method of a plugin's backend (params: passed through)")
+		log.Info("Screenshot:")
+		log.Info(" screenshot.capture          - Capture and save, returns path, size and optional base64 data (params: mode?, output?, cursor?, format?, quality?, pngCompression?, dir?, filename?, filenameTemplate?, clipboard?, saveFile?, notify?, history?, delay?, countdown?, includeData?, auto?, idle?)")
+		log.Info(" screenshot.stop             - Finish a running scroll capture, keeping what was stitched")
+		log.Info(" screenshot.getState         - Get the state of the running or last capture")
+		log.Info(" screenshot.list             - List capture history, newest first (params: limit?)")
+		log.Info(" screenshot.get              - Get a history entry (params: id)")
+		log.Info(" screenshot.copy             - Copy a capture to the clipboard (params: id)")
+		log.Info(" screenshot.open             - Open a capture with the default viewer (params: id)")
+		log.Info(" screenshot.reveal           - Show a capture in the file manager (params: id)")
+		log.Info(" screenshot.delete           - Delete a capture (params: id, deleteFile? [default: true])")
+		log.Info(" screenshot.clear            - Clear the capture history (files are kept)")
+		log.Info(" screenshot.getConfig        - Get capture defaults (filename template, format, quality, PNG compression)")
+		log.Info(" screenshot.setConfig        - Set capture defaults (params: filenameTemplate?, outputDir?, format?, quality?, pngCompression?)")
+		log.Info("Color Picker:")
+		log.Info(" colorpicker.getState        - Get the color history and palettes")
+		log.Info(" colorpicker.history.list    - List picked colors, newest first (params: limit?)")
+		log.Info(" colorpicker.history.remove  - Remove a color from the history (params: color)")
+		log.Info(" colorpicker.history.clear   - Clear the color history")
+		log.Info(" colorpicker.history.export  - Export the history (params: format? [gpl, css, json], limit?)")
+		log.Info(" colorpicker.palettes.list   - List named palettes")
+		log.Info(" colorpicker.palettes.get    - Get a palette (params: name)")
+		log.Info(" colorpicker.palettes.create - Create an empty palette (params: name)")
+		log.Info(" colorpicker.palettes.delete - Delete a palette (params: name)")
+		log.Info(" colorpicker.palettes.rename - Rename a palette (params: name, newName)")
+		log.Info(" colorpicker.palettes.addColor    - Add a color, creating the palette if needed (params: name, color)")
+		log.Info(" colorpicker.palettes.removeColor - Remove a color (params: name, color)")
+		log.Info(" colorpicker.palettes.export - Export a palette (params: name, format? [gpl, css, json])")
 		log.Info("Network:")
 		log.Info(" network.getState            - Get current network state")
 		log.Info(" network.wifi.scan           - Scan for WiFi networks (params: device?)")
@@ -1549,6 +1852,22 @@ func Start(printDocs bool) error {
 		log.Debugf("AppPicker manager unavailable: %v", err)
 	}
 
+	if err := InitializeScreenshotManager(); err != nil {
+		log.Debugf("Screenshot manager unavailable: %v", err)
+	}
+
+	if err := InitializeColorPickerManager(); err != nil {
+		log.Debugf("Color picker manager unavailable: %v", err)
+	}
+
+	if err := InitializePluginBackendManager(); err != nil {
+		log.Debugf("Plugin backend manager unavailable: %v", err)
+	}
+
+	if err := InitializePluginSettingsManager(); err != nil {
+		log.Debugf("Plugin settings manager unavailable: %v", err)
+	}
+
 	if err := InitializeDwlManager(); err != nil {
 		log.Debugf("DWL manager unavailable: %v", err)
 	}
@@ -7,9 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/network"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/pluginbackend"
+	serverPlugins "github.com/AvengeMedia/DankMaterialShell/core/internal/server/plugins"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -108,6 +111,51 @@ func TestRespond(t *testing.T) {
 	assert.Equal(t, "bar", (*resp.Result)["foo"])
 }
 
+func TestPluginConnection(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	token, err := serverPlugins.IssueToken("clock")
+	require.NoError(t, err)
+
+	conn := &mockConn{}
+	defer pluginConnections.Delete(conn)
+	authenticatePlugin(conn, models.Request{ID: 1, Method: "plugins.authenticate", Params: map[string]any{"token": "guess"}})
+	assert.Contains(t, string(conn.written), "invalid plugin token")
+
+	conn.written = nil
+	authenticatePlugin(conn, models.Request{ID: 2, Method: "plugins.authenticate", Params: map[string]any{"token": token}})
+	assert.Contains(t, string(conn.written), `"plugin":"clock"`)
+
+	// The token is used up by the first connection.
+	other := &mockConn{}
+	defer pluginConnections.Delete(other)
+	authenticatePlugin(other, models.Request{ID: 5, Method: "plugins.authenticate", Params: map[string]any{"token": token}})
+	assert.Contains(t, string(other.written), "invalid plugin token")
+
+	// Plugins can't manage plugins or their own permissions.
+	conn.written = nil
+	RouteRequest(conn, models.Request{ID: 3, Method: "plugins.permissions.grant", Params: map[string]any{"plugin": "clock"}})
+	assert.Contains(t, string(conn.written), "not allowed to call plugins.permissions.grant")
+
+	conn.written = nil
+	RouteRequest(conn, models.Request{ID: 4, Method: "ping"})
+	assert.Contains(t, string(conn.written), "pong")
+
+	// The shell tags its plugin connections by name, for installed plugins.
+	shell := &mockConn{}
+	defer pluginConnections.Delete(shell)
+	authenticatePlugin(shell, models.Request{ID: 6, Method: "plugins.authenticate", Params: map[string]any{"plugin": "clock"}})
+	assert.Contains(t, string(shell.written), "plugin not installed")
+	_, tagged := pluginConnections.Load(shell)
+	assert.False(t, tagged)
+
+	manifest := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "DankMaterialShell", "plugins", "clock", "plugin.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(manifest), 0o755))
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"id": "clock"}`), 0o644))
+	shell.written = nil
+	authenticatePlugin(shell, models.Request{ID: 7, Method: "plugins.authenticate", Params: map[string]any{"plugin": "clock"}})
+	assert.Contains(t, string(shell.written), `"plugin":"clock"`)
+}
+
 func TestRequest_JSON(t *testing.T) {
 	jsonStr := `{"id":123,"method":"test.method","params":{"key":"value"}}`
 	var req models.Request
@@ -178,3 +226,66 @@ func TestCleanupStaleSockets(t *testing.T) {
 	_, err = os.Stat(activeSocket)
 	assert.NoError(t, err)
 }
+
+func TestPeerProcesses(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "test.sock"))
+	require.NoError(t, err)
+	defer listener.Close()
+
+	client, err := net.Dial("unix", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	peers := peerProcesses(conn)
+	require.NotEmpty(t, peers)
+	assert.Equal(t, os.Getpid(), peers[0])
+	if os.Getppid() > 1 {
+		assert.Contains(t, peers, os.Getppid())
+	}
+	assert.Nil(t, peerProcesses(&mockConn{}))
+}
+
+func TestTagBackendConnection(t *testing.T) {
+	previous := pluginBackendManager
+	pluginBackendManager = pluginbackend.NewManager(func(id string) (pluginbackend.Spec, error) {
+		return pluginbackend.Spec{Exec: "sleep", Args: []string{"30"}}, nil
+	})
+	defer func() {
+		pluginBackendManager.Close()
+		pluginBackendManager = previous
+	}()
+	require.NoError(t, pluginBackendManager.Start("clock"))
+	var pid int
+	require.Eventually(t, func() bool {
+		statuses := pluginBackendManager.Status()
+		pid = statuses[0].PID
+		return pid != 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	conn := &mockConn{}
+	defer pluginConnections.Delete(conn)
+	tagBackendConnection(conn, []int{os.Getpid()})
+	_, tagged := pluginConnections.Load(conn)
+	assert.False(t, tagged)
+
+	// A process started by the backend counts as the backend.
+	tagBackendConnection(conn, []int{pid + 100000, pid})
+	id, tagged := pluginConnections.Load(conn)
+	assert.True(t, tagged)
+	assert.Equal(t, "clock", id)
+
+	// The backend can still authenticate as its own plugin, not another.
+	token, err := serverPlugins.IssueToken("clock")
+	require.NoError(t, err)
+	authenticatePlugin(conn, models.Request{ID: 1, Method: "plugins.authenticate", Params: map[string]any{"token": token}})
+	assert.Contains(t, string(conn.written), `"plugin":"clock"`)
+
+	conn.written = nil
+	token, err = serverPlugins.IssueToken("weather")
+	require.NoError(t, err)
+	authenticatePlugin(conn, models.Request{ID: 2, Method: "plugins.authenticate", Params: map[string]any{"token": token}})
+	assert.Contains(t, string(conn.written), "already authenticated as plugin clock")
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"slices"
+	"time"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/matugen"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/models"
 	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/params"
 )
@@ -28,6 +31,17 @@ func HandleRequest(conn net.Conn, req models.Request, manager *Manager) {
 		handleSetLocation(conn, req, manager)
 	case "theme.auto.setUseIPLocation":
 		handleSetUseIPLocation(conn, req, manager)
+	case "theme.auto.setPalettes":
+		handleSetPalettes(conn, req, manager)
+	case "theme.auto.setAmbientThresholds":
+		handleSetAmbientThresholds(conn, req, manager)
+	case "theme.auto.setHooks":
+		handleSetHooks(conn, req, manager)
+	case "theme.auto.override":
+		handleOverride(conn, req, manager)
+	case "theme.auto.clearOverride":
+		manager.ClearOverride()
+		models.Respond(conn, req.ID, manager.GetState())
 	case "theme.auto.trigger":
 		handleTrigger(conn, req, manager)
 	case "theme.auto.subscribe":
@@ -59,7 +73,7 @@ func handleSetMode(conn net.Conn, req models.Request, manager *Manager) {
 		return
 	}
 
-	if mode != "time" && mode != "location" {
+	if !slices.Contains(Modes, mode) {
 		models.RespondError(conn, req.ID, "invalid mode")
 		return
 	}
@@ -126,6 +140,65 @@ func handleSetUseIPLocation(conn net.Conn, req models.Request, manager *Manager)
 	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "theme auto IP location set"})
 }
 
+func handleSetPalettes(conn net.Conn, req models.Request, manager *Manager) {
+	day := paletteParam(req.Params, "day")
+	night := paletteParam(req.Params, "night")
+
+	manager.SetPalettes(day, night)
+	models.Respond(conn, req.ID, manager.GetState())
+}
+
+// paletteParam reads a wallpaper path or "#rrggbb" color; empty or absent
+// clears the period's palette.
+func paletteParam(p map[string]any, key string) PaletteSource {
+	value := params.StringOpt(p, key, "")
+	if value == "" {
+		return PaletteSource{}
+	}
+	return PaletteSource{Kind: matugen.SourceKind(value), Value: value}
+}
+
+func handleSetAmbientThresholds(conn net.Conn, req models.Request, manager *Manager) {
+	darkLux, err := params.Float(req.Params, "darkLux")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	lightLux, err := params.Float(req.Params, "lightLux")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	if darkLux < 0 || lightLux < darkLux {
+		models.RespondError(conn, req.ID, "invalid thresholds: need 0 <= darkLux <= lightLux")
+		return
+	}
+
+	manager.SetAmbientThresholds(darkLux, lightLux)
+	models.Respond(conn, req.ID, manager.GetState())
+}
+
+func handleSetHooks(conn net.Conn, req models.Request, manager *Manager) {
+	manager.SetHooks(params.StringOpt(req.Params, "onLight", ""), params.StringOpt(req.Params, "onDark", ""))
+	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "theme auto hooks set"})
+}
+
+func handleOverride(conn net.Conn, req models.Request, manager *Manager) {
+	isLight, err := params.Bool(req.Params, "isLight")
+	if err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+	hours := params.FloatOpt(req.Params, "hours", 0)
+	if hours < 0 {
+		models.RespondError(conn, req.ID, "hours must not be negative")
+		return
+	}
+
+	manager.SetOverride(isLight, time.Duration(hours*float64(time.Hour)))
+	models.Respond(conn, req.ID, manager.GetState())
+}
+
 func handleTrigger(conn net.Conn, req models.Request, manager *Manager) {
 	manager.TriggerUpdate()
 	models.Respond(conn, req.ID, models.SuccessResult{Success: true, Message: "theme auto update triggered"})
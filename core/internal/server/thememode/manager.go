@@ -2,6 +2,7 @@ package thememode
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -16,6 +17,8 @@ const (
 	defaultEndMinute         = 0
 	defaultElevationTwilight = -6.0
 	defaultElevationDaylight = 3.0
+	defaultAmbientDarkLux    = 50.0
+	defaultAmbientLightLux   = 200.0
 )
 
 type Manager struct {
@@ -31,6 +34,14 @@ type Manager struct {
 	cachedIPLat   *float64
 	cachedIPLon   *float64
 
+	triggerMutex sync.RWMutex
+	gammaNight   *bool
+	ambientLux   *float64
+	onAC         *bool
+	override     *Override
+	triggerLight bool
+	sysRoot      string
+
 	stopChan      chan struct{}
 	updateTrigger chan struct{}
 	wg            sync.WaitGroup
@@ -47,7 +58,10 @@ func NewManager() *Manager {
 			EndMinute:         defaultEndMinute,
 			ElevationTwilight: defaultElevationTwilight,
 			ElevationDaylight: defaultElevationDaylight,
+			AmbientDarkLux:    defaultAmbientDarkLux,
+			AmbientLightLux:   defaultAmbientLightLux,
 		},
+		sysRoot:       "/sys",
 		stopChan:      make(chan struct{}),
 		updateTrigger: make(chan struct{}, 1),
 	}
@@ -165,6 +179,75 @@ func (m *Manager) SetUseIPLocation(use bool) {
 	m.TriggerUpdate()
 }
 
+// SetPalettes schedules the sources the day and night themes are
+// generated from. An empty source keeps the shell's wallpaper.
+func (m *Manager) SetPalettes(day, night PaletteSource) {
+	m.configMutex.Lock()
+	if m.config.DayPalette == day && m.config.NightPalette == night {
+		m.configMutex.Unlock()
+		return
+	}
+	m.config.DayPalette = day
+	m.config.NightPalette = night
+	m.configMutex.Unlock()
+	m.TriggerUpdate()
+}
+
+// ActivePalette returns the palette source scheduled for the current
+// period, if automation is on and one is set.
+func (m *Manager) ActivePalette() (PaletteSource, bool) {
+	state := m.GetState()
+	if state.Palette == nil {
+		return PaletteSource{}, false
+	}
+	return *state.Palette, true
+}
+
+func (m *Manager) SetAmbientThresholds(darkLux, lightLux float64) {
+	m.configMutex.Lock()
+	if m.config.AmbientDarkLux == darkLux && m.config.AmbientLightLux == lightLux {
+		m.configMutex.Unlock()
+		return
+	}
+	m.config.AmbientDarkLux = darkLux
+	m.config.AmbientLightLux = lightLux
+	m.configMutex.Unlock()
+	m.TriggerUpdate()
+}
+
+// SetHooks sets the commands run when the theme turns light or dark.
+func (m *Manager) SetHooks(onLight, onDark string) {
+	m.configMutex.Lock()
+	m.config.OnLight = onLight
+	m.config.OnDark = onDark
+	m.configMutex.Unlock()
+	m.TriggerUpdate()
+}
+
+// SetOverride pins the theme to light or dark for d, or until the active
+// trigger next switches when d is zero.
+func (m *Manager) SetOverride(isLight bool, d time.Duration) {
+	override := &Override{IsLight: isLight}
+	if d > 0 {
+		until := time.Now().Add(d)
+		override.Until = &until
+	}
+	triggerIsLight, _, _ := m.computeSchedule(time.Now(), m.getConfig())
+	override.triggerIsLight = triggerIsLight
+
+	m.triggerMutex.Lock()
+	m.override = override
+	m.triggerMutex.Unlock()
+	m.TriggerUpdate()
+}
+
+func (m *Manager) ClearOverride() {
+	m.triggerMutex.Lock()
+	m.override = nil
+	m.triggerMutex.Unlock()
+	m.TriggerUpdate()
+}
+
 func (m *Manager) TriggerUpdate() {
 	select {
 	case m.updateTrigger <- struct{}{}:
@@ -193,23 +276,10 @@ func (m *Manager) schedulerLoop() {
 	var timer *time.Timer
 	for {
 		config := m.getConfig()
-		now := time.Now()
-		var isLight bool
-		var next time.Time
-		if config.Enabled {
-			isLight, next = m.computeSchedule(now, config)
-		} else {
-			m.stateMutex.RLock()
-			if m.state != nil {
-				isLight = m.state.IsLight
-			}
-			m.stateMutex.RUnlock()
-			next = now.Add(24 * time.Hour)
-		}
-
-		m.updateStateWithValues(config, isLight, next)
+		d := m.evaluate(time.Now(), config)
+		m.updateStateWithValues(config, d)
 
-		waitDur := time.Until(next)
+		waitDur := time.Until(d.next)
 		if !config.Enabled {
 			waitDur = 24 * time.Hour
 		}
@@ -237,35 +307,98 @@ func (m *Manager) schedulerLoop() {
 
 func (m *Manager) updateState(now time.Time) {
 	config := m.getConfig()
-	var isLight bool
-	var next time.Time
-	if config.Enabled {
-		isLight, next = m.computeSchedule(now, config)
-	} else {
-		m.stateMutex.RLock()
-		if m.state != nil {
-			isLight = m.state.IsLight
+	m.updateStateWithValues(config, m.evaluate(now, config))
+}
+
+// decision is the mode the manager settled on, when to look again and why.
+type decision struct {
+	isLight bool
+	next    time.Time
+	reason  string
+}
+
+// evaluate asks the configured trigger for the mode and lets a manual
+// override win while it lasts.
+func (m *Manager) evaluate(now time.Time, config Config) decision {
+	if !config.Enabled {
+		return decision{isLight: m.currentIsLight(), next: now.Add(24 * time.Hour), reason: "automation disabled"}
+	}
+
+	isLight, next, reason := m.computeSchedule(now, config)
+
+	m.triggerMutex.Lock()
+	defer m.triggerMutex.Unlock()
+	m.triggerLight = isLight
+	o := m.override
+	switch {
+	case o == nil:
+	case o.Until != nil && !now.Before(*o.Until):
+		m.override = nil
+	case o.Until == nil && isLight != o.triggerIsLight:
+		m.override = nil
+	default:
+		reason = "manual override until next transition"
+		if o.Until != nil {
+			reason = "manual override until " + o.Until.Format("15:04")
+			if o.Until.Before(next) {
+				next = *o.Until
+			}
 		}
-		m.stateMutex.RUnlock()
-		next = now.Add(24 * time.Hour)
+		return decision{isLight: o.IsLight, next: next, reason: reason}
 	}
-	m.updateStateWithValues(config, isLight, next)
+	return decision{isLight: isLight, next: next, reason: reason}
+}
+
+func (m *Manager) currentIsLight() bool {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	return m.state != nil && m.state.IsLight
 }
 
-func (m *Manager) updateStateWithValues(config Config, isLight bool, next time.Time) {
+// lastTriggerIsLight is what the trigger last asked for, which it sticks
+// to when its input is unavailable or undecided. Unlike the state, it
+// isn't affected by an override.
+func (m *Manager) lastTriggerIsLight() bool {
+	m.triggerMutex.RLock()
+	defer m.triggerMutex.RUnlock()
+	return m.triggerLight
+}
+
+func (m *Manager) updateStateWithValues(config Config, d decision) {
 	newState := State{
 		Config:         config,
-		IsLight:        isLight,
-		NextTransition: next,
+		IsLight:        d.isLight,
+		NextTransition: d.next,
+		Palette:        scheduledPalette(config, d.isLight),
+		Reason:         d.reason,
 	}
 
-	m.stateMutex.Lock()
-	if m.state != nil && statesEqual(m.state, &newState) {
-		m.stateMutex.Unlock()
-		return
+	m.triggerMutex.RLock()
+	if m.override != nil {
+		override := *m.override
+		newState.Override = &override
 	}
+	newState.Triggers = Triggers{GammaNight: m.gammaNight, AmbientLux: m.ambientLux, OnAC: m.onAC}
+	m.triggerMutex.RUnlock()
+
+	// Readings and poll times are kept fresh for GetState, but only a
+	// change of mode, reason, override or config is sent to subscribers.
+	m.stateMutex.Lock()
+	changed := !statesEqual(m.state, &newState)
+	transition := m.state != nil && m.state.IsLight != newState.IsLight && config.Enabled
 	m.state = &newState
 	m.stateMutex.Unlock()
+	if !changed {
+		return
+	}
+
+	if transition {
+		if newState.IsLight {
+			runHook(config.OnLight, true, newState.Reason)
+		} else {
+			runHook(config.OnDark, false, newState.Reason)
+		}
+	}
 
 	m.notifySubscribers()
 }
@@ -316,21 +449,135 @@ func (m *Manager) getLocation(config Config) (*float64, *float64) {
 	return lat, lon
 }
 
+func scheduledPalette(config Config, isLight bool) *PaletteSource {
+	if !config.Enabled {
+		return nil
+	}
+	palette := config.NightPalette
+	if isLight {
+		palette = config.DayPalette
+	}
+	if !palette.IsSet() {
+		return nil
+	}
+	return &palette
+}
+
+// statesEqual reports whether subscribers would see a different state.
+// Sensor readings are left out, and so is the next check of the polled
+// triggers, which moves on every poll; the next transition of the time and
+// location schedules is a real change.
 func statesEqual(a, b *State) bool {
 	if a == nil || b == nil {
 		return a == b
 	}
-	if a.IsLight != b.IsLight || !a.NextTransition.Equal(b.NextTransition) {
+	if a.IsLight != b.IsLight || a.Reason != b.Reason || a.Config != b.Config {
+		return false
+	}
+	if scheduledMode(a.Config.Mode) && !a.NextTransition.Equal(b.NextTransition) {
+		return false
+	}
+	if a.Override == nil || b.Override == nil {
+		return a.Override == b.Override
+	}
+	if a.Override.IsLight != b.Override.IsLight || (a.Override.Until == nil) != (b.Override.Until == nil) {
+		return false
+	}
+	return a.Override.Until == nil || a.Override.Until.Equal(*b.Override.Until)
+}
+
+// scheduledMode reports whether a trigger mode switches at times it knows
+// in advance, rather than by polling.
+func scheduledMode(mode string) bool {
+	switch mode {
+	case "gamma", "ambient", "power":
 		return false
 	}
-	return a.Config == b.Config
+	return true
+}
+
+// computeSchedule returns the mode the configured trigger asks for, when
+// to check again and why.
+func (m *Manager) computeSchedule(now time.Time, config Config) (bool, time.Time, string) {
+	switch config.Mode {
+	case "location":
+		isLight, next := m.computeLocationSchedule(now, config)
+		if isLight {
+			return isLight, next, "sun is up"
+		}
+		return isLight, next, "sun is down"
+	case "gamma":
+		return m.computeGammaSchedule(now)
+	case "ambient":
+		return m.computeAmbientSchedule(now, config)
+	case "power":
+		return m.computePowerSchedule(now)
+	}
+	isLight, next := computeTimeSchedule(now, config)
+	if isLight {
+		return isLight, next, "scheduled light period"
+	}
+	return isLight, next, "scheduled dark period"
+}
+
+func (m *Manager) computeGammaSchedule(now time.Time) (bool, time.Time, string) {
+	m.triggerMutex.RLock()
+	night := m.gammaNight
+	m.triggerMutex.RUnlock()
+
+	// The gamma manager pushes its changes, the timer is only a fallback.
+	next := now.Add(10 * time.Minute)
+	switch {
+	case night == nil:
+		return m.lastTriggerIsLight(), next, "night mode is off or unavailable"
+	case *night:
+		return false, next, "night mode is active"
+	default:
+		return true, next, "night mode is inactive"
+	}
+}
+
+func (m *Manager) computeAmbientSchedule(now time.Time, config Config) (bool, time.Time, string) {
+	next := now.Add(sensorPollInterval)
+	lux, err := readAmbientLux(m.sysRoot)
+	if err != nil {
+		m.setReading(func() { m.ambientLux = nil })
+		return m.lastTriggerIsLight(), next, err.Error()
+	}
+	lux = float64(int(lux + 0.5))
+	m.setReading(func() { m.ambientLux = &lux })
+
+	// The reading itself is in Triggers; keeping it out of the reason
+	// keeps subscribers from being woken on every poll.
+	switch {
+	case lux >= config.AmbientLightLux:
+		return true, next, fmt.Sprintf("ambient light is above %.0f lx", config.AmbientLightLux)
+	case lux <= config.AmbientDarkLux:
+		return false, next, fmt.Sprintf("ambient light is below %.0f lx", config.AmbientDarkLux)
+	default:
+		return m.lastTriggerIsLight(), next, "ambient light is between thresholds"
+	}
 }
 
-func (m *Manager) computeSchedule(now time.Time, config Config) (bool, time.Time) {
-	if config.Mode == "location" {
-		return m.computeLocationSchedule(now, config)
+func (m *Manager) computePowerSchedule(now time.Time) (bool, time.Time, string) {
+	next := now.Add(sensorPollInterval)
+	onAC, err := readOnAC(m.sysRoot)
+	if err != nil {
+		m.setReading(func() { m.onAC = nil })
+		return m.lastTriggerIsLight(), next, err.Error()
+	}
+	m.setReading(func() { m.onAC = &onAC })
+
+	if onAC {
+		return true, next, "on AC power"
 	}
-	return computeTimeSchedule(now, config)
+	return false, next, "on battery"
+}
+
+func (m *Manager) setReading(set func()) {
+	m.triggerMutex.Lock()
+	set()
+	m.triggerMutex.Unlock()
 }
 
 func computeTimeSchedule(now time.Time, config Config) (bool, time.Time) {
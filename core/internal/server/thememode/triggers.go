@@ -0,0 +1,151 @@
+package thememode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/server/wayland"
+)
+
+const sensorPollInterval = 30 * time.Second
+
+// FollowGamma feeds the gamma manager's night state to the "gamma" mode,
+// so the theme turns dark when the screen turns warm.
+func (m *Manager) FollowGamma(gamma *wayland.Manager) {
+	states := gamma.Subscribe("thememode")
+	m.setGammaNight(gammaNight(gamma.GetState()))
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer gamma.Unsubscribe("thememode")
+		for {
+			select {
+			case <-m.stopChan:
+				return
+			case state, ok := <-states:
+				if !ok {
+					m.setGammaNight(nil)
+					return
+				}
+				m.setGammaNight(gammaNight(state))
+			}
+		}
+	}()
+}
+
+func gammaNight(state wayland.State) *bool {
+	if !state.Config.Enabled {
+		return nil
+	}
+	night := !state.IsDay
+	return &night
+}
+
+func (m *Manager) setGammaNight(night *bool) {
+	m.triggerMutex.Lock()
+	changed := !boolPtrEqual(m.gammaNight, night)
+	m.gammaNight = night
+	m.triggerMutex.Unlock()
+	if changed && m.getConfig().Mode == "gamma" {
+		m.TriggerUpdate()
+	}
+}
+
+// readAmbientLux reads the first iio light sensor, in lux.
+func readAmbientLux(sysRoot string) (float64, error) {
+	devices, _ := filepath.Glob(filepath.Join(sysRoot, "bus", "iio", "devices", "iio:device*"))
+	for _, dev := range devices {
+		if inputs, _ := filepath.Glob(filepath.Join(dev, "in_illuminance*_input")); len(inputs) > 0 {
+			return readFloat(inputs[0])
+		}
+		raws, _ := filepath.Glob(filepath.Join(dev, "in_illuminance*_raw"))
+		if len(raws) == 0 {
+			continue
+		}
+		raw, err := readFloat(raws[0])
+		if err != nil {
+			return 0, err
+		}
+		prefix := strings.TrimSuffix(raws[0], "_raw")
+		scale, err := readFloat(prefix + "_scale")
+		if err != nil {
+			scale, err = readFloat(filepath.Join(dev, "in_illuminance_scale"))
+		}
+		if err != nil {
+			scale = 1
+		}
+		offset, _ := readFloat(filepath.Join(dev, "in_illuminance_offset"))
+		return (raw + offset) * scale, nil
+	}
+	return 0, errors.New("no ambient light sensor")
+}
+
+// readOnAC reports whether the machine runs on external power: no battery
+// is discharging. Machines without a battery count as on AC.
+func readOnAC(sysRoot string) (bool, error) {
+	supplies, err := os.ReadDir(filepath.Join(sysRoot, "class", "power_supply"))
+	if err != nil {
+		return false, fmt.Errorf("no power supply information: %w", err)
+	}
+	for _, supply := range supplies {
+		dir := filepath.Join(sysRoot, "class", "power_supply", supply.Name())
+		if readString(filepath.Join(dir, "type")) != "Battery" {
+			continue
+		}
+		if readString(filepath.Join(dir, "status")) == "Discharging" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readFloat(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// runHook runs a transition hook in the background. The new mode and the
+// reason are passed as DMS_THEME_MODE and DMS_THEME_REASON.
+func runHook(command string, isLight bool, reason string) {
+	if command == "" {
+		return
+	}
+	mode := "dark"
+	if isLight {
+		mode = "light"
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), "DMS_THEME_MODE="+mode, "DMS_THEME_REASON="+reason)
+	go func() {
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Warnf("Theme %s hook failed: %v: %s", mode, err, strings.TrimSpace(string(output)))
+		}
+	}()
+}
+
+func boolPtrEqual(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,127 @@
+package thememode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0o644))
+	}
+}
+
+func TestReadSensors(t *testing.T) {
+	root := t.TempDir()
+	_, err := readAmbientLux(root)
+	assert.Error(t, err)
+
+	writeSysfs(t, root, map[string]string{
+		"bus/iio/devices/iio:device0/in_accel_x_raw":        "12",
+		"bus/iio/devices/iio:device1/in_illuminance_raw":    "400",
+		"bus/iio/devices/iio:device1/in_illuminance_scale":  "0.5",
+		"bus/iio/devices/iio:device1/in_illuminance_offset": "10",
+		"class/power_supply/AC/type":                        "Mains",
+		"class/power_supply/BAT0/type":                      "Battery",
+		"class/power_supply/BAT0/status":                    "Charging",
+	})
+	lux, err := readAmbientLux(root)
+	require.NoError(t, err)
+	assert.InDelta(t, 205, lux, 0.001)
+
+	onAC, err := readOnAC(root)
+	require.NoError(t, err)
+	assert.True(t, onAC)
+
+	writeSysfs(t, root, map[string]string{"class/power_supply/BAT0/status": "Discharging"})
+	onAC, err = readOnAC(root)
+	require.NoError(t, err)
+	assert.False(t, onAC)
+}
+
+func TestTriggersAndOverride(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{"bus/iio/devices/iio:device0/in_illuminance_input": "20"})
+	m := &Manager{
+		config:  Config{Enabled: true, Mode: "ambient", AmbientDarkLux: 50, AmbientLightLux: 200},
+		sysRoot: root,
+	}
+	now := time.Now()
+
+	d := m.evaluate(now, m.getConfig())
+	assert.False(t, d.isLight)
+	assert.Equal(t, "ambient light is below 50 lx", d.reason)
+
+	// Between the thresholds the trigger keeps its last decision.
+	writeSysfs(t, root, map[string]string{"bus/iio/devices/iio:device0/in_illuminance_input": "120"})
+	assert.False(t, m.evaluate(now, m.getConfig()).isLight)
+
+	// An override until the next transition survives undecided readings
+	// and ends when the trigger switches.
+	m.SetOverride(true, 0)
+	d = m.evaluate(now, m.getConfig())
+	assert.True(t, d.isLight)
+	assert.Contains(t, d.reason, "override")
+	writeSysfs(t, root, map[string]string{"bus/iio/devices/iio:device0/in_illuminance_input": "30"})
+	assert.True(t, m.evaluate(now, m.getConfig()).isLight)
+	writeSysfs(t, root, map[string]string{"bus/iio/devices/iio:device0/in_illuminance_input": "500"})
+	d = m.evaluate(now, m.getConfig())
+	assert.True(t, d.isLight)
+	assert.NotContains(t, d.reason, "override")
+	assert.Nil(t, m.override)
+
+	// A timed override wins until it expires.
+	m.SetOverride(false, time.Hour)
+	assert.False(t, m.evaluate(now, m.getConfig()).isLight)
+	assert.True(t, m.evaluate(now.Add(2*time.Hour), m.getConfig()).isLight)
+
+	night := true
+	m.config.Mode = "gamma"
+	m.gammaNight = &night
+	d = m.evaluate(now, m.getConfig())
+	assert.False(t, d.isLight)
+	assert.Equal(t, "night mode is active", d.reason)
+}
+
+func TestStatesEqualIgnoresReadings(t *testing.T) {
+	now := time.Now()
+	lux, otherLux := 20.0, 25.0
+	a := &State{Config: Config{Mode: "ambient"}, Reason: "ambient light is below 50 lx", NextTransition: now, Triggers: Triggers{AmbientLux: &lux}}
+	b := *a
+	b.NextTransition = now.Add(sensorPollInterval)
+	b.Triggers = Triggers{AmbientLux: &otherLux}
+	assert.True(t, statesEqual(a, &b))
+
+	b.Override = &Override{IsLight: true}
+	assert.False(t, statesEqual(a, &b))
+	c := b
+	until := now.Add(time.Hour)
+	c.Override = &Override{IsLight: true, Until: &until}
+	assert.False(t, statesEqual(&b, &c))
+
+	b.Override = nil
+	b.IsLight = true
+	assert.False(t, statesEqual(a, &b))
+}
+
+func TestStatesEqualComparesScheduledTransitions(t *testing.T) {
+	now := time.Now()
+	a := &State{Config: Config{Mode: "location"}, Reason: "sun is up", NextTransition: now}
+	b := *a
+	assert.True(t, statesEqual(a, &b))
+
+	// A new location moves sunset without flipping the mode.
+	b.NextTransition = now.Add(10 * time.Minute)
+	assert.False(t, statesEqual(a, &b))
+
+	a.Config.Mode, b.Config.Mode = "time", "time"
+	assert.False(t, statesEqual(a, &b))
+}
@@ -2,6 +2,12 @@ package thememode
 
 import "time"
 
+// Modes decide what switches the theme: the clock ("time"), the sun at
+// the configured location ("location"), the gamma manager's night state
+// ("gamma"), the ambient light sensor ("ambient") or the power source
+// ("power", dark on battery).
+var Modes = []string{"time", "location", "gamma", "ambient", "power"}
+
 type Config struct {
 	Enabled           bool     `json:"enabled"`
 	Mode              string   `json:"mode"`
@@ -14,10 +20,56 @@ type Config struct {
 	UseIPLocation     bool     `json:"useIPLocation"`
 	ElevationTwilight float64  `json:"elevationTwilight"`
 	ElevationDaylight float64  `json:"elevationDaylight"`
+	// DayPalette and NightPalette, when set, replace the wallpaper the
+	// theme is generated from during the light and dark periods.
+	DayPalette   PaletteSource `json:"dayPalette"`
+	NightPalette PaletteSource `json:"nightPalette"`
+	// Below AmbientDarkLux the theme turns dark, above AmbientLightLux
+	// light; in between it stays as it is.
+	AmbientDarkLux  float64 `json:"ambientDarkLux"`
+	AmbientLightLux float64 `json:"ambientLightLux"`
+	// OnLight and OnDark are shell commands run on every transition.
+	OnLight string `json:"onLight,omitempty"`
+	OnDark  string `json:"onDark,omitempty"`
+}
+
+// Override pins the mode by hand until a time, or with Until unset until
+// the active trigger next changes its mind.
+type Override struct {
+	IsLight bool       `json:"isLight"`
+	Until   *time.Time `json:"until,omitempty"`
+
+	triggerIsLight bool
+}
+
+// Triggers are the last readings of the inputs the modes switch on; nil
+// when unknown or not in use.
+type Triggers struct {
+	GammaNight *bool    `json:"gammaNight,omitempty"`
+	AmbientLux *float64 `json:"ambientLux,omitempty"`
+	OnAC       *bool    `json:"onAC,omitempty"`
+}
+
+// PaletteSource is what a palette is generated from: an image path or a
+// hex color, as passed to matugen.
+type PaletteSource struct {
+	Kind  string `json:"kind,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+func (p PaletteSource) IsSet() bool {
+	return p.Value != ""
 }
 
 type State struct {
 	Config         Config    `json:"config"`
 	IsLight        bool      `json:"isLight"`
 	NextTransition time.Time `json:"nextTransition"`
+	// Palette is the source the current period's theme is generated from,
+	// if one is scheduled.
+	Palette *PaletteSource `json:"palette,omitempty"`
+	// Reason says why the theme is in its current mode.
+	Reason   string    `json:"reason"`
+	Override *Override `json:"override,omitempty"`
+	Triggers Triggers  `json:"triggers"`
 }
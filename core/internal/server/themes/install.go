@@ -33,13 +33,19 @@ func HandleInstall(conn net.Conn, req models.Request) {
 		return
 	}
 
+	allowUntrusted, _ := models.Get[bool](req, "allowUntrusted")
+	if err := themes.CheckTrust(*theme, allowUntrusted); err != nil {
+		models.RespondError(conn, req.ID, err.Error())
+		return
+	}
+
 	manager, err := themes.NewManager()
 	if err != nil {
 		models.RespondError(conn, req.ID, fmt.Sprintf("failed to create manager: %v", err))
 		return
 	}
 
-	registryThemeDir := registry.GetThemeDir(theme.SourceDir)
+	registryThemeDir := registry.ThemeDir(*theme)
 	if err := manager.Install(*theme, registryThemeDir); err != nil {
 		models.RespondError(conn, req.ID, fmt.Sprintf("failed to install theme: %v", err))
 		return
@@ -39,6 +39,9 @@ func HandleList(conn net.Conn, req models.Request) {
 			Description: t.Description,
 			PreviewPath: t.PreviewPath,
 			SourceDir:   t.SourceDir,
+			Source:      t.Source,
+			Trusted:     t.Trusted,
+			ThemeDir:    t.Dir,
 			Installed:   installed,
 			FirstParty:  isFirstParty(t.Author),
 		}
@@ -44,8 +44,13 @@ func HandleSearch(conn net.Conn, req models.Request) {
 			Version:     t.Version,
 			Author:      t.Author,
 			Description: t.Description,
+			PreviewPath: t.PreviewPath,
+			SourceDir:   t.SourceDir,
 			Installed:   installed,
 			FirstParty:  isFirstParty(t.Author),
+			Source:      t.Source,
+			Trusted:     t.Trusted,
+			ThemeDir:    t.Dir,
 		}
 	}
 
@@ -44,4 +44,7 @@ type ThemeInfo struct {
 	HasUpdate   bool          `json:"hasUpdate,omitempty"`
 	HasVariants bool          `json:"hasVariants,omitempty"`
 	Variants    *VariantsInfo `json:"variants,omitempty"`
+	Source      string        `json:"source,omitempty"`
+	Trusted     bool          `json:"trusted,omitempty"`
+	ThemeDir    string        `json:"themeDir,omitempty"`
 }
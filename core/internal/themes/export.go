@@ -0,0 +1,137 @@
+package themes
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// dynamicColors maps theme colors to the matugen roles the shell reads in
+// dynamic mode, so an exported theme looks exactly like the current one.
+var dynamicColors = map[string]string{
+	"primary":                 "primary",
+	"primaryText":             "on_primary",
+	"primaryContainer":        "primary_container",
+	"secondary":               "secondary",
+	"surface":                 "surface",
+	"surfaceText":             "on_background",
+	"surfaceVariant":          "surface_variant",
+	"surfaceVariantText":      "on_surface_variant",
+	"surfaceTint":             "surface_tint",
+	"background":              "background",
+	"backgroundText":          "on_background",
+	"outline":                 "outline",
+	"surfaceContainer":        "surface_container",
+	"surfaceContainerHigh":    "surface_container_high",
+	"surfaceContainerHighest": "surface_container_highest",
+	"error":                   "error",
+}
+
+type dmsColors struct {
+	Colors struct {
+		Dark  map[string]string `json:"dark"`
+		Light map[string]string `json:"light"`
+	} `json:"colors"`
+}
+
+func schemeFromDynamic(roles map[string]string) (ColorScheme, error) {
+	colors := map[string]string{
+		"warning": "#FF9800",
+		"info":    "#2196F3",
+	}
+	for name, role := range dynamicColors {
+		value := roles[role]
+		if value == "" {
+			return ColorScheme{}, fmt.Errorf("color %s is missing", role)
+		}
+		colors[name] = value
+	}
+
+	data, err := json.Marshal(colors)
+	if err != nil {
+		return ColorScheme{}, err
+	}
+	var scheme ColorScheme
+	err = json.Unmarshal(data, &scheme)
+	return scheme, err
+}
+
+// ExportDynamic turns the generated dms-colors.json into a registry-ready
+// theme directory: theme.json with the metadata from meta plus dark and
+// light previews.
+func ExportDynamic(fs afero.Fs, colorsPath, outDir string, meta Theme) (*Theme, error) {
+	data, err := afero.ReadFile(fs, colorsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dynamic colors: %w", err)
+	}
+	var generated dmsColors
+	if err := json.Unmarshal(data, &generated); err != nil {
+		return nil, fmt.Errorf("failed to parse dynamic colors: %w", err)
+	}
+
+	theme := meta
+	theme.SourceDir = ""
+	theme.Variants = nil
+	if theme.Dark, err = schemeFromDynamic(generated.Colors.Dark); err != nil {
+		return nil, fmt.Errorf("dark colors: %w", err)
+	}
+	if theme.Light, err = schemeFromDynamic(generated.Colors.Light); err != nil {
+		return nil, fmt.Errorf("light colors: %w", err)
+	}
+
+	if err := writeThemeDir(fs, outDir, theme); err != nil {
+		return nil, err
+	}
+	for mode, scheme := range map[string]ColorScheme{"dark": theme.Dark, "light": theme.Light} {
+		preview := filepath.Join(outDir, "preview-"+mode+".svg")
+		if err := afero.WriteFile(fs, preview, []byte(PreviewSVG(theme.Name, scheme)), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write preview: %w", err)
+		}
+	}
+	return &theme, nil
+}
+
+func writeThemeDir(fs afero.Fs, dir string, theme Theme) error {
+	if err := fs.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create theme directory: %w", err)
+	}
+	data, err := json.MarshalIndent(theme, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal theme: %w", err)
+	}
+	if err := afero.WriteFile(fs, filepath.Join(dir, "theme.json"), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write theme file: %w", err)
+	}
+	return nil
+}
+
+// PreviewSVG draws a small mock of the shell in the scheme's colors: a
+// bar, a card with text and a primary button.
+func PreviewSVG(title string, c ColorScheme) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="320" height="200" viewBox="0 0 320 200">
+  <rect width="320" height="200" fill="%s"/>
+  <rect x="8" y="8" width="304" height="28" rx="8" fill="%s"/>
+  <rect x="16" y="15" width="44" height="14" rx="7" fill="%s"/>
+  <circle cx="296" cy="22" r="6" fill="%s"/>
+  <rect x="24" y="52" width="272" height="132" rx="14" fill="%s" stroke="%s"/>
+  <text x="40" y="82" font-family="sans-serif" font-size="16" fill="%s">%s</text>
+  <rect x="40" y="96" width="180" height="8" rx="4" fill="%s"/>
+  <rect x="40" y="112" width="140" height="8" rx="4" fill="%s"/>
+  <rect x="40" y="140" width="96" height="28" rx="14" fill="%s"/>
+  <text x="88" y="159" font-family="sans-serif" font-size="12" text-anchor="middle" fill="%s">Primary</text>
+  <rect x="148" y="140" width="96" height="28" rx="14" fill="%s"/>
+</svg>
+`, c.Background, c.SurfaceContainer, c.Primary, c.Secondary,
+		c.SurfaceContainerHigh, c.Outline, c.SurfaceText, xmlEscape(title),
+		c.SurfaceVariantText, c.SurfaceVariant,
+		c.Primary, c.PrimaryText, c.PrimaryContainer)
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
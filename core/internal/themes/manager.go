@@ -117,7 +117,7 @@ func (m *Manager) InstallFromRegistry(registry *Registry, themeID string) error
 		return err
 	}
 
-	registryThemeDir := registry.GetThemeDir(theme.SourceDir)
+	registryThemeDir := registry.ThemeDir(*theme)
 	return m.Install(*theme, registryThemeDir)
 }
 
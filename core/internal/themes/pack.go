@@ -0,0 +1,52 @@
+package themes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Pack validates the theme in srcDir and lays it out the way the registry
+// repository expects, as outDir/themes/<id>/ with theme.json and its
+// preview images. Themes with validation errors are refused; the issues
+// are returned either way.
+func Pack(fs afero.Fs, srcDir, outDir string) (string, []Issue, error) {
+	theme, issues, err := Validate(fs, srcDir)
+	if err != nil {
+		return "", nil, err
+	}
+	if HasErrors(issues) {
+		return "", issues, fmt.Errorf("theme has validation errors")
+	}
+
+	dstDir := filepath.Join(outDir, "themes", theme.ID)
+	if exists, _ := afero.DirExists(fs, dstDir); exists {
+		if err := fs.RemoveAll(dstDir); err != nil {
+			return "", issues, fmt.Errorf("failed to replace %s: %w", dstDir, err)
+		}
+	}
+	if err := writeThemeDir(fs, dstDir, *theme); err != nil {
+		return "", issues, err
+	}
+
+	entries, err := afero.ReadDir(fs, srcDir)
+	if err != nil {
+		return "", issues, fmt.Errorf("failed to read theme directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "preview") || filepath.Ext(name) != ".svg" {
+			continue
+		}
+		data, err := afero.ReadFile(fs, filepath.Join(srcDir, name))
+		if err != nil {
+			return "", issues, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := afero.WriteFile(fs, filepath.Join(dstDir, name), data, 0o644); err != nil {
+			return "", issues, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return dstDir, issues, nil
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/log"
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/registries"
 	"github.com/go-git/go-git/v6"
 	"github.com/spf13/afero"
 )
 
-const registryRepo = "https://github.com/AvengeMedia/dms-plugin-registry.git"
+const registryRepo = registries.OfficialURL
 
 type ColorScheme struct {
 	Primary                 string `json:"primary,omitempty"`
@@ -115,6 +117,12 @@ type Theme struct {
 	Variants    *ThemeVariants `json:"variants,omitempty"`
 	PreviewPath string         `json:"-"`
 	SourceDir   string         `json:"sourceDir,omitempty"`
+
+	// Dir is the theme's directory in the registry it was listed in,
+	// Source that registry's name and Trusted whether it is trusted.
+	Dir     string `json:"-"`
+	Source  string `json:"-"`
+	Trusted bool   `json:"-"`
 }
 
 type GitClient interface {
@@ -154,6 +162,7 @@ func (g *realGitClient) Pull(path string) error {
 type Registry struct {
 	fs       afero.Fs
 	cacheDir string
+	sources  []registries.Source
 	themes   []Theme
 	git      GitClient
 }
@@ -167,6 +176,7 @@ func NewRegistryWithFs(fs afero.Fs) (*Registry, error) {
 	return &Registry{
 		fs:       fs,
 		cacheDir: cacheDir,
+		sources:  registries.Sources(),
 		git:      &realGitClient{},
 	}, nil
 }
@@ -175,49 +185,80 @@ func getCacheDir() string {
 	return filepath.Join(os.TempDir(), "dankdots-plugin-registry")
 }
 
-func (r *Registry) Update() error {
-	exists, err := afero.DirExists(r.fs, r.cacheDir)
-	if err != nil {
-		return fmt.Errorf("failed to check cache directory: %w", err)
+func (r *Registry) getSources() []registries.Source {
+	if r.sources == nil {
+		return []registries.Source{registries.Official()}
 	}
+	return r.sources
+}
 
-	if !exists {
-		if err := r.fs.MkdirAll(filepath.Dir(r.cacheDir), 0o755); err != nil {
-			return fmt.Errorf("failed to create cache directory: %w", err)
-		}
+func (r *Registry) sourceDir(source registries.Source) string {
+	if source.URL == registryRepo {
+		return r.cacheDir
+	}
+	return source.Dir()
+}
 
-		if err := r.git.PlainClone(r.cacheDir, registryRepo); err != nil {
-			return fmt.Errorf("failed to clone registry: %w", err)
+// Update syncs every registry source and reloads the merged theme list,
+// skipping sources that can't be reached as long as one works.
+func (r *Registry) Update() error {
+	sources := r.getSources()
+	var errs []error
+	for _, source := range sources {
+		if err := registries.Sync(r.fs, r.git, source, r.sourceDir(source)); err != nil {
+			errs = append(errs, fmt.Errorf("registry %s: %w", source.Name, err))
 		}
-	} else {
-		if err := r.git.Pull(r.cacheDir); err != nil {
-			if err := r.fs.RemoveAll(r.cacheDir); err != nil {
-				return fmt.Errorf("failed to remove corrupted registry: %w", err)
-			}
+	}
+	if len(errs) == len(sources) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Warnf("Skipping %v", err)
+	}
 
-			if err := r.fs.MkdirAll(filepath.Dir(r.cacheDir), 0o755); err != nil {
-				return fmt.Errorf("failed to create cache directory: %w", err)
-			}
+	return r.loadThemes()
+}
+
+// loadThemes merges the themes of every source; the first source in
+// lookup order to offer an ID wins.
+func (r *Registry) loadThemes() error {
+	r.themes = []Theme{}
+	seen := make(map[string]bool)
 
-			if err := r.git.PlainClone(r.cacheDir, registryRepo); err != nil {
-				return fmt.Errorf("failed to re-clone registry: %w", err)
+	var errs []error
+	for _, source := range r.getSources() {
+		themes, err := r.loadSource(source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+			continue
+		}
+		for _, theme := range themes {
+			if seen[theme.ID] {
+				continue
 			}
+			seen[theme.ID] = true
+			r.themes = append(r.themes, theme)
 		}
 	}
 
-	return r.loadThemes()
+	if len(errs) == len(r.getSources()) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Warnf("Skipping registry %v", err)
+	}
+	return nil
 }
 
-func (r *Registry) loadThemes() error {
-	themesDir := filepath.Join(r.cacheDir, "themes")
+func (r *Registry) loadSource(source registries.Source) ([]Theme, error) {
+	themesDir := filepath.Join(r.sourceDir(source), "themes")
 
 	entries, err := afero.ReadDir(r.fs, themesDir)
 	if err != nil {
-		return fmt.Errorf("failed to read themes directory: %w", err)
+		return nil, fmt.Errorf("failed to read themes directory: %w", err)
 	}
 
-	r.themes = []Theme{}
-
+	var themes []Theme
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -240,16 +281,19 @@ func (r *Registry) loadThemes() error {
 			theme.ID = entry.Name()
 		}
 		theme.SourceDir = entry.Name()
+		theme.Dir = themeDir
+		theme.Source = source.Name
+		theme.Trusted = source.Trusted
 
 		previewPath := filepath.Join(themeDir, "preview.svg")
 		if exists, _ := afero.Exists(r.fs, previewPath); exists {
 			theme.PreviewPath = previewPath
 		}
 
-		r.themes = append(r.themes, theme)
+		themes = append(themes, theme)
 	}
 
-	return nil
+	return themes, nil
 }
 
 func (r *Registry) List() ([]Theme, error) {
@@ -304,6 +348,24 @@ func (r *Registry) GetThemeDir(themeID string) string {
 	return filepath.Join(r.cacheDir, "themes", themeID)
 }
 
+// ThemeDir is the directory a listed theme came from, in whichever
+// registry listed it.
+func (r *Registry) ThemeDir(theme Theme) string {
+	if theme.Dir != "" {
+		return theme.Dir
+	}
+	return r.GetThemeDir(theme.SourceDir)
+}
+
+// CheckTrust refuses themes listed by a registry the user hasn't marked
+// trusted, unless they explicitly allow it.
+func CheckTrust(theme Theme, allowUntrusted bool) error {
+	if theme.Source == "" || theme.Trusted || allowUntrusted {
+		return nil
+	}
+	return fmt.Errorf("theme %s comes from untrusted registry %q; allow untrusted sources to install it", theme.ID, theme.Source)
+}
+
 func SortByFirstParty(themes []Theme) []Theme {
 	return themes
 }
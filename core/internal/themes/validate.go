@@ -0,0 +1,345 @@
+package themes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/AvengeMedia/DankMaterialShell/core/internal/dank16"
+	"github.com/spf13/afero"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is one problem found in a theme. Errors keep a theme out of the
+// registry; warnings are worth fixing but don't block packing.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Path, i.Message)
+}
+
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredColors are the scheme colors the shell reads without a fallback.
+var requiredColors = []string{
+	"primary", "primaryText", "primaryContainer", "secondary",
+	"surface", "surfaceText", "surfaceVariant", "surfaceVariantText", "surfaceTint",
+	"background", "backgroundText", "outline", "surfaceContainer", "surfaceContainerHigh",
+}
+
+// contrastPairs are the text/background pairs the shell draws together.
+var contrastPairs = [][2]string{
+	{"primaryText", "primary"},
+	{"surfaceText", "surface"},
+	{"surfaceVariantText", "surfaceVariant"},
+	{"backgroundText", "background"},
+	{"surfaceText", "surfaceContainer"},
+	{"surfaceText", "surfaceContainerHigh"},
+	{"surfaceText", "surfaceContainerHighest"},
+}
+
+const (
+	minContrast       = 3.0
+	preferredContrast = 4.5
+)
+
+var (
+	themeIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	versionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+	hexPattern     = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+)
+
+// colors returns the scheme's non-empty colors keyed by JSON name.
+func (c ColorScheme) colors() map[string]string {
+	colors := make(map[string]string)
+	v := reflect.ValueOf(c)
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if value := v.Field(i).String(); value != "" {
+			colors[name] = value
+		}
+	}
+	return colors
+}
+
+// merge overlays the non-empty colors of over, the way the shell applies
+// a variant to the base scheme.
+func (c ColorScheme) merge(over ColorScheme) ColorScheme {
+	dst := reflect.ValueOf(&c).Elem()
+	src := reflect.ValueOf(over)
+	for i := range src.NumField() {
+		if value := src.Field(i).String(); value != "" {
+			dst.Field(i).SetString(value)
+		}
+	}
+	return c
+}
+
+func (c ColorScheme) isEmpty() bool {
+	return len(c.colors()) == 0
+}
+
+// Validate checks the theme in dir the way the registry would: theme.json
+// must parse strictly, carry its metadata, define every color the shell
+// needs in valid hex for both modes and every variant, keep text readable
+// on its backgrounds and ship the preview images the theme browser shows.
+func Validate(fs afero.Fs, dir string) (*Theme, []Issue, error) {
+	data, err := afero.ReadFile(fs, filepath.Join(dir, "theme.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read theme.json: %w", err)
+	}
+
+	var theme Theme
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return nil, []Issue{{SeverityError, "theme.json", err.Error()}}, nil
+	}
+
+	v := &validator{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&Theme{}); err != nil {
+		v.warn("theme.json", "%v", err)
+	}
+
+	v.checkMetadata(theme, filepath.Base(dir))
+	v.checkSchemes(theme)
+	v.checkPreviews(fs, dir, theme)
+	return &theme, v.issues, nil
+}
+
+type validator struct {
+	issues []Issue
+}
+
+func (v *validator) error(path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{SeverityError, path, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{SeverityWarning, path, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) checkMetadata(theme Theme, dirName string) {
+	switch {
+	case theme.ID == "":
+		v.error("id", "is required")
+	case !themeIDPattern.MatchString(theme.ID):
+		v.error("id", "%q may only contain letters, digits, - and _", theme.ID)
+	case theme.ID != dirName:
+		v.warn("id", "%q does not match the directory name %q", theme.ID, dirName)
+	}
+	for field, value := range map[string]string{"name": theme.Name, "author": theme.Author, "description": theme.Description} {
+		if strings.TrimSpace(value) == "" {
+			v.error(field, "is required")
+		}
+	}
+	switch {
+	case theme.Version == "":
+		v.error("version", "is required")
+	case !versionPattern.MatchString(theme.Version):
+		v.error("version", "%q is not a dotted version like 1.0.0", theme.Version)
+	}
+}
+
+func (v *validator) checkSchemes(theme Theme) {
+	variants := theme.Variants
+	hasVariants := variants != nil && (len(variants.Options) > 0 || len(variants.Flavors) > 0)
+
+	for mode, scheme := range map[string]ColorScheme{"dark": theme.Dark, "light": theme.Light} {
+		v.checkHex(mode, scheme)
+		if !hasVariants {
+			v.checkScheme(mode, scheme)
+		}
+	}
+	if variants == nil {
+		return
+	}
+
+	switch variants.Type {
+	case "":
+		v.checkOptions(theme)
+	case "multi":
+		v.checkMulti(theme)
+	default:
+		v.error("variants.type", "unknown type %q (want multi or none)", variants.Type)
+	}
+}
+
+func (v *validator) checkOptions(theme Theme) {
+	variants := theme.Variants
+	if len(variants.Options) == 0 {
+		v.error("variants.options", "no variants defined")
+		return
+	}
+
+	ids := make(map[string]bool)
+	for i, opt := range variants.Options {
+		path := fmt.Sprintf("variants.options[%d]", i)
+		v.checkVariantID(path, opt.ID, opt.Name, ids)
+		v.checkHex(path+".dark", opt.Dark)
+		v.checkHex(path+".light", opt.Light)
+		v.checkScheme(path+".dark", theme.Dark.merge(opt.Dark))
+		v.checkScheme(path+".light", theme.Light.merge(opt.Light))
+	}
+	if variants.Default != "" && !ids[variants.Default] {
+		v.error("variants.default", "%q is not one of the variant ids", variants.Default)
+	}
+}
+
+func (v *validator) checkMulti(theme Theme) {
+	variants := theme.Variants
+	if len(variants.Flavors) == 0 {
+		v.error("variants.flavors", "multi variants need at least one flavor")
+	}
+	if len(variants.Accents) == 0 {
+		v.error("variants.accents", "multi variants need at least one accent")
+	}
+
+	flavors := make(map[string]bool)
+	for i, flavor := range variants.Flavors {
+		v.checkVariantID(fmt.Sprintf("variants.flavors[%d]", i), flavor.ID, flavor.Name, flavors)
+	}
+	accents := make(map[string]bool)
+	for i, accent := range variants.Accents {
+		v.checkVariantID(fmt.Sprintf("variants.accents[%d]", i), accent.ID, accent.Name, accents)
+		for flavorID := range accent.FlavorColors {
+			if !flavors[flavorID] {
+				v.error(fmt.Sprintf("variants.accents[%d].%s", i, flavorID), "no flavor with this id")
+			}
+		}
+	}
+
+	for i, flavor := range variants.Flavors {
+		for mode, colors := range map[string]ColorScheme{"dark": flavor.Dark, "light": flavor.Light} {
+			path := fmt.Sprintf("variants.flavors[%d].%s", i, mode)
+			v.checkHex(path, colors)
+			if colors.isEmpty() {
+				// A flavor only needs to cover the modes it is meant for.
+				continue
+			}
+			base := theme.Dark
+			if mode == "light" {
+				base = theme.Light
+			}
+			for j, accent := range variants.Accents {
+				accentPath := fmt.Sprintf("variants.accents[%d].%s", j, flavor.ID)
+				v.checkHex(accentPath, accent.FlavorColors[flavor.ID])
+				v.checkScheme(path+"+"+accentPath, base.merge(colors).merge(accent.FlavorColors[flavor.ID]))
+			}
+		}
+	}
+
+	if variants.Defaults == nil {
+		v.error("variants.defaults", "multi variants need default dark and light selections")
+		return
+	}
+	for mode, defaults := range map[string]map[string]string{"dark": variants.Defaults.Dark, "light": variants.Defaults.Light} {
+		path := "variants.defaults." + mode
+		if !flavors[defaults["flavor"]] {
+			v.error(path+".flavor", "%q is not one of the flavor ids", defaults["flavor"])
+		}
+		if !accents[defaults["accent"]] {
+			v.error(path+".accent", "%q is not one of the accent ids", defaults["accent"])
+		}
+	}
+}
+
+func (v *validator) checkVariantID(path, id, name string, seen map[string]bool) {
+	switch {
+	case id == "":
+		v.error(path+".id", "is required")
+	case seen[id]:
+		v.error(path+".id", "duplicate id %q", id)
+	}
+	if name == "" {
+		v.warn(path+".name", "is empty")
+	}
+	seen[id] = true
+}
+
+func (v *validator) checkHex(path string, scheme ColorScheme) {
+	for name, value := range scheme.colors() {
+		if !hexPattern.MatchString(value) {
+			v.error(path+"."+name, "%q is not a #rgb, #rrggbb or #aarrggbb color", value)
+		}
+	}
+}
+
+// checkScheme checks the fully merged scheme the shell ends up with.
+func (v *validator) checkScheme(path string, scheme ColorScheme) {
+	colors := scheme.colors()
+	for _, name := range requiredColors {
+		if colors[name] == "" {
+			v.error(path+"."+name, "is required")
+		}
+	}
+
+	for _, pair := range contrastPairs {
+		fg, bg := colors[pair[0]], colors[pair[1]]
+		if !isOpaqueHex(fg) || !isOpaqueHex(bg) {
+			continue
+		}
+		ratio := dank16.ContrastRatio(fg, bg)
+		switch {
+		case ratio < minContrast:
+			v.error(path+"."+pair[0], "contrast %.2f:1 on %s is below %.1f:1", ratio, pair[1], minContrast)
+		case ratio < preferredContrast:
+			v.warn(path+"."+pair[0], "contrast %.2f:1 on %s is below %.1f:1", ratio, pair[1], preferredContrast)
+		}
+	}
+}
+
+func isOpaqueHex(value string) bool {
+	return hexPattern.MatchString(value) && len(value) == 7
+}
+
+// expectedPreviews are the images the theme browser looks up for a theme.
+func expectedPreviews(theme Theme) []string {
+	variants := theme.Variants
+	switch {
+	case variants != nil && variants.Type == "multi":
+		var previews []string
+		for _, flavor := range variants.Flavors {
+			for _, accent := range variants.Accents {
+				previews = append(previews, fmt.Sprintf("preview-%s-%s.svg", flavor.ID, accent.ID))
+			}
+		}
+		return previews
+	case variants != nil && len(variants.Options) > 0:
+		var previews []string
+		for _, opt := range variants.Options {
+			previews = append(previews, fmt.Sprintf("preview-%s-dark.svg", opt.ID), fmt.Sprintf("preview-%s-light.svg", opt.ID))
+		}
+		return previews
+	}
+	return []string{"preview-dark.svg", "preview-light.svg"}
+}
+
+func (v *validator) checkPreviews(fs afero.Fs, dir string, theme Theme) {
+	for _, preview := range expectedPreviews(theme) {
+		if exists, _ := afero.Exists(fs, filepath.Join(dir, preview)); !exists {
+			v.warn(preview, "preview image is missing")
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package themes
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDynamicColors = `{"colors": {
+  "dark": {"primary": "#cfbdfe", "on_primary": "#36275d", "primary_container": "#4d3d75", "secondary": "#cbc2db",
+    "surface": "#141218", "on_background": "#e6e0e9", "surface_variant": "#49454f", "on_surface_variant": "#cac4d0",
+    "surface_tint": "#cfbdfe", "background": "#141218", "outline": "#948f99", "surface_container": "#1d1b20",
+    "surface_container_high": "#211f26", "surface_container_highest": "#2b2930", "error": "#ffb4ab"},
+  "light": {"primary": "#65558f", "on_primary": "#ffffff", "primary_container": "#e9ddff", "secondary": "#625b71",
+    "surface": "#fef7ff", "on_background": "#1d1b20", "surface_variant": "#e7e0eb", "on_surface_variant": "#49454e",
+    "surface_tint": "#65558f", "background": "#fef7ff", "outline": "#7a757f", "surface_container": "#f3edf7",
+    "surface_container_high": "#ece6f0", "surface_container_highest": "#e6e0e9", "error": "#ba1a1a"}
+}}`
+
+func exportTestTheme(t *testing.T, fs afero.Fs, dir string) {
+	t.Helper()
+	require.NoError(t, afero.WriteFile(fs, "/state/dms-colors.json", []byte(testDynamicColors), 0o644))
+	_, err := ExportDynamic(fs, "/state/dms-colors.json", dir, Theme{
+		ID: filepath.Base(dir), Name: "Test", Version: "1.0.0", Author: "me", Description: "test theme",
+	})
+	require.NoError(t, err)
+}
+
+func hasIssue(issues []Issue, severity Severity, path string) bool {
+	return slices.ContainsFunc(issues, func(i Issue) bool { return i.Severity == severity && i.Path == path })
+}
+
+func TestExportValidatePack(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	exportTestTheme(t, fs, "/src/mytheme")
+
+	theme, issues, err := Validate(fs, "/src/mytheme")
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+	assert.Equal(t, "#36275d", theme.Dark.PrimaryText)
+	assert.Equal(t, "#fef7ff", theme.Light.Background)
+
+	dir, _, err := Pack(fs, "/src/mytheme", "/registry")
+	require.NoError(t, err)
+	assert.Equal(t, "/registry/themes/mytheme", dir)
+	for _, name := range []string{"theme.json", "preview-dark.svg", "preview-light.svg"} {
+		exists, _ := afero.Exists(fs, filepath.Join(dir, name))
+		assert.True(t, exists, name)
+	}
+}
+
+func TestValidateIssues(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/src/bad/theme.json", []byte(`{
+  "id": "bad", "name": "Bad", "version": "one", "author": "me", "description": "x", "colour": "#fff",
+  "dark": {"primary": "#ffffff", "primaryText": "#eeeeee", "surface": "blue"},
+  "light": {}
+}`), 0o644))
+
+	_, issues, err := Validate(fs, "/src/bad")
+	require.NoError(t, err)
+	assert.True(t, hasIssue(issues, SeverityError, "version"))
+	assert.True(t, hasIssue(issues, SeverityWarning, "theme.json"), "unknown field")
+	assert.True(t, hasIssue(issues, SeverityError, "dark.surface"), "invalid hex")
+	assert.True(t, hasIssue(issues, SeverityError, "dark.primaryText"), "contrast")
+	assert.True(t, hasIssue(issues, SeverityError, "light.background"), "missing color")
+	assert.True(t, hasIssue(issues, SeverityWarning, "preview-dark.svg"))
+
+	_, _, err = Pack(fs, "/src/bad", "/registry")
+	assert.Error(t, err)
+	exists, _ := afero.DirExists(fs, "/registry/themes/bad")
+	assert.False(t, exists)
+}
+
+func TestValidateMultiVariants(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	exportTestTheme(t, fs, "/src/multi")
+	theme, _, err := Validate(fs, "/src/multi")
+	require.NoError(t, err)
+
+	theme.Variants = &ThemeVariants{
+		Type:     "multi",
+		Defaults: &MultiVariantDefaults{Dark: map[string]string{"flavor": "night", "accent": "blue"}, Light: map[string]string{"flavor": "day", "accent": "pink"}},
+		Flavors: []ThemeFlavor{
+			{ID: "night", Name: "Night", Dark: ColorScheme{Surface: "#000000"}},
+			{ID: "day", Name: "Day", Light: ColorScheme{Surface: "#ffffff"}},
+		},
+		Accents: []ThemeAccent{
+			{ID: "blue", Name: "Blue", FlavorColors: map[string]ColorScheme{"night": {Primary: "#89b4fa"}, "dusk": {Primary: "#89b4fa"}}},
+		},
+	}
+	require.NoError(t, writeThemeDir(fs, "/src/multi", *theme))
+
+	_, issues, err := Validate(fs, "/src/multi")
+	require.NoError(t, err)
+	assert.True(t, hasIssue(issues, SeverityError, "variants.accents[0].dusk"))
+	assert.True(t, hasIssue(issues, SeverityError, "variants.defaults.light.accent"))
+	assert.False(t, hasIssue(issues, SeverityError, "variants.defaults.dark.accent"))
+	assert.True(t, hasIssue(issues, SeverityWarning, "preview-night-blue.svg"))
+	assert.False(t, HasErrors(slices.DeleteFunc(issues, func(i Issue) bool {
+		return i.Path == "variants.accents[0].dusk" || i.Path == "variants.defaults.light.accent"
+	})))
+}
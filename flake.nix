@@ -79,7 +79,7 @@
               inherit version;
               pname = "dms-shell";
               src = ./core;
-              vendorHash = "sha256-Ij5jlmWpZkqQU8j8HXLt6RsRDK3pKfhLBbjlRq5UZms=";
+              vendorHash = "sha256-RXq81Xgv8VkgRfDnTlQCkdrLj5E7xC/ShP/9FHjiv+A=";
 
               subPackages = [ "cmd/dms" ];
 
@@ -2277,6 +2277,24 @@ Singleton {
         savePluginSettings();
     }
 
+    // Replaces a plugin's settings with the ones the DMS server stores,
+    // keeping the keys the shell itself stores for the plugin.
+    function syncPluginSettings(pluginId, settings) {
+        const current = pluginSettings[pluginId] || {};
+        const synced = JSON.parse(JSON.stringify(settings || {}));
+        for (const key of ["enabled", "variants"]) {
+            if (current[key] !== undefined)
+                synced[key] = current[key];
+        }
+        if (JSON.stringify(current) === JSON.stringify(synced))
+            return false;
+        const updated = JSON.parse(JSON.stringify(pluginSettings));
+        updated[pluginId] = synced;
+        pluginSettings = updated;
+        savePluginSettings();
+        return true;
+    }
+
     function removePluginSettings(pluginId) {
         if (pluginSettings[pluginId]) {
             delete pluginSettings[pluginId];
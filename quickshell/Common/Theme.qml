@@ -132,6 +132,17 @@ Singleton {
             "default": def
         };
     }
+    // Colors generated from an output's own wallpaper, keyed by matugen
+    // role, or null when the output uses the main palette.
+    function outputColors(screenName) {
+        if (currentTheme !== dynamic)
+            return null;
+        const palette = matugenColors?.outputs?.[screenName];
+        if (!palette)
+            return null;
+        return isLightMode ? palette.light : palette.dark;
+    }
+
     property var customThemeData: null
     property var customThemeRawData: null
     readonly property var currentThemeVariants: customThemeRawData?.variants || null
@@ -1268,6 +1279,14 @@ Singleton {
         if (typeof SettingsData !== "undefined" && SettingsData.terminalsAlwaysDark) {
             args.push("--terminals-always-dark");
         }
+        if (typeof SessionData !== "undefined" && SessionData.perMonitorWallpaper && !stockColors) {
+            const screens = Quickshell.screens;
+            for (let i = 0; i < screens.length; i++) {
+                const wallpaper = SessionData.monitorWallpapers[screens[i].name];
+                if (wallpaper && wallpaper !== desired.value)
+                    args.push("--output-palette", screens[i].name + "=" + wallpaper);
+            }
+        }
 
         if (typeof SettingsData !== "undefined") {
             const skipTemplates = [];
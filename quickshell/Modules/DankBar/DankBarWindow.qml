@@ -128,7 +128,7 @@ PanelWindow {
     property bool gothCornersEnabled: barConfig?.gothCornersEnabled ?? false
     property real wingtipsRadius: barConfig?.gothCornerRadiusOverride ? (barConfig?.gothCornerRadiusValue ?? 12) : Theme.cornerRadius
     readonly property real _wingR: Math.max(0, wingtipsRadius)
-    readonly property color _surfaceContainer: Theme.surfaceContainer
+    readonly property color _surfaceContainer: Theme.outputColors(screenName)?.surface ?? Theme.surfaceContainer
     readonly property string _barId: barConfig?.id ?? "default"
     readonly property var _liveBarConfig: SettingsData.barConfigs.find(c => c.id === _barId) || barConfig
     readonly property real _backgroundAlpha: _liveBarConfig?.transparency ?? 1.0
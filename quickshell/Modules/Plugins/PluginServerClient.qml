@@ -0,0 +1,133 @@
+import QtQuick
+import Quickshell.Io
+import qs.Common
+import qs.Services
+
+// Connection to the DMS server for a plugin. It authenticates as the
+// plugin, so the server only allows the methods covered by the
+// permissions the user granted it. For QML this is a courtesy to the
+// user, not a sandbox: plugin code runs inside the shell and could use
+// DMSService directly instead. Backends are held to their permissions by
+// the server, which recognises their connections by process.
+//
+//   PluginServerClient {
+//       id: server
+//       pluginId: root.pluginId
+//   }
+//   server.request("clipboard.getHistory", {}, response => ...)
+//   server.call("refresh", {}, response => ...)   // the plugin's backend
+Item {
+    id: root
+
+    required property string pluginId
+    property bool authenticated: false
+    property string lastError: ""
+    property var _pending: ({})
+    property var _queue: []
+    property int _requestId: 0
+
+    signal event(string service, var data)
+
+    function request(method, params, callback) {
+        if (!authenticated) {
+            _queue.push({
+                "method": method,
+                "params": params,
+                "callback": callback
+            });
+            _connect();
+            return;
+        }
+        _send(method, params, callback);
+    }
+
+    // Calls a method of the plugin's own backend.
+    function call(method, params, callback) {
+        request("plugin." + pluginId + "." + method, params, callback);
+    }
+
+    function _send(method, params, callback) {
+        _requestId++;
+        const message = {
+            "id": _requestId,
+            "method": method
+        };
+        if (params)
+            message.params = params;
+        if (callback)
+            _pending[_requestId] = callback;
+        socket.send(message);
+    }
+
+    function _connect() {
+        if (socket.connected || !DMSService.isConnected)
+            return;
+        socket.connected = true;
+    }
+
+    // Authentication is handled before any request queued after it.
+    function _authenticate() {
+        _send("plugins.authenticate", {
+            "plugin": pluginId
+        }, response => {
+            if (response.error) {
+                socket.connected = false;
+                _fail(response.error);
+                return;
+            }
+            authenticated = true;
+            const queued = _queue;
+            _queue = [];
+            for (const item of queued)
+                _send(item.method, item.params, item.callback);
+        });
+    }
+
+    function _fail(error) {
+        lastError = error;
+        console.warn("PluginServerClient:", pluginId, error);
+        const queued = _queue;
+        _queue = [];
+        for (const item of queued) {
+            if (item.callback)
+                item.callback({
+                    "error": error
+                });
+        }
+    }
+
+    DankSocket {
+        id: socket
+        path: DMSService.socketPath
+        connected: false
+
+        onConnectionStateChanged: {
+            root.authenticated = false;
+            if (socketConnected)
+                root._authenticate();
+        }
+
+        parser: SplitParser {
+            onRead: line => {
+                if (!line)
+                    return;
+                let response;
+                try {
+                    response = JSON.parse(line);
+                } catch (e) {
+                    return;
+                }
+                // Subscription events carry the ID of the subscribe request
+                if (response.result?.service !== undefined) {
+                    root.event(response.result.service, response.result.data);
+                    return;
+                }
+                const callback = root._pending[response.id];
+                if (callback) {
+                    delete root._pending[response.id];
+                    callback(response);
+                }
+            }
+        }
+    }
+}
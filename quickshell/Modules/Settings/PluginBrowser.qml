@@ -78,16 +78,55 @@ FloatingWindow {
             keyboardNavigationActive = false;
     }
 
-    function installPlugin(pluginName, enableAfterInstall) {
+    function installPlugin(pluginName, enableAfterInstall, withDependencies, force, allowUnverified) {
         ToastService.showInfo(I18n.tr("Installing: %1", "installation progress").arg(pluginName));
         DMSService.install(pluginName, response => {
             if (response.error) {
                 ToastService.showError(I18n.tr("Install failed: %1", "installation error").arg(response.error));
                 return;
             }
+            const unverified = response.result?.unverified;
+            if (unverified) {
+                urlInstallConfirm.showWithOptions({
+                    "title": I18n.tr("Verification Failed", "plugin verification dialog title"),
+                    "message": I18n.tr("'%1' does not match what its registry published: %2\nOnly install it if you trust where it came from.", "plugin verification warning").arg(pluginName).arg(unverified),
+                    "confirmText": I18n.tr("Install Anyway", "install despite incompatibility button"),
+                    "cancelText": I18n.tr("Cancel"),
+                    "onConfirm": () => installPlugin(pluginName, enableAfterInstall, withDependencies, force, true),
+                    "onCancel": () => {}
+                });
+                return;
+            }
+            const incompatible = response.result?.incompatible;
+            if (incompatible && incompatible.length > 0) {
+                urlInstallConfirm.showWithOptions({
+                    "title": I18n.tr("Incompatible Plugin", "plugin compatibility dialog title"),
+                    "message": I18n.tr("'%1' may not work on this system:\n%2\nInstall anyway?", "plugin compatibility confirmation").arg(pluginName).arg(incompatible.join("\n")),
+                    "confirmText": I18n.tr("Install Anyway", "install despite incompatibility button"),
+                    "cancelText": I18n.tr("Cancel"),
+                    "onConfirm": () => installPlugin(pluginName, enableAfterInstall, withDependencies, true, allowUnverified),
+                    "onCancel": () => {}
+                });
+                return;
+            }
+            const requires = response.result?.requires;
+            if (requires && requires.length > 0) {
+                urlInstallConfirm.showWithOptions({
+                    "title": I18n.tr("Install Dependencies", "plugin dependency dialog title"),
+                    "message": I18n.tr("'%1' requires these plugins: %2. Install them too?", "plugin dependency confirmation").arg(pluginName).arg(requires.join(", ")),
+                    "confirmText": I18n.tr("Install", "install action button"),
+                    "cancelText": I18n.tr("Cancel"),
+                    "onConfirm": () => installPlugin(pluginName, enableAfterInstall, true, force, allowUnverified),
+                    "onCancel": () => {}
+                });
+                return;
+            }
             ToastService.showInfo(I18n.tr("Installed: %1", "installation success").arg(pluginName));
             PluginService.scanPlugins();
             refreshPlugins();
+            const pending = response.result?.pending;
+            if (pending && Object.keys(pending).length > 0)
+                Qt.callLater(() => confirmPermissions(pending));
             if (enableAfterInstall) {
                 Qt.callLater(() => {
                     PluginService.enablePlugin(pluginName);
@@ -99,6 +138,22 @@ FloatingWindow {
                     hide();
                 });
             }
+        }, withDependencies, force, allowUnverified);
+    }
+
+    function confirmPermissions(pending) {
+        const ids = Object.keys(pending);
+        const lines = ids.map(id => id + ": " + pending[id].join(", "));
+        urlInstallConfirm.showWithOptions({
+            "title": I18n.tr("Grant Permissions", "plugin permissions dialog title"),
+            "message": I18n.tr("Installed plugins request permission to use:\n%1\nPermissions can be revoked in the plugin settings.", "plugin permissions confirmation").arg(lines.join("\n")),
+            "confirmText": I18n.tr("Grant", "grant permissions button"),
+            "cancelText": I18n.tr("Don't Grant", "decline permissions button"),
+            "onConfirm": () => {
+                for (const id of ids)
+                    DMSService.grantPluginPermissions(id, pending[id]);
+            },
+            "onCancel": () => {}
         });
     }
 
@@ -14,6 +14,7 @@ StyledRect {
     property bool hasUpdate: false
     property bool isReloading: false
     property var sharedTooltip: null
+    property var sharedConfirm: null
 
     property string pluginId: pluginData ? pluginData.id : ""
     property string pluginDirectoryName: {
@@ -30,6 +31,9 @@ StyledRect {
     property string pluginIcon: pluginData ? (pluginData.icon || "extension") : "extension"
     property string pluginSettingsPath: pluginData ? (pluginData.settingsPath || "") : ""
     property var pluginPermissions: pluginData ? (pluginData.permissions || []) : []
+    property var serverPluginInfo: (DMSService.installedPlugins || []).find(p => p.id === pluginId) || null
+    property var serverPermissions: serverPluginInfo ? (serverPluginInfo.permissions || []) : []
+    property var grantedPermissions: serverPluginInfo ? (serverPluginInfo.granted || []) : []
     property bool hasSettings: pluginData && pluginData.settings !== undefined && pluginData.settings !== ""
     property bool isDesktopPlugin: pluginData ? (pluginData.type === "desktop") : false
     property bool showSettings: hasSettings && !isDesktopPlugin
@@ -49,6 +53,37 @@ StyledRect {
         return PluginService.loadedPlugins[pluginId] !== undefined;
     }
 
+    function updatePlugin(trustChange) {
+        const currentPluginName = root.pluginName;
+        const currentPluginId = root.pluginId;
+        DMSService.update(currentPluginName, response => {
+            if (response.error) {
+                ToastService.showError("Update failed: " + response.error);
+                return;
+            }
+            const unverified = response.result?.unverified;
+            if (unverified && response.result.retrust && root.sharedConfirm) {
+                root.sharedConfirm.showWithOptions({
+                    "title": I18n.tr("Trust New Version", "plugin trust dialog title"),
+                    "message": I18n.tr("'%1' no longer matches the version you trusted: %2\nOnly update it if you trust where it came from.", "plugin trust warning").arg(currentPluginName).arg(unverified),
+                    "confirmText": I18n.tr("Trust and Update", "plugin trust confirm button"),
+                    "cancelText": I18n.tr("Cancel"),
+                    "onConfirm": () => root.updatePlugin(true),
+                    "onCancel": () => {}
+                });
+                return;
+            }
+            if (unverified) {
+                ToastService.showError(I18n.tr("Update not applied, verification failed: %1", "plugin verification error").arg(unverified));
+                return;
+            }
+            ToastService.showInfo("Plugin updated: " + currentPluginName);
+            PluginService.forceRescanPlugin(currentPluginId);
+            if (DMSService.apiVersion >= 8)
+                DMSService.listInstalled();
+        }, trustChange);
+    }
+
     width: parent.width
     height: pluginItemColumn.implicitHeight + Theme.spacingM * 2 + settingsContainer.height
     radius: Theme.cornerRadius
@@ -198,20 +233,7 @@ StyledRect {
                         anchors.fill: parent
                         hoverEnabled: true
                         cursorShape: Qt.PointingHandCursor
-                        onClicked: {
-                            const currentPluginName = root.pluginName;
-                            const currentPluginId = root.pluginId;
-                            DMSService.update(currentPluginName, response => {
-                                if (response.error) {
-                                    ToastService.showError("Update failed: " + response.error);
-                                    return;
-                                }
-                                ToastService.showInfo("Plugin updated: " + currentPluginName);
-                                PluginService.forceRescanPlugin(currentPluginId);
-                                if (DMSService.apiVersion >= 8)
-                                    DMSService.listInstalled();
-                            });
-                        }
+                        onClicked: root.updatePlugin(false)
                         onEntered: {
                             if (root.sharedTooltip)
                                 root.sharedTooltip.show(I18n.tr("Update Plugin"), parent, 0, 0, "top");
@@ -249,6 +271,11 @@ StyledRect {
                                     ToastService.showError("Uninstall failed: " + response.error);
                                     return;
                                 }
+                                const dependents = response.result?.dependents;
+                                if (dependents && dependents.length > 0) {
+                                    ToastService.showError(I18n.tr("Uninstall failed: required by %1", "plugin uninstall blocked by dependents").arg(dependents.join(", ")));
+                                    return;
+                                }
                                 ToastService.showInfo("Plugin uninstalled: " + currentPluginName);
                                 PluginService.scanPlugins();
                                 if (root.isExpanded)
@@ -354,11 +381,15 @@ StyledRect {
                 model: root.pluginPermissions
 
                 Rectangle {
+                    // Server permissions can be granted and revoked by clicking them
+                    readonly property bool isServerPermission: root.serverPermissions.indexOf(modelData) !== -1
+                    readonly property bool isGranted: !isServerPermission || root.grantedPermissions.indexOf(modelData) !== -1
+
                     height: 20
                     width: permissionText.implicitWidth + Theme.spacingXS * 2
                     radius: 10
-                    color: Theme.withAlpha(Theme.primary, 0.1)
-                    border.color: Theme.withAlpha(Theme.primary, 0.3)
+                    color: isGranted ? Theme.withAlpha(Theme.primary, 0.1) : "transparent"
+                    border.color: isGranted ? Theme.withAlpha(Theme.primary, 0.3) : Theme.outline
                     border.width: 1
 
                     StyledText {
@@ -366,7 +397,22 @@ StyledRect {
                         anchors.centerIn: parent
                         text: modelData
                         font.pixelSize: Theme.fontSizeSmall - 1
-                        color: Theme.primary
+                        font.strikeout: !parent.isGranted
+                        color: parent.isGranted ? Theme.primary : Theme.surfaceVariantText
+                    }
+
+                    MouseArea {
+                        anchors.fill: parent
+                        enabled: parent.isServerPermission
+                        cursorShape: Qt.PointingHandCursor
+                        onClicked: {
+                            const permission = modelData;
+                            const change = parent.isGranted ? DMSService.revokePluginPermissions : DMSService.grantPluginPermissions;
+                            change(root.pluginId, [permission], response => {
+                                if (response.error)
+                                    ToastService.showError(I18n.tr("Failed to change permission: %1").arg(response.error));
+                            });
+                        }
                     }
                 }
             }
@@ -1,6 +1,7 @@
 import QtQuick
 import Quickshell
 import qs.Common
+import qs.Modals.Common
 import qs.Services
 import qs.Widgets
 
@@ -16,6 +17,7 @@ FocusScope {
     property var installedPluginsData: ({})
     property bool isReloading: false
     property alias sharedTooltip: sharedTooltip
+    property alias sharedConfirm: sharedConfirm
 
     focus: true
 
@@ -23,6 +25,10 @@ FocusScope {
         id: sharedTooltip
     }
 
+    ConfirmModal {
+        id: sharedConfirm
+    }
+
     DankFlickable {
         anchors.fill: parent
         clip: true
@@ -174,7 +180,7 @@ FocusScope {
                             }
 
                             StyledText {
-                                text: I18n.tr("Some plugins require a newer version of DMS:") + " " + incompatWarning.incompatPlugins.map(p => p.name + " (" + p.requires_dms + ")").join(", ")
+                                text: I18n.tr("Some plugins don't support this DMS version or compositor:") + " " + incompatWarning.incompatPlugins.map(p => p.name + " (" + (PluginService.checkPluginCompatibility(p.requires_dms) ? p.compositors.join(", ") : p.requires_dms) + ")").join(", ")
                                 font.pixelSize: Theme.fontSizeSmall - 1
                                 color: Theme.surfaceVariantText
                                 wrapMode: Text.WordWrap
@@ -323,6 +329,7 @@ FocusScope {
                                 }
                                 isReloading: pluginsTab.isReloading
                                 sharedTooltip: pluginsTab.sharedTooltip
+                                sharedConfirm: pluginsTab.sharedConfirm
                                 onExpandedPluginIdChanged: {
                                     pluginsTab.expandedPluginId = expandedPluginId;
                                 }
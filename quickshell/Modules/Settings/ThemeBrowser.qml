@@ -398,7 +398,7 @@ FloatingWindow {
                             return variants.default || (variants.options?.[0]?.id ?? "");
                         }
                         property string previewPath: {
-                            const baseDir = "/tmp/dankdots-plugin-registry/themes/" + (modelData.sourceDir || modelData.id);
+                            const baseDir = modelData.themeDir || ("/tmp/dankdots-plugin-registry/themes/" + (modelData.sourceDir || modelData.id));
                             const mode = Theme.isLightMode ? "light" : "dark";
                             if (hasVariants && selectedVariantId) {
                                 if (variants?.type === "multi")
@@ -61,12 +61,14 @@ Singleton {
     signal appPickerRequested(var data)
     signal screensaverStateUpdate(var data)
     signal clipboardStateUpdate(var data)
+    signal pluginBackendEvent(string pluginId, string event, var data)
+    signal pluginSettingsChanged(string pluginId, var settings)
 
     property bool capsLockState: false
     property bool screensaverInhibited: false
     property var screensaverInhibitors: []
 
-    property var activeSubscriptions: ["network", "network.credentials", "loginctl", "freedesktop", "freedesktop.screensaver", "gamma", "theme.auto", "bluetooth", "bluetooth.pairing", "dwl", "brightness", "wlroutput", "evdev", "browser", "dbus", "clipboard"]
+    property var activeSubscriptions: ["network", "network.credentials", "loginctl", "freedesktop", "freedesktop.screensaver", "gamma", "theme.auto", "bluetooth", "bluetooth.pairing", "dwl", "brightness", "wlroutput", "evdev", "browser", "dbus", "clipboard", "plugin", "plugins.settings"]
 
     Component.onCompleted: {
         if (socketPath && socketPath.length > 0) {
@@ -395,6 +397,10 @@ Singleton {
             dbusSignalReceived(data.subscriptionId || "", data);
         } else if (service === "clipboard") {
             clipboardStateUpdate(data);
+        } else if (service === "plugins.settings") {
+            pluginSettingsChanged(data.plugin, data.settings || {});
+        } else if (service.startsWith("plugin.")) {
+            pluginBackendEvent(data.plugin, data.event, data.data);
         }
     }
 
@@ -492,9 +498,12 @@ Singleton {
         });
     }
 
-    function install(pluginName, callback) {
+    function install(pluginName, callback, withDependencies, force, allowUnverified) {
         sendRequest("plugins.install", {
-            "name": pluginName
+            "name": pluginName,
+            "withDependencies": withDependencies === true,
+            "force": force === true,
+            "allowUnverified": allowUnverified === true
         }, response => {
             if (callback) {
                 callback(response);
@@ -505,9 +514,76 @@ Singleton {
         });
     }
 
-    function uninstall(pluginName, callback) {
+    function listPluginPermissions(pluginId, callback) {
+        sendRequest("plugins.permissions.list", pluginId ? {
+            "plugin": pluginId
+        } : null, callback);
+    }
+
+    function grantPluginPermissions(pluginId, permissions, callback) {
+        sendRequest("plugins.permissions.grant", {
+            "plugin": pluginId,
+            "permissions": permissions || []
+        }, response => {
+            if (callback)
+                callback(response);
+            if (!response.error)
+                listInstalled();
+        });
+    }
+
+    function revokePluginPermissions(pluginId, permissions, callback) {
+        sendRequest("plugins.permissions.revoke", {
+            "plugin": pluginId,
+            "permissions": permissions || []
+        }, response => {
+            if (callback)
+                callback(response);
+            if (!response.error)
+                listInstalled();
+        });
+    }
+
+    function startPluginBackend(pluginId, callback) {
+        sendRequest("plugins.backend.start", {
+            "plugin": pluginId
+        }, callback);
+    }
+
+    function stopPluginBackend(pluginId, callback) {
+        sendRequest("plugins.backend.stop", {
+            "plugin": pluginId
+        }, callback);
+    }
+
+    function restartPluginBackend(pluginId, callback) {
+        sendRequest("plugins.backend.restart", {
+            "plugin": pluginId
+        }, callback);
+    }
+
+    function getPluginSettings(pluginId, callback) {
+        sendRequest("plugins.settings.get", {
+            "plugin": pluginId
+        }, callback);
+    }
+
+    function setPluginSetting(pluginId, key, value, callback) {
+        sendRequest("plugins.settings.set", {
+            "plugin": pluginId,
+            "key": key,
+            "value": value === undefined ? null : value
+        }, callback);
+    }
+
+    function pluginBackendStatus(callback) {
+        sendRequest("plugins.backend.status", null, callback);
+    }
+
+    function uninstall(pluginName, callback, cascade) {
         sendRequest("plugins.uninstall", {
-            "name": pluginName
+            "name": pluginName,
+            "cascade": cascade === true
         }, response => {
             if (callback) {
                 callback(response);
@@ -518,9 +594,10 @@ Singleton {
         });
     }
 
-    function update(pluginName, callback) {
+    function update(pluginName, callback, trustChange) {
         sendRequest("plugins.update", {
-            "name": pluginName
+            "name": pluginName,
+            "trustChange": trustChange === true
         }, response => {
             if (callback) {
                 callback(response);
@@ -336,6 +336,9 @@ Singleton {
             newLoaded[pluginId] = plugin;
             loadedPlugins = newLoaded;
 
+            _startBackend(plugin);
+            if (_serverSettings())
+                _syncSettings(pluginId);
             pluginLoaded(pluginId);
             return true;
         } catch (e) {
@@ -389,6 +392,8 @@ Singleton {
             loadedPlugins = newLoaded;
 
             _cleanupPluginStateWriter(pluginId);
+            if (plugin.backend && DMSService.isConnected)
+                DMSService.stopPluginBackend(pluginId);
             pluginUnloaded(pluginId);
             return true;
         } catch (error) {
@@ -397,6 +402,33 @@ Singleton {
         }
     }
 
+    // Backends run in the DMS server while their plugin is loaded.
+    function _startBackend(plugin) {
+        if (!plugin.backend || !DMSService.isConnected || !DMSService.capabilities.includes("plugin.backend"))
+            return;
+        DMSService.startPluginBackend(plugin.id, response => {
+            if (response.error)
+                console.warn("PluginService: failed to start backend for", plugin.id, response.error);
+        });
+    }
+
+    Connections {
+        target: DMSService
+
+        function onCapabilitiesReceived() {
+            for (const pluginId in root.loadedPlugins) {
+                root._startBackend(root.loadedPlugins[pluginId]);
+                if (root._serverSettings())
+                    root._syncSettings(pluginId);
+            }
+        }
+
+        function onPluginSettingsChanged(pluginId, settings) {
+            if (SettingsData.syncPluginSettings(pluginId, settings))
+                root.pluginDataChanged(pluginId);
+        }
+    }
+
     function getWidgetComponents() {
         return pluginWidgetComponents;
     }
@@ -600,9 +632,34 @@ Singleton {
     function savePluginData(pluginId, key, value) {
         SettingsData.setPluginSetting(pluginId, key, value);
         pluginDataChanged(pluginId);
+        if (_serverSettings()) {
+            DMSService.setPluginSetting(pluginId, key, value, response => {
+                if (!response.error)
+                    return;
+                // The server refused the value, so go back to what it has.
+                console.warn("PluginService: setting", key, "of", pluginId, "rejected:", response.error);
+                _syncSettings(pluginId);
+            });
+        }
         return true;
     }
 
+    // Plugin settings are stored by the DMS server when it is running, so
+    // they can be changed with `dms plugins config` and are shared by every
+    // shell. plugin_settings.json keeps a copy for when it isn't.
+    function _serverSettings() {
+        return DMSService.isConnected && DMSService.capabilities.includes("plugins.settings");
+    }
+
+    function _syncSettings(pluginId) {
+        DMSService.getPluginSettings(pluginId, response => {
+            if (response.error || !response.result)
+                return;
+            if (SettingsData.syncPluginSettings(pluginId, response.result))
+                pluginDataChanged(pluginId);
+        });
+    }
+
     function loadPluginData(pluginId, key, defaultValue) {
         return SettingsData.getPluginSetting(pluginId, key, defaultValue);
     }
@@ -863,11 +920,22 @@ Singleton {
         return SystemUpdateService.checkVersionRequirement(requiresDms, SystemUpdateService.getParsedShellVersion());
     }
 
+    function checkCompositorCompatibility(compositors) {
+        if (!Array.isArray(compositors) || compositors.length === 0 || CompositorService.compositor === "unknown")
+            return true;
+        return compositors.some(c => {
+            const name = String(c).trim().toLowerCase();
+            return name === "any" || name === "all" || name === "*" || name === CompositorService.compositor;
+        });
+    }
+
     function getIncompatiblePlugins() {
         const result = [];
         for (const pluginId in availablePlugins) {
             const plugin = availablePlugins[pluginId];
-            if (plugin.loaded && plugin.requires_dms && !checkPluginCompatibility(plugin.requires_dms)) {
+            if (!plugin.loaded)
+                continue;
+            if ((plugin.requires_dms && !checkPluginCompatibility(plugin.requires_dms)) || !checkCompositorCompatibility(plugin.compositors)) {
                 result.push(plugin);
             }
         }
@@ -470,30 +470,17 @@ Singleton {
         if (!requirementStr || typeof requirementStr !== "string")
             return true;
 
-        const req = requirementStr.trim();
-        let operator = "";
-        let versionPart = req;
-
-        if (req.startsWith(">=")) {
-            operator = ">=";
-            versionPart = req.substring(2);
-        } else if (req.startsWith("<=")) {
-            operator = "<=";
-            versionPart = req.substring(2);
-        } else if (req.startsWith(">")) {
-            operator = ">";
-            versionPart = req.substring(1);
-        } else if (req.startsWith("<")) {
-            operator = "<";
-            versionPart = req.substring(1);
-        } else if (req.startsWith("=")) {
-            operator = "=";
-            versionPart = req.substring(1);
-        } else {
-            operator = ">=";
-        }
+        // Alternatives separated by "||", each a set of comparators that must all hold
+        return requirementStr.split("||").some(alternative => {
+            const comparators = alternative.trim().split(/[\s,]+/).filter(c => c.length > 0);
+            return comparators.length > 0 && comparators.every(c => checkVersionComparator(c, currentVersion));
+        });
+    }
 
-        const reqVersion = parseVersion(versionPart);
+    function checkVersionComparator(req, currentVersion) {
+        const match = req.match(/^(>=|<=|==|>|<|=|\^|~)?(.*)$/);
+        const operator = match[1] || ">=";
+        const reqVersion = parseVersion(match[2]);
         const cmp = compareVersions(currentVersion, reqVersion);
 
         switch (operator) {
@@ -506,7 +493,27 @@ Singleton {
         case "<":
             return cmp < 0;
         case "=":
+        case "==":
             return cmp === 0;
+        case "^":
+            {
+                const upper = reqVersion.major === 0 ? {
+                    major: 0,
+                    minor: reqVersion.minor + 1,
+                    patch: 0
+                } : {
+                    major: reqVersion.major + 1,
+                    minor: 0,
+                    patch: 0
+                };
+                return cmp >= 0 && compareVersions(currentVersion, upper) < 0;
+            }
+        case "~":
+            return cmp >= 0 && compareVersions(currentVersion, {
+                major: reqVersion.major,
+                minor: reqVersion.minor + 1,
+                patch: 0
+            }) < 0;
         default:
             return cmp >= 0;
         }